@@ -0,0 +1,31 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bucket
+
+// ConfigFieldEndpoint is the configuration field name for the base url
+// of the S3-compatible API that objects are fetched from. Defaults to
+// AWS S3.
+const ConfigFieldEndpoint = "endpoint"
+
+// ConfigFieldRegion is the configuration field name for the region that
+// requests are signed for. Defaults to us-east-1.
+const ConfigFieldRegion = "region"
+
+// ConfigFieldMaxObjectSize is the configuration field name for the
+// largest object, in bytes, that the resolver will fetch. Defaults to
+// 20MiB.
+const ConfigFieldMaxObjectSize = "max-object-size"
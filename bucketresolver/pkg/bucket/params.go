@@ -0,0 +1,32 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bucket
+
+// BucketParam is the name of the bucket to fetch the object from
+const BucketParam string = "bucket"
+
+// KeyParam is the key of the object to fetch
+const KeyParam string = "key"
+
+// VersionParam is the version of the object to fetch. Defaults to the
+// object's latest version
+const VersionParam string = "version"
+
+// SecretParam is the name of a secret in the request's namespace
+// holding the access keys to fetch the object with. The object is
+// fetched anonymously when it's not set
+const SecretParam string = "secret"
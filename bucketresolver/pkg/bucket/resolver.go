@@ -0,0 +1,305 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bucket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+)
+
+// LabelValueBucketResolverType is the value to use for the
+// resolution.tekton.dev/type label on resource requests
+const LabelValueBucketResolverType string = "bucket"
+
+// BucketResolverName is the name that the bucket resolver should be
+// associated with
+const BucketResolverName string = "Bucket"
+
+// AnnotationKeyETag is the annotation key recording the ETag of the
+// resolved object.
+const AnnotationKeyETag = "resolution.tekton.dev/etag"
+
+// AnnotationKeyVersion is the annotation key recording the version of
+// the resolved object, when its bucket is versioned.
+const AnnotationKeyVersion = "resolution.tekton.dev/version"
+
+const (
+	// SecretKeyAccessKeyID is the key in a SecretParam secret holding
+	// the access key id.
+	SecretKeyAccessKeyID = "access-key-id"
+	// SecretKeySecretAccessKey is the key in a SecretParam secret
+	// holding the secret access key.
+	SecretKeySecretAccessKey = "secret-access-key"
+	// SecretKeySessionToken is the optional key in a SecretParam secret
+	// holding a session token for temporary credentials.
+	SecretKeySessionToken = "session-token"
+)
+
+// defaultEndpoint is the API objects are fetched from when
+// ConfigFieldEndpoint isn't set.
+const defaultEndpoint = "https://s3.amazonaws.com"
+
+// defaultRegion is the region requests are signed for when
+// ConfigFieldRegion isn't set.
+const defaultRegion = "us-east-1"
+
+// defaultMaxObjectSize is the largest object fetched when
+// ConfigFieldMaxObjectSize isn't set.
+const defaultMaxObjectSize = 20 << 20
+
+var _ framework.Resolver = &Resolver{}
+
+// Resolver implements a framework.Resolver that can fetch objects from
+// S3-compatible object storage, such as AWS S3 or GCS.
+type Resolver struct {
+	kubeClientSet kubernetes.Interface
+}
+
+// Initialize performs any setup required by the bucket resolver.
+func (r *Resolver) Initialize(ctx context.Context) error {
+	r.kubeClientSet = kubeclient.Get(ctx)
+	return nil
+}
+
+// GetName returns the string name that the bucket resolver should be
+// associated with.
+func (r *Resolver) GetName(_ context.Context) string {
+	return BucketResolverName
+}
+
+// GetSelector returns the labels that resource requests are required to
+// have for the bucket resolver to process them.
+func (r *Resolver) GetSelector(_ context.Context) map[string]string {
+	return map[string]string{
+		resolutioncommon.LabelKeyResolverType: LabelValueBucketResolverType,
+	}
+}
+
+// ValidateParams returns an error if the given parameter map is not
+// valid for a resource request targeting the bucket resolver.
+func (r *Resolver) ValidateParams(ctx context.Context, params map[string]string) error {
+	missing := []string{}
+	for _, key := range []string{BucketParam, KeyParam} {
+		if strings.TrimSpace(params[key]) == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing %s", strings.Join(missing, " or "))
+	}
+	if strings.Contains(params[BucketParam], "/") {
+		return fmt.Errorf("invalid %q %q: must not contain \"/\"", BucketParam, params[BucketParam])
+	}
+	if _, err := endpointURL(ctx); err != nil {
+		return err
+	}
+	if _, err := maxObjectSize(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Resolve fetches the requested object from its bucket.
+func (r *Resolver) Resolve(ctx context.Context, params map[string]string) (framework.ResolvedResource, error) {
+	if err := r.ValidateParams(ctx, params); err != nil {
+		return nil, err
+	}
+	endpoint, err := endpointURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := maxObjectSize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bucket, key := params[BucketParam], params[KeyParam]
+	objectURL := *endpoint
+	objectURL.Path = strings.TrimSuffix(objectURL.Path, "/") + "/" + bucket + "/" + strings.TrimPrefix(key, "/")
+	objectURL.RawPath = uriEncode(objectURL.Path, false)
+	if version := params[VersionParam]; version != "" {
+		objectURL.RawQuery = url.Values{"versionId": {version}}.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, objectURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error building request for %s/%s: %w", bucket, key, err)
+	}
+	if secretName := params[SecretParam]; secretName != "" {
+		creds, err := r.credentials(ctx, secretName)
+		if err != nil {
+			return nil, err
+		}
+		signRequest(req, creds, region(ctx), time.Now())
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s/%s: %w", bucket, key, err)
+	}
+	defer resp.Body.Close()
+	if err := statusError(resp, bucket, key); err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s/%s: %w", bucket, key, err)
+	}
+	if int64(len(content)) > limit {
+		return nil, fmt.Errorf("%s/%s is larger than the %q of %d bytes", bucket, key, ConfigFieldMaxObjectSize, limit)
+	}
+	return &ResolvedBucketResource{
+		Content:     content,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+		Version:     resp.Header.Get("X-Amz-Version-Id"),
+	}, nil
+}
+
+// statusError returns an error describing resp if it isn't a success,
+// wrapping framework.ErrorResourceNotFound or
+// framework.ErrorAuthenticationFailed where it applies.
+func statusError(resp *http.Response, bucket, key string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err := fmt.Errorf("error fetching %s/%s: %s: %s", bucket, key, resp.Status, strings.TrimSpace(string(body)))
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %v", framework.ErrorResourceNotFound, err)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %v", framework.ErrorAuthenticationFailed, err)
+	default:
+		return err
+	}
+}
+
+// credentials reads the access keys in the named secret.
+func (r *Resolver) credentials(ctx context.Context, secretName string) (credentials, error) {
+	if r.kubeClientSet == nil {
+		return credentials{}, errors.New("bucket resolver has no kubernetes client to read secrets with")
+	}
+	namespace := resolutioncommon.RequestNamespace(ctx)
+	secret, err := r.kubeClientSet.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		return credentials{}, fmt.Errorf("error getting secret %s/%s: %w", namespace, secretName, err)
+	}
+	creds := credentials{
+		accessKeyID:     strings.TrimSpace(string(secret.Data[SecretKeyAccessKeyID])),
+		secretAccessKey: strings.TrimSpace(string(secret.Data[SecretKeySecretAccessKey])),
+		sessionToken:    strings.TrimSpace(string(secret.Data[SecretKeySessionToken])),
+	}
+	missing := []string{}
+	if creds.accessKeyID == "" {
+		missing = append(missing, SecretKeyAccessKeyID)
+	}
+	if creds.secretAccessKey == "" {
+		missing = append(missing, SecretKeySecretAccessKey)
+	}
+	if len(missing) > 0 {
+		return credentials{}, fmt.Errorf("invalid secret %q: missing %s", secretName, strings.Join(missing, ", "))
+	}
+	return creds, nil
+}
+
+// endpointURL returns the configured ConfigFieldEndpoint, or
+// defaultEndpoint if it's not set.
+func endpointURL(ctx context.Context) (*url.URL, error) {
+	endpoint := strings.TrimSpace(framework.GetResolverConfigFromContext(ctx)[ConfigFieldEndpoint])
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid %q config %q: must be an http(s) url", ConfigFieldEndpoint, endpoint)
+	}
+	return u, nil
+}
+
+// region returns the configured ConfigFieldRegion, or defaultRegion if
+// it's not set.
+func region(ctx context.Context) string {
+	if configured := strings.TrimSpace(framework.GetResolverConfigFromContext(ctx)[ConfigFieldRegion]); configured != "" {
+		return configured
+	}
+	return defaultRegion
+}
+
+// maxObjectSize returns the configured ConfigFieldMaxObjectSize, or
+// defaultMaxObjectSize if it's not set.
+func maxObjectSize(ctx context.Context) (int64, error) {
+	val := strings.TrimSpace(framework.GetResolverConfigFromContext(ctx)[ConfigFieldMaxObjectSize])
+	if val == "" {
+		return defaultMaxObjectSize, nil
+	}
+	size, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("invalid %q config %q: must be a positive integer", ConfigFieldMaxObjectSize, val)
+	}
+	return size, nil
+}
+
+var _ framework.ConfigWatcher = &Resolver{}
+
+// GetConfigName returns the name of the bucket resolver's configmap.
+func (r *Resolver) GetConfigName(context.Context) string {
+	return "bucket-resolver-config"
+}
+
+// ResolvedBucketResource implements framework.ResolvedResource and
+// returns the content of an object fetched from a bucket.
+type ResolvedBucketResource struct {
+	Content     []byte
+	ContentType string
+	ETag        string
+	Version     string
+}
+
+var _ framework.ResolvedResource = &ResolvedBucketResource{}
+
+// Data returns the bytes of the object.
+func (r *ResolvedBucketResource) Data() []byte {
+	return r.Content
+}
+
+// Annotations returns the metadata that accompanies the object.
+func (r *ResolvedBucketResource) Annotations() map[string]string {
+	annotations := map[string]string{}
+	if r.ContentType != "" {
+		annotations[resolutioncommon.AnnotationKeyContentType] = r.ContentType
+	}
+	if r.ETag != "" {
+		annotations[AnnotationKeyETag] = r.ETag
+	}
+	if r.Version != "" {
+		annotations[AnnotationKeyVersion] = r.Version
+	}
+	return annotations
+}
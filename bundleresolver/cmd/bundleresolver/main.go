@@ -17,7 +17,6 @@ import (
 	"context"
 	"time"
 
-	"github.com/google/go-containerregistry/pkg/authn/k8schain"
 	"github.com/tektoncd/resolution/bundleresolver/pkg/bundle"
 	"github.com/tektoncd/resolution/pkg/common"
 	"github.com/tektoncd/resolution/pkg/resolver/framework"
@@ -73,10 +72,10 @@ func (r *resolver) Resolve(ctx context.Context, params map[string]string) (frame
 		return nil, err
 	}
 	namespace := common.RequestNamespace(ctx)
-	kc, err := k8schain.New(ctx, r.kubeClientSet, k8schain.Options{
-		Namespace:          namespace,
-		ServiceAccountName: opts.ServiceAccount,
-	})
+	kc, err := bundle.Keychain(ctx, r.kubeClientSet, namespace, opts)
+	if err != nil {
+		return nil, err
+	}
 	ctx, cancelFn := context.WithTimeout(ctx, timeoutDuration)
 	defer cancelFn()
 	return bundle.GetEntry(ctx, kc, opts)
@@ -24,3 +24,11 @@ const BundleAnnotationName = "dev.tekton.image.name"
 // BundleAnnotationAPIVersion is the image layer annotation used to
 // indicate the "apiVersion" of resource stored in a given layer.
 const BundleAnnotationAPIVersion = "dev.tekton.image.apiVersion"
+
+// AnnotationKeyDigest is the annotation added to resolved resources to
+// record the digest of the bundle image they were read from.
+const AnnotationKeyDigest = "resolution.tekton.dev/digest"
+
+// AnnotationKeyLayerDigest is the annotation added to resolved resources
+// to record the digest of the bundle layer they were read from.
+const AnnotationKeyLayerDigest = "resolution.tekton.dev/layer-digest"
@@ -21,14 +21,18 @@ import (
 	"io/ioutil"
 
 	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/authn/k8schain"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	"k8s.io/client-go/kubernetes"
 )
 
 // RequestOptions are the options used to request a resource from
 // a remote bundle.
 type RequestOptions struct {
 	ServiceAccount string
+	PullSecret     string
 	Bundle         string
 	EntryName      string
 	Kind           string
@@ -51,6 +55,24 @@ func (br *ResolvedResource) Annotations() map[string]string {
 	return br.annotations
 }
 
+// Keychain returns the keychain to pull a bundle with, built from the
+// pull secrets of the requested service account along with the
+// requested pull secret, if any, in the given namespace.
+func Keychain(ctx context.Context, client kubernetes.Interface, namespace string, opts RequestOptions) (authn.Keychain, error) {
+	k8sOpts := k8schain.Options{
+		Namespace:          namespace,
+		ServiceAccountName: opts.ServiceAccount,
+	}
+	if opts.PullSecret != "" {
+		k8sOpts.ImagePullSecrets = []string{opts.PullSecret}
+	}
+	kc, err := k8schain.New(ctx, client, k8sOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error building keychain: %w", err)
+	}
+	return kc, nil
+}
+
 // GetEntry accepts a keychain and options for the request and returns
 // either a successfully resolved bundle entry or an error.
 func GetEntry(ctx context.Context, keychain authn.Keychain, opts RequestOptions) (*ResolvedResource, error) {
@@ -69,6 +91,11 @@ func GetEntry(ctx context.Context, keychain authn.Keychain, opts RequestOptions)
 		return nil, fmt.Errorf("error parsing bundle manifest: %w", err)
 	}
 
+	imageDigest, err := image.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("error reading bundle digest: %w", err)
+	}
+
 	for idx, manifestLayer := range manifest.Layers {
 		layerKind, ok := manifestLayer.Annotations[BundleAnnotationKind]
 		if !ok {
@@ -108,10 +135,10 @@ func GetEntry(ctx context.Context, keychain authn.Keychain, opts RequestOptions)
 						return nil, fmt.Errorf("error reading tarball header: %w", err)
 					}
 					data := make([]byte, header.Size)
-					if n, err := tarReader.Read(data); err != nil && err != io.EOF {
-						return nil, fmt.Errorf("invalid tarball: %w", err)
-					} else if int64(n) != header.Size {
+					if n, err := io.ReadFull(tarReader, data); err == io.ErrUnexpectedEOF || err == io.EOF {
 						return nil, fmt.Errorf("layer data does not match size reported in header: expected %d received %d", header.Size, n)
+					} else if err != nil {
+						return nil, fmt.Errorf("invalid tarball: %w", err)
 					}
 					resource := ResolvedResource{
 						data: data,
@@ -119,6 +146,8 @@ func GetEntry(ctx context.Context, keychain authn.Keychain, opts RequestOptions)
 							BundleAnnotationKind:       layerKind,
 							BundleAnnotationName:       layerName,
 							BundleAnnotationAPIVersion: layerAPIVersion,
+							AnnotationKeyDigest:        imageDigest.String(),
+							AnnotationKeyLayerDigest:   manifestLayerDigest,
 						},
 					}
 					return &resource, nil
@@ -139,9 +168,16 @@ func GetEntry(ctx context.Context, keychain authn.Keychain, opts RequestOptions)
 				}
 				return &ResolvedResource{
 					data: data,
+					annotations: map[string]string{
+						BundleAnnotationKind:       layerKind,
+						BundleAnnotationName:       layerName,
+						BundleAnnotationAPIVersion: layerAPIVersion,
+						AnnotationKeyDigest:        imageDigest.String(),
+						AnnotationKeyLayerDigest:   manifestLayerDigest,
+					},
 				}, nil
 			}
 		}
 	}
-	return nil, fmt.Errorf("no matching image layer")
+	return nil, fmt.Errorf("%w: no %s named %q in bundle %s", framework.ErrorResourceNotFound, opts.Kind, opts.EntryName, opts.Bundle)
 }
@@ -0,0 +1,226 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	testUsername = "bundle-user"
+	testPassword = "bundle-password"
+)
+
+// newAuthenticatedRegistry starts an in-memory registry that requires
+// basic auth with the test credentials for every request.
+func newAuthenticatedRegistry(t *testing.T) string {
+	t.Helper()
+	handler := registry.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != testUsername || pass != testPassword {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+// bundleEntry is a single Tekton resource to store in a test bundle.
+type bundleEntry struct {
+	kind    string
+	name    string
+	content string
+}
+
+// pushTestBundle pushes a bundle holding one layer per entry to ref and
+// returns the bundle's digest.
+func pushTestBundle(t *testing.T, ref string, entries ...bundleEntry) string {
+	t.Helper()
+	img := empty.Image
+	for _, entry := range entries {
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		if err := tw.WriteHeader(&tar.Header{Name: entry.name, Mode: 0600, Size: int64(len(entry.content))}); err != nil {
+			t.Fatalf("error writing tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(entry.content)); err != nil {
+			t.Fatalf("error writing tar content: %v", err)
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatalf("error closing tar: %v", err)
+		}
+		var err error
+		img, err = mutate.Append(img, mutate.Addendum{
+			Layer: static.NewLayer(buf.Bytes(), types.DockerLayer),
+			Annotations: map[string]string{
+				BundleAnnotationKind:       entry.kind,
+				BundleAnnotationName:       entry.name,
+				BundleAnnotationAPIVersion: "v1beta1",
+			},
+		})
+		if err != nil {
+			t.Fatalf("error building bundle: %v", err)
+		}
+	}
+	parsed, err := name.ParseReference(ref)
+	if err != nil {
+		t.Fatalf("error parsing reference: %v", err)
+	}
+	if err := remote.Write(parsed, img, remote.WithAuth(&authn.Basic{Username: testUsername, Password: testPassword})); err != nil {
+		t.Fatalf("error pushing bundle: %v", err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatalf("error reading bundle digest: %v", err)
+	}
+	return digest.String()
+}
+
+func pullSecret(t *testing.T, registryHost string) *corev1.Secret {
+	t.Helper()
+	config, err := json.Marshal(map[string]interface{}{
+		"auths": map[string]interface{}{
+			registryHost: map[string]string{
+				"auth": base64.StdEncoding.EncodeToString([]byte(testUsername + ":" + testPassword)),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("error encoding docker config: %v", err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "registry-creds", Namespace: "foo"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: config,
+		},
+	}
+}
+
+func TestGetEntryWithPullSecret(t *testing.T) {
+	registryHost := newAuthenticatedRegistry(t)
+	ref := registryHost + "/catalog/hello:latest"
+	digest := pushTestBundle(t, ref, bundleEntry{kind: "task", name: "hello", content: "kind: Task\n"})
+
+	client := fakekube.NewSimpleClientset(
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "foo"}},
+		pullSecret(t, registryHost),
+	)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name        string
+		params      map[string]string
+		expectedErr bool
+	}{{
+		name: "with pull secret",
+		params: map[string]string{
+			ParamBundle:     ref,
+			ParamName:       "hello",
+			ParamKind:       "task",
+			ParamPullSecret: "registry-creds",
+		},
+	}, {
+		name: "without pull secret",
+		params: map[string]string{
+			ParamBundle: ref,
+			ParamName:   "hello",
+			ParamKind:   "task",
+		},
+		expectedErr: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts, err := OptionsFromParams(tc.params)
+			if err != nil {
+				t.Fatalf("unexpected error parsing params: %v", err)
+			}
+			kc, err := Keychain(ctx, client, "foo", opts)
+			if err != nil {
+				t.Fatalf("unexpected error building keychain: %v", err)
+			}
+			resource, err := GetEntry(ctx, kc, opts)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("expected error pulling private bundle without credentials")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != "kind: Task\n" {
+				t.Errorf("unexpected content %q", resource.Data())
+			}
+			if got := resource.Annotations()[AnnotationKeyDigest]; got != digest {
+				t.Errorf("expected digest annotation %q, received %q", digest, got)
+			}
+		})
+	}
+}
+
+func TestGetEntrySelectsResource(t *testing.T) {
+	registryHost := newAuthenticatedRegistry(t)
+	ref := registryHost + "/catalog/multi:latest"
+	digest := pushTestBundle(t, ref,
+		bundleEntry{kind: "task", name: "build", content: "kind: Task\nmetadata:\n  name: build\n"},
+		bundleEntry{kind: "task", name: "test", content: "kind: Task\nmetadata:\n  name: test\n"},
+		bundleEntry{kind: "pipeline", name: "build", content: "kind: Pipeline\nmetadata:\n  name: build\n"},
+	)
+	kc := staticKeychain{&authn.Basic{Username: testUsername, Password: testPassword}}
+	ctx := context.Background()
+
+	resource, err := GetEntry(ctx, kc, RequestOptions{Bundle: ref, Kind: "pipeline", EntryName: "build"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resource.Data()) != "kind: Pipeline\nmetadata:\n  name: build\n" {
+		t.Errorf("unexpected content %q", resource.Data())
+	}
+	annotations := resource.Annotations()
+	if annotations[BundleAnnotationKind] != "pipeline" || annotations[BundleAnnotationName] != "build" {
+		t.Errorf("unexpected kind and name annotations %v", annotations)
+	}
+	if annotations[AnnotationKeyDigest] != digest {
+		t.Errorf("expected digest annotation %q, received %q", digest, annotations[AnnotationKeyDigest])
+	}
+	if !strings.HasPrefix(annotations[AnnotationKeyLayerDigest], "sha256:") {
+		t.Errorf("expected layer digest annotation, received %q", annotations[AnnotationKeyLayerDigest])
+	}
+
+	_, err = GetEntry(ctx, kc, RequestOptions{Bundle: ref, Kind: "pipeline", EntryName: "test"})
+	if !errors.Is(err, framework.ErrorResourceNotFound) {
+		t.Fatalf("expected ErrorResourceNotFound for missing entry, received %v", err)
+	}
+}
+
+// staticKeychain resolves every registry to the same credentials.
+type staticKeychain struct {
+	authn.Authenticator
+}
+
+func (k staticKeychain) Resolve(authn.Resource) (authn.Authenticator, error) {
+	return k.Authenticator, nil
+}
@@ -34,6 +34,11 @@ const ParamName = "name"
 // image is.
 const ParamKind = "kind"
 
+// ParamPullSecret is the parameter defining the name of an image pull
+// secret in the request's namespace to use for bundle requests, in
+// addition to the service account's pull secrets.
+const ParamPullSecret = "pullSecret"
+
 const defaultServiceAccountName = "default"
 
 // OptionsFromParams parses the params from a resolution request and
@@ -65,6 +70,7 @@ func OptionsFromParams(params map[string]string) (RequestOptions, error) {
 	}
 
 	opts.ServiceAccount = sa
+	opts.PullSecret = params[ParamPullSecret]
 	opts.Bundle = bundle
 	opts.EntryName = name
 	opts.Kind = kind
@@ -0,0 +1,32 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+// ConfigFieldAllowedKinds is the configuration field name for a
+// comma-separated list of the resource kinds that may be resolved.
+// Defaults to every kind the resolver supports.
+const ConfigFieldAllowedKinds = "allowed-kinds"
+
+// ConfigFieldAllowedNamespaces is the configuration field name for a
+// comma-separated list of the namespaces that resources may be resolved
+// from, or "*" for any namespace. When unset resources may only be
+// resolved from the namespace of the request.
+const ConfigFieldAllowedNamespaces = "allowed-namespaces"
+
+// allowAllNamespaces is the ConfigFieldAllowedNamespaces entry that
+// allows resources to be resolved from any namespace.
+const allowAllNamespaces = "*"
@@ -0,0 +1,33 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+// KindParam is the kind of Tekton resource to fetch, e.g. "task" or
+// "pipeline"
+const KindParam string = "kind"
+
+// NamespaceParam is the namespace the resource lives in. Defaults to the
+// namespace of the resolution request
+const NamespaceParam string = "namespace"
+
+// NameParam is the name of the resource to fetch
+const NameParam string = "name"
+
+// StripServerFieldsParam is whether to drop the resource's status,
+// managedFields and other server-populated metadata, returning only the
+// parts of it that can be re-applied. Defaults to "true"
+const StripServerFieldsParam string = "stripServerFields"
@@ -0,0 +1,246 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"knative.dev/pkg/injection/clients/dynamicclient"
+	"sigs.k8s.io/yaml"
+)
+
+// LabelValueClusterResolverType is the value to use for the
+// resolution.tekton.dev/type label on resource requests
+const LabelValueClusterResolverType string = "cluster"
+
+// ClusterResolverName is the name that the cluster resolver should be
+// associated with
+const ClusterResolverName string = "Cluster"
+
+// YAMLContentType is the content type to use when returning yaml
+const YAMLContentType string = "application/x-yaml"
+
+// supportedKinds maps the kinds that can be requested to the Tekton
+// resources they're fetched from.
+var supportedKinds = map[string]schema.GroupVersionResource{
+	"task":     {Group: "tekton.dev", Version: "v1beta1", Resource: "tasks"},
+	"pipeline": {Group: "tekton.dev", Version: "v1beta1", Resource: "pipelines"},
+}
+
+var _ framework.Resolver = &Resolver{}
+
+// Resolver implements a framework.Resolver that can fetch Tekton
+// resources that have already been applied to the cluster.
+type Resolver struct {
+	dynamicClient dynamic.Interface
+}
+
+// Initialize performs any setup required by the cluster resolver.
+func (r *Resolver) Initialize(ctx context.Context) error {
+	r.dynamicClient = dynamicclient.Get(ctx)
+	return nil
+}
+
+// GetName returns the string name that the cluster resolver should be
+// associated with.
+func (r *Resolver) GetName(_ context.Context) string {
+	return ClusterResolverName
+}
+
+// GetSelector returns the labels that resource requests are required to
+// have for the cluster resolver to process them.
+func (r *Resolver) GetSelector(_ context.Context) map[string]string {
+	return map[string]string{
+		resolutioncommon.LabelKeyResolverType: LabelValueClusterResolverType,
+	}
+}
+
+// ValidateParams returns an error if the given parameter map is not
+// valid for a resource request targeting the cluster resolver.
+func (r *Resolver) ValidateParams(ctx context.Context, params map[string]string) error {
+	_, err := populateDefaultParams(ctx, params)
+	return err
+}
+
+// Resolve fetches the requested resource from the cluster and returns
+// it serialized as yaml.
+func (r *Resolver) Resolve(ctx context.Context, origParams map[string]string) (framework.ResolvedResource, error) {
+	params, err := populateDefaultParams(ctx, origParams)
+	if err != nil {
+		return nil, err
+	}
+	kind := params[KindParam]
+	obj, err := r.dynamicClient.Resource(supportedKinds[kind]).Namespace(params[NamespaceParam]).Get(ctx, params[NameParam], metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// Let a CompositeResolver fall through to its next layer.
+		return nil, fmt.Errorf("error getting %s %s/%s: %w", kind, params[NamespaceParam], params[NameParam], framework.ErrorResourceNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s %s/%s: %w", kind, params[NamespaceParam], params[NameParam], err)
+	}
+	content := obj.Object
+	if params[StripServerFieldsParam] == "true" {
+		content = sanitize(obj)
+	}
+	data, err := yaml.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing %s %s/%s: %w", kind, params[NamespaceParam], params[NameParam], err)
+	}
+	return &ResolvedClusterResource{
+		Content: data,
+	}, nil
+}
+
+// populateDefaultParams returns a copy of params with the namespace
+// defaulted to the request's namespace, checking that every param is
+// set and permitted by the resolver's config. The namespace must be the
+// request's own unless ConfigFieldAllowedNamespaces allows it.
+func populateDefaultParams(ctx context.Context, origParams map[string]string) (map[string]string, error) {
+	params := map[string]string{}
+	for key, val := range origParams {
+		params[key] = val
+	}
+	if params[NamespaceParam] == "" {
+		params[NamespaceParam] = resolutioncommon.RequestNamespace(ctx)
+	}
+
+	missing := []string{}
+	for _, key := range []string{KindParam, NamespaceParam, NameParam} {
+		if params[key] == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing %s", strings.Join(missing, " or "))
+	}
+
+	kind := strings.ToLower(params[KindParam])
+	if _, ok := supportedKinds[kind]; !ok {
+		return nil, fmt.Errorf("unsupported kind %q, must be one of %s", params[KindParam], strings.Join(supportedKindNames(), ", "))
+	}
+	params[KindParam] = kind
+
+	strip := true
+	if val := params[StripServerFieldsParam]; val != "" {
+		parsed, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: must be true or false", StripServerFieldsParam, val)
+		}
+		strip = parsed
+	}
+	params[StripServerFieldsParam] = strconv.FormatBool(strip)
+
+	conf := framework.GetResolverConfigFromContext(ctx)
+	if allowed, ok := allowList(conf, ConfigFieldAllowedKinds); ok && !allowed[kind] {
+		return nil, fmt.Errorf("kind %q is not in the allowed kinds", kind)
+	}
+	// Requests may only read from their own namespace unless admins
+	// allow others, so that the resolver can't be used to read
+	// resources from namespaces the requester has no access to.
+	if allowed, ok := allowList(conf, ConfigFieldAllowedNamespaces); !ok {
+		if requestNamespace := resolutioncommon.RequestNamespace(ctx); params[NamespaceParam] != requestNamespace {
+			return nil, fmt.Errorf("namespace %q is not the request's namespace %q and %s is not set", params[NamespaceParam], requestNamespace, ConfigFieldAllowedNamespaces)
+		}
+	} else if !allowed[allowAllNamespaces] && !allowed[params[NamespaceParam]] {
+		return nil, fmt.Errorf("namespace %q is not in the allowed namespaces", params[NamespaceParam])
+	}
+	return params, nil
+}
+
+// allowList parses a comma-separated config field into a set. The
+// returned bool is false if the field isn't set.
+func allowList(conf map[string]string, field string) (map[string]bool, bool) {
+	val := strings.TrimSpace(conf[field])
+	if val == "" {
+		return nil, false
+	}
+	allowed := map[string]bool{}
+	for _, entry := range strings.Split(val, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			allowed[strings.ToLower(entry)] = true
+		}
+	}
+	return allowed, true
+}
+
+func supportedKindNames() []string {
+	names := []string{}
+	for name := range supportedKinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sanitize returns the parts of obj that make up its definition,
+// dropping status, managedFields and other server-populated metadata.
+func sanitize(obj *unstructured.Unstructured) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"name":      obj.GetName(),
+		"namespace": obj.GetNamespace(),
+	}
+	if labels := obj.GetLabels(); len(labels) > 0 {
+		metadata["labels"] = labels
+	}
+	sanitized := map[string]interface{}{
+		"apiVersion": obj.GetAPIVersion(),
+		"kind":       obj.GetKind(),
+		"metadata":   metadata,
+	}
+	if spec, ok := obj.Object["spec"]; ok {
+		sanitized["spec"] = spec
+	}
+	return sanitized
+}
+
+var _ framework.ConfigWatcher = &Resolver{}
+
+// GetConfigName returns the name of the cluster resolver's configmap.
+func (r *Resolver) GetConfigName(context.Context) string {
+	return "cluster-resolver-config"
+}
+
+// ResolvedClusterResource implements framework.ResolvedResource and
+// returns the serialized resource fetched from the cluster.
+type ResolvedClusterResource struct {
+	Content []byte
+}
+
+var _ framework.ResolvedResource = &ResolvedClusterResource{}
+
+// Data returns the bytes of the serialized resource.
+func (r *ResolvedClusterResource) Data() []byte {
+	return r.Content
+}
+
+// Annotations returns the metadata that accompanies the resource.
+func (r *ResolvedClusterResource) Annotations() map[string]string {
+	return map[string]string{
+		resolutioncommon.AnnotationKeyContentType: YAMLContentType,
+	}
+}
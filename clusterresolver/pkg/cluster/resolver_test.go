@@ -0,0 +1,263 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"sigs.k8s.io/yaml"
+)
+
+func tektonObject(kind, namespace, name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "tekton.dev/v1beta1",
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":            name,
+			"namespace":       namespace,
+			"resourceVersion": "12345",
+			"uid":             "abc-def",
+			"labels": map[string]interface{}{
+				"app": name,
+			},
+			"managedFields": []interface{}{map[string]interface{}{
+				"manager":   "kubectl-client-side-apply",
+				"operation": "Update",
+			}},
+		},
+		"spec": map[string]interface{}{
+			"description": kind + " " + name,
+		},
+		"status": map[string]interface{}{
+			"ignored": true,
+		},
+	}}
+}
+
+func newTestResolver(objects ...runtime.Object) *Resolver {
+	listKinds := map[schema.GroupVersionResource]string{}
+	for kind, gvr := range supportedKinds {
+		listKinds[gvr] = strings.Title(kind) + "List"
+	}
+	return &Resolver{
+		dynamicClient: fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...),
+	}
+}
+
+func TestGetSelector(t *testing.T) {
+	resolver := Resolver{}
+	sel := resolver.GetSelector(context.Background())
+	if typ, has := sel[resolutioncommon.LabelKeyResolverType]; !has {
+		t.Fatalf("unexpected selector: %v", sel)
+	} else if typ != LabelValueClusterResolverType {
+		t.Fatalf("unexpected type: %q", typ)
+	}
+}
+
+func TestValidateParams(t *testing.T) {
+	ctx := resolutioncommon.InjectRequestNamespace(context.Background(), "foo")
+	for _, tc := range []struct {
+		name        string
+		conf        map[string]string
+		params      map[string]string
+		expectedErr string
+	}{{
+		name:   "namespace defaults to request namespace",
+		params: map[string]string{KindParam: "task", NameParam: "build"},
+	}, {
+		name:        "missing name",
+		params:      map[string]string{KindParam: "task"},
+		expectedErr: "missing name",
+	}, {
+		name:        "unsupported kind",
+		params:      map[string]string{KindParam: "configmap", NameParam: "build"},
+		expectedErr: `unsupported kind "configmap"`,
+	}, {
+		name:        "kind not allowed",
+		conf:        map[string]string{ConfigFieldAllowedKinds: "task"},
+		params:      map[string]string{KindParam: "pipeline", NameParam: "build"},
+		expectedErr: `kind "pipeline" is not in the allowed kinds`,
+	}, {
+		name:   "namespace allowed",
+		conf:   map[string]string{ConfigFieldAllowedNamespaces: "foo, bar"},
+		params: map[string]string{KindParam: "Task", NamespaceParam: "bar", NameParam: "build"},
+	}, {
+		name:   "any namespace allowed",
+		conf:   map[string]string{ConfigFieldAllowedNamespaces: "*"},
+		params: map[string]string{KindParam: "task", NamespaceParam: "bar", NameParam: "build"},
+	}, {
+		name:        "other namespace not allowed by default",
+		params:      map[string]string{KindParam: "task", NamespaceParam: "bar", NameParam: "build"},
+		expectedErr: `namespace "bar" is not the request's namespace "foo"`,
+	}, {
+		name:        "namespace not allowed",
+		conf:        map[string]string{ConfigFieldAllowedNamespaces: "bar"},
+		params:      map[string]string{KindParam: "task", NameParam: "build"},
+		expectedErr: `namespace "foo" is not in the allowed namespaces`,
+	}, {
+		name:        "invalid stripServerFields",
+		params:      map[string]string{KindParam: "task", NameParam: "build", StripServerFieldsParam: "maybe"},
+		expectedErr: `invalid stripServerFields "maybe"`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := Resolver{}
+			err := resolver.ValidateParams(framework.InjectResolverConfigToContext(ctx, tc.conf), tc.params)
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Fatalf("expected error containing %q, received %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	resolver := newTestResolver(
+		tektonObject("Task", "foo", "build"),
+		tektonObject("Pipeline", "bar", "release"),
+	)
+	ctx := resolutioncommon.InjectRequestNamespace(context.Background(), "foo")
+
+	resource, err := resolver.Resolve(ctx, map[string]string{
+		KindParam: "task",
+		NameParam: "build",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := map[string]interface{}{}
+	if err := yaml.Unmarshal(resource.Data(), &got); err != nil {
+		t.Fatalf("error parsing resolved data: %v", err)
+	}
+	if got["kind"] != "Task" || got["spec"].(map[string]interface{})["description"] != "Task build" {
+		t.Errorf("unexpected resource %v", got)
+	}
+	if _, has := got["status"]; has {
+		t.Errorf("expected status to be dropped from resolved resource")
+	}
+	if _, has := got["metadata"].(map[string]interface{})["uid"]; has {
+		t.Errorf("expected server-populated metadata to be dropped from resolved resource")
+	}
+	if ct := resource.Annotations()[resolutioncommon.AnnotationKeyContentType]; ct != YAMLContentType {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	resource, err = resolver.Resolve(framework.InjectResolverConfigToContext(ctx, map[string]string{
+		ConfigFieldAllowedNamespaces: "bar",
+	}), map[string]string{
+		KindParam:      "pipeline",
+		NamespaceParam: "bar",
+		NameParam:      "release",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(resource.Data()), "Pipeline release") {
+		t.Errorf("unexpected resource %s", resource.Data())
+	}
+
+	if _, err := resolver.Resolve(ctx, map[string]string{
+		KindParam: "pipeline",
+		NameParam: "release",
+	}); !errors.Is(err, framework.ErrorResourceNotFound) {
+		t.Errorf("expected not found error resolving pipeline from the wrong namespace, received %v", err)
+	}
+}
+
+// fallbackResolver is a framework.Resolver that resolves every request
+// to the same data, standing in for the layer after the cluster
+// resolver in a framework.CompositeResolver.
+type fallbackResolver struct{}
+
+func (fallbackResolver) Initialize(context.Context) error { return nil }
+
+func (fallbackResolver) GetName(context.Context) string { return "Fallback" }
+
+func (fallbackResolver) GetSelector(context.Context) map[string]string { return nil }
+
+func (fallbackResolver) ValidateParams(context.Context, map[string]string) error { return nil }
+
+func (fallbackResolver) Resolve(context.Context, map[string]string) (framework.ResolvedResource, error) {
+	return &ResolvedClusterResource{Content: []byte("fallback")}, nil
+}
+
+func TestCompositeFallsThroughClusterMiss(t *testing.T) {
+	composite := framework.NewCompositeResolver("layered", map[string]string{
+		resolutioncommon.LabelKeyResolverType: "layered",
+	}, newTestResolver(tektonObject("Task", "foo", "build")), fallbackResolver{})
+	ctx := resolutioncommon.InjectRequestNamespace(context.Background(), "foo")
+
+	resource, err := composite.Resolve(ctx, map[string]string{KindParam: "task", NameParam: "build"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(resource.Data()), "Task build") {
+		t.Errorf("expected task from the cluster, received %s", resource.Data())
+	}
+
+	resource, err = composite.Resolve(ctx, map[string]string{KindParam: "task", NameParam: "missing"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resource.Data()) != "fallback" {
+		t.Errorf("expected a cluster miss to fall through to the next layer, received %s", resource.Data())
+	}
+}
+
+func TestResolveStripServerFields(t *testing.T) {
+	resolver := newTestResolver(tektonObject("Task", "foo", "build"))
+	ctx := resolutioncommon.InjectRequestNamespace(context.Background(), "foo")
+
+	for _, tc := range []struct {
+		name  string
+		strip string
+	}{{
+		name: "stripped by default",
+	}, {
+		name:  "stripped",
+		strip: "true",
+	}, {
+		name:  "kept",
+		strip: "false",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resource, err := resolver.Resolve(ctx, map[string]string{
+				KindParam:              "task",
+				NameParam:              "build",
+				StripServerFieldsParam: tc.strip,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := map[string]interface{}{}
+			if err := yaml.Unmarshal(resource.Data(), &got); err != nil {
+				t.Fatalf("error parsing resolved data: %v", err)
+			}
+			metadata := got["metadata"].(map[string]interface{})
+
+			if metadata["name"] != "build" || metadata["namespace"] != "foo" || metadata["labels"].(map[string]interface{})["app"] != "build" {
+				t.Errorf("expected core metadata to remain, received %v", metadata)
+			}
+			if got["spec"].(map[string]interface{})["description"] != "Task build" {
+				t.Errorf("expected spec to remain, received %v", got["spec"])
+			}
+
+			stripped := tc.strip != "false"
+			if _, has := metadata["managedFields"]; has == stripped {
+				t.Errorf("expected managedFields present %t, received %v", !stripped, metadata)
+			}
+			if _, has := got["status"]; has == stripped {
+				t.Errorf("expected status present %t, received %v", !stripped, got)
+			}
+		})
+	}
+}
@@ -17,13 +17,32 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"flag"
+
 	"github.com/tektoncd/resolution/pkg/reconciler/resolutionrequest"
 	"k8s.io/utils/clock"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
 	"knative.dev/pkg/injection/sharedmain"
 )
 
+var requeueJitter = flag.Float64("requeue-jitter", resolutionrequest.DefaultRequeueJitter,
+	"The maximum fraction by which the interval before a ResolutionRequest is requeued is randomly lengthened.")
+
+var stuckThreshold = flag.Duration("stuck-threshold", resolutionrequest.DefaultStuckThreshold,
+	"How long a ResolutionRequest may be in progress before it's reported as stuck in the resolution_request_stuck_count metric. Set to 0 to disable.")
+
 func main() {
 	sharedmain.Main("controller",
-		resolutionrequest.NewController(clock.RealClock{}),
+		// Flags are only parsed once sharedmain starts so the
+		// controller is built from them lazily.
+		func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+			return resolutionrequest.NewController(resolutionrequest.Options{
+				Clock:          clock.RealClock{},
+				RequeueJitter:  *requeueJitter,
+				StuckThreshold: *stuckThreshold,
+			})(ctx, cmw)
+		},
 	)
 }
@@ -33,7 +33,9 @@ import (
 	"knative.dev/pkg/webhook/resourcesemantics/defaulting"
 	"knative.dev/pkg/webhook/resourcesemantics/validation"
 
+	"github.com/tektoncd/resolution/pkg/apis/config"
 	"github.com/tektoncd/resolution/pkg/apis/resolution/v1alpha1"
+	resolutionwebhook "github.com/tektoncd/resolution/pkg/webhook"
 )
 
 var types = map[schema.GroupVersionKind]resourcesemantics.GenericCRD{
@@ -72,7 +74,10 @@ func NewDefaultingAdmissionController(ctx context.Context, cmw configmap.Watcher
 // NewValidationAdmissionController returns the validating webhook's
 // controller.
 func NewValidationAdmissionController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
-	return validation.NewAdmissionController(ctx,
+	store := config.NewStore(logging.FromContext(ctx).Named("config-store"))
+	store.WatchConfigs(cmw)
+
+	impl := validation.NewAdmissionController(ctx,
 
 		// Name of the resource webhook.
 		"validation.webhook.resolution.tekton.dev",
@@ -84,11 +89,7 @@ func NewValidationAdmissionController(ctx context.Context, cmw configmap.Watcher
 		types,
 
 		// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
-		func(ctx context.Context) context.Context {
-			// Here is where you would infuse the context with state
-			// (e.g. attach a store with configmap data)
-			return ctx
-		},
+		store.ToContext,
 
 		// Whether to disallow unknown fields.
 		true,
@@ -96,6 +97,7 @@ func NewValidationAdmissionController(ctx context.Context, cmw configmap.Watcher
 		// Extra validating callbacks to be applied to resources.
 		callbacks,
 	)
+	return resolutionwebhook.WithDeprecationWarnings(impl, store.ToContext)
 }
 
 // NewConfigValidationController returns the configmap validation
@@ -111,8 +113,9 @@ func NewConfigValidationController(ctx context.Context, cmw configmap.Watcher) *
 
 		// The configmaps to validate.
 		configmap.Constructors{
-			logging.ConfigMapName(): logging.NewConfigFromConfigMap,
-			metrics.ConfigMapName(): metrics.NewObservabilityConfigFromConfigMap,
+			logging.ConfigMapName():       logging.NewConfigFromConfigMap,
+			metrics.ConfigMapName():       metrics.NewObservabilityConfigFromConfigMap,
+			config.DeprecationsConfigName: config.NewDeprecationsFromConfigMap,
 		},
 	)
 }
@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// LabelValueFilesystemResolverType is the value to use for the
+// resolution.tekton.dev/type label on resource requests
+const LabelValueFilesystemResolverType string = "filesystem"
+
+// FilesystemResolverName is the name that the filesystem resolver
+// should be associated with
+const FilesystemResolverName string = "Filesystem"
+
+// YAMLContentType is the content type to use when returning yaml
+const YAMLContentType string = "application/x-yaml"
+
+var _ framework.Resolver = &Resolver{}
+
+// Resolver implements a framework.Resolver that reads files from a
+// directory mounted into the resolver's pod. It's intended for
+// development and testing.
+type Resolver struct{}
+
+// Initialize performs any setup required by the filesystem resolver.
+func (r *Resolver) Initialize(context.Context) error {
+	return nil
+}
+
+// GetName returns the string name that the filesystem resolver should
+// be associated with.
+func (r *Resolver) GetName(_ context.Context) string {
+	return FilesystemResolverName
+}
+
+// GetSelector returns the labels that resource requests are required to
+// have for the filesystem resolver to process them.
+func (r *Resolver) GetSelector(_ context.Context) map[string]string {
+	return map[string]string{
+		resolutioncommon.LabelKeyResolverType: LabelValueFilesystemResolverType,
+	}
+}
+
+// ValidateParams returns an error if the given parameter map is not
+// valid for a resource request targeting the filesystem resolver.
+func (r *Resolver) ValidateParams(_ context.Context, params map[string]string) error {
+	return validatePath(params[PathParam])
+}
+
+// validatePath returns an error if path is missing or could name a file
+// outside of the root.
+func validatePath(path string) error {
+	if strings.TrimSpace(path) == "" {
+		return fmt.Errorf("missing %s", PathParam)
+	}
+	if filepath.IsAbs(path) || strings.HasPrefix(path, "/") {
+		return fmt.Errorf("invalid %q %q: must be relative to the root", PathParam, path)
+	}
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if part == ".." {
+			return fmt.Errorf("invalid %q %q: must not contain %q", PathParam, path, "..")
+		}
+	}
+	return nil
+}
+
+// Resolve reads the requested file from beneath the configured root.
+func (r *Resolver) Resolve(ctx context.Context, params map[string]string) (framework.ResolvedResource, error) {
+	path := params[PathParam]
+	if err := validatePath(path); err != nil {
+		return nil, err
+	}
+	root := framework.GetResolverConfigFromContext(ctx)[ConfigFieldRoot]
+	if root == "" {
+		return nil, fmt.Errorf("no %q configured to read files from", ConfigFieldRoot)
+	}
+	fullPath, err := resolveWithinRoot(root, path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(fullPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error reading %q: %w", path, framework.ErrorResourceNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %q: %w", path, err)
+	}
+	return &ResolvedFilesystemResource{
+		Content: content,
+	}, nil
+}
+
+// resolveWithinRoot returns the location of path beneath root, following
+// symlinks, and returns an error if it would be outside of root.
+func resolveWithinRoot(root, path string) (string, error) {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("error reading %q %q: %w", ConfigFieldRoot, root, err)
+	}
+	fullPath := filepath.Join(realRoot, filepath.FromSlash(path))
+	realPath, err := filepath.EvalSymlinks(fullPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("error reading %q: %w", path, framework.ErrorResourceNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading %q: %w", path, err)
+	}
+	rel, err := filepath.Rel(realRoot, realPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid %q %q: resolves to a file outside of the root", PathParam, path)
+	}
+	return realPath, nil
+}
+
+var _ framework.ConfigWatcher = &Resolver{}
+
+// GetConfigName returns the name of the filesystem resolver's configmap.
+func (r *Resolver) GetConfigName(context.Context) string {
+	return "filesystem-resolver-config"
+}
+
+// ResolvedFilesystemResource implements framework.ResolvedResource and
+// returns the content of a file read from the filesystem.
+type ResolvedFilesystemResource struct {
+	Content []byte
+}
+
+var _ framework.ResolvedResource = &ResolvedFilesystemResource{}
+
+// Data returns the bytes of the file.
+func (r *ResolvedFilesystemResource) Data() []byte {
+	return r.Content
+}
+
+// Annotations returns the metadata that accompanies the file.
+func (r *ResolvedFilesystemResource) Annotations() map[string]string {
+	return map[string]string{
+		resolutioncommon.AnnotationKeyContentType: YAMLContentType,
+	}
+}
@@ -0,0 +1,87 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+func TestGetSelector(t *testing.T) {
+	resolver := Resolver{}
+	sel := resolver.GetSelector(context.Background())
+	if typ, has := sel[resolutioncommon.LabelKeyResolverType]; !has {
+		t.Fatalf("unexpected selector: %v", sel)
+	} else if typ != LabelValueFilesystemResolverType {
+		t.Fatalf("unexpected type: %q", typ)
+	}
+}
+
+func TestValidateParams(t *testing.T) {
+	resolver := Resolver{}
+	for _, path := range []string{"task.yaml", "tasks/build.yaml", "./tasks/build.yaml"} {
+		if err := resolver.ValidateParams(context.Background(), map[string]string{PathParam: path}); err != nil {
+			t.Errorf("unexpected error for path %q: %v", path, err)
+		}
+	}
+	for _, path := range []string{"", " ", "/etc/passwd", "../secret.yaml", "tasks/../../secret.yaml", `tasks\..\..\secret.yaml`} {
+		if err := resolver.ValidateParams(context.Background(), map[string]string{PathParam: path}); err == nil {
+			t.Errorf("expected error for path %q", path)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("error creating directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("error writing %q: %v", path, err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	writeFile(t, filepath.Join(root, "tasks", "build.yaml"), "kind: Task\n")
+	writeFile(t, filepath.Join(dir, "secret.yaml"), "kind: Secret\n")
+	if err := os.Symlink(filepath.Join(dir, "secret.yaml"), filepath.Join(root, "escape.yaml")); err != nil {
+		t.Fatalf("error creating symlink: %v", err)
+	}
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldRoot: root,
+	})
+	resolver := Resolver{}
+
+	resource, err := resolver.Resolve(ctx, map[string]string{PathParam: "tasks/build.yaml"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resource.Data()) != "kind: Task\n" {
+		t.Errorf("unexpected content %q", resource.Data())
+	}
+
+	for _, path := range []string{"../secret.yaml", "escape.yaml"} {
+		if _, err := resolver.Resolve(ctx, map[string]string{PathParam: path}); err == nil || !strings.Contains(err.Error(), PathParam) {
+			t.Errorf("expected %q to be rejected for escaping the root, received %v", path, err)
+		}
+	}
+
+	_, err = resolver.Resolve(ctx, map[string]string{PathParam: "tasks/missing.yaml"})
+	if !errors.Is(err, framework.ErrorResourceNotFound) {
+		t.Errorf("expected ErrorResourceNotFound for missing file, received %v", err)
+	}
+}
+
+func TestResolveWithoutRoot(t *testing.T) {
+	resolver := Resolver{}
+	if _, err := resolver.Resolve(context.Background(), map[string]string{PathParam: "task.yaml"}); err == nil {
+		t.Fatalf("expected error when no root is configured")
+	}
+}
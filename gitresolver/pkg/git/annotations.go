@@ -20,4 +20,125 @@ const (
 	// AnnotationKeyCommitHash is the commit hash that was fetched
 	// from git
 	AnnotationKeyCommitHash = "commit"
+
+	// AnnotationKeyContentSHA256 is the hex-encoded sha256 digest
+	// of the resolved content
+	AnnotationKeyContentSHA256 = "resolution.tekton.dev/content-sha256"
+
+	// AnnotationKeyBytesFetched is the number of bytes received from
+	// the git host while cloning
+	AnnotationKeyBytesFetched = "resolution.tekton.dev/bytes-fetched"
+
+	// AnnotationKeyCheckoutRetries is the number of times checking out
+	// the fetched commit was retried before it succeeded
+	AnnotationKeyCheckoutRetries = "resolution.tekton.dev/checkout-retries"
+
+	// AnnotationKeyFetchTags is the FetchTagsParam mode, e.g. "none",
+	// that tags were fetched with while cloning
+	AnnotationKeyFetchTags = "resolution.tekton.dev/fetch-tags"
+
+	// AnnotationKeyHTTPCacheHits is the number of responses reused
+	// while cloning because the git host confirmed they were current
+	AnnotationKeyHTTPCacheHits = "resolution.tekton.dev/http-cache-hits"
+
+	// AnnotationKeyDescribe is the `git describe` style name of the
+	// fetched commit, added when DescribeParam is "true"
+	AnnotationKeyDescribe = "resolution.tekton.dev/describe"
+
+	// AnnotationKeyTreeHash is the hash of the fetched commit's tree,
+	// added when TreeHashParam is "true"
+	AnnotationKeyTreeHash = "resolution.tekton.dev/tree-hash"
+
+	// AnnotationKeyLineRange is the range of lines, e.g. "3-10", that
+	// was selected with StartLineParam and EndLineParam
+	AnnotationKeyLineRange = "resolution.tekton.dev/line-range"
+
+	// AnnotationKeyOutputFormat is the format, e.g. "json", that the
+	// file was converted to with OutputFormatParam
+	AnnotationKeyOutputFormat = "resolution.tekton.dev/output-format"
+
+	// AnnotationKeyMaterial is the resolved file as an in-toto
+	// material in JSON, added when ProvenanceParam is "true"
+	AnnotationKeyMaterial = "resolution.tekton.dev/material"
+
+	// AnnotationKeyCommitParents is the comma-separated hashes of the
+	// fetched commit's parents, in order, added unless it's a root
+	// commit
+	AnnotationKeyCommitParents = "resolution.tekton.dev/commit-parents"
+
+	// AnnotationKeyNotes is the git note attached to the fetched
+	// commit, added when NotesParam is "true" and the commit has one
+	AnnotationKeyNotes = "resolution.tekton.dev/notes"
+
+	// AnnotationKeyPath is the path in the repo that a WellKnownParam
+	// file was found at
+	AnnotationKeyPath = "resolution.tekton.dev/path"
+
+	// AnnotationKeyLFSOID is the sha256 of a file fetched from Git
+	// LFS with LFSParam
+	AnnotationKeyLFSOID = "resolution.tekton.dev/lfs-oid"
+
+	// AnnotationKeyLFSSize is the size in bytes of a file fetched from
+	// Git LFS with LFSParam
+	AnnotationKeyLFSSize = "resolution.tekton.dev/lfs-size"
+
+	// AnnotationKeyExpandedURL is the full clone url that a short
+	// URLParam, e.g. github.com/org/repo, was expanded to
+	AnnotationKeyExpandedURL = "resolution.tekton.dev/expanded-url"
+
+	// AnnotationKeyRefACommit is the commit hash that RefAParam
+	// resolved to
+	AnnotationKeyRefACommit = "resolution.tekton.dev/ref-a-commit"
+
+	// AnnotationKeyRefBCommit is the commit hash that RefBParam
+	// resolved to
+	AnnotationKeyRefBCommit = "resolution.tekton.dev/ref-b-commit"
+
+	// AnnotationKeyMergeBase is the merge-base commit resolved when
+	// MergeBaseParam is set
+	AnnotationKeyMergeBase = "resolution.tekton.dev/merge-base"
+
+	// AnnotationKeyServedBy is the clone url, either a configured read
+	// replica or the primary URLParam, that the repo was cloned from
+	AnnotationKeyServedBy = "resolution.tekton.dev/served-by"
+
+	// AnnotationKeyDependencies is the json list of tasks that a
+	// resolved pipeline references, added when DependenciesParam is set
+	AnnotationKeyDependencies = "resolution.tekton.dev/dependencies"
+
+	// AnnotationKeyManifest is the json list of the path, kind and name
+	// of each document resolved with TektonBundleDirParam or
+	// PacEventParam
+	AnnotationKeyManifest = "resolution.tekton.dev/manifest"
+
+	// AnnotationKeyTagger is the "name <email>" identity of the tagger
+	// of a tag whose message was resolved with TagMessageParam.
+	AnnotationKeyTagger = "resolution.tekton.dev/tagger"
+
+	// AnnotationKeyTaggedAt is the RFC3339 time at which a tag whose
+	// message was resolved with TagMessageParam was created.
+	AnnotationKeyTaggedAt = "resolution.tekton.dev/tagged-at"
+
+	// AnnotationKeyServedStale is the RFC3339 time at which content
+	// served from the cache, because the repo couldn't be reached, was
+	// originally resolved.
+	AnnotationKeyServedStale = "resolution.tekton.dev/served-stale"
+
+	// AnnotationKeyEnvironmentTag is the environment tag that an
+	// EnvironmentParam was resolved to. The commit it pointed to is
+	// recorded under AnnotationKeyCommitHash.
+	AnnotationKeyEnvironmentTag = "resolution.tekton.dev/environment-tag"
+
+	// AnnotationKeyFileMode is the git mode, e.g. "100755", of the
+	// resolved file in the fetched commit's tree
+	AnnotationKeyFileMode = "resolution.tekton.dev/file-mode"
+
+	// AnnotationKeyDocuments is the json list of the start and end
+	// offsets, kind and name of each yaml document in the resolved
+	// content, added when SplitDocumentsParam is "true"
+	AnnotationKeyDocuments = "resolution.tekton.dev/documents"
+
+	// AnnotationKeyCommittedAt is the RFC3339 time at which the commit
+	// resolved with HeadOnlyParam was committed
+	AnnotationKeyCommittedAt = "resolution.tekton.dev/committed-at"
 )
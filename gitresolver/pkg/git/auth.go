@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// cloneAuth returns the credentials to clone with based on the
+// request's params, or nil if the repo at url should be cloned
+// anonymously.
+func (r *Resolver) cloneAuth(ctx context.Context, url string, params map[string]string) (transport.AuthMethod, error) {
+	if secretName := params[GitHubAppSecretParam]; secretName != "" {
+		return r.githubAppAuth(ctx, secretName)
+	}
+	if secretName := params[BearerTokenSecretParam]; secretName != "" {
+		return r.bearerTokenAuth(ctx, secretName)
+	}
+	if user, ok := sshUser(url); ok {
+		return r.sshAuth(ctx, user, params)
+	}
+	if helper := strings.TrimSpace(framework.GetResolverConfigFromContext(ctx)[ConfigFieldCredentialHelper]); helper != "" {
+		return credentialHelperAuth(ctx, helper, url)
+	}
+	return nil, nil
+}
+
+// githubAppAuth mints, or reuses, an installation token for the GitHub
+// App described by the named secret and returns it as basic auth.
+func (r *Resolver) githubAppAuth(ctx context.Context, secretName string) (transport.AuthMethod, error) {
+	secret, err := r.getSecret(ctx, secretName)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := parseGitHubAppCredentials(secret.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid github app secret %q: %w", secretName, err)
+	}
+	apiURL := defaultGitHubAPIURL
+	if configured := framework.GetResolverConfigFromContext(ctx)[ConfigFieldGitHubAPIURL]; configured != "" {
+		apiURL = configured
+	}
+	token, err := r.githubAppTokens.get(ctx, http.DefaultClient, apiURL, resolutioncommon.RequestNamespace(ctx), secretName, creds)
+	if err != nil {
+		return nil, err
+	}
+	return &githttp.BasicAuth{
+		Username: githubInstallationTokenUsername,
+		Password: token,
+	}, nil
+}
+
+// BearerTokenSecretKeyToken is the key in a BearerTokenSecretParam
+// secret holding the token.
+const BearerTokenSecretKeyToken = "token"
+
+// bearerTokenAuth returns the token in the named secret as bearer auth.
+func (r *Resolver) bearerTokenAuth(ctx context.Context, secretName string) (transport.AuthMethod, error) {
+	secret, err := r.getSecret(ctx, secretName)
+	if err != nil {
+		return nil, err
+	}
+	token := strings.TrimSpace(string(secret.Data[BearerTokenSecretKeyToken]))
+	if token == "" {
+		return nil, fmt.Errorf("invalid bearer token secret %q: missing %s", secretName, BearerTokenSecretKeyToken)
+	}
+	return &githttp.TokenAuth{Token: token}, nil
+}
+
+// getSecret fetches a secret from the namespace of the request being
+// resolved. Transient errors from the api server are retried, with
+// backoff, as far as the request's retry budget allows.
+func (r *Resolver) getSecret(ctx context.Context, name string) (*corev1.Secret, error) {
+	if r.kubeClientSet == nil {
+		return nil, errors.New("git resolver has no kubernetes client to read secrets with")
+	}
+	namespace := resolutioncommon.RequestNamespace(ctx)
+	budget := retryBudgetFromContext(ctx)
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		secret, err := r.kubeClientSet.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			return secret, nil
+		}
+		if !transientAPIError(err) || attempt == maxHTTPRetries || !budget.allow(backoff) {
+			return nil, fmt.Errorf("error getting secret %s/%s: %w", namespace, name, err)
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// transientAPIError returns whether err from the api server may succeed
+// if the request is retried.
+func transientAPIError(err error) bool {
+	return apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsServiceUnavailable(err)
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// checkoutParams are the params that determine which clone and commit
+// a request is resolved from. Requests that agree on all of them can
+// share a single checkout.
+var checkoutParams = []string{
+	URLParam,
+	CommitParam,
+	BranchParam,
+	AsOfParam,
+	GitHubAppSecretParam,
+	BearerTokenSecretParam,
+	ProxyParam,
+	ProxySecretParam,
+	RefAParam,
+	RefBParam,
+	NotesParam,
+	WorktreeParam,
+	RevisionParam,
+	EnvironmentParam,
+	CommitMessageFilterParam,
+	FetchTagsParam,
+	// DescribeParam changes which tags are fetched by default.
+	DescribeParam,
+	// StrictHostKeyCheckingParam changes which ssh hosts a clone may
+	// be made from, so a strict request mustn't share a clone made
+	// under a laxer policy.
+	StrictHostKeyCheckingParam,
+}
+
+// checkoutKey returns a string identifying the checkout that params
+// resolve from.
+func checkoutKey(params map[string]string) string {
+	parts := []string{}
+	for _, p := range checkoutParams {
+		parts = append(parts, p+"="+params[p])
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// cloneKey returns a string identifying the clone that params resolve
+// from. Requests for different commits of the same clone can share it,
+// each checking out its own commit in turn.
+func cloneKey(params map[string]string) string {
+	parts := []string{}
+	for _, p := range checkoutParams {
+		if p != CommitParam {
+			parts = append(parts, p+"="+params[p])
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+var _ framework.BatchResolver = &Resolver{}
+
+// ResolveBatch resolves several files, cloning each distinct repo and
+// commit only once and reading every requested path from it. Requests
+// pinned to different commits of the same repo share one clone, which
+// is checked out at each of their commits in turn. Requests for repos
+// with a scheme registered with RegisterScheme are resolved by its
+// handler.
+func (r *Resolver) ResolveBatch(ctx context.Context, paramsList []map[string]string) ([]framework.ResolvedResource, []error) {
+	resources := make([]framework.ResolvedResource, len(paramsList))
+	errs := make([]error, len(paramsList))
+	checkouts := map[string]*checkout{}
+	checkoutErrs := map[string]error{}
+	commitClones := map[string]*checkout{}
+	for i, params := range paramsList {
+		if handler, ok := customSchemeHandler(params); ok {
+			resources[i], errs[i] = handler.Resolve(withResolver(ctx, r), params)
+			continue
+		}
+		if cached, ok := r.cachedResult(ctx, params); ok {
+			resources[i] = cached
+			continue
+		}
+		key := checkoutKey(params)
+		commit := params[CommitParam]
+		co, seen := checkouts[key]
+		if !seen && commit != "" {
+			if shared := commitClones[cloneKey(params)]; shared != nil && shared.recheckout(commit) == nil {
+				co, seen = shared, true
+				checkouts[key], checkoutErrs[key] = co, nil
+			}
+		}
+		if !seen {
+			var err error
+			co, err = r.checkout(ctx, params)
+			checkouts[key], checkoutErrs[key] = co, err
+			if err == nil && commit != "" {
+				commitClones[cloneKey(params)] = co
+			}
+		}
+		if err := checkoutErrs[key]; err != nil {
+			resources[i], errs[i] = r.withStaleFallback(ctx, params, nil, err)
+			continue
+		}
+		// A clone shared between commits may have since been checked
+		// out at another one.
+		if commit != "" && co.commit != commit {
+			if err := co.recheckout(commit); err != nil {
+				errs[i] = err
+				continue
+			}
+		}
+		resource, err := r.resolveFromCheckout(ctx, co, params)
+		resource, err = r.withStaleFallback(ctx, params, resource, err)
+		resources[i], errs[i] = r.cacheResult(ctx, params, resource, err)
+	}
+	return resources, errs
+}
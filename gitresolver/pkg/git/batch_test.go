@@ -0,0 +1,128 @@
+package git
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+func TestResolveBatchMatchesSequential(t *testing.T) {
+	repo, commits := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{
+			"a.yaml": "a-first",
+			"b.yaml": "b-first",
+		},
+	}, {
+		Files: map[string]string{
+			"a.yaml": "a-second",
+			"c.yaml": "c-second",
+		},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	batch := []map[string]string{
+		{URLParam: server.repoURL(), PathParam: "a.yaml"},
+		{URLParam: server.repoURL(), PathParam: "b.yaml"},
+		{URLParam: server.repoURL(), PathParam: "c.yaml"},
+		{URLParam: server.repoURL(), PathParam: "a.yaml", CommitParam: commits[0]},
+		{URLParam: server.repoURL(), PathParam: "missing.yaml"},
+	}
+
+	resolver := Resolver{}
+	sequential := []framework.ResolvedResource{}
+	sequentialErrs := []error{}
+	for _, params := range batch {
+		resource, err := resolver.Resolve(context.Background(), params)
+		sequential = append(sequential, resource)
+		sequentialErrs = append(sequentialErrs, err)
+	}
+	sequentialRequests := len(server.receivedHeaders())
+
+	resources, errs := framework.ResolveBatch(context.Background(), &resolver, batch)
+	batchRequests := len(server.receivedHeaders()) - sequentialRequests
+
+	for i := range batch {
+		if (errs[i] == nil) != (sequentialErrs[i] == nil) {
+			t.Fatalf("batch and sequential errors differ at %d: %v vs %v", i, errs[i], sequentialErrs[i])
+		}
+		if errs[i] != nil {
+			continue
+		}
+		if string(resources[i].Data()) != string(sequential[i].Data()) {
+			t.Errorf("batch and sequential content differ at %d: %q vs %q", i, resources[i].Data(), sequential[i].Data())
+		}
+		if resources[i].Annotations()[AnnotationKeyCommitHash] != sequential[i].Annotations()[AnnotationKeyCommitHash] {
+			t.Errorf("batch and sequential commits differ at %d", i)
+		}
+	}
+	if errs[4] == nil {
+		t.Errorf("expected error for missing file")
+	}
+	// Two distinct checkouts are needed: HEAD and the first commit.
+	if batchRequests*len(batch) > sequentialRequests*2 {
+		t.Errorf("expected batch to share clones: %d requests for batch vs %d sequential", batchRequests, sequentialRequests)
+	}
+}
+
+func TestResolveBatchSharesCloneBetweenCommits(t *testing.T) {
+	repo, commits := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "first", "old.yaml": "old"},
+	}, {
+		Files: map[string]string{"task.yaml": "second"},
+	}, {
+		Files: map[string]string{"task.yaml": "third"},
+	}, {
+		// old.yaml must not linger in a clone shared with the first
+		// commit.
+		Files:  map[string]string{"task.yaml": "fourth"},
+		Delete: []string{"old.yaml"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	clones := countRefAdvertisements(server)
+
+	batch := []map[string]string{}
+	expected := []string{}
+	for _, i := range []int{2, 0, 3, 1, 0} {
+		batch = append(batch, map[string]string{
+			URLParam:    server.repoURL(),
+			PathParam:   "task.yaml",
+			CommitParam: commits[i],
+		})
+		expected = append(expected, []string{"first", "second", "third", "fourth"}[i])
+	}
+	batch = append(batch, map[string]string{
+		URLParam:    server.repoURL(),
+		PathParam:   "old.yaml",
+		CommitParam: commits[3],
+	})
+
+	resolver := Resolver{}
+	resources, errs := framework.ResolveBatch(context.Background(), &resolver, batch)
+	for i := range expected {
+		if errs[i] != nil {
+			t.Fatalf("unexpected error resolving %d: %v", i, errs[i])
+		}
+		if string(resources[i].Data()) != expected[i] {
+			t.Errorf("expected %q at %d, received %q", expected[i], i, resources[i].Data())
+		}
+		if commit := resources[i].Annotations()[AnnotationKeyCommitHash]; commit != batch[i][CommitParam] {
+			t.Errorf("expected commit %s at %d, received %s", batch[i][CommitParam], i, commit)
+		}
+	}
+	if errs[len(batch)-1] == nil {
+		t.Errorf("expected old.yaml to be missing at the last commit")
+	}
+	if n := clones(); n != 1 {
+		t.Errorf("expected the commits to share one clone, made %d", n)
+	}
+}
+
+func TestCheckoutKeyDependsOnHostKeyPolicy(t *testing.T) {
+	strict := map[string]string{URLParam: "git@github.com:tektoncd/catalog.git", StrictHostKeyCheckingParam: hostKeyPolicyStrict}
+	for _, policy := range []string{hostKeyPolicyAcceptNew, hostKeyPolicyInsecure} {
+		lax := map[string]string{URLParam: strict[URLParam], StrictHostKeyCheckingParam: policy}
+		if checkoutKey(strict) == checkoutKey(lax) || cloneKey(strict) == cloneKey(lax) {
+			t.Errorf("expected a strict request not to share a checkout or clone with a %q one", policy)
+		}
+	}
+}
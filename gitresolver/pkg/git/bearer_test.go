@@ -0,0 +1,88 @@
+package git
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+func bearerTokenSecret(token string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "git-token",
+			Namespace: "foo",
+		},
+		Data: map[string][]byte{
+			BearerTokenSecretKeyToken: []byte(token),
+		},
+	}
+}
+
+func TestResolveWithBearerToken(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="git"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return true
+		}
+		return false
+	})
+
+	resolver := Resolver{
+		kubeClientSet: fakekube.NewSimpleClientset(bearerTokenSecret("s3cr3t\n")),
+	}
+	ctx := resolutioncommon.InjectRequestNamespace(context.Background(), "foo")
+	resource, err := resolver.Resolve(ctx, map[string]string{
+		URLParam:               server.repoURL(),
+		PathParam:              "task.yaml",
+		BearerTokenSecretParam: "git-token",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resource.Data()) != "content" {
+		t.Fatalf("unexpected content %q", resource.Data())
+	}
+	for _, header := range server.receivedHeaders() {
+		if _, _, ok := (&http.Request{Header: header}).BasicAuth(); ok {
+			t.Errorf("expected no basic auth to be sent, received %q", header.Get("Authorization"))
+		}
+	}
+}
+
+func TestResolveWithBearerTokenMissingToken(t *testing.T) {
+	resolver := Resolver{
+		kubeClientSet: fakekube.NewSimpleClientset(bearerTokenSecret("")),
+	}
+	ctx := resolutioncommon.InjectRequestNamespace(context.Background(), "foo")
+	_, err := resolver.Resolve(ctx, map[string]string{
+		URLParam:               "https://example.com/repo.git",
+		PathParam:              "task.yaml",
+		BearerTokenSecretParam: "git-token",
+	})
+	if err == nil || !strings.Contains(err.Error(), BearerTokenSecretKeyToken) {
+		t.Fatalf("expected error naming missing token, received %v", err)
+	}
+}
+
+func TestValidateParamsBearerTokenWithGitHubApp(t *testing.T) {
+	err := (&Resolver{}).ValidateParams(context.Background(), map[string]string{
+		URLParam:               "https://example.com/repo.git",
+		PathParam:              "task.yaml",
+		GitHubAppSecretParam:   "github-app",
+		BearerTokenSecretParam: "git-token",
+	})
+	if err == nil || !strings.Contains(err.Error(), "supplied both") {
+		t.Fatalf("expected error rejecting both auth secrets, received %v", err)
+	}
+}
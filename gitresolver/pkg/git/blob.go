@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// validateBlob returns an error if BlobParam isn't a full object hash or
+// is combined with params that need the path of a file.
+func validateBlob(params map[string]string) error {
+	blob := params[BlobParam]
+	if blob == "" {
+		return nil
+	}
+	for _, p := range []string{PathParam, WellKnownParam, ResolveIncludesParam, ProvenanceParam} {
+		if params[p] != "" {
+			return fmt.Errorf("supplied both %q and %q", BlobParam, p)
+		}
+	}
+	if len(blob) != 40 || !plumbing.IsHash(blob) {
+		return fmt.Errorf("invalid %q %q: must be a full 40 character object hash", BlobParam, blob)
+	}
+	return nil
+}
+
+// readBlob returns the content of the blob with the given hash from
+// repository's fetched objects.
+func readBlob(repository *git.Repository, hash string) ([]byte, error) {
+	blob, err := repository.BlobObject(plumbing.NewHash(hash))
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, fmt.Errorf("error reading blob %s: %w", hash, ErrBlobNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading blob %s: %w", hash, err)
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("error reading blob %s: %w", hash, err)
+	}
+	defer r.Close()
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading blob %s: %w", hash, err)
+	}
+	return content, nil
+}
@@ -0,0 +1,92 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResolveBlob(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"tasks/old.yaml": "kind: Task\nversion: 1\n"},
+	}, {
+		Files:  map[string]string{"tasks/new.yaml": "kind: Task\nversion: 2\n"},
+		Delete: []string{"tasks/old.yaml"},
+	}})
+	for _, tc := range []struct {
+		name        string
+		blob        string
+		expected    string
+		expectedErr error
+	}{{
+		name:     "blob in resolved commit",
+		blob:     testBlobHash("kind: Task\nversion: 2\n"),
+		expected: "kind: Task\nversion: 2\n",
+	}, {
+		name:     "blob earlier in history",
+		blob:     testBlobHash("kind: Task\nversion: 1\n"),
+		expected: "kind: Task\nversion: 1\n",
+	}, {
+		name:        "unknown blob",
+		blob:        testBlobHash("kind: Pipeline\n"),
+		expectedErr: ErrBlobNotFound,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			params := map[string]string{
+				URLParam:  repo,
+				BlobParam: tc.blob,
+			}
+			resolver := Resolver{}
+			if err := resolver.ValidateParams(context.Background(), params); err != nil {
+				t.Fatalf("unexpected error validating params: %v", err)
+			}
+			resource, err := resolver.Resolve(context.Background(), params)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, received %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.expected {
+				t.Errorf("expected content %q, received %q", tc.expected, resource.Data())
+			}
+		})
+	}
+}
+
+func TestValidateParamsBlob(t *testing.T) {
+	blob := testBlobHash("kind: Task\n")
+	for _, tc := range []struct {
+		name        string
+		params      map[string]string
+		expectedErr string
+	}{{
+		name:        "blob and path",
+		params:      map[string]string{BlobParam: blob, PathParam: "task.yaml"},
+		expectedErr: `supplied both "blob" and "path"`,
+	}, {
+		name:        "blob and well-known file",
+		params:      map[string]string{BlobParam: blob, WellKnownParam: wellKnownLicense},
+		expectedErr: `supplied both`,
+	}, {
+		name:        "blob and includes",
+		params:      map[string]string{BlobParam: blob, ResolveIncludesParam: "true"},
+		expectedErr: `supplied both "blob" and "resolveIncludes"`,
+	}, {
+		name:        "abbreviated blob",
+		params:      map[string]string{BlobParam: blob[:12]},
+		expectedErr: `invalid "blob"`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.params[URLParam] = "https://github.com/tektoncd/catalog.git"
+			err := (&Resolver{}).ValidateParams(context.Background(), tc.params)
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Fatalf("expected error containing %q, received %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultRetryBudget is the number of retries a request may make in
+// total when ConfigFieldRetryBudget isn't set.
+const defaultRetryBudget = 10
+
+// retryBudgetReserve is the fraction of the time left to resolve a
+// request that retries can't use, so that there's still time to return
+// the last response or error once retries are exhausted.
+const retryBudgetReserve = 0.1
+
+// retryBudgetKey is the context key for a request's retryBudget.
+type retryBudgetKey struct{}
+
+// retryBudget bounds the retries made while resolving a single request,
+// across every operation that retries: http requests to the git host and
+// its LFS server and secret lookups. A retry is only allowed while there
+// are retries left and it would start before the deadline.
+type retryBudget struct {
+	mu        sync.Mutex
+	remaining int
+	// deadline is zero if the request has no deadline.
+	deadline time.Time
+}
+
+// newRetryBudget returns a budget of retries retries that must start by
+// the request's deadline in ctx, less retryBudgetReserve of the time
+// left until it.
+func newRetryBudget(ctx context.Context, retries int) *retryBudget {
+	b := &retryBudget{remaining: retries}
+	if deadline, ok := ctx.Deadline(); ok {
+		reserve := time.Duration(float64(time.Until(deadline)) * retryBudgetReserve)
+		b.deadline = deadline.Add(-reserve)
+	}
+	return b
+}
+
+// parseRetryBudget parses ConfigFieldRetryBudget from conf.
+func parseRetryBudget(conf map[string]string) (int, error) {
+	val, ok := conf[ConfigFieldRetryBudget]
+	if !ok || val == "" {
+		return defaultRetryBudget, nil
+	}
+	retries, err := strconv.Atoi(val)
+	if err != nil || retries < 0 {
+		return 0, fmt.Errorf("invalid %q config %q: must be a non-negative integer", ConfigFieldRetryBudget, val)
+	}
+	return retries, nil
+}
+
+// withRetryBudget returns a context carrying b.
+func withRetryBudget(ctx context.Context, b *retryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, b)
+}
+
+// retryBudgetFromContext returns the retryBudget in ctx or nil if there
+// isn't one.
+func retryBudgetFromContext(ctx context.Context) *retryBudget {
+	b, _ := ctx.Value(retryBudgetKey{}).(*retryBudget)
+	return b
+}
+
+// allow spends one retry of the budget on a retry that will start after
+// delay, returning false if the budget can't afford it. A nil budget
+// allows every retry.
+func (b *retryBudget) allow(delay time.Duration) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.remaining <= 0 {
+		return false
+	}
+	if !b.deadline.IsZero() && time.Now().Add(delay).After(b.deadline) {
+		return false
+	}
+	b.remaining--
+	return true
+}
@@ -0,0 +1,165 @@
+package git
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+// countFailingRequests makes server fail every request with a 503,
+// returning a func that reports how many it has received.
+func countFailingRequests(server *fakeGitHTTPServer) func() int {
+	mu := sync.Mutex{}
+	attempts := 0
+	server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	})
+	return func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts
+	}
+}
+
+func TestResolveRetryBudget(t *testing.T) {
+	oldBackoff := retryBackoff
+	retryBackoff = time.Millisecond
+	t.Cleanup(func() { retryBackoff = oldBackoff })
+
+	for _, tc := range []struct {
+		name             string
+		budget           string
+		timeout          time.Duration
+		expectedAttempts int
+	}{{
+		name:             "default budget leaves per-request limit",
+		expectedAttempts: maxHTTPRetries + 1,
+	}, {
+		name:             "budget smaller than per-request limit",
+		budget:           "2",
+		expectedAttempts: 3,
+	}, {
+		name:             "no retries",
+		budget:           "0",
+		expectedAttempts: 1,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, _ := createTestRepo(t, []commitForRepo{{
+				Files: map[string]string{"task.yaml": "kind: Task\n"},
+			}})
+			server := newFakeGitHTTPServer(t, repo)
+			attempts := countFailingRequests(server)
+			ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+				ConfigFieldRetryStatusCodes: "503",
+				ConfigFieldRetryBudget:      tc.budget,
+			})
+			resolver := Resolver{}
+			if _, err := resolver.Resolve(ctx, map[string]string{
+				URLParam:  server.repoURL(),
+				PathParam: "task.yaml",
+			}); err == nil {
+				t.Fatalf("expected error from unavailable server")
+			}
+			if n := attempts(); n != tc.expectedAttempts {
+				t.Errorf("expected %d attempts, received %d", tc.expectedAttempts, n)
+			}
+		})
+	}
+}
+
+func TestResolveRetryBudgetRespectsTimeout(t *testing.T) {
+	oldBackoff := retryBackoff
+	retryBackoff = time.Second
+	t.Cleanup(func() { retryBackoff = oldBackoff })
+
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "kind: Task\n"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	attempts := countFailingRequests(server)
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldRetryStatusCodes: "503",
+	})
+	// A retry after the 1s backoff would start after the request's
+	// deadline, less the reserve.
+	ctx, cancel := context.WithTimeout(ctx, 1050*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	resolver := Resolver{}
+	if _, err := resolver.Resolve(ctx, map[string]string{
+		URLParam:  server.repoURL(),
+		PathParam: "task.yaml",
+	}); err == nil {
+		t.Fatalf("expected error from unavailable server")
+	}
+	if n := attempts(); n != 1 {
+		t.Errorf("expected a single attempt, received %d", n)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected to give up without waiting to retry, took %s", elapsed)
+	}
+}
+
+func TestResolveRetryBudgetSharedWithSecretLookups(t *testing.T) {
+	oldBackoff := retryBackoff
+	retryBackoff = time.Millisecond
+	t.Cleanup(func() { retryBackoff = oldBackoff })
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	api := newFakeGitHubAPI(t, key, "1234", "42", "ghs_token", time.Hour)
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "kind: Task\n"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	attempts := countFailingRequests(server)
+
+	kubeClient := fakekube.NewSimpleClientset(githubAppSecret(t, key, "1234", "42"))
+	lookups := 0
+	kubeClient.PrependReactor("get", "secrets", func(ktesting.Action) (bool, runtime.Object, error) {
+		lookups++
+		if lookups <= 2 {
+			return true, nil, apierrors.NewServerTimeout(schema.GroupResource{Resource: "secrets"}, "get", 1)
+		}
+		return false, nil, nil
+	})
+	resolver := Resolver{kubeClientSet: kubeClient}
+	ctx := resolutioncommon.InjectRequestNamespace(context.Background(), "foo")
+	ctx = framework.InjectResolverConfigToContext(ctx, map[string]string{
+		ConfigFieldGitHubAPIURL:     api.URL,
+		ConfigFieldRetryStatusCodes: "503",
+		ConfigFieldRetryBudget:      "3",
+	})
+	if _, err := resolver.Resolve(ctx, map[string]string{
+		URLParam:             server.repoURL(),
+		PathParam:            "task.yaml",
+		GitHubAppSecretParam: "github-app",
+	}); err == nil {
+		t.Fatalf("expected error from unavailable server")
+	}
+	if lookups != 3 {
+		t.Errorf("expected secret lookup to be retried twice, looked up %d times", lookups)
+	}
+	// Two of the three retries were spent looking up the secret.
+	if n := attempts(); n != 2 {
+		t.Errorf("expected 2 attempts after the secret lookup retries, received %d", n)
+	}
+}
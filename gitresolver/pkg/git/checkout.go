@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// checkoutWorktree checks a commit out into a worktree. It's a variable
+// so that tests can make checkouts fail.
+var checkoutWorktree = (*git.Worktree).Checkout
+
+// checkoutRetryBackoff is the delay before the first retry of a failed
+// checkout. It doubles with each retry.
+var checkoutRetryBackoff = time.Second
+
+// checkoutError is a failure to check out a commit that's in the clone,
+// e.g. because objects it refers to were missing from what a lagging
+// replica sent. Unlike a commit missing from the clone it may succeed
+// when retried. Every checkoutError matches ErrCheckoutFailed.
+type checkoutError struct {
+	err error
+}
+
+func (e *checkoutError) Error() string {
+	return "checkout error: " + e.err.Error()
+}
+
+func (e *checkoutError) Unwrap() error {
+	return e.err
+}
+
+func (e *checkoutError) Is(target error) bool {
+	return target == ErrCheckoutFailed
+}
+
+// checkoutRetries parses ConfigFieldCheckoutRetries from conf, returning
+// 0 if it isn't set.
+func checkoutRetries(conf map[string]string) (int, error) {
+	val := strings.TrimSpace(conf[ConfigFieldCheckoutRetries])
+	if val == "" {
+		return 0, nil
+	}
+	retries, err := strconv.Atoi(val)
+	if err != nil || retries < 0 {
+		return 0, fmt.Errorf("invalid %q config %q: must be a non-negative integer", ConfigFieldCheckoutRetries, val)
+	}
+	return retries, nil
+}
+
+// checkoutWithRetries checks out commit, retrying checkoutErrors up to
+// retries times and calling refetch before each retry to fetch any
+// objects missing from the clone. Other errors, such as the commit
+// missing from the clone altogether, fail without retrying. It returns
+// the number of retries made.
+func checkoutWithRetries(ctx context.Context, repository *git.Repository, commit string, retries int, refetch func() error) (int, error) {
+	backoff := checkoutRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := checkoutCommit(repository, commit)
+		if err == nil || !errors.Is(err, ErrCheckoutFailed) || attempt == retries {
+			return attempt, err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, fmt.Errorf("%w: %v", ctx.Err(), err)
+		case <-timer.C:
+		}
+		backoff *= 2
+
+		if err := refetch(); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return attempt, fmt.Errorf("error fetching before retrying checkout: %w", err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// failCheckouts makes the next failures checkouts fail as though objects
+// the commit refers to were missing, returning a func reporting the
+// number of checkouts attempted.
+func failCheckouts(t *testing.T, failures int) func() int {
+	t.Helper()
+	oldCheckout, oldBackoff := checkoutWorktree, checkoutRetryBackoff
+	t.Cleanup(func() {
+		checkoutWorktree, checkoutRetryBackoff = oldCheckout, oldBackoff
+	})
+	checkoutRetryBackoff = time.Millisecond
+	attempts := 0
+	checkoutWorktree = func(w *git.Worktree, opts *git.CheckoutOptions) error {
+		attempts++
+		if attempts <= failures {
+			return plumbing.ErrObjectNotFound
+		}
+		return oldCheckout(w, opts)
+	}
+	return func() int { return attempts }
+}
+
+func TestResolveRetriesTransientCheckoutFailures(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+
+	for _, tc := range []struct {
+		name             string
+		retries          string
+		failures         int
+		expectedAttempts int
+		expectedRetries  string
+		expectFailure    bool
+	}{{
+		name:             "succeeds after retries",
+		retries:          "3",
+		failures:         2,
+		expectedAttempts: 3,
+		expectedRetries:  "2",
+	}, {
+		name:             "fails once retries are used up",
+		retries:          "1",
+		failures:         5,
+		expectedAttempts: 2,
+		expectFailure:    true,
+	}, {
+		name:             "not retried by default",
+		failures:         1,
+		expectedAttempts: 1,
+		expectFailure:    true,
+	}, {
+		name:             "not retried when it succeeds",
+		retries:          "3",
+		expectedAttempts: 1,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := failCheckouts(t, tc.failures)
+			ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+				ConfigFieldCheckoutRetries: tc.retries,
+			})
+			resolver := Resolver{}
+			resource, err := resolver.Resolve(ctx, map[string]string{
+				URLParam:  repo,
+				PathParam: "task.yaml",
+			})
+			if attempts() != tc.expectedAttempts {
+				t.Errorf("expected %d checkout attempts, received %d", tc.expectedAttempts, attempts())
+			}
+			if tc.expectFailure {
+				if !errors.Is(err, ErrCheckoutFailed) || !errors.Is(err, plumbing.ErrObjectNotFound) {
+					t.Fatalf("expected transient checkout failure, received %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != "content" {
+				t.Errorf("unexpected content %q", resource.Data())
+			}
+			if retries := resource.Annotations()[AnnotationKeyCheckoutRetries]; retries != tc.expectedRetries {
+				t.Errorf("expected %q checkout retries recorded, received %q", tc.expectedRetries, retries)
+			}
+		})
+	}
+}
+
+func TestCheckoutOfMissingCommitFailsFast(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+	repository, err := git.PlainOpen(repo)
+	if err != nil {
+		t.Fatalf("error opening test repo: %v", err)
+	}
+	attempts := failCheckouts(t, 0)
+	refetches := 0
+	missing := strings.Repeat("1", 40)
+
+	retries, err := checkoutWithRetries(context.Background(), repository, missing, 3, func() error {
+		refetches++
+		return nil
+	})
+	if !errors.Is(err, ErrCommitNotFound) || errors.Is(err, ErrCheckoutFailed) {
+		t.Fatalf("expected permanent commit not found error, received %v", err)
+	}
+	if retries != 0 || refetches != 0 || attempts() != 0 {
+		t.Errorf("expected no retries, refetches or checkouts of a missing commit, received %d, %d and %d", retries, refetches, attempts())
+	}
+}
+
+func TestInvalidCheckoutRetries(t *testing.T) {
+	if _, err := checkoutRetries(map[string]string{ConfigFieldCheckoutRetries: "-1"}); err == nil || !strings.Contains(err.Error(), `invalid "checkout-retries" config "-1"`) {
+		t.Errorf("expected invalid config error, received %v", err)
+	}
+}
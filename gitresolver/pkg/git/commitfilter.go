@@ -0,0 +1,74 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// validateCommitMessageFilter returns an error if CommitMessageFilterParam
+// isn't a valid regular expression or is combined with params that
+// select a commit some other way.
+func validateCommitMessageFilter(params map[string]string) error {
+	filter := params[CommitMessageFilterParam]
+	if filter == "" {
+		return nil
+	}
+	for _, p := range []string{CommitParam, AsOfParam, RevisionParam, EnvironmentParam} {
+		if params[p] != "" {
+			return fmt.Errorf("supplied both %q and %q", CommitMessageFilterParam, p)
+		}
+	}
+	if _, err := regexp.Compile(filter); err != nil {
+		return fmt.Errorf("invalid %q %q: %w", CommitMessageFilterParam, filter, err)
+	}
+	return nil
+}
+
+// commitMatchingMessage returns the newest commit reachable from tip
+// whose message matches the regular expression filter.
+func commitMatchingMessage(repository *git.Repository, tip plumbing.Hash, filter string) (string, error) {
+	re, err := regexp.Compile(filter)
+	if err != nil {
+		return "", fmt.Errorf("invalid %q: %w", CommitMessageFilterParam, err)
+	}
+	commits, err := repository.Log(&git.LogOptions{
+		From:  tip,
+		Order: git.LogOrderCommitterTime,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error reading commit history: %w", err)
+	}
+	defer commits.Close()
+	for {
+		c, err := commits.Next()
+		if err == io.EOF {
+			return "", fmt.Errorf("%w: no commit reachable from %s has a message matching %q", ErrNoMatchingCommit, tip, filter)
+		}
+		if err != nil {
+			return "", fmt.Errorf("error reading commit history: %w", err)
+		}
+		if re.MatchString(c.Message) {
+			return c.Hash.String(), nil
+		}
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResolveCommitMessageFilter(t *testing.T) {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	repo, hashes := createTestRepo(t, []commitForRepo{{
+		Files:   map[string]string{"task.yaml": "version: 1\n"},
+		Message: "Add task [deploy]",
+		When:    start,
+	}, {
+		Files:   map[string]string{"task.yaml": "version: 2\n"},
+		Message: "Fix typo",
+		When:    start.Add(time.Hour),
+	}, {
+		Files:   map[string]string{"task.yaml": "version: 3\n"},
+		Message: "Bump version\n\nShip it. [deploy]",
+		When:    start.Add(2 * time.Hour),
+	}, {
+		Files:   map[string]string{"task.yaml": "version: 4\n"},
+		Message: "Work in progress",
+		When:    start.Add(3 * time.Hour),
+	}})
+
+	for _, tc := range []struct {
+		name            string
+		filter          string
+		branch          string
+		expectedCommit  string
+		expectedContent string
+		expectedErr     error
+	}{{
+		name:            "newest matching commit",
+		filter:          `\[deploy\]`,
+		expectedCommit:  hashes[2],
+		expectedContent: "version: 3\n",
+	}, {
+		name:            "matching commit on branch",
+		filter:          `^Add task`,
+		branch:          "master",
+		expectedCommit:  hashes[0],
+		expectedContent: "version: 1\n",
+	}, {
+		name:        "no matching commit",
+		filter:      `\[release\]`,
+		expectedErr: ErrNoMatchingCommit,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			params := map[string]string{
+				URLParam:                 repo,
+				PathParam:                "task.yaml",
+				BranchParam:              tc.branch,
+				CommitMessageFilterParam: tc.filter,
+			}
+			resolver := Resolver{}
+			if err := resolver.ValidateParams(context.Background(), params); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			resource, err := resolver.Resolve(context.Background(), params)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, received %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.expectedContent {
+				t.Errorf("expected content %q, received %q", tc.expectedContent, resource.Data())
+			}
+			if commit := resource.Annotations()[AnnotationKeyCommitHash]; commit != tc.expectedCommit {
+				t.Errorf("expected commit %s, received %s", tc.expectedCommit, commit)
+			}
+		})
+	}
+}
+
+func TestValidateCommitMessageFilter(t *testing.T) {
+	for _, params := range []map[string]string{
+		{CommitMessageFilterParam: `[deploy`},
+		{CommitMessageFilterParam: `deploy`, CommitParam: "abc123"},
+		{CommitMessageFilterParam: `deploy`, AsOfParam: "2022-01-01T00:00:00Z"},
+		{CommitMessageFilterParam: `deploy`, RevisionParam: "HEAD~1"},
+		{CommitMessageFilterParam: `deploy`, EnvironmentParam: "prod"},
+	} {
+		params[URLParam] = "https://example.com/repo.git"
+		params[PathParam] = "task.yaml"
+		resolver := Resolver{}
+		if err := resolver.ValidateParams(context.Background(), params); err == nil {
+			t.Errorf("expected params %v to be rejected", params)
+		}
+	}
+}
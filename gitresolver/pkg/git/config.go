@@ -3,3 +3,189 @@ package git
 // ConfigFieldTimeout is the configuration field name for controlling
 // the maximum duration of a resolution request for a file from git.
 const ConfigFieldTimeout = "fetch-timeout"
+
+// ConfigFieldGitHubAPIURL is the configuration field name for the
+// GitHub API that GitHub App installation tokens are minted from.
+const ConfigFieldGitHubAPIURL = "github-api-url"
+
+// ConfigFieldUserAgent is the configuration field name for the
+// user-agent sent to git hosts when cloning over http(s).
+const ConfigFieldUserAgent = "user-agent"
+
+// ConfigFieldMaxCommitFetchDepth is the configuration field name for the
+// maximum number of commits of history that may be fetched while
+// deepening a shallow clone to find a requested commit. When unset the
+// full history is cloned.
+const ConfigFieldMaxCommitFetchDepth = "max-commit-fetch-depth"
+
+// ConfigFieldRetryStatusCodes is the configuration field name for a
+// comma-separated list of the http status codes from git hosts that
+// cause requests to be retried. A Retry-After header on a 429 response
+// is honored.
+const ConfigFieldRetryStatusCodes = "retry-status-codes"
+
+// ConfigFieldLineRangeMode is the configuration field name controlling
+// what happens when StartLineParam or EndLineParam is past the end of
+// the file: "error", the default, fails the request and "clamp" returns
+// the lines that exist.
+const ConfigFieldLineRangeMode = "line-range-mode"
+
+// ConfigFieldCommitPropagationGrace is the configuration field name for
+// how long to keep looking for a requested commit that isn't found, to
+// allow for replication lag on the git host after a push.
+const ConfigFieldCommitPropagationGrace = "commit-propagation-grace"
+
+// ConfigFieldCheckoutRetries is the configuration field name for the
+// number of times checking out a commit that was fetched is retried,
+// fetching again before each retry, when it fails, e.g. because a
+// lagging replica sent an incomplete pack. Commits missing from the
+// fetch altogether fail without retrying. Defaults to 0.
+const ConfigFieldCheckoutRetries = "checkout-retries"
+
+// ConfigFieldCredentialHelper is the configuration field name for a git
+// credential helper to get http credentials from, in the same form as
+// git's credential.helper setting.
+const ConfigFieldCredentialHelper = "credential-helper"
+
+// ConfigFieldRejectEmpty is the configuration field name for the default
+// of RejectEmptyParam. Empty files are resolved successfully when unset.
+const ConfigFieldRejectEmpty = "reject-empty"
+
+// ConfigFieldProxy is the configuration field name for the socks5:// URL
+// of a proxy to clone repos through when a request doesn't give its own
+// proxy param.
+const ConfigFieldProxy = "proxy"
+
+// ConfigFieldDefaultBranchCacheTTL is the configuration field name for
+// how long the default branch of a repo is cached for, to resolve
+// requests that give neither a branch nor a commit. Set to "0" to probe
+// the repo every time.
+const ConfigFieldDefaultBranchCacheTTL = "default-branch-cache-ttl"
+
+// ConfigFieldKeytab is the configuration field name for the path to a
+// mounted keytab. When set, repos are cloned over http(s) with SPNEGO
+// (Negotiate) auth as ConfigFieldKerberosPrincipal.
+const ConfigFieldKeytab = "kerberos-keytab"
+
+// ConfigFieldKerberosPrincipal is the configuration field name for the
+// principal, of the form user@REALM, to authenticate as with the keytab.
+const ConfigFieldKerberosPrincipal = "kerberos-principal"
+
+// ConfigFieldKerberosConfig is the configuration field name for the path
+// to the krb5.conf describing the realm. Defaults to /etc/krb5.conf.
+const ConfigFieldKerberosConfig = "kerberos-config"
+
+// ConfigFieldKerberosHosts is the configuration field name for a
+// comma-separated list of the hostnames of git hosts that Negotiate auth
+// is sent to with every request. Other hosts are only sent it once they
+// challenge a request for it.
+const ConfigFieldKerberosHosts = "kerberos-hosts"
+
+// ConfigFieldHTTPMaxIdleConnsPerHost is the configuration field name for
+// the number of idle connections to each git host kept open for reuse by
+// later requests. Defaults to 16. Set to "0" to disable keep-alives.
+const ConfigFieldHTTPMaxIdleConnsPerHost = "http-max-idle-conns-per-host"
+
+// ConfigFieldHTTPIdleConnTimeout is the configuration field name for how
+// long an idle connection to a git host is kept open. Defaults to 90s.
+const ConfigFieldHTTPIdleConnTimeout = "http-idle-conn-timeout"
+
+// ConfigFieldRetryBudget is the configuration field name for the total
+// number of retries, of http requests to git hosts and of secret
+// lookups, that resolving a single request may make. Retries also stop
+// once most of the request's timeout has been used. Defaults to 10.
+const ConfigFieldRetryBudget = "retry-budget"
+
+// ConfigFieldFollowRedirects is the configuration field name for which
+// http redirects are followed while cloning: "none", "same-host", only
+// those to the host of the original request, or "all". Defaults to
+// "all".
+const ConfigFieldFollowRedirects = "follow-redirects"
+
+// ConfigFieldMaxRedirects is the configuration field name for the
+// number of http redirects a request may follow while cloning before
+// it fails. Requests redirected back to a url they've already visited
+// fail regardless. Defaults to 10.
+const ConfigFieldMaxRedirects = "max-redirects"
+
+// ConfigFieldMinTLSVersion is the configuration field name for the
+// minimum TLS version, "1.0", "1.1", "1.2" or "1.3", that git hosts must
+// negotiate. Defaults to Go's default minimum.
+const ConfigFieldMinTLSVersion = "min-tls-version"
+
+// ConfigFieldReadReplicas is the configuration field name for the
+// comma- or newline-separated <primary url>=<replica url> prefix pairs
+// of read replicas that repos are cloned from in preference to their
+// primary url. The primary is cloned from when a replica doesn't have
+// the requested commit yet.
+const ConfigFieldReadReplicas = "read-replicas"
+
+// ConfigFieldHTTPIdleTimeout is the configuration field name for how
+// long an http request to a git host may go without receiving any data
+// before it's aborted, so that a stalled connection fails well before
+// the resolution times out. Unset disables the timeout.
+const ConfigFieldHTTPIdleTimeout = "http-idle-timeout"
+
+// ConfigFieldMaxHTTPTimeout is the configuration field name for the
+// longest http idle timeout that a request's http-timeout annotation may
+// ask for. Longer timeouts are clamped to it. Defaults to 5m.
+const ConfigFieldMaxHTTPTimeout = "max-http-timeout"
+
+// ConfigFieldHTTPCacheControl is the configuration field name for the
+// Cache-Control header, e.g. "max-age=60", sent with requests for a
+// repo's refs, so that a caching proxy in front of git hosts may answer
+// them from its cache. Unset no Cache-Control header is sent.
+const ConfigFieldHTTPCacheControl = "http-cache-control"
+
+// ConfigFieldHTTPConditionalRequests is the configuration field name for
+// whether the responses to requests for a repo's refs that have an ETag
+// or Last-Modified header are kept and revalidated with conditional
+// requests, reusing them when the git host or a caching proxy answers
+// 304 Not Modified. Defaults to false.
+const ConfigFieldHTTPConditionalRequests = "http-conditional-requests"
+
+// ConfigFieldSSHAgent is the configuration field name for whether ssh
+// repos are cloned with the keys of the ssh agent listening on
+// SSH_AUTH_SOCK, when one is, in preference to ConfigFieldSSHPrivateKey.
+const ConfigFieldSSHAgent = "ssh-agent"
+
+// ConfigFieldSSHPrivateKey is the configuration field name for the path
+// to a mounted private key that ssh repos are cloned with.
+const ConfigFieldSSHPrivateKey = "ssh-private-key"
+
+// ConfigFieldKnownHosts is the configuration field name for the path to
+// a mounted known_hosts file that the keys of ssh hosts are checked
+// against. When unset, the files that ssh reads by default are used.
+const ConfigFieldKnownHosts = "ssh-known-hosts"
+
+// ConfigFieldServeStaleOnError is the configuration field name for
+// whether a request pinned to a commit is served the content last
+// resolved for it, marked as stale, when the repo can't be reached.
+const ConfigFieldServeStaleOnError = "serve-stale-on-error"
+
+// ConfigFieldAllowGitProtocol is the configuration field name for
+// whether repos may be cloned from a git daemon over the anonymous,
+// unencrypted git:// protocol. Defaults to false.
+const ConfigFieldAllowGitProtocol = "allow-git-protocol"
+
+// ConfigFieldResultCacheTTL is the configuration field name for how
+// long a resolved resource is cached for and returned, without cloning
+// or post-processing again, to later requests with identical params.
+// Unset or "0" disables the cache.
+const ConfigFieldResultCacheTTL = "result-cache-ttl"
+
+// ConfigFieldEnvironmentTagPrefix is the configuration field name for
+// the prefix of the tags that track which commit is deployed to each
+// environment named by EnvironmentParam. Defaults to "deployed/".
+const ConfigFieldEnvironmentTagPrefix = "environment-tag-prefix"
+
+// ConfigFieldMaxConcurrentClonesPerRepo is the configuration field name
+// for the number of clones of any one repo that may be in flight at
+// once. Requests over the cap wait for a slot. Unset or "0" means no
+// cap.
+const ConfigFieldMaxConcurrentClonesPerRepo = "max-concurrent-clones-per-repo"
+
+// ConfigFieldMaxLFSObjectSize is the configuration field name for the
+// largest Git LFS object, in bytes, that LFSParam will fetch. Defaults
+// to 20MiB.
+const ConfigFieldMaxLFSObjectSize = "max-lfs-object-size"
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// credentialHelperTimeout is how long a git credential helper may run
+// before it's killed, so that a helper waiting on input it will never
+// get can't hang a resolution.
+var credentialHelperTimeout = 10 * time.Second
+
+// credentialHelperAuth asks the configured git credential helper for the
+// credentials to clone repoURL with. It returns nil auth if the helper
+// doesn't have any for the url.
+func credentialHelperAuth(ctx context.Context, helper, repoURL string) (transport.AuthMethod, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		// Credential helpers only provide http credentials.
+		return nil, nil
+	}
+	protocol, host, path := u.Scheme, u.Host, strings.TrimPrefix(u.Path, "/")
+	// Like git, refuse values that would inject lines into the
+	// helper's input, e.g. a second host from a %0a in the path.
+	for _, val := range []string{protocol, host, path} {
+		if strings.ContainsAny(val, "\n\r\x00") {
+			return nil, fmt.Errorf("refusing to ask git credential helper %q for credentials for %q: url contains a newline, carriage return or NUL", helper, repoURL)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, credentialHelperTimeout)
+	defer cancel()
+
+	cmd := credentialHelperCommand(ctx, helper)
+	input := fmt.Sprintf("protocol=%s\nhost=%s\npath=%s\n\n", protocol, host, path)
+	cmd.Stdin = strings.NewReader(input)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("git credential helper %q timed out after %s", helper, credentialHelperTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("git credential helper %q failed: %w: %s", helper, err, strings.TrimSpace(stderr.String()))
+	}
+
+	creds := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if key, val, ok := cut(scanner.Text(), "="); ok {
+			creds[key] = val
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading output of git credential helper %q: %w", helper, err)
+	}
+	if creds["username"] == "" && creds["password"] == "" {
+		return nil, nil
+	}
+	if creds["password"] == "" {
+		return nil, errors.New("git credential helper returned a username without a password")
+	}
+	return &githttp.BasicAuth{
+		Username: creds["username"],
+		Password: creds["password"],
+	}, nil
+}
+
+// credentialHelperCommand builds the command to run a helper the way git
+// does: a helper starting with "!" is a shell snippet, an absolute path
+// is run directly and any other name is run as "git credential-<name>".
+func credentialHelperCommand(ctx context.Context, helper string) *exec.Cmd {
+	if strings.HasPrefix(helper, "!") {
+		return exec.CommandContext(ctx, "/bin/sh", "-c", strings.TrimPrefix(helper, "!")+" get")
+	}
+	fields := append(strings.Fields(helper), "get")
+	if filepath.IsAbs(fields[0]) {
+		return exec.CommandContext(ctx, fields[0], fields[1:]...)
+	}
+	return exec.CommandContext(ctx, "git", append([]string{"credential-" + fields[0]}, fields[1:]...)...)
+}
+
+// cut slices s around the first instance of sep.
+func cut(s, sep string) (string, string, bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,103 @@
+package git
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// writeCredentialHelper writes an executable credential helper script to
+// a temporary directory and returns its path.
+func writeCredentialHelper(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "git-credential-stub")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("error writing credential helper: %v", err)
+	}
+	return path
+}
+
+func TestResolveWithCredentialHelper(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "helper-user" || pass != "helper-pass" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return true
+		}
+		return false
+	})
+	// The stub only has credentials for the requested host, which it
+	// reads from git's credential protocol on stdin.
+	helper := writeCredentialHelper(t, `
+[ "$1" = "get" ] || exit 1
+while read line; do
+  [ -z "$line" ] && break
+  case "$line" in host=*) host="${line#host=}" ;; esac
+done
+[ "$host" = "`+strings.TrimPrefix(server.URL, "http://")+`" ] || exit 0
+echo username=helper-user
+echo password=helper-pass
+`)
+	params := map[string]string{
+		URLParam:  server.repoURL(),
+		PathParam: "task.yaml",
+	}
+	resolver := Resolver{}
+
+	if _, err := resolver.Resolve(context.Background(), params); err == nil {
+		t.Fatalf("expected clone without credentials to fail")
+	}
+
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldCredentialHelper: helper,
+	})
+	resource, err := resolver.Resolve(ctx, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resource.Data()) != "content" {
+		t.Errorf("unexpected content %q", resource.Data())
+	}
+}
+
+func TestCredentialHelperTimeout(t *testing.T) {
+	oldTimeout := credentialHelperTimeout
+	credentialHelperTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { credentialHelperTimeout = oldTimeout })
+
+	helper := writeCredentialHelper(t, "exec sleep 10\n")
+	start := time.Now()
+	_, err := credentialHelperAuth(context.Background(), helper, "https://example.com/repo.git")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, received %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected hanging helper to be killed promptly, took %s", elapsed)
+	}
+}
+
+func TestCredentialHelperRejectsInjectedLines(t *testing.T) {
+	// The helper has credentials for any host it's asked about, so
+	// only refusing to run it keeps them from the attacker's host.
+	helper := writeCredentialHelper(t, "echo username=victim\necho password=secret\n")
+	for _, repoURL := range []string{
+		"https://attacker.example/x%0ahost=github.com",
+		"https://attacker.example/x%0dhost=github.com",
+		"https://attacker.example/x%00host=github.com",
+	} {
+		auth, err := credentialHelperAuth(context.Background(), helper, repoURL)
+		if err == nil || !strings.Contains(err.Error(), "refusing") {
+			t.Errorf("expected %q to be refused, received auth %v and error %v", repoURL, auth, err)
+		}
+	}
+}
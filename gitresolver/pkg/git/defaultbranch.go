@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// defaultDefaultBranchCacheTTL is how long a repo's default branch is
+// cached for when ConfigFieldDefaultBranchCacheTTL isn't set.
+const defaultDefaultBranchCacheTTL = time.Minute
+
+// defaultBranchCacheTTL parses ConfigFieldDefaultBranchCacheTTL from
+// conf. A zero TTL disables caching.
+func defaultBranchCacheTTL(conf map[string]string) (time.Duration, error) {
+	val := strings.TrimSpace(conf[ConfigFieldDefaultBranchCacheTTL])
+	if val == "" {
+		return defaultDefaultBranchCacheTTL, nil
+	}
+	ttl, err := time.ParseDuration(val)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("invalid %q config %q: must be a non-negative duration", ConfigFieldDefaultBranchCacheTTL, val)
+	}
+	return ttl, nil
+}
+
+// cachedDefaultBranch is a repo's default branch and when it stops
+// being valid.
+type cachedDefaultBranch struct {
+	branch  string
+	expires time.Time
+}
+
+// defaultBranchCache holds the default branches of repos, keyed by url,
+// so that requests without a branch or commit don't have to probe the
+// remote's HEAD every time.
+type defaultBranchCache struct {
+	mu       sync.Mutex
+	branches map[string]cachedDefaultBranch
+}
+
+// get returns the default branch of the repo at url, probing the remote
+// for it if it isn't cached or the cached branch has expired, along with
+// whether the branch came from the cache. An empty branch is returned
+// when the remote doesn't advertise where its HEAD points.
+func (c *defaultBranchCache) get(ctx context.Context, url string, auth transport.AuthMethod, ttl time.Duration) (string, bool, error) {
+	c.mu.Lock()
+	cached, ok := c.branches[url]
+	c.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.branch, true, nil
+	}
+	branch, err := probeDefaultBranch(ctx, url, auth)
+	if err != nil || branch == "" || ttl == 0 {
+		return branch, false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.branches == nil {
+		c.branches = map[string]cachedDefaultBranch{}
+	}
+	c.branches[url] = cachedDefaultBranch{
+		branch:  branch,
+		expires: time.Now().Add(ttl),
+	}
+	return branch, false, nil
+}
+
+// forget drops the cached default branch of the repo at url, e.g.
+// because it no longer exists, so that the next get probes for it.
+func (c *defaultBranchCache) forget(url string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.branches, url)
+}
+
+// probeDefaultBranch lists the remote's refs and returns the branch its
+// HEAD points to, or an empty string if HEAD isn't a branch.
+func probeDefaultBranch(ctx context.Context, url string, auth transport.AuthMethod) (string, error) {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{url},
+	})
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth})
+	if err != nil {
+		return "", err
+	}
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD && ref.Type() == plumbing.SymbolicReference && ref.Target().IsBranch() {
+			return ref.Target().Short(), nil
+		}
+	}
+	return "", nil
+}
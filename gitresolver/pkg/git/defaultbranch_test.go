@@ -0,0 +1,156 @@
+package git
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// countRefAdvertisements makes server count the ref advertisements it
+// serves, one for each ls-remote style probe or clone, returning a func
+// to read the count.
+func countRefAdvertisements(server *fakeGitHTTPServer) func() int {
+	mu := sync.Mutex{}
+	count := 0
+	server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+		if strings.HasSuffix(r.URL.Path, "/info/refs") {
+			mu.Lock()
+			count++
+			mu.Unlock()
+		}
+		return false
+	})
+	return func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return count
+	}
+}
+
+func TestResolveCachesDefaultBranch(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config map[string]string
+		wait   time.Duration
+		// expectedAdvertisements is the number of ref advertisements
+		// served for two resolutions: each clone needs one and each
+		// probe of the default branch another.
+		expectedAdvertisements int
+	}{{
+		name:                   "probed once within ttl",
+		config:                 map[string]string{},
+		expectedAdvertisements: 3,
+	}, {
+		name: "probed again after ttl",
+		config: map[string]string{
+			ConfigFieldDefaultBranchCacheTTL: "10ms",
+		},
+		wait:                   20 * time.Millisecond,
+		expectedAdvertisements: 4,
+	}, {
+		name: "caching disabled",
+		config: map[string]string{
+			ConfigFieldDefaultBranchCacheTTL: "0",
+		},
+		expectedAdvertisements: 4,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, _ := createTestRepo(t, []commitForRepo{{
+				Files: map[string]string{"task.yaml": "content"},
+			}})
+			server := newFakeGitHTTPServer(t, repo)
+			advertisements := countRefAdvertisements(server)
+			resolver := Resolver{}
+			ctx := framework.InjectResolverConfigToContext(context.Background(), tc.config)
+			params := map[string]string{
+				URLParam:  server.repoURL(),
+				PathParam: "task.yaml",
+			}
+			for i := 0; i < 2; i++ {
+				if i > 0 {
+					time.Sleep(tc.wait)
+				}
+				resource, err := resolver.Resolve(ctx, params)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if string(resource.Data()) != "content" {
+					t.Fatalf("unexpected content %q", resource.Data())
+				}
+			}
+			if count := advertisements(); count != tc.expectedAdvertisements {
+				t.Errorf("expected %d ref advertisements, received %d", tc.expectedAdvertisements, count)
+			}
+		})
+	}
+}
+
+func TestResolveRefreshesChangedDefaultBranch(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		conf map[string]string
+	}{{
+		name: "unlimited clones",
+	}, {
+		// The refresh mustn't wait on the clone slot it already holds.
+		name: "one clone at a time",
+		conf: map[string]string{ConfigFieldMaxConcurrentClonesPerRepo: "1"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			ctx = framework.InjectResolverConfigToContext(ctx, tc.conf)
+			repoDir, hashes := createTestRepo(t, []commitForRepo{{
+				Files: map[string]string{"task.yaml": "content"},
+			}})
+			server := newFakeGitHTTPServer(t, repoDir)
+			resolver := Resolver{}
+			params := map[string]string{
+				URLParam:  server.repoURL(),
+				PathParam: "task.yaml",
+			}
+			if _, err := resolver.Resolve(ctx, params); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// Rename the default branch, leaving the cached one stale.
+			repo, err := git.PlainOpen(repoDir)
+			if err != nil {
+				t.Fatalf("error opening test repo: %v", err)
+			}
+			head, err := repo.Head()
+			if err != nil {
+				t.Fatalf("error reading test repo HEAD: %v", err)
+			}
+			main := plumbing.NewBranchReferenceName("main")
+			if err := repo.Storer.SetReference(plumbing.NewHashReference(main, plumbing.NewHash(hashes[0]))); err != nil {
+				t.Fatalf("error creating branch: %v", err)
+			}
+			if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, main)); err != nil {
+				t.Fatalf("error updating HEAD: %v", err)
+			}
+			if err := repo.Storer.RemoveReference(head.Name()); err != nil {
+				t.Fatalf("error removing branch: %v", err)
+			}
+
+			resource, err := resolver.Resolve(ctx, params)
+			if err != nil {
+				t.Fatalf("unexpected error resolving after default branch changed: %v", err)
+			}
+			if string(resource.Data()) != "content" {
+				t.Errorf("unexpected content %q", resource.Data())
+			}
+			if params := resource.Annotations()[framework.AnnotationKeyParams]; !strings.Contains(params, `"branch":"main"`) {
+				t.Errorf("expected file to be resolved from the new default branch, received params %s", params)
+			}
+
+		})
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// taskDependency is a task that a pipeline task of a resolved pipeline
+// references, along with the pipeline tasks that it runs after.
+type taskDependency struct {
+	// PipelineTask is the name of the pipeline task referencing the
+	// task.
+	PipelineTask string `json:"pipelineTask"`
+	// Finally is true for the pipeline's finally tasks.
+	Finally bool `json:"finally,omitempty"`
+	// Name, Kind and Bundle are those of the task's taskRef.
+	Name   string `json:"name,omitempty"`
+	Kind   string `json:"kind,omitempty"`
+	Bundle string `json:"bundle,omitempty"`
+	// Resolver and Params are the remote resolver, and the params for
+	// it, that the task's taskRef is resolved with.
+	Resolver string                 `json:"resolver,omitempty"`
+	Params   map[string]interface{} `json:"params,omitempty"`
+	// RunAfter are the pipeline tasks that the task runs after.
+	RunAfter []string `json:"runAfter,omitempty"`
+}
+
+// pipelineDocument holds the parts of a pipeline that its task
+// references are read from.
+type pipelineDocument struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Tasks   []pipelineTask `json:"tasks"`
+		Finally []pipelineTask `json:"finally"`
+	} `json:"spec"`
+}
+
+type pipelineTask struct {
+	Name    string `json:"name"`
+	TaskRef *struct {
+		Name     string `json:"name"`
+		Kind     string `json:"kind"`
+		Bundle   string `json:"bundle"`
+		Resolver string `json:"resolver"`
+		Params   []struct {
+			Name  string      `json:"name"`
+			Value interface{} `json:"value"`
+		} `json:"params"`
+	} `json:"taskRef"`
+	RunAfter []string `json:"runAfter"`
+}
+
+// taskDependencies returns the taskRefs of every task of the pipelines
+// in the yaml stream content, as a json list. Tasks with an embedded
+// taskSpec and documents that aren't pipelines are skipped.
+func taskDependencies(content []byte) (string, error) {
+	deps := []taskDependency{}
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(content)))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		var pipeline pipelineDocument
+		if err := yaml.Unmarshal(doc, &pipeline); err != nil {
+			return "", err
+		}
+		if pipeline.Kind != "Pipeline" {
+			continue
+		}
+		deps = appendTaskDependencies(deps, pipeline.Spec.Tasks, false)
+		deps = appendTaskDependencies(deps, pipeline.Spec.Finally, true)
+	}
+	encoded, err := json.Marshal(deps)
+	if err != nil {
+		return "", fmt.Errorf("error serializing dependencies: %w", err)
+	}
+	return string(encoded), nil
+}
+
+func appendTaskDependencies(deps []taskDependency, tasks []pipelineTask, finally bool) []taskDependency {
+	for _, task := range tasks {
+		if task.TaskRef == nil {
+			continue
+		}
+		dep := taskDependency{
+			PipelineTask: task.Name,
+			Finally:      finally,
+			Name:         task.TaskRef.Name,
+			Kind:         task.TaskRef.Kind,
+			Bundle:       task.TaskRef.Bundle,
+			Resolver:     task.TaskRef.Resolver,
+			RunAfter:     task.RunAfter,
+		}
+		for _, p := range task.TaskRef.Params {
+			if dep.Params == nil {
+				dep.Params = map[string]interface{}{}
+			}
+			dep.Params[p.Name] = p.Value
+		}
+		deps = append(deps, dep)
+	}
+	return deps
+}
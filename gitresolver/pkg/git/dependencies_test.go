@@ -0,0 +1,89 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+const dependenciesTestPipeline = `apiVersion: tekton.dev/v1beta1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+  - name: clone
+    taskRef:
+      name: git-clone
+      bundle: gcr.io/tekton-releases/catalog/upstream/git-clone:0.5
+  - name: build
+    runAfter: ["clone"]
+    taskRef:
+      resolver: git
+      params:
+      - name: url
+        value: https://github.com/tektoncd/catalog
+      - name: path
+        value: task/golang-build/0.3/golang-build.yaml
+  - name: inline
+    taskSpec:
+      steps:
+      - image: alpine
+`
+
+func TestResolveDependencies(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{
+			"pipeline.yaml": dependenciesTestPipeline,
+			"broken.yaml":   "kind: Pipeline\nspec: [\n",
+		},
+	}})
+
+	resolver := Resolver{}
+	resource, err := resolver.Resolve(context.Background(), map[string]string{
+		URLParam:          repo,
+		PathParam:         "pipeline.yaml",
+		DependenciesParam: "true",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var deps []taskDependency
+	if err := json.Unmarshal([]byte(resource.Annotations()[AnnotationKeyDependencies]), &deps); err != nil {
+		t.Fatalf("error parsing dependencies annotation %q: %v", resource.Annotations()[AnnotationKeyDependencies], err)
+	}
+	expected := []taskDependency{{
+		PipelineTask: "clone",
+		Name:         "git-clone",
+		Bundle:       "gcr.io/tekton-releases/catalog/upstream/git-clone:0.5",
+	}, {
+		PipelineTask: "build",
+		Resolver:     "git",
+		Params: map[string]interface{}{
+			"url":  "https://github.com/tektoncd/catalog",
+			"path": "task/golang-build/0.3/golang-build.yaml",
+		},
+		RunAfter: []string{"clone"},
+	}}
+	if !reflect.DeepEqual(deps, expected) {
+		t.Errorf("expected dependencies %+v, received %+v", expected, deps)
+	}
+
+	resource, err = resolver.Resolve(context.Background(), map[string]string{
+		URLParam:          repo,
+		PathParam:         "broken.yaml",
+		DependenciesParam: "true",
+	})
+	if err != nil {
+		t.Fatalf("expected unparseable content to still resolve, received %v", err)
+	}
+	if deps, ok := resource.Annotations()[AnnotationKeyDependencies]; ok {
+		t.Errorf("unexpected dependencies %s of unparseable content", deps)
+	}
+	if warnings := framework.Warnings(resource); len(warnings) != 1 {
+		t.Errorf("expected a warning about the unparseable content, received %q", warnings)
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// describeAbbrevLength is the number of hex digits of the commit hash
+// included in describe output, matching git's default.
+const describeAbbrevLength = 7
+
+// describe returns a `git describe --tags --always` style name for
+// commit: the nearest tag reachable from it, followed by the number of
+// commits since that tag and the abbreviated commit hash when the
+// commit isn't tagged itself. The abbreviated hash alone is returned if
+// no tag is reachable.
+func describe(repository *git.Repository, commit plumbing.Hash) (string, error) {
+	tags, err := tagsByCommit(repository)
+	if err != nil {
+		return "", err
+	}
+	abbrev := commit.String()[:describeAbbrevLength]
+
+	// Breadth-first search for the closest tagged ancestor.
+	var tagName string
+	var tagged plumbing.Hash
+	seen := map[plumbing.Hash]bool{commit: true}
+	queue := []plumbing.Hash{commit}
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		if names, ok := tags[hash]; ok {
+			tagName, tagged = names[0], hash
+			break
+		}
+		c, err := repository.CommitObject(hash)
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			// The history of a shallow clone ends here.
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("error reading commit %s: %w", hash, err)
+		}
+		for _, parent := range c.ParentHashes {
+			if !seen[parent] {
+				seen[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+	if tagName == "" {
+		return abbrev, nil
+	}
+	if tagged == commit {
+		return tagName, nil
+	}
+
+	tagAncestors, err := ancestors(repository, tagged)
+	if err != nil {
+		return "", err
+	}
+	commitAncestors, err := ancestors(repository, commit)
+	if err != nil {
+		return "", err
+	}
+	distance := 0
+	for hash := range commitAncestors {
+		if !tagAncestors[hash] {
+			distance++
+		}
+	}
+	return fmt.Sprintf("%s-%d-g%s", tagName, distance, abbrev), nil
+}
+
+// tagsByCommit maps each tagged commit to the names of its tags, sorted
+// so that the choice between several tags on one commit is stable.
+func tagsByCommit(repository *git.Repository) (map[plumbing.Hash][]string, error) {
+	refs, err := repository.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("error listing tags: %w", err)
+	}
+	tags := map[plumbing.Hash][]string{}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		hash := ref.Hash()
+		tag, err := repository.TagObject(hash)
+		switch {
+		case err == nil:
+			c, err := tag.Commit()
+			if err != nil {
+				// Tags of trees or blobs can't describe a commit.
+				return nil
+			}
+			hash = c.Hash
+		case !errors.Is(err, plumbing.ErrObjectNotFound):
+			return err
+		}
+		tags[hash] = append(tags[hash], ref.Name().Short())
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading tags: %w", err)
+	}
+	for _, names := range tags {
+		sort.Strings(names)
+	}
+	return tags, nil
+}
+
+// ancestors returns the set of commits reachable from commit, including
+// commit itself.
+func ancestors(repository *git.Repository, commit plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	c, err := repository.CommitObject(commit)
+	if err != nil {
+		return nil, fmt.Errorf("error reading commit %s: %w", commit, err)
+	}
+	reachable := map[plumbing.Hash]bool{}
+	err = object.NewCommitPreorderIter(c, nil, nil).ForEach(func(c *object.Commit) error {
+		reachable[c.Hash] = true
+		return nil
+	})
+	if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, fmt.Errorf("error reading commit history: %w", err)
+	}
+	return reachable, nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// resolvingDiff returns whether params request the diff of PathParam
+// between RefAParam and RefBParam rather than the file at each.
+func resolvingDiff(params map[string]string) bool {
+	d, err := parseBoolParam(params, DiffParam)
+	return err == nil && d
+}
+
+// resolveDiff generates the unified diff of the requested file from
+// RefAParam to RefBParam in co, as `git diff refA refB -- path` would.
+// A file that only exists at one of the refs is diffed as added or
+// deleted, and one that's the same at both has an empty diff.
+func (r *Resolver) resolveDiff(co *checkout, params map[string]string) (*ResolvedGitResource, error) {
+	refA, refB := params[RefAParam], params[RefBParam]
+	commitA, err := resolveRef(co.repository, refA)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving %q %q: %w", RefAParam, refA, err)
+	}
+	commitB, err := resolveRef(co.repository, refB)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving %q %q: %w", RefBParam, refB, err)
+	}
+	content, err := diffFile(commitA, commitB, strings.TrimPrefix(params[PathParam], "/"))
+	if err != nil {
+		return nil, fmt.Errorf("error diffing %q between %q and %q: %w", params[PathParam], refA, refB, err)
+	}
+
+	annotations, err := refAnnotations(co, params)
+	if err != nil {
+		return nil, err
+	}
+	annotations[AnnotationKeyRefACommit] = commitA.Hash.String()
+	annotations[AnnotationKeyRefBCommit] = commitB.Hash.String()
+	return &ResolvedGitResource{
+		Commit:           commitB.Hash.String(),
+		Content:          content,
+		ContentType:      DiffContentType,
+		ExtraAnnotations: annotations,
+	}, nil
+}
+
+// diffFile returns the unified diff of the file at path from commit a
+// to commit b. It returns ErrFileNotFound if the file is in neither.
+func diffFile(a, b *object.Commit, path string) ([]byte, error) {
+	treeA, err := a.Tree()
+	if err != nil {
+		return nil, err
+	}
+	treeB, err := b.Tree()
+	if err != nil {
+		return nil, err
+	}
+	changes, err := object.DiffTree(treeA, treeB)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changes {
+		if change.From.Name != path && change.To.Name != path {
+			continue
+		}
+		patch, err := change.Patch()
+		if err != nil {
+			return nil, err
+		}
+		buf := &bytes.Buffer{}
+		if err := diff.NewUnifiedEncoder(buf, diff.DefaultContextLines).Encode(patch); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	// Unchanged files don't appear among the changes.
+	_, err = treeA.File(path)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte{}, nil
+}
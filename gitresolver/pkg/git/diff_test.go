@@ -0,0 +1,139 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+)
+
+func TestResolveDiff(t *testing.T) {
+	repo, hashes := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{
+			"task.yaml": "kind: Task\nversion: 1\n",
+			"old.yaml":  "kind: Pipeline\n",
+		},
+		Tag: "v1",
+	}, {
+		Files:  map[string]string{"task.yaml": "kind: Task\nversion: 2\n", "new.yaml": "kind: StepAction\n"},
+		Delete: []string{"old.yaml"},
+		Tag:    "v2",
+	}})
+
+	for _, tc := range []struct {
+		name        string
+		path        string
+		expected    string
+		expectedErr error
+	}{{
+		name: "modified file",
+		path: "task.yaml",
+		expected: "diff --git a/task.yaml b/task.yaml\n" +
+			"index " + testBlobHash("kind: Task\nversion: 1\n") + ".." + testBlobHash("kind: Task\nversion: 2\n") + " 100644\n" +
+			"--- a/task.yaml\n" +
+			"+++ b/task.yaml\n" +
+			"@@ -1,2 +1,2 @@\n" +
+			" kind: Task\n" +
+			"-version: 1\n" +
+			"+version: 2\n",
+	}, {
+		name: "added file",
+		path: "new.yaml",
+		expected: "diff --git a/new.yaml b/new.yaml\n" +
+			"new file mode 100644\n" +
+			"index 0000000000000000000000000000000000000000.." + testBlobHash("kind: StepAction\n") + "\n" +
+			"--- /dev/null\n" +
+			"+++ b/new.yaml\n" +
+			"@@ -0,0 +1 @@\n" +
+			"+kind: StepAction\n",
+	}, {
+		name: "deleted file",
+		path: "/old.yaml",
+		expected: "diff --git a/old.yaml b/old.yaml\n" +
+			"deleted file mode 100644\n" +
+			"index " + testBlobHash("kind: Pipeline\n") + "..0000000000000000000000000000000000000000\n" +
+			"--- a/old.yaml\n" +
+			"+++ /dev/null\n" +
+			"@@ -1 +0,0 @@\n" +
+			"-kind: Pipeline\n",
+	}, {
+		name:        "missing file",
+		path:        "missing.yaml",
+		expectedErr: ErrFileNotFound,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := Resolver{}
+			params := map[string]string{
+				URLParam:  repo,
+				PathParam: tc.path,
+				RefAParam: "v1",
+				RefBParam: "v2",
+				DiffParam: "true",
+			}
+			if err := resolver.ValidateParams(context.Background(), params); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			resource, err := resolver.Resolve(context.Background(), params)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, received %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.expected {
+				t.Errorf("expected diff:\n%s\nreceived:\n%s", tc.expected, resource.Data())
+			}
+			annotations := resource.Annotations()
+			if annotations[AnnotationKeyRefACommit] != hashes[0] || annotations[AnnotationKeyRefBCommit] != hashes[1] {
+				t.Errorf("expected ref commit annotations %s and %s, received %s and %s", hashes[0], hashes[1], annotations[AnnotationKeyRefACommit], annotations[AnnotationKeyRefBCommit])
+			}
+			if annotations[resolutioncommon.AnnotationKeyContentType] != DiffContentType {
+				t.Errorf("expected content type %q, received %q", DiffContentType, annotations[resolutioncommon.AnnotationKeyContentType])
+			}
+		})
+	}
+}
+
+func TestResolveDiffUnchangedFile(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "kind: Task\n"},
+		Tag:   "v1",
+	}, {
+		Files: map[string]string{"other.yaml": "kind: Pipeline\n"},
+		Tag:   "v2",
+	}})
+	resolver := Resolver{}
+	resource, err := resolver.Resolve(context.Background(), map[string]string{
+		URLParam:  repo,
+		PathParam: "task.yaml",
+		RefAParam: "v1",
+		RefBParam: "v2",
+		DiffParam: "true",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resource.Data()) != 0 {
+		t.Errorf("expected an empty diff, received %q", resource.Data())
+	}
+}
+
+func TestValidateDiff(t *testing.T) {
+	for _, params := range []map[string]string{
+		{DiffParam: "true", PathParam: "task.yaml"},
+		{DiffParam: "true", RefAParam: "v1", PathParam: "task.yaml"},
+		{DiffParam: "true", RefAParam: "v1", RefBParam: "v2"},
+		{DiffParam: "true", RefAParam: "v1", RefBParam: "v2", MergeBaseParam: "true"},
+		{DiffParam: "yes", RefAParam: "v1", RefBParam: "v2", PathParam: "task.yaml"},
+	} {
+		params[URLParam] = "https://example.com/repo.git"
+		resolver := Resolver{}
+		if err := resolver.ValidateParams(context.Background(), params); err == nil {
+			t.Errorf("expected params %v to be rejected", params)
+		}
+	}
+}
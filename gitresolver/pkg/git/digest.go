@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+)
+
+// digestAlgorithmSHA256 is the only algorithm supported in an
+// ExpectedDigestParam.
+const digestAlgorithmSHA256 = "sha256"
+
+// validateExpectedDigest returns an error if ExpectedDigestParam isn't a
+// sha256 digest of the form sha256:<hex>.
+func validateExpectedDigest(params map[string]string) error {
+	expected := params[ExpectedDigestParam]
+	if expected == "" {
+		return nil
+	}
+	parts := strings.SplitN(expected, ":", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid %q %q: must be of the form %s:<hex>", ExpectedDigestParam, expected, digestAlgorithmSHA256)
+	}
+	if parts[0] != digestAlgorithmSHA256 {
+		return fmt.Errorf("invalid %q %q: unsupported digest algorithm %q, only %s is supported", ExpectedDigestParam, expected, parts[0], digestAlgorithmSHA256)
+	}
+	if decoded, err := hex.DecodeString(parts[1]); err != nil || len(decoded) != sha256.Size {
+		return fmt.Errorf("invalid %q %q: must be a hex-encoded %s digest", ExpectedDigestParam, expected, digestAlgorithmSHA256)
+	}
+	return nil
+}
+
+// verifyDigest returns an error with reason
+// ReasonResolvedContentDigestMismatch if content doesn't have the
+// expected digest, which must be valid or empty.
+func verifyDigest(content []byte, expected string) error {
+	if expected == "" {
+		return nil
+	}
+	digest := sha256.Sum256(content)
+	actual := digestAlgorithmSHA256 + ":" + hex.EncodeToString(digest[:])
+	if !strings.EqualFold(actual, expected) {
+		return resolutioncommon.NewError(resolutioncommon.ReasonResolvedContentDigestMismatch, fmt.Errorf("expected digest %s, resolved content has digest %s: %w", expected, actual, ErrDigestMismatch))
+	}
+	return nil
+}
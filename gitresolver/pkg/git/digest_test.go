@@ -0,0 +1,88 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+)
+
+func TestResolveExpectedDigest(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+	// The sha256 digest of "content".
+	const digest = "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73"
+
+	for _, tc := range []struct {
+		name     string
+		expected string
+		mismatch bool
+	}{{
+		name:     "matching digest",
+		expected: "sha256:" + digest,
+	}, {
+		name:     "matching uppercase digest",
+		expected: "sha256:" + strings.ToUpper(digest),
+	}, {
+		name:     "mismatching digest",
+		expected: "sha256:" + strings.Repeat("0", 64),
+		mismatch: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			params := map[string]string{
+				URLParam:            repo,
+				PathParam:           "task.yaml",
+				ExpectedDigestParam: tc.expected,
+			}
+			resolver := Resolver{}
+			if err := resolver.ValidateParams(context.Background(), params); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			resource, err := resolver.Resolve(context.Background(), params)
+			if !tc.mismatch {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if string(resource.Data()) != "content" {
+					t.Errorf("unexpected content %q", resource.Data())
+				}
+				return
+			}
+			if !errors.Is(err, ErrDigestMismatch) {
+				t.Fatalf("expected ErrDigestMismatch, received %v", err)
+			}
+			if reason, _ := resolutioncommon.ReasonError(err); reason != resolutioncommon.ReasonResolvedContentDigestMismatch {
+				t.Errorf("expected reason %q, received %q", resolutioncommon.ReasonResolvedContentDigestMismatch, reason)
+			}
+			if !strings.Contains(err.Error(), digest) {
+				t.Errorf("expected the error to name the actual digest, received %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateExpectedDigest(t *testing.T) {
+	for _, tc := range []struct {
+		digest string
+		err    string
+	}{
+		{digest: "sha512:" + strings.Repeat("0", 128), err: "unsupported digest algorithm"},
+		{digest: "md5:" + strings.Repeat("0", 32), err: "unsupported digest algorithm"},
+		{digest: strings.Repeat("0", 64), err: "must be of the form"},
+		{digest: "sha256:abc", err: "hex-encoded"},
+		{digest: "sha256:" + strings.Repeat("z", 64), err: "hex-encoded"},
+	} {
+		resolver := Resolver{}
+		err := resolver.ValidateParams(context.Background(), map[string]string{
+			URLParam:            "https://github.com/tektoncd/catalog",
+			PathParam:           "task.yaml",
+			ExpectedDigestParam: tc.digest,
+		})
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("expected error containing %q validating %q, received %v", tc.err, tc.digest, err)
+		}
+	}
+}
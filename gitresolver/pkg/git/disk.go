@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/go-git/go-billy/v5"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+)
+
+// diskBudget bounds the storage used by a clone, i.e. the bytes fetched
+// from the git host plus those written to its worktree, to the
+// resolutioncommon.AnnotationKeyMaxDisk of its request. A nil
+// diskBudget is unbounded.
+type diskBudget struct {
+	limit int64
+	used  int64
+}
+
+// newDiskBudget returns a diskBudget of limit bytes, or nil if limit is
+// 0.
+func newDiskBudget(limit int64) *diskBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &diskBudget{limit: limit}
+}
+
+// use counts n more bytes against the budget, returning an error once
+// they exceed it.
+func (b *diskBudget) use(n int64) error {
+	if b == nil {
+		return nil
+	}
+	if atomic.AddInt64(&b.used, n) > b.limit {
+		return b.err()
+	}
+	return nil
+}
+
+// exceeded returns whether more bytes have been used than the budget
+// allows.
+func (b *diskBudget) exceeded() bool {
+	return b != nil && atomic.LoadInt64(&b.used) > b.limit
+}
+
+// fits returns whether n more bytes can be used without exceeding the
+// budget.
+func (b *diskBudget) fits(n int64) bool {
+	return b == nil || atomic.LoadInt64(&b.used)+n <= b.limit
+}
+
+func (b *diskBudget) err() error {
+	return fmt.Errorf("%w: clone needs more than the %s allowed by the %s annotation", ErrDiskBudgetExceeded, resource.NewQuantity(b.limit, resource.BinarySI), resolutioncommon.AnnotationKeyMaxDisk)
+}
+
+// filesystem returns fs with the bytes written to it counted against
+// the budget, or fs itself if the budget is unbounded.
+func (b *diskBudget) filesystem(fs billy.Filesystem) billy.Filesystem {
+	if b == nil {
+		return fs
+	}
+	return &budgetedFilesystem{Filesystem: fs, budget: b}
+}
+
+// budgetedFilesystem is a billy.Filesystem whose files count the bytes
+// written to them against a diskBudget.
+type budgetedFilesystem struct {
+	billy.Filesystem
+	budget *diskBudget
+}
+
+func (fs *budgetedFilesystem) Create(filename string) (billy.File, error) {
+	return fs.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+func (fs *budgetedFilesystem) OpenFile(filename string, flag int, perm os.FileMode) (billy.File, error) {
+	f, err := fs.Filesystem.OpenFile(filename, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &budgetedFile{File: f, budget: fs.budget}, nil
+}
+
+func (fs *budgetedFilesystem) TempFile(dir, prefix string) (billy.File, error) {
+	f, err := fs.Filesystem.TempFile(dir, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return &budgetedFile{File: f, budget: fs.budget}, nil
+}
+
+func (fs *budgetedFilesystem) Chroot(path string) (billy.Filesystem, error) {
+	chrooted, err := fs.Filesystem.Chroot(path)
+	if err != nil {
+		return nil, err
+	}
+	return &budgetedFilesystem{Filesystem: chrooted, budget: fs.budget}, nil
+}
+
+// budgetedFile is a billy.File that counts the bytes written to it
+// against a diskBudget, refusing writes that would exceed it.
+type budgetedFile struct {
+	billy.File
+	budget *diskBudget
+}
+
+func (f *budgetedFile) Write(p []byte) (int, error) {
+	if err := f.budget.use(int64(len(p))); err != nil {
+		return 0, err
+	}
+	return f.File.Write(p)
+}
@@ -0,0 +1,71 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+)
+
+func TestResolveEnforcesDiskBudget(t *testing.T) {
+	// Random content doesn't compress, so fetching it takes as many
+	// bytes as it has.
+	large := make([]byte, 256<<10)
+	rand.New(rand.NewSource(1)).Read(large)
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{
+			"task.yaml":  "content",
+			"large.data": string(large),
+		},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+
+	for _, tc := range []struct {
+		name        string
+		url         string
+		maxDisk     int64
+		expectAbort bool
+	}{{
+		name: "unbounded",
+		url:  server.repoURL(),
+	}, {
+		name:    "within budget",
+		url:     server.repoURL(),
+		maxDisk: 2 << 20,
+	}, {
+		name:        "fetch exceeds budget",
+		url:         server.repoURL(),
+		maxDisk:     64 << 10,
+		expectAbort: true,
+	}, {
+		name:        "checkout exceeds budget",
+		url:         repo,
+		maxDisk:     64 << 10,
+		expectAbort: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.maxDisk > 0 {
+				ctx = resolutioncommon.InjectRequestMaxDisk(ctx, tc.maxDisk)
+			}
+			resource, err := (&Resolver{}).Resolve(ctx, map[string]string{
+				URLParam:  tc.url,
+				PathParam: "task.yaml",
+			})
+			if tc.expectAbort {
+				if !errors.Is(err, ErrDiskBudgetExceeded) {
+					t.Fatalf("expected %v, received %v", ErrDiskBudgetExceeded, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != "content" {
+				t.Errorf("unexpected content %q", resource.Data())
+			}
+		})
+	}
+}
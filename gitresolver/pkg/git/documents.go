@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+)
+
+// documentBoundary locates one yaml document within resolved content.
+type documentBoundary struct {
+	// Start and End are the offsets of the first byte of the document
+	// and of the byte after its last, excluding the "---" separators
+	// between documents.
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Kind  string `json:"kind,omitempty"`
+	Name  string `json:"name,omitempty"`
+}
+
+// validateSplitDocuments returns an error if SplitDocumentsParam is
+// combined with converting the content to json, which already returns
+// several documents as an array.
+func validateSplitDocuments(params map[string]string) error {
+	split, err := parseBoolParam(params, SplitDocumentsParam)
+	if err != nil || !split {
+		return err
+	}
+	if params[OutputFormatParam] == outputFormatJSON {
+		return fmt.Errorf("supplied both %q and %q %q", SplitDocumentsParam, OutputFormatParam, outputFormatJSON)
+	}
+	return nil
+}
+
+// splitDocuments returns the boundaries of each yaml document in
+// content, in order, as json. Documents are separated as a
+// utilyaml.YAMLReader would separate them, by lines that are "---" and
+// trailing whitespace. Documents holding nothing but comments and blank
+// lines are skipped.
+func splitDocuments(content []byte) (string, error) {
+	boundaries := []documentBoundary{}
+	addDocument := func(start, end int) {
+		doc := content[start:end]
+		if stripLeadingComments(doc) == nil {
+			return
+		}
+		boundary := documentBoundary{Start: start, End: end}
+		var resource struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		}
+		if err := yaml.Unmarshal(doc, &resource); err == nil {
+			boundary.Kind = resource.Kind
+			boundary.Name = resource.Metadata.Name
+		}
+		boundaries = append(boundaries, boundary)
+	}
+	start := 0
+	for offset := 0; offset < len(content); {
+		lineEnd := len(content)
+		if i := bytes.IndexByte(content[offset:], '\n'); i >= 0 {
+			lineEnd = offset + i + 1
+		}
+		line := content[offset:lineEnd]
+		if bytes.HasPrefix(line, []byte("---")) && len(bytes.TrimSpace(line[3:])) == 0 {
+			addDocument(start, offset)
+			start = lineEnd
+		}
+		offset = lineEnd
+	}
+	addDocument(start, len(content))
+	encoded, err := json.Marshal(boundaries)
+	if err != nil {
+		return "", fmt.Errorf("error serializing document boundaries: %w", err)
+	}
+	return string(encoded), nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestResolveSplitDocuments(t *testing.T) {
+	content := "# leading comment\n" +
+		"---\n" +
+		"apiVersion: tekton.dev/v1beta1\n" +
+		"kind: Task\n" +
+		"metadata:\n" +
+		"  name: build\n" +
+		"---\n" +
+		"# only a comment\n" +
+		"---   \n" +
+		"apiVersion: tekton.dev/v1beta1\n" +
+		"kind: Pipeline\n" +
+		"metadata:\n" +
+		"  name: ci\n" +
+		"--- \n" +
+		"plain: document\n"
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"resources.yaml": content},
+	}})
+
+	resolver := Resolver{}
+	params := map[string]string{
+		URLParam:            repo,
+		PathParam:           "resources.yaml",
+		SplitDocumentsParam: "true",
+	}
+	if err := resolver.ValidateParams(context.Background(), params); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	resource, err := resolver.Resolve(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resource.Data()) != content {
+		t.Errorf("expected content to be returned as-is, received %q", resource.Data())
+	}
+
+	var boundaries []documentBoundary
+	if err := json.Unmarshal([]byte(resource.Annotations()[AnnotationKeyDocuments]), &boundaries); err != nil {
+		t.Fatalf("error parsing documents annotation: %v", err)
+	}
+	received := []string{}
+	for _, b := range boundaries {
+		received = append(received, string(resource.Data()[b.Start:b.End]))
+	}
+	expected := []string{
+		"apiVersion: tekton.dev/v1beta1\nkind: Task\nmetadata:\n  name: build\n",
+		"apiVersion: tekton.dev/v1beta1\nkind: Pipeline\nmetadata:\n  name: ci\n",
+		"plain: document\n",
+	}
+	if !reflect.DeepEqual(received, expected) {
+		t.Errorf("expected documents %q, received %q", expected, received)
+	}
+	if boundaries[0].Kind != "Task" || boundaries[0].Name != "build" || boundaries[1].Kind != "Pipeline" || boundaries[1].Name != "ci" || boundaries[2].Kind != "" {
+		t.Errorf("unexpected document kinds and names: %+v", boundaries)
+	}
+}
+
+func TestSplitDocumentsSingleDocument(t *testing.T) {
+	content := "kind: Task\nmetadata:\n  name: build"
+	documents, err := splitDocuments([]byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `[{"start":0,"end":34,"kind":"Task","name":"build"}]`
+	if documents != expected {
+		t.Errorf("expected %s, received %s", expected, documents)
+	}
+}
+
+func TestValidateSplitDocuments(t *testing.T) {
+	for _, params := range []map[string]string{
+		{SplitDocumentsParam: "yes"},
+		{SplitDocumentsParam: "true", OutputFormatParam: outputFormatJSON},
+	} {
+		params[URLParam] = "https://example.com/repo.git"
+		params[PathParam] = "task.yaml"
+		resolver := Resolver{}
+		if err := resolver.ValidateParams(context.Background(), params); err == nil {
+			t.Errorf("expected params %v to be rejected", params)
+		}
+	}
+}
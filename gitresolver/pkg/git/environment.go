@@ -0,0 +1,49 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultEnvironmentTagPrefix is the prefix of environment tags when
+// ConfigFieldEnvironmentTagPrefix isn't set, e.g. "deployed/prod".
+const defaultEnvironmentTagPrefix = "deployed/"
+
+// validateEnvironment returns an error if EnvironmentParam can't name
+// part of a tag.
+func validateEnvironment(params map[string]string) error {
+	env := params[EnvironmentParam]
+	if env == "" {
+		return nil
+	}
+	if strings.ContainsAny(env, " \t~^:?*[\\") || strings.Contains(env, "..") || strings.HasPrefix(env, "/") || strings.HasSuffix(env, "/") {
+		return fmt.Errorf("invalid %q %q: must be usable in a tag name", EnvironmentParam, env)
+	}
+	return nil
+}
+
+// environmentTag returns the name of the tag that tracks the commit
+// deployed to env.
+func environmentTag(conf map[string]string, env string) string {
+	prefix, ok := conf[ConfigFieldEnvironmentTagPrefix]
+	if !ok {
+		prefix = defaultEnvironmentTagPrefix
+	}
+	return strings.TrimSpace(prefix) + env
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+func TestResolveEnvironment(t *testing.T) {
+	repo, commits := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "v1"},
+		Tag:   "deployed/prod",
+	}, {
+		Files:      map[string]string{"task.yaml": "v2"},
+		Tag:        "deployed/staging",
+		TagMessage: "promoted to staging",
+	}, {
+		Files: map[string]string{"task.yaml": "v3"},
+		Tag:   "release/prod",
+	}})
+	resolver := Resolver{}
+
+	for _, tc := range []struct {
+		name            string
+		env             string
+		config          map[string]string
+		expectedContent string
+		expectedCommit  string
+		expectedTag     string
+	}{{
+		name:            "lightweight environment tag",
+		env:             "prod",
+		expectedContent: "v1",
+		expectedCommit:  commits[0],
+		expectedTag:     "deployed/prod",
+	}, {
+		name:            "annotated environment tag",
+		env:             "staging",
+		expectedContent: "v2",
+		expectedCommit:  commits[1],
+		expectedTag:     "deployed/staging",
+	}, {
+		name:            "configured prefix",
+		env:             "prod",
+		config:          map[string]string{ConfigFieldEnvironmentTagPrefix: "release/"},
+		expectedContent: "v3",
+		expectedCommit:  commits[2],
+		expectedTag:     "release/prod",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := framework.InjectResolverConfigToContext(context.Background(), tc.config)
+			params := map[string]string{
+				URLParam:         repo,
+				PathParam:        "task.yaml",
+				EnvironmentParam: tc.env,
+			}
+			if err := resolver.ValidateParams(ctx, params); err != nil {
+				t.Fatalf("unexpected error validating params: %v", err)
+			}
+			resource, err := resolver.Resolve(ctx, params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.expectedContent {
+				t.Errorf("expected content %q, received %q", tc.expectedContent, resource.Data())
+			}
+			annotations := resource.Annotations()
+			if annotations[AnnotationKeyCommitHash] != tc.expectedCommit {
+				t.Errorf("expected commit %s, received %s", tc.expectedCommit, annotations[AnnotationKeyCommitHash])
+			}
+			if annotations[AnnotationKeyEnvironmentTag] != tc.expectedTag {
+				t.Errorf("expected environment tag %q, received %q", tc.expectedTag, annotations[AnnotationKeyEnvironmentTag])
+			}
+		})
+	}
+}
+
+func TestResolveMissingEnvironment(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "v1"},
+	}})
+	resolver := Resolver{}
+	_, err := resolver.Resolve(context.Background(), map[string]string{
+		URLParam:         repo,
+		PathParam:        "task.yaml",
+		EnvironmentParam: "prod",
+	})
+	if !errors.Is(err, ErrTagNotFound) {
+		t.Fatalf("expected ErrTagNotFound, received %v", err)
+	}
+}
+
+func TestValidateEnvironment(t *testing.T) {
+	resolver := Resolver{}
+	for _, params := range []map[string]string{{
+		URLParam:         "https://github.com/tektoncd/catalog",
+		PathParam:        "task.yaml",
+		EnvironmentParam: "prod",
+		CommitParam:      "abc123",
+	}, {
+		URLParam:         "https://github.com/tektoncd/catalog",
+		PathParam:        "task.yaml",
+		EnvironmentParam: "prod env",
+	}} {
+		if err := resolver.ValidateParams(context.Background(), params); err == nil {
+			t.Errorf("expected error validating %v", params)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"errors"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// ErrEmptyRepository is returned when the repository at URLParam
+// exists but has no commits to fetch a file from.
+var ErrEmptyRepository = errors.New("repository is empty: it has no commits to resolve a file from")
+
+// ErrNoMergeBase is returned when MergeBaseParam is set and RefAParam
+// and RefBParam have no common ancestor, i.e. their histories are
+// unrelated.
+var ErrNoMergeBase = errors.New("refs have no common ancestor")
+
+// ErrConnectionStalled is returned when a connection to a git host
+// goes without receiving any data for ConfigFieldHTTPIdleTimeout.
+var ErrConnectionStalled = errors.New("connection stalled")
+
+// ErrTooManyRedirects is returned when an http request to a git host
+// is redirected more times than ConfigFieldMaxRedirects allows.
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// ErrRedirectLoop is returned when an http request to a git host is
+// redirected back to a url it has already visited.
+var ErrRedirectLoop = errors.New("redirect loop")
+
+// ErrCheckoutFailed is returned when checking out a commit that was
+// fetched fails, which ConfigFieldCheckoutRetries may retry.
+var ErrCheckoutFailed = errors.New("checkout failed")
+
+// ErrDiskBudgetExceeded is returned when cloning a repository needs
+// more storage than its request's max-disk annotation allows.
+var ErrDiskBudgetExceeded = errors.New("disk budget exceeded")
+
+// ErrDigestMismatch is returned when the digest of the resolved content
+// differs from ExpectedDigestParam.
+var ErrDigestMismatch = errors.New("content digest mismatch")
+
+// ErrUnknownSSHHost is returned when an ssh host isn't in the known
+// hosts and StrictHostKeyCheckingParam is "strict".
+var ErrUnknownSSHHost = errors.New("unknown ssh host")
+
+// ErrSSHHostKeyMismatch is returned when an ssh host presents a key
+// other than the one it's known by.
+var ErrSSHHostKeyMismatch = errors.New("ssh host key mismatch")
+
+// ErrCommitNotFound is returned when a requested commit can't be found
+// in the history fetched from the repository.
+var ErrCommitNotFound error = notFoundError("commit not found")
+
+// ErrBranchNotFound is returned when the repository has no branch named
+// by BranchParam.
+var ErrBranchNotFound error = notFoundError("branch not found")
+
+// ErrFileNotFound is returned when there's no file at PathParam in the
+// resolved commit.
+var ErrFileNotFound error = notFoundError("file not found")
+
+// ErrBlobNotFound is returned when there's no blob with the hash given
+// by BlobParam in the history fetched from the repository.
+var ErrBlobNotFound error = notFoundError("blob not found")
+
+// ErrWorktreeNotFound is returned when the local repository at URLParam
+// has no linked worktree named by WorktreeParam.
+var ErrWorktreeNotFound error = notFoundError("worktree not found")
+
+// ErrTagNotFound is returned when the repository has no tag named by
+// TagMessageParam.
+var ErrTagNotFound error = notFoundError("tag not found")
+
+// ErrNoMatchingCommit is returned when no commit on the branch has a
+// message matching CommitMessageFilterParam.
+var ErrNoMatchingCommit error = notFoundError("no matching commit")
+
+// ErrUnexpectedFormat is returned when the file doesn't parse as the
+// format given by ExpectFormatParam.
+var ErrUnexpectedFormat = errors.New("content doesn't parse as the expected format")
+
+// ErrLightweightTag is returned when the tag named by TagMessageParam
+// is a lightweight tag, which has no message to resolve.
+var ErrLightweightTag = errors.New("lightweight tags have no message: only annotated tags can be resolved")
+
+// notFoundError is a sentinel error for something missing from a
+// repository. Every notFoundError matches framework.ErrorResourceNotFound
+// so that a CompositeResolver treats it as a miss.
+type notFoundError string
+
+func (e notFoundError) Error() string {
+	return string(e)
+}
+
+func (e notFoundError) Is(target error) bool {
+	return target == framework.ErrorResourceNotFound
+}
+
+// authError wraps an error authenticating to a repository so that it
+// matches framework.ErrorAuthenticationFailed.
+type authError struct {
+	err error
+}
+
+func (e *authError) Error() string {
+	return e.err.Error()
+}
+
+func (e *authError) Unwrap() error {
+	return e.err
+}
+
+func (e *authError) Is(target error) bool {
+	return target == framework.ErrorAuthenticationFailed
+}
+
+// wrapAuthError returns err as an authError if the git host rejected the
+// request's credentials, or their absence.
+func wrapAuthError(err error) error {
+	if errors.Is(err, transport.ErrAuthenticationRequired) || errors.Is(err, transport.ErrAuthorizationFailed) {
+		return &authError{err: err}
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+)
+
+const (
+	// fetchTagsAll fetches every tag in the repo.
+	fetchTagsAll = "all"
+	// fetchTagsNone fetches no tags beyond any the request names.
+	fetchTagsNone = "none"
+	// fetchTagsFollowing fetches the tags that point into the fetched
+	// history.
+	fetchTagsFollowing = "following"
+)
+
+// validateFetchTags returns an error if FetchTagsParam is set to an
+// unsupported mode.
+func validateFetchTags(params map[string]string) error {
+	switch mode := params[FetchTagsParam]; mode {
+	case "", fetchTagsAll, fetchTagsNone, fetchTagsFollowing:
+		return nil
+	default:
+		return fmt.Errorf("invalid %q %q: must be %q, %q or %q", FetchTagsParam, mode, fetchTagsAll, fetchTagsNone, fetchTagsFollowing)
+	}
+}
+
+// fetchTagsMode returns the FetchTagsParam mode that params are cloned
+// with. Unless it's set tags are only fetched, all of them, when the
+// request may name one as a ref or describes the commit in terms of
+// them. Tags named by EnvironmentParam or TagMessageParam are fetched
+// by themselves regardless.
+func fetchTagsMode(params map[string]string) string {
+	if mode := params[FetchTagsParam]; mode != "" {
+		return mode
+	}
+	describe, _ := parseBoolParam(params, DescribeParam)
+	if comparingRefs(params) || describe {
+		return fetchTagsAll
+	}
+	return fetchTagsNone
+}
+
+// tagMode returns the go-git tag mode that fetches tags as mode does.
+func tagMode(mode string) git.TagMode {
+	switch mode {
+	case fetchTagsAll:
+		return git.AllTags
+	case fetchTagsFollowing:
+		return git.TagFollowing
+	default:
+		return git.NoTags
+	}
+}
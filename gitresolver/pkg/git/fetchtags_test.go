@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestCloneFetchTags(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "v1"},
+		Tag:   "v1",
+	}, {
+		Files:      map[string]string{"task.yaml": "v2"},
+		Tag:        "v2",
+		TagMessage: "release v2",
+	}})
+
+	for _, tc := range []struct {
+		name         string
+		fetchTags    string
+		describe     string
+		expectedMode string
+		expectedTags []string
+	}{{
+		name:         "default for a branch",
+		expectedMode: fetchTagsNone,
+	}, {
+		name:         "none",
+		fetchTags:    fetchTagsNone,
+		expectedMode: fetchTagsNone,
+	}, {
+		name:         "all",
+		fetchTags:    fetchTagsAll,
+		expectedMode: fetchTagsAll,
+		expectedTags: []string{"v1", "v2"},
+	}, {
+		name:         "default when describing",
+		describe:     "true",
+		expectedMode: fetchTagsAll,
+		expectedTags: []string{"v1", "v2"},
+	}, {
+		name:         "none when describing",
+		fetchTags:    fetchTagsNone,
+		describe:     "true",
+		expectedMode: fetchTagsNone,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			params := map[string]string{
+				URLParam:       repo,
+				BranchParam:    "master",
+				PathParam:      "task.yaml",
+				FetchTagsParam: tc.fetchTags,
+				DescribeParam:  tc.describe,
+			}
+			resolver := Resolver{}
+			if err := resolver.ValidateParams(context.Background(), params); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			co, err := resolver.cloneAndCheckout(context.Background(), params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mode := co.annotations[AnnotationKeyFetchTags]; mode != tc.expectedMode {
+				t.Errorf("expected fetch-tags annotation %q, received %q", tc.expectedMode, mode)
+			}
+			refs, err := co.repository.Tags()
+			if err != nil {
+				t.Fatalf("error listing tags: %v", err)
+			}
+			tags := []string{}
+			if err := refs.ForEach(func(ref *plumbing.Reference) error {
+				tags = append(tags, ref.Name().Short())
+				return nil
+			}); err != nil {
+				t.Fatalf("error listing tags: %v", err)
+			}
+			sort.Strings(tags)
+			if strings.Join(tags, ",") != strings.Join(tc.expectedTags, ",") {
+				t.Errorf("expected tags %v in the clone, received %v", tc.expectedTags, tags)
+			}
+			// No tag objects are fetched without their refs either.
+			if len(tc.expectedTags) == 0 {
+				objects, err := co.repository.TagObjects()
+				if err != nil {
+					t.Fatalf("error listing tag objects: %v", err)
+				}
+				if _, err := objects.Next(); err == nil {
+					t.Errorf("expected no tag objects in the clone")
+				}
+			}
+		})
+	}
+}
+
+func TestValidateFetchTags(t *testing.T) {
+	err := (&Resolver{}).ValidateParams(context.Background(), map[string]string{
+		URLParam:       "https://github.com/tektoncd/catalog",
+		PathParam:      "task.yaml",
+		FetchTagsParam: "some",
+	})
+	if err == nil || !strings.Contains(err.Error(), `invalid "fetchTags" "some"`) {
+		t.Errorf("expected invalid mode error, received %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// fileMode returns the git mode, e.g. "100644" or "100755", of the tree
+// entry at filePath in commit.
+func fileMode(repository *git.Repository, commit string, filePath string) (string, error) {
+	c, err := repository.CommitObject(plumbing.NewHash(commit))
+	if err != nil {
+		return "", fmt.Errorf("error reading commit %s: %w", commit, err)
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return "", fmt.Errorf("error reading tree of commit %s: %w", commit, err)
+	}
+	// Paths are read from the worktree, which accepts a leading slash
+	// and redundant elements that tree lookups don't.
+	entry, err := tree.FindEntry(strings.TrimPrefix(path.Clean("/"+filePath), "/"))
+	if err != nil {
+		return "", fmt.Errorf("error finding %q in commit %s: %w", filePath, commit, err)
+	}
+	return strconv.FormatUint(uint64(entry.Mode), 8), nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"testing"
+)
+
+func TestResolveFileMode(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{
+			"scripts/build.sh": "#!/bin/sh\nmake\n",
+			"task.yaml":        "kind: Task\n",
+			"LICENSE":          "Apache License\n",
+		},
+		Executable: []string{"scripts/build.sh"},
+	}})
+
+	for _, tc := range []struct {
+		name     string
+		params   map[string]string
+		expected string
+	}{{
+		name:     "executable file",
+		params:   map[string]string{PathParam: "scripts/build.sh"},
+		expected: "100755",
+	}, {
+		name:     "regular file",
+		params:   map[string]string{PathParam: "/task.yaml"},
+		expected: "100644",
+	}, {
+		name:     "well-known file",
+		params:   map[string]string{WellKnownParam: wellKnownLicense},
+		expected: "100644",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.params[URLParam] = repo
+			resolver := Resolver{}
+			resource, err := resolver.Resolve(context.Background(), tc.params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mode := resource.Annotations()[AnnotationKeyFileMode]; mode != tc.expected {
+				t.Errorf("expected file mode %q, received %q", tc.expected, mode)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+
+	yamlv2 "gopkg.in/yaml.v2"
+)
+
+const (
+	// expectFormatYAML expects a stream of yaml documents.
+	expectFormatYAML = "yaml"
+	// expectFormatJSON expects a single json value.
+	expectFormatJSON = "json"
+	// expectFormatText expects utf-8 text without NUL bytes.
+	expectFormatText = "text"
+)
+
+// validateExpectFormat returns an error if ExpectFormatParam is set to
+// an unsupported format.
+func validateExpectFormat(params map[string]string) error {
+	switch format := params[ExpectFormatParam]; format {
+	case "", expectFormatYAML, expectFormatJSON, expectFormatText:
+		return nil
+	default:
+		return fmt.Errorf("invalid %q %q: must be %q, %q or %q", ExpectFormatParam, format, expectFormatYAML, expectFormatJSON, expectFormatText)
+	}
+}
+
+// formatError locates where content first failed to parse as the
+// format it was expected to have. Every formatError matches
+// ErrUnexpectedFormat.
+type formatError struct {
+	format string
+	// line and column are 1-based, or 0 when the parser didn't report
+	// them.
+	line   int
+	column int
+	msg    string
+}
+
+func (e *formatError) Error() string {
+	switch {
+	case e.line > 0 && e.column > 0:
+		return fmt.Sprintf("invalid %s at line %d, column %d: %s", e.format, e.line, e.column, e.msg)
+	case e.line > 0:
+		return fmt.Sprintf("invalid %s at line %d: %s", e.format, e.line, e.msg)
+	default:
+		return fmt.Sprintf("invalid %s: %s", e.format, e.msg)
+	}
+}
+
+func (e *formatError) Is(target error) bool {
+	return target == ErrUnexpectedFormat
+}
+
+// checkFormat returns a formatError if content doesn't parse as format.
+func checkFormat(content []byte, format string) error {
+	switch format {
+	case expectFormatYAML:
+		return checkYAML(content)
+	case expectFormatJSON:
+		return checkJSON(content)
+	case expectFormatText:
+		return checkText(content)
+	}
+	return nil
+}
+
+// yamlErrorLine matches the line that yaml parse errors are prefixed
+// with, counted from the start of the stream.
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// checkYAML parses every document of the yaml stream in content. The
+// yaml parser only reports the line of an error, not its column.
+func checkYAML(content []byte) error {
+	decoder := yamlv2.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc interface{}
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			ferr := &formatError{format: expectFormatYAML, msg: err.Error()}
+			if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+				ferr.line, _ = strconv.Atoi(m[1])
+				ferr.msg = m[2]
+			}
+			return ferr
+		}
+	}
+}
+
+// checkJSON parses content as a single json value.
+func checkJSON(content []byte) error {
+	var value interface{}
+	err := json.Unmarshal(content, &value)
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return nil
+	}
+	// The offset counts the bytes read up to and including the one
+	// that failed to parse, or all of them when the input ended early.
+	line, column := position(content, int(syntaxErr.Offset)-1)
+	return &formatError{format: expectFormatJSON, line: line, column: column, msg: syntaxErr.Error()}
+}
+
+// checkText checks that content is utf-8 without any NUL bytes.
+func checkText(content []byte) error {
+	for offset := 0; offset < len(content); {
+		r, size := utf8.DecodeRune(content[offset:])
+		var msg string
+		switch {
+		case r == utf8.RuneError && size <= 1:
+			msg = fmt.Sprintf("invalid utf-8 byte %#x", content[offset])
+		case r == 0:
+			msg = "unexpected NUL byte"
+		default:
+			offset += size
+			continue
+		}
+		line, column := position(content, offset)
+		return &formatError{format: expectFormatText, line: line, column: column, msg: msg}
+	}
+	return nil
+}
+
+// position returns the 1-based line and byte column of offset in
+// content.
+func position(content []byte, offset int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(content) {
+		offset = len(content)
+	}
+	before := content[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
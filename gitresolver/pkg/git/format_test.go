@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckFormat(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		format        string
+		content       string
+		expectedError string
+	}{{
+		name:    "valid yaml stream",
+		format:  expectFormatYAML,
+		content: "kind: Task\nmetadata:\n  name: a\n---\nkind: Pipeline\n",
+	}, {
+		name:    "valid json",
+		format:  expectFormatJSON,
+		content: "{\n  \"kind\": \"Task\",\n  \"spec\": [1, 2]\n}\n",
+	}, {
+		name:    "valid text",
+		format:  expectFormatText,
+		content: "héllo\nwörld\n",
+	}, {
+		name:    "no expected format",
+		content: "{\x00",
+	}, {
+		name:          "yaml with bad indentation",
+		format:        expectFormatYAML,
+		content:       "kind: Task\nspec:\n  steps:\n  - name: a\n   image: b\n",
+		expectedError: "invalid yaml at line 4: did not find expected key",
+	}, {
+		name:          "yaml error in later document",
+		format:        expectFormatYAML,
+		content:       "kind: Task\n---\nkind: Pipeline\nname: \"unterminated\n",
+		expectedError: "invalid yaml at line 5: found unexpected end of stream",
+	}, {
+		name:          "truncated json",
+		format:        expectFormatJSON,
+		content:       "{\n  \"kind\": \"Task\",\n  \"spec\": [1, 2",
+		expectedError: "invalid json at line 3, column 15: unexpected end of JSON input",
+	}, {
+		name:          "json with invalid character",
+		format:        expectFormatJSON,
+		content:       "{\n  \"kind\": Task\n}\n",
+		expectedError: "invalid json at line 2, column 11: invalid character 'T' looking for beginning of value",
+	}, {
+		name:          "yaml is not json",
+		format:        expectFormatJSON,
+		content:       "kind: Task\n",
+		expectedError: "invalid json at line 1, column 1: invalid character 'k' looking for beginning of value",
+	}, {
+		name:          "text with invalid utf-8",
+		format:        expectFormatText,
+		content:       "abc\nde\xfff\n",
+		expectedError: "invalid text at line 2, column 3: invalid utf-8 byte 0xff",
+	}, {
+		name:          "text with NUL byte",
+		format:        expectFormatText,
+		content:       "abc\x00",
+		expectedError: "invalid text at line 1, column 4: unexpected NUL byte",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkFormat([]byte(tc.content), tc.format)
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.expectedError {
+				t.Fatalf("expected error %q, received %v", tc.expectedError, err)
+			}
+			if !errors.Is(err, ErrUnexpectedFormat) {
+				t.Errorf("expected error to match ErrUnexpectedFormat")
+			}
+		})
+	}
+}
+
+func TestResolveExpectFormat(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{
+			"task.yaml":      "kind: Task\n",
+			"truncated.json": "{\"kind\": \"Ta",
+		},
+	}})
+	resolver := Resolver{}
+
+	params := map[string]string{
+		URLParam:          repo,
+		PathParam:         "task.yaml",
+		ExpectFormatParam: expectFormatYAML,
+	}
+	if err := resolver.ValidateParams(context.Background(), params); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	resource, err := resolver.Resolve(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resource.Data()) != "kind: Task\n" {
+		t.Errorf("unexpected content %q", resource.Data())
+	}
+
+	_, err = resolver.Resolve(context.Background(), map[string]string{
+		URLParam:          repo,
+		PathParam:         "truncated.json",
+		ExpectFormatParam: expectFormatJSON,
+	})
+	if !errors.Is(err, ErrUnexpectedFormat) || !strings.Contains(err.Error(), `file "truncated.json"`) || !strings.Contains(err.Error(), "line 1, column 12") {
+		t.Errorf("expected format error locating the truncation, received %v", err)
+	}
+
+	err = resolver.ValidateParams(context.Background(), map[string]string{
+		URLParam:          repo,
+		PathParam:         "task.yaml",
+		ExpectFormatParam: "toml",
+	})
+	if err == nil || !strings.Contains(err.Error(), `invalid "expectFormat" "toml"`) {
+		t.Errorf("expected invalid format error, received %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// GitHubAppSecretKeyAppID is the key in a GitHub App secret
+	// holding the App's ID.
+	GitHubAppSecretKeyAppID = "app-id"
+
+	// GitHubAppSecretKeyInstallationID is the key in a GitHub App
+	// secret holding the ID of the App's installation to mint tokens
+	// for.
+	GitHubAppSecretKeyInstallationID = "installation-id"
+
+	// GitHubAppSecretKeyPrivateKey is the key in a GitHub App secret
+	// holding the App's PEM-encoded private key.
+	GitHubAppSecretKeyPrivateKey = "private-key"
+)
+
+// defaultGitHubAPIURL is the GitHub API used to mint installation
+// tokens when ConfigFieldGitHubAPIURL isn't set.
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// githubAppTokenRefreshWindow is how close to expiry a cached
+// installation token may get before a fresh one is minted.
+const githubAppTokenRefreshWindow = 5 * time.Minute
+
+// githubAppJWTLifetime is how long the JWT used to authenticate as the
+// App is valid for. GitHub allows a maximum of 10 minutes.
+const githubAppJWTLifetime = 9 * time.Minute
+
+// githubInstallationTokenUsername is the username GitHub expects to
+// accompany an installation token in basic auth.
+const githubInstallationTokenUsername = "x-access-token"
+
+// githubAppCredentials are the fields read from a GitHub App secret.
+type githubAppCredentials struct {
+	appID          string
+	installationID string
+	privateKey     *rsa.PrivateKey
+}
+
+// installationToken is a minted GitHub App installation token.
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// githubAppTokenCache holds installation tokens so that they're reused
+// across requests until they're close to expiry.
+type githubAppTokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]installationToken
+}
+
+// get returns a valid token for the given App installation, minting a
+// new one if none is cached or the cached one is near expiry. Tokens
+// are cached per secret and private key so that a request can only
+// reuse a token minted with credentials it can read itself.
+func (c *githubAppTokenCache) get(ctx context.Context, client *http.Client, apiURL, namespace, secretName string, creds *githubAppCredentials) (string, error) {
+	fingerprint, err := publicKeyFingerprint(creds.privateKey)
+	if err != nil {
+		return "", err
+	}
+	key := strings.Join([]string{apiURL, namespace, secretName, creds.appID, creds.installationID, fingerprint}, "/")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tokens == nil {
+		c.tokens = map[string]installationToken{}
+	}
+	if tok, ok := c.tokens[key]; ok && time.Until(tok.ExpiresAt) > githubAppTokenRefreshWindow {
+		return tok.Token, nil
+	}
+	tok, err := mintInstallationToken(ctx, client, apiURL, creds)
+	if err != nil {
+		return "", err
+	}
+	c.tokens[key] = tok
+	return tok.Token, nil
+}
+
+// parseGitHubAppCredentials reads GitHub App credentials out of the
+// data of a secret.
+func parseGitHubAppCredentials(data map[string][]byte) (*githubAppCredentials, error) {
+	missing := []string{}
+	for _, key := range []string{GitHubAppSecretKeyAppID, GitHubAppSecretKeyInstallationID, GitHubAppSecretKeyPrivateKey} {
+		if len(data[key]) == 0 {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("github app secret missing %s", strings.Join(missing, ", "))
+	}
+	key, err := parseRSAPrivateKey(data[GitHubAppSecretKeyPrivateKey])
+	if err != nil {
+		return nil, err
+	}
+	return &githubAppCredentials{
+		appID:          strings.TrimSpace(string(data[GitHubAppSecretKeyAppID])),
+		installationID: strings.TrimSpace(string(data[GitHubAppSecretKeyInstallationID])),
+		privateKey:     key,
+	}, nil
+}
+
+// publicKeyFingerprint returns the hex-encoded sha256 of the key's
+// public half.
+func publicKeyFingerprint(key *rsa.PrivateKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("error fingerprinting github app private key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func parseRSAPrivateKey(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("github app private key is not PEM-encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing github app private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("github app private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// githubAppJWT returns a signed RS256 JWT identifying the App.
+func githubAppJWT(creds *githubAppCredentials, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		// Backdate issue time to allow for clock drift.
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(githubAppJWTLifetime).Unix(),
+		"iss": creds.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+	encoding := base64.RawURLEncoding
+	unsigned := encoding.EncodeToString(header) + "." + encoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, creds.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("error signing github app jwt: %w", err)
+	}
+	return unsigned + "." + encoding.EncodeToString(signature), nil
+}
+
+// mintInstallationToken exchanges a JWT for an App installation token.
+func mintInstallationToken(ctx context.Context, client *http.Client, apiURL string, creds *githubAppCredentials) (installationToken, error) {
+	tok := installationToken{}
+	jwt, err := githubAppJWT(creds, time.Now())
+	if err != nil {
+		return tok, err
+	}
+	url := fmt.Sprintf("%s/app/installations/%s/access_tokens", strings.TrimSuffix(apiURL, "/"), creds.installationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return tok, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	res, err := client.Do(req)
+	if err != nil {
+		return tok, fmt.Errorf("error minting github app installation token: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return tok, fmt.Errorf("error minting github app installation token: unexpected status %s", res.Status)
+	}
+	if err := json.NewDecoder(res.Body).Decode(&tok); err != nil {
+		return tok, fmt.Errorf("error decoding github app installation token: %w", err)
+	}
+	if tok.Token == "" {
+		return tok, errors.New("github app installation token response did not include a token")
+	}
+	return tok, nil
+}
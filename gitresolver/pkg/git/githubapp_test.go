@@ -0,0 +1,229 @@
+package git
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeGitHubAPI stubs the GitHub endpoint that mints installation
+// tokens, verifying the App JWT it receives.
+type fakeGitHubAPI struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	minted int
+}
+
+func newFakeGitHubAPI(t *testing.T, key *rsa.PrivateKey, appID, installationID, token string, lifetime time.Duration) *fakeGitHubAPI {
+	api := &fakeGitHubAPI{}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/"+installationID+"/access_tokens" {
+			http.NotFound(w, r)
+			return
+		}
+		jwt := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		parts := strings.Split(jwt, ".")
+		if len(parts) != 3 {
+			http.Error(w, "malformed jwt", http.StatusUnauthorized)
+			return
+		}
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			http.Error(w, "malformed jwt signature", http.StatusUnauthorized)
+			return
+		}
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			http.Error(w, "bad jwt signature", http.StatusUnauthorized)
+			return
+		}
+		claimBytes, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		claims := map[string]interface{}{}
+		if err := json.Unmarshal(claimBytes, &claims); err != nil || claims["iss"] != appID {
+			http.Error(w, "bad jwt claims", http.StatusUnauthorized)
+			return
+		}
+		api.mu.Lock()
+		api.minted++
+		api.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(installationToken{
+			Token:     token,
+			ExpiresAt: time.Now().Add(lifetime),
+		})
+	}))
+	t.Cleanup(api.Close)
+	return api
+}
+
+func (api *fakeGitHubAPI) mintCount() int {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.minted
+}
+
+func githubAppSecret(t *testing.T, key *rsa.PrivateKey, appID, installationID string) *corev1.Secret {
+	keyPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "github-app",
+			Namespace: "foo",
+		},
+		Data: map[string][]byte{
+			GitHubAppSecretKeyAppID:          []byte(appID),
+			GitHubAppSecretKeyInstallationID: []byte(installationID),
+			GitHubAppSecretKeyPrivateKey:     keyPEM,
+		},
+	}
+}
+
+func TestResolveWithGitHubApp(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		lifetime       time.Duration
+		expectedMinted int
+	}{{
+		name:           "cached token is reused",
+		lifetime:       time.Hour,
+		expectedMinted: 1,
+	}, {
+		name:           "token near expiry is refreshed",
+		lifetime:       time.Minute,
+		expectedMinted: 2,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				t.Fatalf("error generating key: %v", err)
+			}
+			api := newFakeGitHubAPI(t, key, "1234", "42", "ghs_token", tc.lifetime)
+
+			repo, _ := createTestRepo(t, []commitForRepo{{
+				Files: map[string]string{"task.yaml": "content"},
+			}})
+			server := newFakeGitHTTPServer(t, repo)
+			server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+				if user, pass, ok := r.BasicAuth(); !ok || user != githubInstallationTokenUsername || pass != "ghs_token" {
+					w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+					w.WriteHeader(http.StatusUnauthorized)
+					return true
+				}
+				return false
+			})
+
+			resolver := Resolver{
+				kubeClientSet: fakekube.NewSimpleClientset(githubAppSecret(t, key, "1234", "42")),
+			}
+			ctx := resolutioncommon.InjectRequestNamespace(context.Background(), "foo")
+			ctx = framework.InjectResolverConfigToContext(ctx, map[string]string{
+				ConfigFieldGitHubAPIURL: api.URL,
+			})
+			params := map[string]string{
+				URLParam:             server.repoURL(),
+				PathParam:            "task.yaml",
+				GitHubAppSecretParam: "github-app",
+			}
+			for i := 0; i < 2; i++ {
+				resource, err := resolver.Resolve(ctx, params)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if string(resource.Data()) != "content" {
+					t.Fatalf("unexpected content %q", resource.Data())
+				}
+			}
+			if minted := api.mintCount(); minted != tc.expectedMinted {
+				t.Errorf("expected %d tokens to be minted, received %d", tc.expectedMinted, minted)
+			}
+		})
+	}
+}
+
+func TestResolveWithGitHubAppMissingSecretFields(t *testing.T) {
+	resolver := Resolver{
+		kubeClientSet: fakekube.NewSimpleClientset(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "github-app",
+				Namespace: "foo",
+			},
+			Data: map[string][]byte{
+				GitHubAppSecretKeyAppID: []byte("1234"),
+			},
+		}),
+	}
+	ctx := resolutioncommon.InjectRequestNamespace(context.Background(), "foo")
+	_, err := resolver.Resolve(ctx, map[string]string{
+		URLParam:             "https://example.com/repo.git",
+		PathParam:            "task.yaml",
+		GitHubAppSecretParam: "github-app",
+	})
+	if err == nil || !strings.Contains(err.Error(), GitHubAppSecretKeyPrivateKey) {
+		t.Fatalf("expected error naming missing secret fields, received %v", err)
+	}
+}
+
+func TestGitHubAppTokenIsNotSharedAcrossNamespaces(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	// The fake API only accepts JWTs signed with key, so a request
+	// holding otherKey can only get a token out of the cache.
+	api := newFakeGitHubAPI(t, key, "1234", "42", "ghs_token", time.Hour)
+
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+
+	otherSecret := githubAppSecret(t, otherKey, "1234", "42")
+	otherSecret.Namespace = "bar"
+	resolver := Resolver{
+		kubeClientSet: fakekube.NewSimpleClientset(githubAppSecret(t, key, "1234", "42"), otherSecret),
+	}
+	params := map[string]string{
+		URLParam:             server.repoURL(),
+		PathParam:            "task.yaml",
+		GitHubAppSecretParam: "github-app",
+	}
+	config := map[string]string{ConfigFieldGitHubAPIURL: api.URL}
+
+	ctx := framework.InjectResolverConfigToContext(resolutioncommon.InjectRequestNamespace(context.Background(), "foo"), config)
+	if _, err := resolver.Resolve(ctx, params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx = framework.InjectResolverConfigToContext(resolutioncommon.InjectRequestNamespace(context.Background(), "bar"), config)
+	if _, err := resolver.Resolve(ctx, params); err == nil || !strings.Contains(err.Error(), "minting github app installation token") {
+		t.Fatalf("expected error minting a token for the second namespace, received %v", err)
+	}
+	if minted := api.mintCount(); minted != 1 {
+		t.Errorf("expected 1 token to be minted, received %d", minted)
+	}
+}
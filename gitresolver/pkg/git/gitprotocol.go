@@ -0,0 +1,46 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// gitProtocolScheme is the scheme of urls served by a git daemon.
+const gitProtocolScheme = "git://"
+
+// checkGitProtocol returns an error if url is a git:// url and
+// ConfigFieldAllowGitProtocol isn't set in conf. The git protocol is
+// neither authenticated nor encrypted, so it must be opted in to.
+func checkGitProtocol(conf map[string]string, url string) error {
+	if !strings.HasPrefix(strings.ToLower(url), gitProtocolScheme) {
+		return nil
+	}
+	allow := false
+	if val := strings.TrimSpace(conf[ConfigFieldAllowGitProtocol]); val != "" {
+		var err error
+		if allow, err = strconv.ParseBool(val); err != nil {
+			return fmt.Errorf("invalid %q config %q: must be true or false", ConfigFieldAllowGitProtocol, val)
+		}
+	}
+	if !allow {
+		return fmt.Errorf("invalid %q %q: cloning over the unauthenticated git:// protocol requires the %q config to be true", URLParam, url, ConfigFieldAllowGitProtocol)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// startTestGitDaemon serves the repo at repoDir with a git daemon and
+// returns the git:// url to clone it from. The daemon is killed when
+// the test completes.
+func startTestGitDaemon(t *testing.T, repoDir string) string {
+	t.Helper()
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skipf("git binary required to serve repos over git://: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error finding a free port: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	cmd := exec.Command(gitPath, "daemon", "--export-all", "--reuseaddr",
+		"--listen=127.0.0.1", fmt.Sprintf("--port=%d", port),
+		"--base-path="+filepath.Dir(repoDir), filepath.Dir(repoDir))
+	if err := cmd.Start(); err != nil {
+		t.Skipf("error starting git daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("git daemon didn't start listening on %s: %v", addr, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return fmt.Sprintf("git://%s/%s", addr, filepath.Base(repoDir))
+}
+
+func TestResolveOverGitProtocol(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "served by git daemon"},
+	}})
+	url := startTestGitDaemon(t, repo)
+	resolver := Resolver{}
+	params := map[string]string{
+		URLParam:  url,
+		PathParam: "task.yaml",
+	}
+
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldAllowGitProtocol: "true",
+	})
+	if err := resolver.ValidateParams(ctx, params); err != nil {
+		t.Fatalf("unexpected error validating params: %v", err)
+	}
+	resource, err := resolver.Resolve(ctx, params)
+	if err != nil {
+		t.Fatalf("unexpected error resolving over git://: %v", err)
+	}
+	if string(resource.Data()) != "served by git daemon" {
+		t.Errorf("unexpected data %q", resource.Data())
+	}
+}
+
+func TestGitProtocolDisallowedByDefault(t *testing.T) {
+	resolver := Resolver{}
+	params := map[string]string{
+		URLParam:  "git://git.example.com/org/repo.git",
+		PathParam: "task.yaml",
+	}
+	err := resolver.ValidateParams(context.Background(), params)
+	if err == nil || !strings.Contains(err.Error(), ConfigFieldAllowGitProtocol) {
+		t.Fatalf("expected error naming %s, received %v", ConfigFieldAllowGitProtocol, err)
+	}
+	if _, err := resolver.Resolve(context.Background(), params); err == nil {
+		t.Fatalf("expected resolving over git:// to fail without %s", ConfigFieldAllowGitProtocol)
+	}
+
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldAllowGitProtocol: "sometimes",
+	})
+	if err := resolver.ValidateParams(ctx, params); err == nil {
+		t.Fatalf("expected error for invalid %s", ConfigFieldAllowGitProtocol)
+	}
+}
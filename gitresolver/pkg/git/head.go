@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// resolvingHead returns whether params request the commit that the
+// branch or other ref selectors resolve to rather than a file.
+func resolvingHead(params map[string]string) bool {
+	headOnly, err := parseBoolParam(params, HeadOnlyParam)
+	return err == nil && headOnly
+}
+
+// validateHeadOnly returns an error if HeadOnlyParam is combined with
+// params that select or process a file, since none is read.
+func validateHeadOnly(params map[string]string) error {
+	if !resolvingHead(params) {
+		return nil
+	}
+	for _, p := range []string{PathParam, WellKnownParam, BlobParam, TektonBundleDirParam, PacEventParam, TagMessageParam, RefAParam, RefBParam, StartLineParam, EndLineParam, OutputFormatParam, SchemaParam, ExpectFormatParam} {
+		if params[p] != "" {
+			return fmt.Errorf("supplied both %q and %q", HeadOnlyParam, p)
+		}
+	}
+	return nil
+}
+
+// resolveHead returns the commit that co was checked out at, without
+// reading any of its files.
+func (r *Resolver) resolveHead(co *checkout, params map[string]string) (*ResolvedGitResource, error) {
+	c, err := co.repository.CommitObject(plumbing.NewHash(co.commit))
+	if err != nil {
+		return nil, fmt.Errorf("error reading commit %s: %w", co.commit, err)
+	}
+
+	annotations := map[string]string{}
+	for key, val := range co.annotations {
+		annotations[key] = val
+	}
+	effectiveParams, err := json.Marshal(effectiveParams(co, params))
+	if err != nil {
+		return nil, fmt.Errorf("error serializing params: %w", err)
+	}
+	annotations[framework.AnnotationKeyParams] = string(effectiveParams)
+	annotations[AnnotationKeyCommittedAt] = c.Committer.When.UTC().Format(time.RFC3339)
+	parents, err := commitParents(co.repository, c.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("error reading parents of commit %s: %w", co.commit, err)
+	}
+	if parents != "" {
+		annotations[AnnotationKeyCommitParents] = parents
+	}
+
+	return &ResolvedGitResource{
+		Commit:           co.commit,
+		Content:          []byte(co.commit),
+		ContentType:      TextContentType,
+		ExtraAnnotations: annotations,
+	}, nil
+}
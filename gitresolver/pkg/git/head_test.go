@@ -0,0 +1,158 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+func TestResolveHeadOnly(t *testing.T) {
+	start := time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+	repo, hashes := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "version: 1\n"},
+		When:  start,
+	}, {
+		Files: map[string]string{"task.yaml": "version: 2\n"},
+		When:  start.Add(time.Hour),
+	}})
+	r, err := git.PlainOpen(repo)
+	if err != nil {
+		t.Fatalf("error opening test repo: %v", err)
+	}
+	if err := r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("release"), plumbing.NewHash(hashes[0]))); err != nil {
+		t.Fatalf("error creating branch: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name                string
+		branch              string
+		expectedCommit      string
+		expectedBranch      string
+		expectedCommittedAt string
+		expectedParents     string
+	}{{
+		name:                "default branch",
+		expectedCommit:      hashes[1],
+		expectedBranch:      "master",
+		expectedCommittedAt: "2022-03-01T13:00:00Z",
+		expectedParents:     hashes[0],
+	}, {
+		name:                "named branch",
+		branch:              "release",
+		expectedCommit:      hashes[0],
+		expectedBranch:      "release",
+		expectedCommittedAt: "2022-03-01T12:00:00Z",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := Resolver{}
+			params := map[string]string{
+				URLParam:      repo,
+				BranchParam:   tc.branch,
+				HeadOnlyParam: "true",
+			}
+			if err := resolver.ValidateParams(context.Background(), params); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			resource, err := resolver.Resolve(context.Background(), params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.expectedCommit {
+				t.Errorf("expected content %q, received %q", tc.expectedCommit, resource.Data())
+			}
+			annotations := resource.Annotations()
+			if annotations[AnnotationKeyCommitHash] != tc.expectedCommit {
+				t.Errorf("expected commit annotation %q, received %q", tc.expectedCommit, annotations[AnnotationKeyCommitHash])
+			}
+			if annotations[resolutioncommon.AnnotationKeyContentType] != TextContentType {
+				t.Errorf("expected content type %q, received %q", TextContentType, annotations[resolutioncommon.AnnotationKeyContentType])
+			}
+			if annotations[AnnotationKeyCommittedAt] != tc.expectedCommittedAt {
+				t.Errorf("expected committed-at %q, received %q", tc.expectedCommittedAt, annotations[AnnotationKeyCommittedAt])
+			}
+			if annotations[AnnotationKeyCommitParents] != tc.expectedParents {
+				t.Errorf("expected parents %q, received %q", tc.expectedParents, annotations[AnnotationKeyCommitParents])
+			}
+			effective := map[string]string{}
+			if err := json.Unmarshal([]byte(annotations[framework.AnnotationKeyParams]), &effective); err != nil {
+				t.Fatalf("error parsing params annotation: %v", err)
+			}
+			if effective[BranchParam] != tc.expectedBranch {
+				t.Errorf("expected branch %q in params annotation, received %q", tc.expectedBranch, effective[BranchParam])
+			}
+		})
+	}
+}
+
+func TestValidateHeadOnly(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		params        map[string]string
+		expectedError string
+	}{{
+		name: "without path",
+		params: map[string]string{
+			URLParam:      "https://github.com/tektoncd/catalog",
+			HeadOnlyParam: "true",
+		},
+	}, {
+		name: "with path",
+		params: map[string]string{
+			URLParam:      "https://github.com/tektoncd/catalog",
+			PathParam:     "task.yaml",
+			HeadOnlyParam: "true",
+		},
+		expectedError: `supplied both "headOnly" and "path"`,
+	}, {
+		name: "with blob",
+		params: map[string]string{
+			URLParam:      "https://github.com/tektoncd/catalog",
+			BlobParam:     testBlobHash("content"),
+			HeadOnlyParam: "true",
+		},
+		expectedError: `supplied both "headOnly" and "blob"`,
+	}, {
+		name: "path still required without headOnly",
+		params: map[string]string{
+			URLParam:      "https://github.com/tektoncd/catalog",
+			HeadOnlyParam: "false",
+		},
+		expectedError: "missing path",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := (&Resolver{}).ValidateParams(context.Background(), tc.params)
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Fatalf("expected error containing %q, received %v", tc.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+package git
+
+import (
+	"crypto/tls"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// commitForRepo describes a single commit to create in a repo made by
+// createTestRepo.
+type commitForRepo struct {
+	// Files maps paths in the repo to the content to write to them.
+	Files map[string]string
+	// Executable lists paths in Files to commit with mode 100755.
+	Executable []string
+	// Delete lists paths in the repo to remove.
+	Delete []string
+	// Merge lists the hashes of commits that the commit merges, which
+	// are made its parents after the current HEAD.
+	Merge []string
+	// Message is the commit message. Defaults to "commit".
+	Message string
+	// When is the author and commit time. Defaults to the start of
+	// 2022.
+	When time.Time
+	// Tag, if set, is the name of a tag to create on the commit.
+	Tag string
+	// TagMessage makes Tag an annotated tag with the given message.
+	// Tag is a lightweight tag when TagMessage is empty.
+	TagMessage string
+}
+
+// testSignature is the author and committer used for commits in a test
+// repo. A zero when defaults to the start of 2022.
+func testSignature(when time.Time) *object.Signature {
+	if when.IsZero() {
+		when = time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return &object.Signature{
+		Name:  "Test",
+		Email: "test@example.com",
+		When:  when,
+	}
+}
+
+// createTestRepo initializes a repo on disk in a temporary directory,
+// creates the given commits in it on the default branch and returns the
+// repo's path along with the hashes of the commits in order. Passing
+// no commits creates an empty repository.
+func createTestRepo(t *testing.T, commits []commitForRepo) (string, []string) {
+	t.Helper()
+	dir := t.TempDir()
+	if _, err := git.PlainInit(dir, false); err != nil {
+		t.Fatalf("error initializing test repo: %v", err)
+	}
+	return dir, appendTestCommits(t, dir, commits)
+}
+
+// appendTestCommits creates the given commits on top of the current
+// branch of the repo at dir, returning their hashes in order.
+func appendTestCommits(t *testing.T, dir string, commits []commitForRepo) []string {
+	t.Helper()
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("error opening test repo: %v", err)
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("error getting test repo worktree: %v", err)
+	}
+	hashes := []string{}
+	for _, c := range commits {
+		for path, content := range c.Files {
+			fullPath := filepath.Join(dir, path)
+			if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+				t.Fatalf("error creating directory for %q: %v", path, err)
+			}
+			perm := os.FileMode(0644)
+			for _, executable := range c.Executable {
+				if executable == path {
+					perm = 0755
+				}
+			}
+			if err := os.WriteFile(fullPath, []byte(content), perm); err != nil {
+				t.Fatalf("error writing %q: %v", path, err)
+			}
+			if _, err := w.Add(path); err != nil {
+				t.Fatalf("error adding %q: %v", path, err)
+			}
+		}
+		for _, path := range c.Delete {
+			if _, err := w.Remove(path); err != nil {
+				t.Fatalf("error removing %q: %v", path, err)
+			}
+		}
+		message := c.Message
+		if message == "" {
+			message = "commit"
+		}
+		opts := &git.CommitOptions{
+			Author:    testSignature(c.When),
+			Committer: testSignature(c.When),
+		}
+		if len(c.Merge) > 0 {
+			head, err := repo.Head()
+			if err != nil {
+				t.Fatalf("error reading test repo HEAD: %v", err)
+			}
+			opts.Parents = []plumbing.Hash{head.Hash()}
+			for _, merged := range c.Merge {
+				opts.Parents = append(opts.Parents, plumbing.NewHash(merged))
+			}
+		}
+		hash, err := w.Commit(message, opts)
+		if err != nil {
+			t.Fatalf("error committing to test repo: %v", err)
+		}
+		if c.Tag != "" {
+			var opts *git.CreateTagOptions
+			if c.TagMessage != "" {
+				opts = &git.CreateTagOptions{
+					Tagger:  testSignature(c.When),
+					Message: c.TagMessage,
+				}
+			}
+			if _, err := repo.CreateTag(c.Tag, hash, opts); err != nil {
+				t.Fatalf("error tagging %s: %v", hash, err)
+			}
+		}
+		hashes = append(hashes, hash.String())
+	}
+	return hashes
+}
+
+// testBlobHash returns the hash of the git blob object holding content.
+func testBlobHash(content string) string {
+	return plumbing.ComputeHash(plumbing.BlobObject, []byte(content)).String()
+}
+
+// detachTestHead checks out commit in the repo at dir, detaching its
+// HEAD.
+func detachTestHead(t *testing.T, dir, commit string) {
+	t.Helper()
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("error opening test repo: %v", err)
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("error getting test repo worktree: %v", err)
+	}
+	if err := w.Checkout(&git.CheckoutOptions{Hash: plumbing.NewHash(commit)}); err != nil {
+		t.Fatalf("error detaching HEAD at %s: %v", commit, err)
+	}
+}
+
+// setTestHead points the HEAD of the repo at dir to branch, which needn't
+// exist yet, so that the next commits made to it are made on branch.
+func setTestHead(t *testing.T, dir, branch string) {
+	t.Helper()
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("error opening test repo: %v", err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(branch))); err != nil {
+		t.Fatalf("error pointing HEAD to %s: %v", branch, err)
+	}
+}
+
+// addTestNote attaches a git note to commit in the repo at dir, as
+// `git notes add` would.
+func addTestNote(t *testing.T, dir, commit, note string) {
+	t.Helper()
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("error opening test repo: %v", err)
+	}
+	blob := repo.Storer.NewEncodedObject()
+	blob.SetType(plumbing.BlobObject)
+	w, err := blob.Writer()
+	if err != nil {
+		t.Fatalf("error writing note: %v", err)
+	}
+	if _, err := w.Write([]byte(note + "\n")); err != nil {
+		t.Fatalf("error writing note: %v", err)
+	}
+	w.Close()
+	blobHash, err := repo.Storer.SetEncodedObject(blob)
+	if err != nil {
+		t.Fatalf("error storing note: %v", err)
+	}
+	tree := &object.Tree{Entries: []object.TreeEntry{{
+		Name: commit,
+		Mode: filemode.Regular,
+		Hash: blobHash,
+	}}}
+	treeObj := repo.Storer.NewEncodedObject()
+	if err := tree.Encode(treeObj); err != nil {
+		t.Fatalf("error encoding notes tree: %v", err)
+	}
+	treeHash, err := repo.Storer.SetEncodedObject(treeObj)
+	if err != nil {
+		t.Fatalf("error storing notes tree: %v", err)
+	}
+	notesCommit := &object.Commit{
+		Author:    *testSignature(time.Time{}),
+		Committer: *testSignature(time.Time{}),
+		Message:   "Notes added by 'git notes add'",
+		TreeHash:  treeHash,
+	}
+	commitObj := repo.Storer.NewEncodedObject()
+	if err := notesCommit.Encode(commitObj); err != nil {
+		t.Fatalf("error encoding notes commit: %v", err)
+	}
+	commitHash, err := repo.Storer.SetEncodedObject(commitObj)
+	if err != nil {
+		t.Fatalf("error storing notes commit: %v", err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(notesRef, commitHash)); err != nil {
+		t.Fatalf("error updating %s: %v", notesRef, err)
+	}
+}
+
+// fakeGitHTTPServer serves a repo created by createTestRepo over git's
+// smart HTTP protocol, using git-http-backend, and records the headers
+// of every request it receives.
+type fakeGitHTTPServer struct {
+	*httptest.Server
+
+	repoName string
+
+	mu      sync.Mutex
+	headers []http.Header
+	// conns is the number of connections accepted.
+	conns int
+	// intercept, if set, is called before each request is served
+	// and may write its own response, in which case it returns true.
+	intercept func(w http.ResponseWriter, r *http.Request) bool
+}
+
+// newFakeGitHTTPServer starts a fakeGitHTTPServer for the repo at
+// repoDir. The server is closed when the test completes.
+func newFakeGitHTTPServer(t *testing.T, repoDir string) *fakeGitHTTPServer {
+	t.Helper()
+	return startFakeGitHTTPServer(t, repoDir, nil)
+}
+
+// newFakeGitHTTPSServer starts a fakeGitHTTPServer for the repo at
+// repoDir that serves https with the given TLS config.
+func newFakeGitHTTPSServer(t *testing.T, repoDir string, tlsConfig *tls.Config) *fakeGitHTTPServer {
+	t.Helper()
+	return startFakeGitHTTPServer(t, repoDir, tlsConfig)
+}
+
+func startFakeGitHTTPServer(t *testing.T, repoDir string, tlsConfig *tls.Config) *fakeGitHTTPServer {
+	t.Helper()
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skipf("git binary required to serve repos over http: %v", err)
+	}
+	s := &fakeGitHTTPServer{
+		repoName: filepath.Base(repoDir),
+	}
+	backend := &cgi.Handler{
+		Path: gitPath,
+		Args: []string{"http-backend"},
+		Env: []string{
+			"GIT_PROJECT_ROOT=" + filepath.Dir(repoDir),
+			"GIT_HTTP_EXPORT_ALL=1",
+		},
+	}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.headers = append(s.headers, r.Header.Clone())
+		intercept := s.intercept
+		s.mu.Unlock()
+		if intercept != nil && intercept(w, r) {
+			return
+		}
+		backend.ServeHTTP(w, r)
+	}))
+	s.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			s.mu.Lock()
+			s.conns++
+			s.mu.Unlock()
+		}
+	}
+	if tlsConfig != nil {
+		s.TLS = tlsConfig
+		// Handshakes that tests expect to fail aren't worth logging.
+		s.Config.ErrorLog = log.New(io.Discard, "", 0)
+		s.StartTLS()
+	} else {
+		s.Start()
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+// repoURL returns the http URL to clone the served repo from.
+func (s *fakeGitHTTPServer) repoURL() string {
+	return s.URL + "/" + s.repoName
+}
+
+// setIntercept replaces the server's intercept func.
+func (s *fakeGitHTTPServer) setIntercept(intercept func(w http.ResponseWriter, r *http.Request) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.intercept = intercept
+}
+
+// acceptedConns returns the number of connections accepted so far.
+func (s *fakeGitHTTPServer) acceptedConns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns
+}
+
+// receivedHeaders returns the headers of every request received so far.
+func (s *fakeGitHTTPServer) receivedHeaders() []http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]http.Header{}, s.headers...)
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// httpResponseCacheSize is the number of responses that are kept to
+// revalidate when ConfigFieldHTTPConditionalRequests is set. The oldest
+// is dropped to make room for a new one.
+const httpResponseCacheSize = 256
+
+// maxCachedResponseSize is the size of the largest response body kept
+// to revalidate. A repo's refs are usually far smaller; larger ones are
+// fetched in full every time.
+const maxCachedResponseSize = 1 << 20
+
+// conditionalRequests parses ConfigFieldHTTPConditionalRequests from
+// conf.
+func conditionalRequests(conf map[string]string) (bool, error) {
+	val := strings.TrimSpace(conf[ConfigFieldHTTPConditionalRequests])
+	if val == "" {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("invalid %q config %q: must be true or false", ConfigFieldHTTPConditionalRequests, val)
+	}
+	return enabled, nil
+}
+
+// cachedResponse is the header and body of a response that had an ETag
+// or Last-Modified header to revalidate it with.
+type cachedResponse struct {
+	header http.Header
+	body   []byte
+}
+
+// httpResponseCache holds the responses most recently received for
+// GET requests, which fetch a repo's refs, keyed by httpResponseCacheKey.
+type httpResponseCache struct {
+	mu        sync.Mutex
+	responses map[string]cachedResponse
+	// order is the keys of responses, oldest first.
+	order []string
+}
+
+func (c *httpResponseCache) put(key string, res cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.responses == nil {
+		c.responses = map[string]cachedResponse{}
+	}
+	if _, ok := c.responses[key]; !ok {
+		if len(c.order) == httpResponseCacheSize {
+			delete(c.responses, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, key)
+	}
+	c.responses[key] = res
+}
+
+func (c *httpResponseCache) get(key string) (cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res, ok := c.responses[key]
+	return res, ok
+}
+
+// httpResponseCacheKey returns the key of the response to req. The
+// credentials it was sent with are part of it, by digest, so that a
+// response is only ever served again to requests with the same ones.
+func httpResponseCacheKey(req *http.Request) string {
+	auth := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	return req.URL.String() + " " + hex.EncodeToString(auth[:])
+}
+
+// cacheableRequest returns whether req fetches something that may be
+// cached: git's smart http protocol only GETs a repo's refs and POSTs
+// everything else.
+func cacheableRequest(req *http.Request) bool {
+	return req.Method == http.MethodGet
+}
+
+// withCacheHeaders returns req with the headers of rt's caching
+// settings: the configured Cache-Control and, when a response to the
+// same request has been kept, the validators to revalidate it with.
+func (rt *requestTransport) withCacheHeaders(req *http.Request, cached *cachedResponse) *http.Request {
+	if rt.cacheControl == "" && cached == nil {
+		return req
+	}
+	req = req.Clone(req.Context())
+	if rt.cacheControl != "" {
+		req.Header.Set("Cache-Control", rt.cacheControl)
+	}
+	if cached != nil {
+		if etag := cached.header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := cached.header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+	return req
+}
+
+// fromCache returns a response to req holding the body of cached,
+// replacing a 304 Not Modified response.
+func (rt *requestTransport) fromCache(req *http.Request, notModified *http.Response, cached cachedResponse) *http.Response {
+	_, _ = io.Copy(io.Discard, notModified.Body)
+	notModified.Body.Close()
+	atomic.AddInt64(&rt.cacheHits, 1)
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        cached.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(cached.body)),
+		ContentLength: int64(len(cached.body)),
+		Request:       req,
+	}
+}
+
+// keep reads the body of res into rt's response cache under key when
+// it can be revalidated later and isn't too large, returning res with
+// its body intact either way.
+func (rt *requestTransport) keep(key string, res *http.Response) (*http.Response, error) {
+	if res.StatusCode != http.StatusOK || (res.Header.Get("ETag") == "" && res.Header.Get("Last-Modified") == "") {
+		return res, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxCachedResponseSize+1))
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	if len(body) > maxCachedResponseSize {
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+		return res, nil
+	}
+	res.Body.Close()
+	rt.responses.put(key, cachedResponse{header: res.Header.Clone(), body: body})
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	return res, nil
+}
+
+// CacheHits returns the number of responses served from the response
+// cache so far during the resolution, because the git host or a caching
+// proxy in front of it confirmed they were still current.
+func (rt *requestTransport) CacheHits() int64 {
+	return atomic.LoadInt64(&rt.cacheHits)
+}
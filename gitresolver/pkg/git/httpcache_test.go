@@ -0,0 +1,136 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+func TestResolveThroughCachingProxy(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "version: 1\n"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+
+	// The intercept stands in for a caching proxy in front of the git
+	// host: it tags the refs it serves with an ETag for their version
+	// and answers requests that already have that version with 304 Not
+	// Modified.
+	var mu sync.Mutex
+	version := `"refs-1"`
+	var conditional, notModified int
+	server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method != http.MethodGet || !strings.HasSuffix(r.URL.Path, "/info/refs") {
+			return false
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if match := r.Header.Get("If-None-Match"); match != "" {
+			conditional++
+			if match == version {
+				notModified++
+				w.Header().Set("ETag", version)
+				w.WriteHeader(http.StatusNotModified)
+				return true
+			}
+		}
+		w.Header().Set("ETag", version)
+		return false
+	})
+
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldHTTPCacheControl:        "max-age=60",
+		ConfigFieldHTTPConditionalRequests: "true",
+	})
+	resolver := Resolver{}
+	resolve := func(expectedContent, expectedCacheHits string) {
+		t.Helper()
+		resource, err := resolver.Resolve(ctx, map[string]string{
+			URLParam:    server.repoURL(),
+			BranchParam: "master",
+			PathParam:   "task.yaml",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(resource.Data()) != expectedContent {
+			t.Errorf("expected content %q, received %q", expectedContent, resource.Data())
+		}
+		if hits := resource.Annotations()[AnnotationKeyHTTPCacheHits]; hits != expectedCacheHits {
+			t.Errorf("expected %q cache hits, received %q", expectedCacheHits, hits)
+		}
+	}
+
+	resolve("version: 1\n", "")
+	if conditional != 0 {
+		t.Errorf("expected no conditional requests before any response was kept, received %d", conditional)
+	}
+	if cc := server.receivedHeaders()[0].Get("Cache-Control"); cc != "max-age=60" {
+		t.Errorf("expected Cache-Control header %q on the refs request, received %q", "max-age=60", cc)
+	}
+
+	// The refs haven't changed, so the kept response is reused.
+	resolve("version: 1\n", "1")
+	if notModified != 1 {
+		t.Errorf("expected the refs to be revalidated with a single 304, received %d", notModified)
+	}
+
+	// Once they have, the new refs are served in full.
+	appendTestCommits(t, repo, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "version: 2\n"},
+	}})
+	mu.Lock()
+	version = `"refs-2"`
+	mu.Unlock()
+	resolve("version: 2\n", "")
+	if conditional != 2 || notModified != 1 {
+		t.Errorf("expected a second conditional request that wasn't answered with 304, received %d conditional requests and %d 304s", conditional, notModified)
+	}
+}
+
+func TestResolveWithoutConditionalRequests(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+		w.Header().Set("ETag", `"refs"`)
+		return false
+	})
+
+	resolver := Resolver{}
+	for i := 0; i < 2; i++ {
+		if _, err := resolver.Resolve(context.Background(), map[string]string{
+			URLParam:    server.repoURL(),
+			BranchParam: "master",
+			PathParam:   "task.yaml",
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for _, header := range server.receivedHeaders() {
+		if header.Get("If-None-Match") != "" || header.Get("Cache-Control") != "" {
+			t.Errorf("expected no caching headers unless configured, received %v", header)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+)
+
+// maxIncludeDepth is the deepest that includes may be nested.
+const maxIncludeDepth = 10
+
+// includeDirective matches a line of the form "# @include <path>". The
+// directive is a yaml comment so files using it remain valid yaml. Any
+// indentation before the directive is applied to every line of the
+// included file.
+var includeDirective = regexp.MustCompile(`^(\s*)#\s*@include\s+(\S+)\s*$`)
+
+// inlineIncludes replaces every include directive in content, which was
+// read from filePath, with the content of the file it references.
+// Included paths are relative to the directory of the including file.
+// stack holds the chain of files currently being included and is used
+// to detect cycles.
+func inlineIncludes(filesystem billy.Filesystem, filePath string, content []byte, stack []string) ([]byte, error) {
+	filePath = path.Clean("/" + filePath)
+	for _, p := range stack {
+		if p == filePath {
+			return nil, fmt.Errorf("include cycle detected: %s -> %s", strings.Join(stack, " -> "), filePath)
+		}
+	}
+	if len(stack) >= maxIncludeDepth {
+		return nil, fmt.Errorf("includes nested deeper than maximum of %d at %q", maxIncludeDepth, filePath)
+	}
+	stack = append(stack, filePath)
+
+	out := &bytes.Buffer{}
+	lines := strings.SplitAfter(string(content), "\n")
+	for _, line := range lines {
+		match := includeDirective.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+		if match == nil {
+			out.WriteString(line)
+			continue
+		}
+		indent, includePath := match[1], match[2]
+		if !path.IsAbs(includePath) {
+			includePath = path.Join(path.Dir(filePath), includePath)
+		}
+		included, err := readFile(filesystem, includePath)
+		if err != nil {
+			return nil, fmt.Errorf("error including %q from %q: %w", includePath, filePath, err)
+		}
+		included, err = inlineIncludes(filesystem, includePath, included, stack)
+		if err != nil {
+			return nil, err
+		}
+		for _, includedLine := range strings.SplitAfter(string(included), "\n") {
+			if includedLine == "" {
+				continue
+			}
+			out.WriteString(indent + includedLine)
+		}
+		if !bytes.HasSuffix(included, []byte("\n")) && strings.HasSuffix(line, "\n") {
+			out.WriteString("\n")
+		}
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	krbclient "github.com/jcmturner/gokrb5/v8/client"
+	krbconfig "github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+// defaultKerberosConfigPath is the krb5.conf read when
+// ConfigFieldKerberosConfig isn't set.
+const defaultKerberosConfigPath = "/etc/krb5.conf"
+
+// negotiator sets a SPNEGO Negotiate Authorization header on a request.
+type negotiator func(*http.Request) error
+
+// newNegotiator returns the negotiator to authenticate with for the
+// given resolver config, or nil if ConfigFieldKeytab isn't set. It's a
+// var so that tests can stub out the KDC.
+var newNegotiator = newKerberosNegotiator
+
+// newKerberosNegotiator logs in to the KDC with the keytab and principal
+// in conf and returns a negotiator that sets a service ticket for each
+// request's host. The client renews its own tickets, so the negotiator
+// can be kept and reused by later resolutions.
+func newKerberosNegotiator(conf map[string]string) (negotiator, error) {
+	keytabPath := strings.TrimSpace(conf[ConfigFieldKeytab])
+	if keytabPath == "" {
+		return nil, nil
+	}
+	principal := strings.TrimSpace(conf[ConfigFieldKerberosPrincipal])
+	at := strings.LastIndex(principal, "@")
+	if at < 1 || at == len(principal)-1 {
+		return nil, fmt.Errorf("invalid %q config %q: must be of the form user@REALM", ConfigFieldKerberosPrincipal, principal)
+	}
+	kt, err := keytab.Load(keytabPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading keytab %q: %w", keytabPath, err)
+	}
+	configPath := defaultKerberosConfigPath
+	if configured := strings.TrimSpace(conf[ConfigFieldKerberosConfig]); configured != "" {
+		configPath = configured
+	}
+	krb5conf, err := krbconfig.Load(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading kerberos config %q: %w", configPath, err)
+	}
+	cl := krbclient.NewWithKeytab(principal[:at], principal[at+1:], kt, krb5conf, krbclient.DisablePAFXFAST(true))
+	if err := cl.Login(); err != nil {
+		return nil, fmt.Errorf("error logging in to kerberos as %q: %w", principal, err)
+	}
+	return func(req *http.Request) error {
+		// Git hosts are expected to use the HTTP service principal of
+		// their hostname.
+		return spnego.SetSPNEGOHeader(cl, req, "HTTP/"+req.URL.Hostname())
+	}, nil
+}
+
+// negotiatorCache holds the negotiator for each keytab and principal, so
+// that the resolver logs in to the KDC once rather than for every clone.
+type negotiatorCache struct {
+	mu          sync.Mutex
+	negotiators map[string]negotiator
+}
+
+// get returns the negotiator for conf, logging in with newNegotiator the
+// first time it's asked for. Failed logins aren't cached.
+func (c *negotiatorCache) get(conf map[string]string) (negotiator, error) {
+	keytabPath := strings.TrimSpace(conf[ConfigFieldKeytab])
+	if keytabPath == "" {
+		return nil, nil
+	}
+	key := strings.Join([]string{
+		keytabPath,
+		strings.TrimSpace(conf[ConfigFieldKerberosPrincipal]),
+		strings.TrimSpace(conf[ConfigFieldKerberosConfig]),
+	}, "\x00")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n, ok := c.negotiators[key]; ok {
+		return n, nil
+	}
+	n, err := newNegotiator(conf)
+	if err != nil {
+		return nil, err
+	}
+	if c.negotiators == nil {
+		c.negotiators = map[string]negotiator{}
+	}
+	c.negotiators[key] = n
+	return n, nil
+}
+
+// parseKerberosHosts parses the comma-separated hostnames in
+// ConfigFieldKerberosHosts.
+func parseKerberosHosts(conf map[string]string) map[string]bool {
+	hosts := map[string]bool{}
+	for _, host := range strings.Split(conf[ConfigFieldKerberosHosts], ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			hosts[host] = true
+		}
+	}
+	return hosts
+}
+
+// negotiateChallenged returns whether res challenges req for Negotiate
+// auth and req can be sent again with it.
+func negotiateChallenged(req *http.Request, res *http.Response) bool {
+	if res.StatusCode != http.StatusUnauthorized || (req.Body != nil && req.GetBody == nil) {
+		return false
+	}
+	for _, challenge := range res.Header.Values("WWW-Authenticate") {
+		scheme, _, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+		if strings.EqualFold(scheme, "Negotiate") {
+			return true
+		}
+	}
+	return false
+}
+
+// roundTripNegotiated sends req again with Negotiate auth after res
+// challenged it for it.
+func (rt *requestTransport) roundTripNegotiated(base http.RoundTripper, req *http.Request, res *http.Response) (*http.Response, error) {
+	_, _ = io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	req = req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+	if err := rt.negotiate(req); err != nil {
+		return nil, fmt.Errorf("error negotiating kerberos auth with %q: %w", req.URL.Host, err)
+	}
+	return roundTripWithRetries(base, req, rt.retryStatusCodes, rt.budget)
+}
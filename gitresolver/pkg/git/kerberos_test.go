@@ -0,0 +1,220 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// stubNegotiator replaces newNegotiator with one that sets token, or
+// fails with err when it's set, returning a func reporting how many times
+// it logged in.
+func stubNegotiator(t *testing.T, token string, err error) func() int {
+	t.Helper()
+	original := newNegotiator
+	t.Cleanup(func() { newNegotiator = original })
+	logins := 0
+	newNegotiator = func(conf map[string]string) (negotiator, error) {
+		if conf[ConfigFieldKeytab] == "" {
+			return nil, nil
+		}
+		logins++
+		return func(req *http.Request) error {
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Authorization", token)
+			return nil
+		}, nil
+	}
+	return func() int { return logins }
+}
+
+// challengeWithout makes server challenge requests for Negotiate auth
+// that don't send token.
+func challengeWithout(server *fakeGitHTTPServer, token string) {
+	server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("Authorization") != token {
+			w.Header().Set("WWW-Authenticate", "Negotiate")
+			w.WriteHeader(http.StatusUnauthorized)
+			return true
+		}
+		return false
+	})
+}
+
+func TestResolveWithKerberos(t *testing.T) {
+	const token = "Negotiate dGlja2V0"
+	for _, tc := range []struct {
+		name string
+		// hosts is the kerberos-hosts config.
+		hosts string
+		// challenge is whether the git host requires Negotiate auth.
+		challenge bool
+		// negotiateErr fails negotiation, as for a host without a
+		// service principal.
+		negotiateErr error
+		// expectedHeaders is the Authorization header of each request
+		// the git host received.
+		expectedHeaders func(headers []string) bool
+	}{{
+		name:      "configured host",
+		hosts:     "127.0.0.1",
+		challenge: true,
+		expectedHeaders: func(headers []string) bool {
+			for _, h := range headers {
+				if h != token {
+					return false
+				}
+			}
+			return true
+		},
+	}, {
+		name:      "challenged",
+		challenge: true,
+		expectedHeaders: func(headers []string) bool {
+			return len(headers) > 1 && headers[0] == "" && headers[len(headers)-1] == token
+		},
+	}, {
+		name:         "host without kerberos",
+		negotiateErr: errors.New("no service principal"),
+		expectedHeaders: func(headers []string) bool {
+			for _, h := range headers {
+				if h != "" {
+					return false
+				}
+			}
+			return true
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			stubNegotiator(t, token, tc.negotiateErr)
+			repo, _ := createTestRepo(t, []commitForRepo{{
+				Files: map[string]string{"task.yaml": "content"},
+			}})
+			server := newFakeGitHTTPServer(t, repo)
+			if tc.challenge {
+				challengeWithout(server, token)
+			}
+
+			resolver := Resolver{}
+			ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+				ConfigFieldKeytab:            "/etc/resolver/krb5.keytab",
+				ConfigFieldKerberosPrincipal: "resolver@EXAMPLE.COM",
+				ConfigFieldKerberosHosts:     tc.hosts,
+			})
+			resource, err := resolver.Resolve(ctx, map[string]string{
+				URLParam:  server.repoURL(),
+				PathParam: "task.yaml",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != "content" {
+				t.Errorf("unexpected content %q", resource.Data())
+			}
+			headers := []string{}
+			for _, h := range server.receivedHeaders() {
+				headers = append(headers, h.Get("Authorization"))
+			}
+			if !tc.expectedHeaders(headers) {
+				t.Errorf("unexpected Authorization headers %q", headers)
+			}
+		})
+	}
+}
+
+func TestResolveKerberosLogsInOnce(t *testing.T) {
+	const token = "Negotiate dGlja2V0"
+	logins := stubNegotiator(t, token, nil)
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	challengeWithout(server, token)
+
+	resolver := Resolver{}
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldKeytab:            "/etc/resolver/krb5.keytab",
+		ConfigFieldKerberosPrincipal: "resolver@EXAMPLE.COM",
+	})
+	for i := 0; i < 2; i++ {
+		if _, err := resolver.Resolve(ctx, map[string]string{
+			URLParam:  server.repoURL(),
+			PathParam: "task.yaml",
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if logins() != 1 {
+		t.Errorf("expected to log in once, logged in %d times", logins())
+	}
+}
+
+func TestResolveKerberosNotSentAcrossRedirects(t *testing.T) {
+	const token = "Negotiate dGlja2V0"
+	stubNegotiator(t, token, nil)
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	challengeWithout(server, token)
+	otherHost := httptest.NewServer(http.HandlerFunc(redirectTo(server.URL, "")))
+	defer otherHost.Close()
+
+	resolver := Resolver{}
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldKeytab:            "/etc/resolver/krb5.keytab",
+		ConfigFieldKerberosPrincipal: "resolver@EXAMPLE.COM",
+		ConfigFieldKerberosHosts:     "127.0.0.1",
+	})
+	if _, err := resolver.Resolve(ctx, map[string]string{
+		URLParam:  otherHost.URL + "/" + server.repoName,
+		PathParam: "task.yaml",
+	}); err == nil {
+		t.Fatalf("expected the redirect target's challenge not to be answered")
+	}
+	for _, h := range server.receivedHeaders() {
+		if h.Get("Authorization") != "" {
+			t.Errorf("expected no ticket to be sent to the redirect target, received %q", h.Get("Authorization"))
+		}
+	}
+}
+
+func TestNewKerberosNegotiatorErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		conf        map[string]string
+		expectedErr string
+	}{{
+		name: "invalid principal",
+		conf: map[string]string{
+			ConfigFieldKeytab:            "/etc/resolver/krb5.keytab",
+			ConfigFieldKerberosPrincipal: "resolver",
+		},
+		expectedErr: ConfigFieldKerberosPrincipal,
+	}, {
+		name: "missing keytab",
+		conf: map[string]string{
+			ConfigFieldKeytab:            filepath.Join(t.TempDir(), "missing.keytab"),
+			ConfigFieldKerberosPrincipal: "resolver@EXAMPLE.COM",
+		},
+		expectedErr: "error loading keytab",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newKerberosNegotiator(tc.conf)
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Fatalf("expected error containing %q, received %v", tc.expectedErr, err)
+			}
+		})
+	}
+	if n, err := newKerberosNegotiator(map[string]string{}); n != nil || err != nil {
+		t.Errorf("expected no negotiator without a keytab, received %v", err)
+	}
+}
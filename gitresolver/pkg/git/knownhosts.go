@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// The policies that StrictHostKeyCheckingParam can be set to.
+const (
+	// hostKeyPolicyStrict only connects to hosts whose key is in the
+	// known hosts. It's the default.
+	hostKeyPolicyStrict = "strict"
+	// hostKeyPolicyAcceptNew connects to hosts that aren't in the
+	// known hosts and records the key they presented, so that later
+	// connections to them are checked against it.
+	hostKeyPolicyAcceptNew = "accept-new"
+	// hostKeyPolicyInsecure connects to any host without checking its
+	// key.
+	hostKeyPolicyInsecure = "insecure"
+)
+
+// validateStrictHostKeyChecking returns an error if
+// StrictHostKeyCheckingParam isn't one of the host key policies.
+func validateStrictHostKeyChecking(params map[string]string) error {
+	switch policy := params[StrictHostKeyCheckingParam]; policy {
+	case "", hostKeyPolicyStrict, hostKeyPolicyAcceptNew, hostKeyPolicyInsecure:
+		return nil
+	default:
+		return fmt.Errorf("invalid %q %q: must be %q, %q or %q", StrictHostKeyCheckingParam, policy, hostKeyPolicyStrict, hostKeyPolicyAcceptNew, hostKeyPolicyInsecure)
+	}
+}
+
+// acceptedHostKeys are the keys of hosts that weren't in the known hosts
+// when first connected to under hostKeyPolicyAcceptNew. The zero value
+// is ready to use.
+type acceptedHostKeys struct {
+	mu   sync.Mutex
+	keys map[string]ssh.PublicKey
+}
+
+// check accepts key for host if it's the key recorded for it, or if no
+// key is and it can be recorded now.
+func (a *acceptedHostKeys) check(host string, key ssh.PublicKey) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.keys == nil {
+		a.keys = map[string]ssh.PublicKey{}
+	}
+	accepted, ok := a.keys[host]
+	if !ok {
+		a.keys[host] = key
+		return nil
+	}
+	if !bytes.Equal(accepted.Marshal(), key.Marshal()) {
+		return fmt.Errorf("%w: %s presented a different key to the one accepted when first connecting to it", ErrSSHHostKeyMismatch, host)
+	}
+	return nil
+}
+
+// hostKeyCallback returns the callback that checks the keys of ssh hosts
+// under the StrictHostKeyCheckingParam policy in params. Hosts are known
+// from the file at ConfigFieldKnownHosts or, when that isn't set, from
+// the files that ssh reads by default.
+func (r *Resolver) hostKeyCallback(ctx context.Context, params map[string]string) (ssh.HostKeyCallback, error) {
+	if err := validateStrictHostKeyChecking(params); err != nil {
+		return nil, err
+	}
+	policy := params[StrictHostKeyCheckingParam]
+	if policy == hostKeyPolicyInsecure {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	var known ssh.HostKeyCallback
+	var err error
+	if path := strings.TrimSpace(framework.GetResolverConfigFromContext(ctx)[ConfigFieldKnownHosts]); path != "" {
+		if known, err = knownhosts.New(path); err != nil {
+			return nil, fmt.Errorf("error reading ssh known hosts %s: %w", path, err)
+		}
+	} else if known, err = gitssh.NewKnownHostsCallback(); err != nil {
+		// Without any known hosts every host is unknown.
+		known = nil
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		host := knownhosts.Normalize(hostname)
+		if known != nil {
+			err := known(hostname, remote, key)
+			var keyErr *knownhosts.KeyError
+			switch {
+			case err == nil:
+				return nil
+			case !errors.As(err, &keyErr):
+				return err
+			case len(keyErr.Want) > 0:
+				return fmt.Errorf("%w: %s presented a key that doesn't match its known hosts entry", ErrSSHHostKeyMismatch, host)
+			}
+		}
+		if policy == hostKeyPolicyAcceptNew {
+			return r.hostKeys.check(host, key)
+		}
+		return fmt.Errorf("%w: %s isn't in the known hosts", ErrUnknownSSHHost, host)
+	}, nil
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testHostKey returns a freshly generated ssh host key.
+func testHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("error converting public key: %v", err)
+	}
+	return key
+}
+
+// writeTestKnownHosts writes a known_hosts file listing key for host to
+// a temporary file and returns its path.
+func writeTestKnownHosts(t *testing.T, host string, key ssh.PublicKey) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(path, []byte(knownhosts.Line([]string{host}, key)+"\n"), 0600); err != nil {
+		t.Fatalf("error writing known hosts: %v", err)
+	}
+	return path
+}
+
+// testHostKeyCallback returns the host key callback that resolver clones
+// the ssh repo at git.example.com with under policy.
+func testHostKeyCallback(t *testing.T, resolver *Resolver, knownHosts string, policy string) ssh.HostKeyCallback {
+	t.Helper()
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldSSHPrivateKey: writeTestSSHKey(t),
+		ConfigFieldKnownHosts:    knownHosts,
+	})
+	auth, err := resolver.cloneAuth(ctx, "git@git.example.com:repo.git", map[string]string{StrictHostKeyCheckingParam: policy})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keys, ok := auth.(*gitssh.PublicKeys)
+	if !ok {
+		t.Fatalf("expected private key auth but got %T", auth)
+	}
+	return keys.HostKeyCallback
+}
+
+func TestHostKeyCallback(t *testing.T) {
+	knownKey := testHostKey(t)
+	otherKey := testHostKey(t)
+	knownHosts := writeTestKnownHosts(t, "git.example.com", knownKey)
+	remote := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 22}
+
+	for _, tc := range []struct {
+		name        string
+		policy      string
+		hostname    string
+		key         ssh.PublicKey
+		expectedErr error
+	}{{
+		name:     "known host",
+		hostname: "git.example.com:22",
+		key:      knownKey,
+	}, {
+		name:        "known host with another key",
+		hostname:    "git.example.com:22",
+		key:         otherKey,
+		expectedErr: ErrSSHHostKeyMismatch,
+	}, {
+		name:        "unknown host",
+		hostname:    "git.unknown.com:22",
+		key:         otherKey,
+		expectedErr: ErrUnknownSSHHost,
+	}, {
+		name:        "unknown host under strict",
+		policy:      hostKeyPolicyStrict,
+		hostname:    "git.unknown.com:22",
+		key:         otherKey,
+		expectedErr: ErrUnknownSSHHost,
+	}, {
+		name:     "unknown host under accept-new",
+		policy:   hostKeyPolicyAcceptNew,
+		hostname: "git.unknown.com:22",
+		key:      otherKey,
+	}, {
+		name:        "known host with another key under accept-new",
+		policy:      hostKeyPolicyAcceptNew,
+		hostname:    "git.example.com:22",
+		key:         otherKey,
+		expectedErr: ErrSSHHostKeyMismatch,
+	}, {
+		name:     "known host with another key under insecure",
+		policy:   hostKeyPolicyInsecure,
+		hostname: "git.example.com:22",
+		key:      otherKey,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			callback := testHostKeyCallback(t, &Resolver{}, knownHosts, tc.policy)
+			err := callback(tc.hostname, remote, tc.key)
+			if tc.expectedErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, received %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestHostKeyCallbackRecordsAcceptedKeys(t *testing.T) {
+	knownHosts := writeTestKnownHosts(t, "git.example.com", testHostKey(t))
+	remote := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 22}
+	firstKey := testHostKey(t)
+	resolver := &Resolver{}
+
+	callback := testHostKeyCallback(t, resolver, knownHosts, hostKeyPolicyAcceptNew)
+	if err := callback("git.unknown.com:22", remote, firstKey); err != nil {
+		t.Fatalf("expected new host to be accepted, received %v", err)
+	}
+
+	callback = testHostKeyCallback(t, resolver, knownHosts, hostKeyPolicyAcceptNew)
+	if err := callback("git.unknown.com:22", remote, firstKey); err != nil {
+		t.Errorf("expected accepted key to be accepted again, received %v", err)
+	}
+	if err := callback("git.unknown.com:22", remote, testHostKey(t)); !errors.Is(err, ErrSSHHostKeyMismatch) {
+		t.Errorf("expected a different key for an accepted host to be rejected, received %v", err)
+	}
+
+	// Keys accepted under accept-new don't make a host known to requests
+	// that check strictly.
+	callback = testHostKeyCallback(t, resolver, knownHosts, hostKeyPolicyStrict)
+	if err := callback("git.unknown.com:22", remote, firstKey); !errors.Is(err, ErrUnknownSSHHost) {
+		t.Errorf("expected accepted host to be unknown under strict, received %v", err)
+	}
+}
+
+func TestValidateParamsStrictHostKeyChecking(t *testing.T) {
+	resolver := Resolver{}
+	for _, policy := range []string{hostKeyPolicyStrict, hostKeyPolicyAcceptNew, hostKeyPolicyInsecure} {
+		params := map[string]string{URLParam: "git@github.com:tektoncd/catalog.git", PathParam: "task.yaml", StrictHostKeyCheckingParam: policy}
+		if err := resolver.ValidateParams(context.Background(), params); err != nil {
+			t.Errorf("unexpected error validating %q: %v", policy, err)
+		}
+	}
+	params := map[string]string{URLParam: "git@github.com:tektoncd/catalog.git", PathParam: "task.yaml", StrictHostKeyCheckingParam: "no"}
+	if err := resolver.ValidateParams(context.Background(), params); err == nil {
+		t.Errorf("expected invalid %q to be rejected", StrictHostKeyCheckingParam)
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+const (
+	// lfsPointerVersion is the first line of every Git LFS pointer file.
+	lfsPointerVersion = "version https://git-lfs.github.com/spec/v1"
+	// lfsMaxPointerSize is the largest size a pointer file can have.
+	lfsMaxPointerSize = 1024
+	// lfsMediaType is the media type of Git LFS batch API requests and
+	// responses.
+	lfsMediaType = "application/vnd.git-lfs+json"
+)
+
+// defaultMaxLFSObjectSize is the largest Git LFS object fetched when
+// ConfigFieldMaxLFSObjectSize isn't set.
+const defaultMaxLFSObjectSize = 20 << 20
+
+// maxLFSObjectSize parses ConfigFieldMaxLFSObjectSize from conf,
+// returning defaultMaxLFSObjectSize if it isn't set.
+func maxLFSObjectSize(conf map[string]string) (int64, error) {
+	val := strings.TrimSpace(conf[ConfigFieldMaxLFSObjectSize])
+	if val == "" {
+		return defaultMaxLFSObjectSize, nil
+	}
+	size, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("invalid %q config %q: must be a positive integer", ConfigFieldMaxLFSObjectSize, val)
+	}
+	return size, nil
+}
+
+// lfsPointer identifies an object stored with Git LFS.
+type lfsPointer struct {
+	OID  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+// parseLFSPointer returns the pointer that content holds, or false if
+// content isn't a Git LFS pointer file.
+func parseLFSPointer(content []byte) (lfsPointer, bool) {
+	if len(content) > lfsMaxPointerSize || !bytes.HasPrefix(content, []byte(lfsPointerVersion+"\n")) {
+		return lfsPointer{}, false
+	}
+	pointer := lfsPointer{Size: -1}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 2)
+		if len(fields) != 2 {
+			return lfsPointer{}, false
+		}
+		switch fields[0] {
+		case "oid":
+			pointer.OID = strings.TrimPrefix(fields[1], "sha256:")
+		case "size":
+			size, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return lfsPointer{}, false
+			}
+			pointer.Size = size
+		}
+	}
+	if len(pointer.OID) != sha256.Size*2 || pointer.Size < 0 {
+		return lfsPointer{}, false
+	}
+	return pointer, true
+}
+
+// lfsBatchRequest is a request to the Git LFS batch API.
+type lfsBatchRequest struct {
+	Operation string       `json:"operation"`
+	Transfers []string     `json:"transfers"`
+	Objects   []lfsPointer `json:"objects"`
+}
+
+// lfsBatchResponse is the Git LFS batch API's response to a download
+// request.
+type lfsBatchResponse struct {
+	Objects []struct {
+		lfsPointer
+		Actions struct {
+			Download *struct {
+				Href   string            `json:"href"`
+				Header map[string]string `json:"header"`
+			} `json:"download"`
+		} `json:"actions"`
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"objects"`
+	Message string `json:"message"`
+}
+
+// lfsEndpoint returns the Git LFS server of the repo at url, following
+// the convention of the git-lfs client.
+func lfsEndpoint(url string) (string, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return "", fmt.Errorf("git lfs objects can only be fetched from http(s) repos, not %q", url)
+	}
+	if !strings.HasSuffix(url, ".git") {
+		url += ".git"
+	}
+	return url + "/info/lfs", nil
+}
+
+// sameOrigin returns whether href has the scheme and host of the repo at
+// repoURL, so that the repo's credentials can be sent to it.
+func sameOrigin(repoURL, href string) bool {
+	repo, err := url.Parse(repoURL)
+	if err != nil {
+		return false
+	}
+	target, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	return repo.Scheme == target.Scheme && strings.EqualFold(repo.Host, target.Host)
+}
+
+// fetchLFSObject downloads the object that pointer identifies from the
+// Git LFS server of co's repo, using the same transport settings the
+// repo was cloned with and its auth for the hrefs on its host. Objects
+// larger than ConfigFieldMaxLFSObjectSize, or than what's left of the
+// request's disk budget, aren't fetched.
+func fetchLFSObject(ctx context.Context, co *checkout, pointer lfsPointer) ([]byte, error) {
+	endpoint, err := lfsEndpoint(co.url)
+	if err != nil {
+		return nil, err
+	}
+	maxSize, err := maxLFSObjectSize(framework.GetResolverConfigFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if pointer.Size > maxSize {
+		return nil, fmt.Errorf("git lfs object %s is %d bytes, larger than the %q of %d", pointer.OID, pointer.Size, ConfigFieldMaxLFSObjectSize, maxSize)
+	}
+	if co.transport != nil {
+		// The object's bytes are counted against the disk budget as
+		// they're downloaded.
+		if !co.transport.disk.fits(pointer.Size) {
+			return nil, co.transport.disk.err()
+		}
+		ctx = withRequestTransport(ctx, co.transport)
+	}
+	body, err := json.Marshal(lfsBatchRequest{
+		Operation: "download",
+		Transfers: []string{"basic"},
+		Objects:   []lfsPointer{pointer},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/objects/batch", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", lfsMediaType)
+	req.Header.Set("Content-Type", lfsMediaType)
+	setLFSAuth(req, co.auth)
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting git lfs object %s: %w", pointer.OID, err)
+	}
+	defer res.Body.Close()
+	batch := lfsBatchResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&batch); err != nil && res.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("error decoding git lfs batch response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("git lfs batch request for %s failed with status %d: %s", pointer.OID, res.StatusCode, batch.Message)
+	}
+	if len(batch.Objects) != 1 {
+		return nil, fmt.Errorf("git lfs batch response has %d objects, expected 1", len(batch.Objects))
+	}
+	object := batch.Objects[0]
+	if object.Error != nil {
+		return nil, fmt.Errorf("git lfs object %s: %d %s", pointer.OID, object.Error.Code, object.Error.Message)
+	}
+	if object.Actions.Download == nil {
+		return nil, fmt.Errorf("git lfs server gave no download action for object %s", pointer.OID)
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, object.Actions.Download.Href, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(object.Actions.Download.Header) == 0 && sameOrigin(co.url, object.Actions.Download.Href) {
+		setLFSAuth(req, co.auth)
+	}
+	for key, val := range object.Actions.Download.Header {
+		req.Header.Set(key, val)
+	}
+	res, err = httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading git lfs object %s: %w", pointer.OID, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading git lfs object %s failed with status %d", pointer.OID, res.StatusCode)
+	}
+	content, err := io.ReadAll(io.LimitReader(res.Body, pointer.Size+1))
+	if err != nil {
+		return nil, fmt.Errorf("error downloading git lfs object %s: %w", pointer.OID, err)
+	}
+	if int64(len(content)) != pointer.Size {
+		return nil, fmt.Errorf("git lfs object %s is not %d bytes", pointer.OID, pointer.Size)
+	}
+	if digest := sha256.Sum256(content); hex.EncodeToString(digest[:]) != pointer.OID {
+		return nil, fmt.Errorf("git lfs object %s doesn't match its sha256", pointer.OID)
+	}
+	return content, nil
+}
+
+// setLFSAuth adds the credentials the repo was cloned with to a Git LFS
+// request.
+func setLFSAuth(req *http.Request, auth transport.AuthMethod) {
+	if httpAuth, ok := auth.(githttp.AuthMethod); ok {
+		httpAuth.SetAuth(req)
+	}
+}
@@ -0,0 +1,254 @@
+package git
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+// lfsTestPointer returns the Git LFS pointer file for content.
+func lfsTestPointer(content string) (string, string) {
+	digest := sha256.Sum256([]byte(content))
+	oid := hex.EncodeToString(digest[:])
+	return fmt.Sprintf("%s\noid sha256:%s\nsize %d\n", lfsPointerVersion, oid, len(content)), oid
+}
+
+// serveLFSObjects stubs the Git LFS batch API and object downloads of
+// server for the given objects, keyed by oid.
+func serveLFSObjects(server *fakeGitHTTPServer, objects map[string]string) {
+	server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+		switch {
+		case strings.HasSuffix(r.URL.Path, ".git/info/lfs/objects/batch"):
+			batch := lfsBatchRequest{}
+			if err := json.NewDecoder(r.Body).Decode(&batch); err != nil || batch.Operation != "download" || len(batch.Objects) != 1 {
+				http.Error(w, "bad batch request", http.StatusBadRequest)
+				return true
+			}
+			oid := batch.Objects[0].OID
+			object := map[string]interface{}{"oid": oid, "size": batch.Objects[0].Size}
+			if _, ok := objects[oid]; ok {
+				object["actions"] = map[string]interface{}{
+					"download": map[string]interface{}{"href": server.URL + "/lfs/objects/" + oid},
+				}
+			} else {
+				object["error"] = map[string]interface{}{"code": 404, "message": "Object does not exist"}
+			}
+			w.Header().Set("Content-Type", lfsMediaType)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"objects": []interface{}{object}})
+			return true
+		case strings.HasPrefix(r.URL.Path, "/lfs/objects/"):
+			content, ok := objects[strings.TrimPrefix(r.URL.Path, "/lfs/objects/")]
+			if !ok {
+				http.NotFound(w, r)
+				return true
+			}
+			_, _ = w.Write([]byte(content))
+			return true
+		}
+		return false
+	})
+}
+
+func TestResolveLFS(t *testing.T) {
+	const content = "apiVersion: tekton.dev/v1beta1\nkind: Task\n"
+	pointer, oid := lfsTestPointer(content)
+	missingPointer, _ := lfsTestPointer("missing")
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{
+			"task.yaml":    pointer,
+			"missing.yaml": missingPointer,
+			"plain.yaml":   "plain",
+		},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	serveLFSObjects(server, map[string]string{oid: content})
+
+	for _, tc := range []struct {
+		name            string
+		path            string
+		lfs             string
+		expectedContent string
+		expectLFS       bool
+		expectedError   string
+	}{{
+		name:            "lfs object is fetched",
+		path:            "task.yaml",
+		lfs:             "true",
+		expectedContent: content,
+		expectLFS:       true,
+	}, {
+		name:            "pointer is returned without lfs param",
+		path:            "task.yaml",
+		expectedContent: pointer,
+	}, {
+		name:            "file that isn't a pointer is returned as-is",
+		path:            "plain.yaml",
+		lfs:             "true",
+		expectedContent: "plain",
+	}, {
+		name:          "missing lfs object",
+		path:          "missing.yaml",
+		lfs:           "true",
+		expectedError: "Object does not exist",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := Resolver{}
+			resource, err := resolver.Resolve(context.Background(), map[string]string{
+				URLParam:  server.repoURL(),
+				PathParam: tc.path,
+				LFSParam:  tc.lfs,
+			})
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, received %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.expectedContent {
+				t.Errorf("expected content %q, received %q", tc.expectedContent, resource.Data())
+			}
+			annotations := resource.Annotations()
+			if _, ok := annotations[AnnotationKeyLFSOID]; ok != tc.expectLFS {
+				t.Errorf("expected lfs annotations to be present: %t, received %v", tc.expectLFS, annotations)
+			}
+			if tc.expectLFS {
+				if annotations[AnnotationKeyLFSOID] != "sha256:"+oid || annotations[AnnotationKeyLFSSize] != fmt.Sprint(len(content)) {
+					t.Errorf("unexpected lfs annotations %v", annotations)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveLFSOnlySendsAuthToRepoHost(t *testing.T) {
+	const content = "apiVersion: tekton.dev/v1beta1\nkind: Task\n"
+	pointer, oid := lfsTestPointer(content)
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": pointer},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	storageAuth := make(chan string, 1)
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		storageAuth <- r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(content))
+	}))
+	t.Cleanup(storage.Close)
+	server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+		if !strings.HasSuffix(r.URL.Path, ".git/info/lfs/objects/batch") {
+			return false
+		}
+		w.Header().Set("Content-Type", lfsMediaType)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"objects": []interface{}{map[string]interface{}{
+			"oid":     oid,
+			"size":    len(content),
+			"actions": map[string]interface{}{"download": map[string]interface{}{"href": storage.URL + "/" + oid}},
+		}}})
+		return true
+	})
+
+	resolver := Resolver{kubeClientSet: fakekube.NewSimpleClientset(bearerTokenSecret("s3cr3t"))}
+	ctx := resolutioncommon.InjectRequestNamespace(context.Background(), "foo")
+	resource, err := resolver.Resolve(ctx, map[string]string{
+		URLParam:               server.repoURL(),
+		PathParam:              "task.yaml",
+		LFSParam:               "true",
+		BearerTokenSecretParam: "git-token",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resource.Data()) != content {
+		t.Errorf("expected content %q, received %q", content, resource.Data())
+	}
+	if auth := <-storageAuth; auth != "" {
+		t.Errorf("expected no credentials to be sent to another host, received %q", auth)
+	}
+	for _, header := range server.receivedHeaders() {
+		if header.Get("Authorization") != "Bearer s3cr3t" {
+			t.Errorf("expected credentials to be sent to the repo's host, received %q", header.Get("Authorization"))
+		}
+	}
+}
+
+func TestResolveLFSObjectSizeLimits(t *testing.T) {
+	content := strings.Repeat("a", 64<<10)
+	pointer, oid := lfsTestPointer(content)
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": pointer},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	serveLFSObjects(server, map[string]string{oid: content})
+
+	for _, tc := range []struct {
+		name          string
+		conf          map[string]string
+		maxDisk       int64
+		expectedError string
+	}{{
+		name: "within the limits",
+	}, {
+		name:          "larger than the max object size",
+		conf:          map[string]string{ConfigFieldMaxLFSObjectSize: "1024"},
+		expectedError: `larger than the "max-lfs-object-size"`,
+	}, {
+		name:          "larger than the disk budget",
+		maxDisk:       32 << 10,
+		expectedError: ErrDiskBudgetExceeded.Error(),
+	}, {
+		name:          "invalid max object size",
+		conf:          map[string]string{ConfigFieldMaxLFSObjectSize: "20MB"},
+		expectedError: `invalid "max-lfs-object-size" config`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := framework.InjectResolverConfigToContext(context.Background(), tc.conf)
+			ctx = resolutioncommon.InjectRequestMaxDisk(ctx, tc.maxDisk)
+			resource, err := (&Resolver{}).Resolve(ctx, map[string]string{
+				URLParam:  server.repoURL(),
+				PathParam: "task.yaml",
+				LFSParam:  "true",
+			})
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, received %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != content {
+				t.Errorf("expected the lfs object's content, received %d bytes", len(resource.Data()))
+			}
+		})
+	}
+}
+
+func TestParseLFSPointer(t *testing.T) {
+	pointer, oid := lfsTestPointer("content")
+	for _, tc := range []struct {
+		content  string
+		expected bool
+	}{
+		{content: pointer, expected: true},
+		{content: "content"},
+		{content: lfsPointerVersion + "\noid sha256:abc\nsize 7\n"},
+		{content: lfsPointerVersion + "\noid sha256:" + oid + "\nsize many\n"},
+	} {
+		if _, ok := parseLFSPointer([]byte(tc.content)); ok != tc.expected {
+			t.Errorf("expected %q to be a pointer: %t", tc.content, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// lineRangeModeClamp and lineRangeModeError are the values of
+// ConfigFieldLineRangeMode.
+const (
+	lineRangeModeClamp = "clamp"
+	lineRangeModeError = "error"
+)
+
+// lineRange is a 1-based, inclusive range of lines. A zero start or end
+// means the first or last line of the file respectively.
+type lineRange struct {
+	start int
+	end   int
+}
+
+// parseLineRange reads StartLineParam and EndLineParam from params. The
+// returned bool is false if neither is set.
+func parseLineRange(params map[string]string) (lineRange, bool, error) {
+	lr := lineRange{}
+	set := false
+	for _, p := range []struct {
+		name string
+		dest *int
+	}{{StartLineParam, &lr.start}, {EndLineParam, &lr.end}} {
+		val := params[p.name]
+		if val == "" {
+			continue
+		}
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 {
+			return lr, false, fmt.Errorf("invalid %q %q: must be a positive integer", p.name, val)
+		}
+		*p.dest = n
+		set = true
+	}
+	if lr.start != 0 && lr.end != 0 && lr.start > lr.end {
+		return lr, false, fmt.Errorf("invalid line range: %q %d is after %q %d", StartLineParam, lr.start, EndLineParam, lr.end)
+	}
+	return lr, set, nil
+}
+
+// selectLines returns the lines of content within lr, keeping their line
+// endings, along with the range that was actually selected. Ranges that
+// extend past the end of the file are an error unless clamp is true, in
+// which case they're shortened to fit.
+func selectLines(content []byte, lr lineRange, clamp bool) ([]byte, lineRange, error) {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	count := len(lines)
+	if count == 0 {
+		return nil, lr, fmt.Errorf("can't select lines of an empty file")
+	}
+	if lr.start == 0 {
+		lr.start = 1
+	}
+	if lr.end == 0 {
+		lr.end = count
+	}
+	if lr.end > count {
+		if !clamp {
+			return nil, lr, fmt.Errorf("line range %d-%d is out of bounds: file has %d lines", lr.start, lr.end, count)
+		}
+		lr.end = count
+		if lr.start > count {
+			lr.start = count
+		}
+	}
+	return []byte(strings.Join(lines[lr.start-1:lr.end], "")), lr, nil
+}
+
+// String returns the range in the form used by AnnotationKeyLineRange.
+func (lr lineRange) String() string {
+	return fmt.Sprintf("%d-%d", lr.start, lr.end)
+}
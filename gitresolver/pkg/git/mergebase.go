@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+)
+
+// resolvingMergeBase returns whether params request the merge-base of
+// RefAParam and RefBParam rather than a file.
+func resolvingMergeBase(params map[string]string) bool {
+	mergeBase, err := parseBoolParam(params, MergeBaseParam)
+	return err == nil && mergeBase
+}
+
+// resolveMergeBase finds the best common ancestor of RefAParam and
+// RefBParam in co, as `git merge-base` would. When there's more than
+// one, as after criss-cross merges, the first found is returned.
+func (r *Resolver) resolveMergeBase(co *checkout, params map[string]string) (*ResolvedGitResource, error) {
+	refA, refB := params[RefAParam], params[RefBParam]
+	commitA, err := resolveRef(co.repository, refA)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving %q %q: %w", RefAParam, refA, err)
+	}
+	commitB, err := resolveRef(co.repository, refB)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving %q %q: %w", RefBParam, refB, err)
+	}
+	bases, err := commitA.MergeBase(commitB)
+	if err != nil {
+		return nil, fmt.Errorf("error finding merge-base of %q and %q: %w", refA, refB, err)
+	}
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("error finding merge-base of %q and %q: %w", refA, refB, ErrNoMergeBase)
+	}
+	base := bases[0].Hash.String()
+
+	annotations, err := refAnnotations(co, params)
+	if err != nil {
+		return nil, err
+	}
+	annotations[AnnotationKeyRefACommit] = commitA.Hash.String()
+	annotations[AnnotationKeyRefBCommit] = commitB.Hash.String()
+	annotations[AnnotationKeyMergeBase] = base
+	return &ResolvedGitResource{
+		Commit:           base,
+		Content:          []byte(base),
+		ContentType:      TextContentType,
+		ExtraAnnotations: annotations,
+	}, nil
+}
@@ -0,0 +1,115 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestResolveMergeBase(t *testing.T) {
+	repo, hashes := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "version: 1\n"},
+	}, {
+		Files: map[string]string{"task.yaml": "version: 2\n"},
+		Tag:   "v2",
+	}})
+	main := appendTestCommits(t, repo, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "version: 3\n"},
+	}})
+	r, err := git.PlainOpen(repo)
+	if err != nil {
+		t.Fatalf("error opening test repo: %v", err)
+	}
+	if err := r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("feature"), plumbing.NewHash(hashes[1]))); err != nil {
+		t.Fatalf("error creating branch: %v", err)
+	}
+	setTestHead(t, repo, "feature")
+	feature := appendTestCommits(t, repo, []commitForRepo{{
+		Files: map[string]string{"feature.yaml": "feature: true\n"},
+	}})
+	setTestHead(t, repo, "unrelated")
+	appendTestCommits(t, repo, []commitForRepo{{
+		Files: map[string]string{"other.yaml": "unrelated: true\n"},
+	}})
+	setTestHead(t, repo, "master")
+
+	for _, tc := range []struct {
+		name              string
+		refA              string
+		refB              string
+		expectedMergeBase string
+		expectedCommitA   string
+		expectedCommitB   string
+		expectedErr       error
+	}{{
+		name:              "diverged branches",
+		refA:              "master",
+		refB:              "feature",
+		expectedMergeBase: hashes[1],
+		expectedCommitA:   main[0],
+		expectedCommitB:   feature[0],
+	}, {
+		name:              "ancestor and descendant",
+		refA:              hashes[0],
+		refB:              "v2",
+		expectedMergeBase: hashes[0],
+		expectedCommitA:   hashes[0],
+		expectedCommitB:   hashes[1],
+	}, {
+		name:        "unrelated histories",
+		refA:        "master",
+		refB:        "unrelated",
+		expectedErr: ErrNoMergeBase,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := Resolver{}
+			params := map[string]string{
+				URLParam:       repo,
+				RefAParam:      tc.refA,
+				RefBParam:      tc.refB,
+				MergeBaseParam: "true",
+			}
+			if err := resolver.ValidateParams(context.Background(), params); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			resource, err := resolver.Resolve(context.Background(), params)
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v, received %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.expectedMergeBase {
+				t.Errorf("expected merge-base %s, received %s", tc.expectedMergeBase, resource.Data())
+			}
+			annotations := resource.Annotations()
+			if annotations[AnnotationKeyMergeBase] != tc.expectedMergeBase {
+				t.Errorf("expected merge-base annotation %s, received %s", tc.expectedMergeBase, annotations[AnnotationKeyMergeBase])
+			}
+			if annotations[AnnotationKeyRefACommit] != tc.expectedCommitA || annotations[AnnotationKeyRefBCommit] != tc.expectedCommitB {
+				t.Errorf("expected ref commit annotations %s and %s, received %s and %s", tc.expectedCommitA, tc.expectedCommitB, annotations[AnnotationKeyRefACommit], annotations[AnnotationKeyRefBCommit])
+			}
+		})
+	}
+}
+
+func TestValidateMergeBase(t *testing.T) {
+	for _, params := range []map[string]string{
+		{MergeBaseParam: "true"},
+		{MergeBaseParam: "true", RefAParam: "v1"},
+		{MergeBaseParam: "true", RefAParam: "v1", RefBParam: "v2", PathParam: "task.yaml"},
+		{MergeBaseParam: "yes", RefAParam: "v1", RefBParam: "v2"},
+	} {
+		params[URLParam] = "https://example.com/repo.git"
+		resolver := Resolver{}
+		if err := resolver.ValidateParams(context.Background(), params); err == nil {
+			t.Errorf("expected params %v to be rejected", params)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"knative.dev/pkg/metrics"
+)
+
+var bytesFetchedMeasure = stats.Int64(
+	"git_resolver_bytes_fetched",
+	"Number of bytes received from git hosts while resolving a single request",
+	stats.UnitBytes,
+)
+
+func init() {
+	if err := metrics.RegisterResourceView(&view.View{
+		Description: bytesFetchedMeasure.Description(),
+		Measure:     bytesFetchedMeasure,
+		Aggregation: view.Distribution(metrics.Buckets125(1024, 1024*1024*1024)...),
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// recordBytesFetched records the bytes fetched for one resolution.
+func recordBytesFetched(ctx context.Context, n int64) {
+	metrics.Record(ctx, bytesFetchedMeasure.M(n))
+}
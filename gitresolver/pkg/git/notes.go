@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// notesRef is the ref that git notes are read from, which is where
+// `git notes add` writes them by default.
+const notesRef plumbing.ReferenceName = "refs/notes/commits"
+
+// fetchNotes fetches notesRef into repository. Repos without any notes
+// aren't an error.
+func fetchNotes(ctx context.Context, repository *git.Repository, auth transport.AuthMethod) error {
+	err := repository.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		Auth:       auth,
+		RefSpecs:   []config.RefSpec{config.RefSpec("+" + notesRef + ":" + notesRef)},
+		Tags:       git.NoTags,
+	})
+	if err == nil || errors.Is(err, git.NoErrAlreadyUpToDate) || errors.Is(err, git.NoMatchingRefSpecError{}) {
+		return nil
+	}
+	return fmt.Errorf("error fetching %s: %w", notesRef, err)
+}
+
+// readNote returns the git note attached to commit, or an empty string
+// if it has none.
+func readNote(repository *git.Repository, commit plumbing.Hash) (string, error) {
+	ref, err := repository.Reference(notesRef, true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	notesCommit, err := repository.CommitObject(ref.Hash())
+	if err != nil {
+		return "", err
+	}
+	tree, err := notesCommit.Tree()
+	if err != nil {
+		return "", err
+	}
+	// Notes are stored in files named for the commit they're attached
+	// to, which git splits into directories (e.g. ab/cdef...) once a
+	// repo has many notes.
+	var note string
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if strings.ReplaceAll(f.Name, "/", "") != commit.String() {
+			return nil
+		}
+		contents, err := f.Contents()
+		if err != nil {
+			return err
+		}
+		note = contents
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(note, "\n"), nil
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// outputFormatYAML returns the file as it's stored in the repo.
+	outputFormatYAML = "yaml"
+	// outputFormatJSON converts the file from yaml to json.
+	outputFormatJSON = "json"
+)
+
+// validateOutputFormat returns an error if OutputFormatParam is set to
+// an unsupported format.
+func validateOutputFormat(params map[string]string) error {
+	switch format := params[OutputFormatParam]; format {
+	case "", outputFormatYAML, outputFormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("invalid %q %q: must be %q or %q", OutputFormatParam, format, outputFormatYAML, outputFormatJSON)
+	}
+}
+
+// yamlToJSON converts content from yaml to json. A single yaml document
+// becomes a single json value and a stream of several documents becomes
+// a json array of them.
+func yamlToJSON(content []byte) ([]byte, error) {
+	docs, err := yamlDocuments(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 1 {
+		return docs[0], nil
+	}
+	return json.Marshal(docs)
+}
+
+// yamlDocuments splits a stream of yaml documents, skipping empty ones,
+// and converts each of them to json.
+func yamlDocuments(content []byte) ([]json.RawMessage, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(content)))
+	docs := []json.RawMessage{}
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		converted, err := yaml.YAMLToJSON(doc)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", len(docs)+1, err)
+		}
+		docs = append(docs, converted)
+	}
+}
+
+// normalizeYAML strips the comments and blank lines that lead each
+// document in a stream of yaml documents, drops documents left empty and
+// rejoins the rest with a plain "---" line between each of them. The
+// content of the documents is otherwise left as it is.
+func normalizeYAML(content []byte) ([]byte, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(content)))
+	buf := &bytes.Buffer{}
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return buf.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		doc = stripLeadingComments(doc)
+		if len(doc) == 0 {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(doc)
+		if doc[len(doc)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+}
+
+// stripLeadingComments returns doc without the comment, blank and
+// separator lines before its first line of content, or nothing if it has
+// no content. The reader splitting a stream leaves the separator at the
+// start of its first document.
+func stripLeadingComments(doc []byte) []byte {
+	for len(doc) > 0 {
+		line := doc
+		rest := []byte{}
+		if i := bytes.IndexByte(doc, '\n'); i >= 0 {
+			line, rest = doc[:i], doc[i+1:]
+		}
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) > 0 && trimmed[0] != '#' && !bytes.Equal(trimmed, []byte("---")) {
+			return doc
+		}
+		doc = rest
+	}
+	return nil
+}
+
+// binarySniffLen is how much of a file is checked for NUL bytes to tell
+// whether it's binary, as git does.
+const binarySniffLen = 8000
+
+// isBinary returns whether content looks like binary rather than text
+// because it has a NUL byte near its start.
+func isBinary(content []byte) bool {
+	if len(content) > binarySniffLen {
+		content = content[:binarySniffLen]
+	}
+	return bytes.IndexByte(content, 0) != -1
+}
+
+// normalizeTrailingNewline returns text content ending with exactly one
+// newline, in the file's own line ending style. Empty and binary content
+// is returned unchanged.
+func normalizeTrailingNewline(content []byte) []byte {
+	if len(content) == 0 || isBinary(content) {
+		return content
+	}
+	newline := []byte("\n")
+	if i := bytes.IndexByte(content, '\n'); i > 0 && content[i-1] == '\r' {
+		newline = []byte("\r\n")
+	}
+	trimmed := bytes.TrimRight(content, "\r\n")
+	normalized := make([]byte, 0, len(trimmed)+len(newline))
+	normalized = append(normalized, trimmed...)
+	return append(normalized, newline...)
+}
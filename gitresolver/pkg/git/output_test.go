@@ -0,0 +1,110 @@
+package git
+
+import (
+	"context"
+	"testing"
+)
+
+func TestResolveNormalizeYAML(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		content   string
+		normalize bool
+		expected  string
+	}{{
+		name:      "leading comments",
+		content:   "# Copyright 2022 The Tekton Authors\n#\n# Licensed under the Apache License\n\nkind: Task\n# a comment within the document\nname: build\n",
+		normalize: true,
+		expected:  "kind: Task\n# a comment within the document\nname: build\n",
+	}, {
+		name:      "multiple documents",
+		content:   "---\n# first\nkind: Task\nname: build\n---   \n\n# second\nkind: Pipeline\nname: release\n---\n# only a comment\n---\nkind: Task\nname: test",
+		normalize: true,
+		expected:  "kind: Task\nname: build\n---\nkind: Pipeline\nname: release\n---\nkind: Task\nname: test\n",
+	}, {
+		name:      "block scalars kept",
+		content:   "# leading\nscript: |\n  # not a yaml comment\n  echo hi\n",
+		normalize: true,
+		expected:  "script: |\n  # not a yaml comment\n  echo hi\n",
+	}, {
+		name:     "not normalized by default",
+		content:  "# leading\n---\nkind: Task\n",
+		expected: "# leading\n---\nkind: Task\n",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, _ := createTestRepo(t, []commitForRepo{{
+				Files: map[string]string{"task.yaml": tc.content},
+			}})
+			params := map[string]string{
+				URLParam:  repo,
+				PathParam: "task.yaml",
+			}
+			if tc.normalize {
+				params[NormalizeYAMLParam] = "true"
+			}
+			resolver := Resolver{}
+			if err := resolver.ValidateParams(context.Background(), params); err != nil {
+				t.Fatalf("unexpected error validating params: %v", err)
+			}
+			resource, err := resolver.Resolve(context.Background(), params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.expected {
+				t.Errorf("expected content %q, received %q", tc.expected, resource.Data())
+			}
+		})
+	}
+}
+
+func TestResolveTrailingNewline(t *testing.T) {
+	binary := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\n\n"
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{
+			"none.yaml":     "kind: Task",
+			"one.yaml":      "kind: Task\n",
+			"multiple.yaml": "kind: Task\n\n\n",
+			"crlf.yaml":     "kind: Task\r\nspec: {}\r\n\r\n",
+			"empty.yaml":    "",
+			"image.png":     binary,
+		},
+	}})
+	for _, tc := range []struct {
+		path     string
+		expected string
+	}{
+		{path: "none.yaml", expected: "kind: Task\n"},
+		{path: "one.yaml", expected: "kind: Task\n"},
+		{path: "multiple.yaml", expected: "kind: Task\n"},
+		{path: "crlf.yaml", expected: "kind: Task\r\nspec: {}\r\n"},
+		{path: "empty.yaml", expected: ""},
+		{path: "image.png", expected: binary},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			resolver := Resolver{}
+			resource, err := resolver.Resolve(context.Background(), map[string]string{
+				URLParam:             repo,
+				PathParam:            tc.path,
+				TrailingNewlineParam: "true",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.expected {
+				t.Errorf("expected %q, received %q", tc.expected, resource.Data())
+			}
+		})
+	}
+
+	resolver := Resolver{}
+	resource, err := resolver.Resolve(context.Background(), map[string]string{
+		URLParam:  repo,
+		PathParam: "multiple.yaml",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resource.Data()) != "kind: Task\n\n\n" {
+		t.Errorf("expected content to be left as-is by default, received %q", resource.Data())
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"sigs.k8s.io/yaml"
+)
+
+// pacDir is the directory that Pipelines-as-Code reads a repo's
+// PipelineRuns, and the Tasks and Pipelines they use, from.
+const pacDir = ".tekton"
+
+const (
+	// pacEventPush is a push to the target branch.
+	pacEventPush = "push"
+	// pacEventPullRequest is a pull request against the target branch.
+	pacEventPullRequest = "pull_request"
+)
+
+const (
+	// pacAnnotationOnEvent lists the events a PipelineRun runs on.
+	pacAnnotationOnEvent = "pipelinesascode.tekton.dev/on-event"
+	// pacAnnotationOnTargetBranch lists the branches, or globs of
+	// them, that a PipelineRun runs for.
+	pacAnnotationOnTargetBranch = "pipelinesascode.tekton.dev/on-target-branch"
+)
+
+// validatePacEvent returns an error if PacEventParam is an unsupported
+// event or is combined with params that name a single file, or if
+// PacTargetBranchParam is given without it.
+func validatePacEvent(params map[string]string) error {
+	event := params[PacEventParam]
+	if event == "" {
+		if params[PacTargetBranchParam] != "" {
+			return fmt.Errorf("%q requires %q", PacTargetBranchParam, PacEventParam)
+		}
+		return nil
+	}
+	if event != pacEventPush && event != pacEventPullRequest {
+		return fmt.Errorf("invalid %q %q: must be %q or %q", PacEventParam, event, pacEventPush, pacEventPullRequest)
+	}
+	for _, p := range []string{PathParam, WellKnownParam, BlobParam, TektonBundleDirParam, ResolveIncludesParam} {
+		if params[p] != "" {
+			return fmt.Errorf("supplied both %q and %q", PacEventParam, p)
+		}
+	}
+	return nil
+}
+
+// readPacConfig returns the PipelineRuns in the repo's .tekton directory
+// that Pipelines-as-Code would run for event against targetBranch,
+// along with every other Tekton resource there for them to refer to,
+// as readTektonBundle does. PipelineRuns are matched on their on-event
+// and on-target-branch annotations; those selected with an
+// on-cel-expression instead aren't matched.
+func readPacConfig(filesystem billy.Filesystem, event, targetBranch string) ([]byte, string, error) {
+	matched := 0
+	content, manifest, err := readTektonResources(filesystem, pacDir, func(doc []byte, entry manifestEntry) bool {
+		if entry.Kind != "PipelineRun" {
+			return true
+		}
+		if !pacMatches(doc, event, targetBranch) {
+			return false
+		}
+		matched++
+		return true
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if matched == 0 {
+		return nil, "", fmt.Errorf("no PipelineRuns in %s directory match %s event for branch %q: %w", pacDir, event, targetBranch, ErrFileNotFound)
+	}
+	return content, manifest, nil
+}
+
+// pacMatches returns whether the PipelineRun in doc runs for event
+// against targetBranch.
+func pacMatches(doc []byte, event, targetBranch string) bool {
+	var run struct {
+		Metadata struct {
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+	}
+	if err := yaml.Unmarshal(doc, &run); err != nil {
+		return false
+	}
+	annotations := run.Metadata.Annotations
+	onEvent := false
+	for _, e := range pacList(annotations[pacAnnotationOnEvent]) {
+		onEvent = onEvent || e == event
+	}
+	if !onEvent {
+		return false
+	}
+	targetBranch = strings.TrimPrefix(targetBranch, "refs/heads/")
+	for _, pattern := range pacList(annotations[pacAnnotationOnTargetBranch]) {
+		pattern = strings.TrimPrefix(pattern, "refs/heads/")
+		if ok, err := path.Match(pattern, targetBranch); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// pacList splits a Pipelines-as-Code annotation, a comma-separated list
+// optionally in brackets, e.g. "[push, pull_request]", into its items.
+func pacList(val string) []string {
+	val = strings.TrimSpace(val)
+	val = strings.TrimSuffix(strings.TrimPrefix(val, "["), "]")
+	items := []string{}
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.Trim(strings.TrimSpace(item), `"'`); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,169 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolvePacConfig(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{
+			".tekton/push.yaml": `apiVersion: tekton.dev/v1beta1
+kind: PipelineRun
+metadata:
+  name: on-push
+  annotations:
+    pipelinesascode.tekton.dev/on-event: "[push]"
+    pipelinesascode.tekton.dev/on-target-branch: "[master]"
+`,
+			".tekton/pull-request.yaml": `apiVersion: tekton.dev/v1beta1
+kind: PipelineRun
+metadata:
+  name: on-pull-request
+  annotations:
+    pipelinesascode.tekton.dev/on-event: "[pull_request]"
+    pipelinesascode.tekton.dev/on-target-branch: "[master, release-*]"
+`,
+			".tekton/any.yaml": `apiVersion: tekton.dev/v1beta1
+kind: PipelineRun
+metadata:
+  name: on-any
+  annotations:
+    pipelinesascode.tekton.dev/on-event: "[pull_request, push]"
+    pipelinesascode.tekton.dev/on-target-branch: "[refs/heads/*]"
+`,
+			".tekton/unannotated.yaml": `apiVersion: tekton.dev/v1beta1
+kind: PipelineRun
+metadata:
+  name: manual
+`,
+			".tekton/tasks/lint.yaml": `apiVersion: tekton.dev/v1beta1
+kind: Task
+metadata:
+  name: lint
+`,
+			"task.yaml": "content",
+		},
+	}})
+
+	for _, tc := range []struct {
+		name             string
+		event            string
+		targetBranch     string
+		expectedManifest []manifestEntry
+		expectedErr      error
+	}{{
+		name:  "push to branch",
+		event: pacEventPush,
+		expectedManifest: []manifestEntry{
+			{Path: ".tekton/any.yaml", Kind: "PipelineRun", Name: "on-any"},
+			{Path: ".tekton/push.yaml", Kind: "PipelineRun", Name: "on-push"},
+			{Path: ".tekton/tasks/lint.yaml", Kind: "Task", Name: "lint"},
+		},
+	}, {
+		name:  "pull request against branch",
+		event: pacEventPullRequest,
+		expectedManifest: []manifestEntry{
+			{Path: ".tekton/any.yaml", Kind: "PipelineRun", Name: "on-any"},
+			{Path: ".tekton/pull-request.yaml", Kind: "PipelineRun", Name: "on-pull-request"},
+			{Path: ".tekton/tasks/lint.yaml", Kind: "Task", Name: "lint"},
+		},
+	}, {
+		name:         "pull request against glob of branches",
+		event:        pacEventPullRequest,
+		targetBranch: "release-1.0",
+		expectedManifest: []manifestEntry{
+			{Path: ".tekton/any.yaml", Kind: "PipelineRun", Name: "on-any"},
+			{Path: ".tekton/pull-request.yaml", Kind: "PipelineRun", Name: "on-pull-request"},
+			{Path: ".tekton/tasks/lint.yaml", Kind: "Task", Name: "lint"},
+		},
+	}, {
+		name:         "push to unmatched branch",
+		event:        pacEventPush,
+		targetBranch: "release-1.0",
+		expectedManifest: []manifestEntry{
+			{Path: ".tekton/any.yaml", Kind: "PipelineRun", Name: "on-any"},
+			{Path: ".tekton/tasks/lint.yaml", Kind: "Task", Name: "lint"},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			params := map[string]string{
+				URLParam:             repo,
+				PacEventParam:        tc.event,
+				PacTargetBranchParam: tc.targetBranch,
+			}
+			resolver := Resolver{}
+			if err := resolver.ValidateParams(context.Background(), params); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			resource, err := resolver.Resolve(context.Background(), params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			manifest := []manifestEntry{}
+			if err := json.Unmarshal([]byte(resource.Annotations()[AnnotationKeyManifest]), &manifest); err != nil {
+				t.Fatalf("error parsing manifest: %v", err)
+			}
+			if !reflect.DeepEqual(manifest, tc.expectedManifest) {
+				t.Errorf("expected manifest %+v, received %+v", tc.expectedManifest, manifest)
+			}
+			if docs := strings.Count(string(resource.Data()), "kind: "); docs != len(tc.expectedManifest) {
+				t.Errorf("expected %d documents, received %q", len(tc.expectedManifest), resource.Data())
+			}
+		})
+	}
+}
+
+func TestResolvePacConfigWithoutMatch(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{
+			".tekton/push.yaml": `apiVersion: tekton.dev/v1beta1
+kind: PipelineRun
+metadata:
+  name: on-push
+  annotations:
+    pipelinesascode.tekton.dev/on-event: "[push]"
+    pipelinesascode.tekton.dev/on-target-branch: "[main]"
+`,
+		},
+	}})
+	_, err := (&Resolver{}).Resolve(context.Background(), map[string]string{
+		URLParam:      repo,
+		PacEventParam: pacEventPullRequest,
+	})
+	if !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("expected not found error, received %v", err)
+	}
+}
+
+func TestValidatePacEvent(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		params        map[string]string
+		expectedError string
+	}{{
+		name:          "unsupported event",
+		params:        map[string]string{PacEventParam: "tag"},
+		expectedError: `invalid "pacEvent" "tag"`,
+	}, {
+		name:          "with path",
+		params:        map[string]string{PacEventParam: pacEventPush, PathParam: "task.yaml"},
+		expectedError: `supplied both "pacEvent" and "path"`,
+	}, {
+		name:          "target branch without event",
+		params:        map[string]string{PathParam: "task.yaml", PacTargetBranchParam: "main"},
+		expectedError: `"pacTargetBranch" requires "pacEvent"`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.params[URLParam] = "https://github.com/tektoncd/catalog"
+			err := (&Resolver{}).ValidateParams(context.Background(), tc.params)
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("expected error containing %q, received %v", tc.expectedError, err)
+			}
+		})
+	}
+}
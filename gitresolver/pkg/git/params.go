@@ -16,6 +16,11 @@ limitations under the License.
 
 package git
 
+import (
+	"fmt"
+	"strconv"
+)
+
 // URLParam is the git repo url
 const URLParam string = "url"
 
@@ -27,3 +32,219 @@ const CommitParam string = "commit"
 
 // BranchParam is the git branch that a file should be fetched from
 const BranchParam string = "branch"
+
+// GitHubAppSecretParam is the name of a secret in the request's
+// namespace holding the credentials of a GitHub App to clone with
+const GitHubAppSecretParam string = "githubAppSecret"
+
+// BearerTokenSecretParam is the name of a secret in the request's
+// namespace holding a token to clone with, sent in an
+// "Authorization: Bearer" header rather than as basic auth. Cannot be
+// combined with GitHubAppSecretParam
+const BearerTokenSecretParam string = "bearerTokenSecret"
+
+// AsOfParam is an RFC3339 timestamp. When set the file is fetched from
+// the latest commit on the branch committed at or before that time
+const AsOfParam string = "asOf"
+
+// ResolveIncludesParam is set to "true" to inline the sibling files that
+// a resolved file includes with "# @include <path>" directives
+const ResolveIncludesParam string = "resolveIncludes"
+
+// DescribeParam is set to "true" to annotate the resolved file with the
+// nearest tag to its commit, in the style of "git describe --tags"
+const DescribeParam string = "describe"
+
+// StartLineParam is the first line, counting from 1, of the file to
+// return. Defaults to the first line of the file
+const StartLineParam string = "startLine"
+
+// EndLineParam is the last line, inclusive, of the file to return.
+// Defaults to the last line of the file
+const EndLineParam string = "endLine"
+
+// RejectEmptyParam is set to "true" to fail the request if the resolved
+// file is empty. Defaults to the value of ConfigFieldRejectEmpty
+const RejectEmptyParam string = "rejectEmpty"
+
+// NotesParam is set to "true" to annotate the resolved file with the git
+// note attached to the commit it was fetched from
+const NotesParam string = "notes"
+
+// RefAParam is a tag, branch or commit to fetch the file from, to be
+// compared with the file at RefBParam. Requires RefBParam
+const RefAParam string = "refA"
+
+// RefBParam is a tag, branch or commit to fetch the file from, to be
+// compared with the file at RefAParam. Requires RefAParam
+const RefBParam string = "refB"
+
+// MergeBaseParam is set to "true" to resolve the merge-base of
+// RefAParam and RefBParam, their best common ancestor, instead of a file
+const MergeBaseParam string = "mergeBase"
+
+// DiffParam is set to "true" to resolve the unified diff of PathParam
+// from RefAParam to RefBParam instead of the file at each
+const DiffParam string = "diff"
+
+// ProvenanceParam is set to "true" to annotate the resolved file with an
+// in-toto material identifying it by its repo, commit, path and digest
+const ProvenanceParam string = "provenance"
+
+// OutputFormatParam is the format to return the file in, either "yaml",
+// the default, which returns the file as-is, or "json", which converts
+// the file from yaml to json
+const OutputFormatParam string = "outputFormat"
+
+// ProxyParam is the socks5:// URL of a proxy to clone the repo through.
+// Defaults to the value of ConfigFieldProxy
+const ProxyParam string = "proxy"
+
+// ProxySecretParam is the name of a secret in the request's namespace
+// holding the username and password to authenticate to the proxy with
+const ProxySecretParam string = "proxySecret"
+
+// BlobParam is the hash of a git blob object to resolve the content of
+// instead of PathParam. The blob must be in the history fetched for the
+// requested commit or branch
+const BlobParam string = "blob"
+
+// WellKnownParam resolves one of the repo's well-known files instead of
+// PathParam: "license", its LICENSE file, "codeowners", its CODEOWNERS
+// file, or "tekton", every yaml file in its .tekton directory
+const WellKnownParam string = "wellKnown"
+
+// LFSParam is set to "true" to fetch the content of a file stored with
+// Git LFS from the repo's LFS server, instead of returning its pointer
+const LFSParam string = "lfs"
+
+// SchemaParam is a JSON schema that every document of the resolved file
+// must match, either inline as a JSON object or as the path of a JSON or
+// YAML file in the repo, read from the same commit
+const SchemaParam string = "schema"
+
+// TemplateParam is set to "true" to render the resolved file as a go
+// template, substituting the values of "var."-prefixed params into its
+// {{ .name }} placeholders
+const TemplateParam string = "template"
+
+// TektonBundleDirParam is a directory of the repo to resolve every
+// Tekton resource in the yaml files under, as one stream of yaml
+// documents, instead of PathParam
+const TektonBundleDirParam string = "tektonBundleDir"
+
+// ExpectedDigestParam is the digest, as sha256:<hex>, that the resolved
+// content must have for the request to succeed
+const ExpectedDigestParam string = "expectedDigest"
+
+// RevisionParam is a branch name, or HEAD for the default branch,
+// followed by ~N and ^N suffixes naming the commit to fetch relative to
+// the branch's tip, e.g. main~3 or HEAD^
+const RevisionParam string = "revision"
+
+// TrailingNewlineParam is set to "true" to make resolved text content
+// end with exactly one newline
+const TrailingNewlineParam string = "trailingNewline"
+
+// DependenciesParam is set to "true" to annotate a resolved pipeline
+// with the tasks that it references
+const DependenciesParam string = "dependencies"
+
+// WorktreeParam is the name, or checkout path, of a linked worktree of
+// the local repo at URLParam whose checked out commit is resolved from
+const WorktreeParam string = "worktree"
+
+// NormalizeYAMLParam is set to "true" to strip the comments and blank
+// lines leading each document of the resolved yaml and to separate its
+// documents with plain "---" lines
+const NormalizeYAMLParam string = "normalizeYAML"
+
+// SplitDocumentsParam is set to "true" to annotate the resolved content
+// with the boundaries of each of the yaml documents in it
+const SplitDocumentsParam string = "splitDocuments"
+
+// TagMessageParam is the name of an annotated tag whose message, e.g.
+// a release's changelog, is resolved instead of PathParam
+const TagMessageParam string = "tagMessage"
+
+// TreeHashParam is set to "true" to annotate the resolved file with the
+// hash of its commit's tree, which is the same for commits with
+// identical content regardless of their history
+const TreeHashParam string = "treeHash"
+
+// EnvironmentParam is the name of an environment, e.g. "prod", whose
+// environment tag, ConfigFieldEnvironmentTagPrefix followed by the
+// name, points to the commit to resolve from
+const EnvironmentParam string = "environment"
+
+// boolParams are the params that must parse as booleans when set.
+var boolParams = []string{
+	ResolveIncludesParam,
+	DescribeParam,
+	RejectEmptyParam,
+	ProvenanceParam,
+	NotesParam,
+	LFSParam,
+	TemplateParam,
+	MergeBaseParam,
+	DiffParam,
+	NormalizeYAMLParam,
+	SplitDocumentsParam,
+	DependenciesParam,
+	TrailingNewlineParam,
+	TreeHashParam,
+	HeadOnlyParam,
+}
+
+// parseBoolParam returns the value of a boolean param, which defaults
+// to false when it's not set.
+func parseBoolParam(params map[string]string, name string) (bool, error) {
+	val, ok := params[name]
+	if !ok || val == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("invalid value %q for %q: must be true or false", val, name)
+	}
+	return b, nil
+}
+
+// StrictHostKeyCheckingParam is the policy for ssh hosts that aren't in
+// the known hosts: "strict", the default, to reject them, "accept-new"
+// to accept and remember their key, or "insecure" to skip checking host
+// keys altogether
+const StrictHostKeyCheckingParam string = "strictHostKeyChecking"
+
+// CommitMessageFilterParam is a regular expression. When set the file
+// is fetched from the newest commit on the branch, or the repo's default
+// branch, whose message matches it
+const CommitMessageFilterParam string = "commitMessageFilter"
+
+// ExpectFormatParam is the format, "yaml", "json" or "text", that the
+// file must parse as. When set, files that don't, e.g. because they
+// were truncated or corrupted, fail to resolve with an error locating
+// where they stopped parsing
+const ExpectFormatParam string = "expectFormat"
+
+// HeadOnlyParam is set to "true", without PathParam, to resolve the
+// commit SHA that the branch, or the repo's default branch, points to
+// instead of a file
+const HeadOnlyParam string = "headOnly"
+
+// FetchTagsParam is which tags are fetched with the repo: "all", "none"
+// or "following", those that point into the fetched history. Defaults
+// to "all" when the request compares refs or describes the commit and
+// "none" otherwise
+const FetchTagsParam string = "fetchTags"
+
+// PacEventParam is the event, "push" or "pull_request", to resolve the
+// PipelineRuns in the repo's .tekton directory that Pipelines-as-Code
+// would run for, along with the Tasks and Pipelines there, instead of
+// PathParam
+const PacEventParam string = "pacEvent"
+
+// PacTargetBranchParam is the branch that the PacEventParam event
+// targets, e.g. the base branch of a pull request. Defaults to the
+// branch that's resolved from
+const PacTargetBranchParam string = "pacTargetBranch"
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Defaults for the connection pool of the transport that git-over-http
+// traffic is sent with.
+const (
+	defaultMaxIdleConnsPerHost = 16
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+// poolSettings configure the connections a pooled transport keeps open
+// between requests.
+type poolSettings struct {
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+	// minTLSVersion is the minimum TLS version negotiated, or 0 for
+	// Go's default.
+	minTLSVersion uint16
+}
+
+// defaultPoolSettings are used when neither pool config field is set.
+var defaultPoolSettings = poolSettings{
+	maxIdleConnsPerHost: defaultMaxIdleConnsPerHost,
+	idleConnTimeout:     defaultIdleConnTimeout,
+}
+
+// parsePoolSettings parses ConfigFieldHTTPMaxIdleConnsPerHost,
+// ConfigFieldHTTPIdleConnTimeout and ConfigFieldMinTLSVersion from conf.
+func parsePoolSettings(conf map[string]string) (poolSettings, error) {
+	settings := defaultPoolSettings
+	if val := conf[ConfigFieldHTTPMaxIdleConnsPerHost]; val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 {
+			return poolSettings{}, fmt.Errorf("invalid %q config %q: must be a non-negative integer", ConfigFieldHTTPMaxIdleConnsPerHost, val)
+		}
+		settings.maxIdleConnsPerHost = n
+	}
+	if val := conf[ConfigFieldHTTPIdleConnTimeout]; val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d <= 0 {
+			return poolSettings{}, fmt.Errorf("invalid %q config %q: must be a positive duration", ConfigFieldHTTPIdleConnTimeout, val)
+		}
+		settings.idleConnTimeout = d
+	}
+	minTLSVersion, err := parseMinTLSVersion(conf)
+	if err != nil {
+		return poolSettings{}, err
+	}
+	settings.minTLSVersion = minTLSVersion
+	return settings, nil
+}
+
+// pooledTransports holds a shared transport for each distinct
+// poolSettings, so that connections to a host are reused across
+// resolutions made with the same config.
+var pooledTransports = struct {
+	mu         sync.Mutex
+	transports map[poolSettings]*http.Transport
+}{transports: map[poolSettings]*http.Transport{}}
+
+// pooledTransport returns the shared transport for settings, creating
+// it on first use.
+func pooledTransport(settings poolSettings) *http.Transport {
+	pooledTransports.mu.Lock()
+	defer pooledTransports.mu.Unlock()
+	if t, ok := pooledTransports.transports[settings]; ok {
+		return t
+	}
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	t := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   settings.maxIdleConnsPerHost,
+		IdleConnTimeout:       settings.idleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		TLSClientConfig:       tlsClientConfig(settings.minTLSVersion),
+	}
+	if settings.maxIdleConnsPerHost == 0 {
+		t.DisableKeepAlives = true
+	}
+	pooledTransports.transports[settings] = t
+	return t
+}
@@ -0,0 +1,64 @@
+package git
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+func TestResolveReusesConnections(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		conf          map[string]string
+		expectedConns func(resolves, conns int) bool
+	}{{
+		name: "pooled by default",
+		expectedConns: func(_, conns int) bool {
+			return conns == 1
+		},
+	}, {
+		name: "keep-alives disabled",
+		conf: map[string]string{ConfigFieldHTTPMaxIdleConnsPerHost: "0"},
+		expectedConns: func(resolves, conns int) bool {
+			return conns > resolves
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, _ := createTestRepo(t, []commitForRepo{{
+				Files: map[string]string{"task.yaml": "content"},
+			}})
+			server := newFakeGitHTTPServer(t, repo)
+			ctx := framework.InjectResolverConfigToContext(context.Background(), tc.conf)
+
+			resolver := Resolver{}
+			resolves := 3
+			for i := 0; i < resolves; i++ {
+				if _, err := resolver.Resolve(ctx, map[string]string{
+					URLParam:  server.repoURL(),
+					PathParam: "task.yaml",
+				}); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if conns := server.acceptedConns(); !tc.expectedConns(resolves, conns) {
+				t.Errorf("unexpected number of connections for %d resolves: %d", resolves, conns)
+			}
+		})
+	}
+}
+
+func TestParsePoolSettingsInvalid(t *testing.T) {
+	for _, conf := range []map[string]string{
+		{ConfigFieldHTTPMaxIdleConnsPerHost: "-1"},
+		{ConfigFieldHTTPMaxIdleConnsPerHost: "many"},
+		{ConfigFieldHTTPIdleConnTimeout: "0s"},
+		{ConfigFieldHTTPIdleConnTimeout: "soon"},
+	} {
+		_, err := parsePoolSettings(conf)
+		if err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Errorf("expected error parsing %v, received %v", conf, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// material is an in-toto material: a reference to an artifact, here the
+// resolved file, along with its digests.
+type material struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+// materialURI returns the SPDX style uri identifying path at commit in
+// the repo at url, e.g.
+// git+https://github.com/tektoncd/catalog.git@<commit>#task/foo.yaml.
+func materialURI(url, commit, path string) string {
+	if !strings.HasPrefix(url, "git+") {
+		url = "git+" + url
+	}
+	return url + "@" + commit + "#" + strings.TrimPrefix(path, "/")
+}
+
+// materialAnnotation serializes the material for a file with the given
+// uri and hex-encoded sha256 digest.
+func materialAnnotation(uri, sha256Digest string) string {
+	// Marshalling only strings can't fail.
+	b, _ := json.Marshal(material{
+		URI:    uri,
+		Digest: map[string]string{"sha256": sha256Digest},
+	})
+	return string(b)
+}
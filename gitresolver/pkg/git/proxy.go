@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	"golang.org/x/net/proxy"
+)
+
+const (
+	// ProxySecretKeyUsername is the key in a proxy secret holding the
+	// username to authenticate to the SOCKS5 proxy with.
+	ProxySecretKeyUsername = "username"
+
+	// ProxySecretKeyPassword is the key in a proxy secret holding the
+	// password to authenticate to the SOCKS5 proxy with.
+	ProxySecretKeyPassword = "password"
+)
+
+// proxyURL returns the SOCKS5 proxy a request should be cloned through,
+// taken from ProxyParam or else ConfigFieldProxy, or nil if the repo
+// should be reached directly.
+func proxyURL(ctx context.Context, params map[string]string) (*url.URL, error) {
+	val := strings.TrimSpace(params[ProxyParam])
+	name := ProxyParam
+	if val == "" {
+		val = strings.TrimSpace(framework.GetResolverConfigFromContext(ctx)[ConfigFieldProxy])
+		name = ConfigFieldProxy
+	}
+	if val == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(val)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %q %q: %w", name, val, err)
+	}
+	if u.Scheme != "socks5" && u.Scheme != "socks5h" {
+		return nil, fmt.Errorf("invalid %q %q: only socks5:// proxies are supported", name, val)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid %q %q: missing proxy host", name, val)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("invalid %q %q: proxy credentials must be given in a secret with %q", name, val, ProxySecretParam)
+	}
+	return u, nil
+}
+
+// proxyAuth reads the SOCKS5 credentials in the named secret.
+func (r *Resolver) proxyAuth(ctx context.Context, secretName string) (*proxy.Auth, error) {
+	secret, err := r.getSecret(ctx, secretName)
+	if err != nil {
+		return nil, err
+	}
+	user := string(secret.Data[ProxySecretKeyUsername])
+	if user == "" {
+		return nil, fmt.Errorf("invalid proxy secret %q: missing %s", secretName, ProxySecretKeyUsername)
+	}
+	return &proxy.Auth{
+		User:     user,
+		Password: string(secret.Data[ProxySecretKeyPassword]),
+	}, nil
+}
+
+// proxyTransport returns an http transport that dials every connection
+// through the SOCKS5 proxy described by params, or nil if no proxy is
+// configured. Callers should close its idle connections once done.
+func (r *Resolver) proxyTransport(ctx context.Context, params map[string]string) (*http.Transport, error) {
+	u, err := proxyURL(ctx, params)
+	if err != nil || u == nil {
+		return nil, err
+	}
+	var auth *proxy.Auth
+	if secretName := params[ProxySecretParam]; secretName != "" {
+		if auth, err = r.proxyAuth(ctx, secretName); err != nil {
+			return nil, err
+		}
+	}
+	dialer, err := proxy.SOCKS5("tcp", u.Host, auth, &net.Dialer{})
+	if err != nil {
+		return nil, fmt.Errorf("error creating socks5 dialer for %q: %w", u.Host, err)
+	}
+	contextDialer, ok := dialer.(proxy.ContextDialer)
+	if !ok {
+		return nil, errors.New("socks5 dialer does not support contexts")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = contextDialer.DialContext
+	return transport, nil
+}
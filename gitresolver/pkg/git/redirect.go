@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// redirectPolicyNone rejects every redirect.
+	redirectPolicyNone = "none"
+	// redirectPolicySameHost only follows redirects to the host that
+	// the original request was sent to.
+	redirectPolicySameHost = "same-host"
+	// redirectPolicyAll follows every redirect.
+	redirectPolicyAll = "all"
+)
+
+// defaultMaxRedirects is the number of redirects followed before a
+// request fails, as with net/http's default policy, when
+// ConfigFieldMaxRedirects isn't set.
+const defaultMaxRedirects = 10
+
+// parseRedirectPolicy returns the policy set by ConfigFieldFollowRedirects,
+// defaulting to redirectPolicyAll.
+func parseRedirectPolicy(conf map[string]string) (string, error) {
+	switch policy := strings.TrimSpace(conf[ConfigFieldFollowRedirects]); policy {
+	case "":
+		return redirectPolicyAll, nil
+	case redirectPolicyNone, redirectPolicySameHost, redirectPolicyAll:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid %q config %q: must be %q, %q or %q", ConfigFieldFollowRedirects, policy, redirectPolicyNone, redirectPolicySameHost, redirectPolicyAll)
+	}
+}
+
+// parseMaxRedirects returns the limit set by ConfigFieldMaxRedirects,
+// defaulting to defaultMaxRedirects.
+func parseMaxRedirects(conf map[string]string) (int, error) {
+	val := strings.TrimSpace(conf[ConfigFieldMaxRedirects])
+	if val == "" {
+		return defaultMaxRedirects, nil
+	}
+	limit, err := strconv.Atoi(val)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("invalid %q config %q: must be a non-negative integer", ConfigFieldMaxRedirects, val)
+	}
+	return limit, nil
+}
+
+// checkRedirect is httpClient's CheckRedirect func. It applies the
+// redirect limit and policy of the resolution that req was made for,
+// and fails requests redirected back to a url they've already visited
+// rather than following the loop until the limit is hit.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	policy, limit := redirectPolicyAll, defaultMaxRedirects
+	if rt := requestTransportFromContext(req.Context()); rt != nil {
+		if rt.redirectPolicy != "" {
+			policy = rt.redirectPolicy
+		}
+		limit = rt.maxRedirects
+	}
+	from := via[len(via)-1].URL
+	for _, visited := range via {
+		if visited.URL.String() == req.URL.String() {
+			return fmt.Errorf("%w: %s redirected back to %s", ErrRedirectLoop, from.Redacted(), req.URL.Redacted())
+		}
+	}
+	if len(via) > limit {
+		return fmt.Errorf("%w: stopped after %d redirects at %s", ErrTooManyRedirects, limit, from.Redacted())
+	}
+	switch {
+	case policy == redirectPolicyNone:
+	case policy == redirectPolicySameHost && !strings.EqualFold(req.URL.Host, via[0].URL.Host):
+	default:
+		return nil
+	}
+	return fmt.Errorf("redirect from %s to %s not allowed by %q policy %q", from.Redacted(), req.URL.Redacted(), ConfigFieldFollowRedirects, policy)
+}
@@ -0,0 +1,201 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// redirectTo returns a handler that redirects every request, keeping its
+// method and body, to the same path and query under target once prefix
+// is trimmed from its path.
+func redirectTo(target, prefix string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		location := target + strings.TrimPrefix(r.URL.Path, prefix)
+		if r.URL.RawQuery != "" {
+			location += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, location, http.StatusTemporaryRedirect)
+	}
+}
+
+func TestResolveFollowRedirects(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+		if !strings.HasPrefix(r.URL.Path, "/mirror/") {
+			return false
+		}
+		redirectTo(server.URL, "/mirror")(w, r)
+		return true
+	})
+	otherHost := httptest.NewServer(http.HandlerFunc(redirectTo(server.URL, "")))
+	defer otherHost.Close()
+
+	sameHostURL := server.URL + "/mirror/" + server.repoName
+	crossHostURL := otherHost.URL + "/" + server.repoName
+
+	for _, tc := range []struct {
+		name          string
+		policy        string
+		url           string
+		expectedError string
+	}{{
+		name: "default follows cross-host redirect",
+		url:  crossHostURL,
+	}, {
+		name:   "all follows cross-host redirect",
+		policy: redirectPolicyAll,
+		url:    crossHostURL,
+	}, {
+		name:   "same-host follows same-host redirect",
+		policy: redirectPolicySameHost,
+		url:    sameHostURL,
+	}, {
+		name:          "same-host rejects cross-host redirect",
+		policy:        redirectPolicySameHost,
+		url:           crossHostURL,
+		expectedError: `not allowed by "follow-redirects" policy "same-host"`,
+	}, {
+		name:   "none without redirects",
+		policy: redirectPolicyNone,
+		url:    server.repoURL(),
+	}, {
+		name:          "none rejects same-host redirect",
+		policy:        redirectPolicyNone,
+		url:           sameHostURL,
+		expectedError: `not allowed by "follow-redirects" policy "none"`,
+	}, {
+		name:          "invalid policy",
+		policy:        "some",
+		url:           server.repoURL(),
+		expectedError: `invalid "follow-redirects" config "some"`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+				ConfigFieldFollowRedirects: tc.policy,
+			})
+			resolver := Resolver{}
+			resource, err := resolver.Resolve(ctx, map[string]string{
+				URLParam:  tc.url,
+				PathParam: "task.yaml",
+			})
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, received %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != "content" {
+				t.Errorf("unexpected content %q", resource.Data())
+			}
+		})
+	}
+}
+
+func TestResolveMaxRedirects(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	// /hops/<n>/repo redirects n times before reaching repo, and
+	// /loop/repo redirects to itself.
+	server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/hops/"):
+			rest := strings.TrimPrefix(r.URL.Path, "/hops/")
+			n, path, _ := strings.Cut(rest, "/")
+			hops, err := strconv.Atoi(n)
+			if err != nil {
+				return false
+			}
+			target := server.URL + "/" + path
+			if hops > 1 {
+				target = fmt.Sprintf("%s/hops/%d/%s", server.URL, hops-1, path)
+			}
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusTemporaryRedirect)
+			return true
+		case strings.HasPrefix(r.URL.Path, "/loop/"):
+			http.Redirect(w, r, r.URL.String(), http.StatusTemporaryRedirect)
+			return true
+		}
+		return false
+	})
+
+	for _, tc := range []struct {
+		name          string
+		maxRedirects  string
+		url           string
+		expectedError error
+	}{{
+		name: "chain within default limit",
+		url:  server.URL + "/hops/5/" + server.repoName,
+	}, {
+		name:         "chain at configured limit",
+		maxRedirects: "3",
+		url:          server.URL + "/hops/3/" + server.repoName,
+	}, {
+		name:          "chain exceeding configured limit",
+		maxRedirects:  "3",
+		url:           server.URL + "/hops/4/" + server.repoName,
+		expectedError: ErrTooManyRedirects,
+	}, {
+		name:          "chain exceeding default limit",
+		url:           server.URL + "/hops/11/" + server.repoName,
+		expectedError: ErrTooManyRedirects,
+	}, {
+		name:          "no redirects allowed",
+		maxRedirects:  "0",
+		url:           server.URL + "/hops/1/" + server.repoName,
+		expectedError: ErrTooManyRedirects,
+	}, {
+		name:          "self-referential loop",
+		maxRedirects:  "100",
+		url:           server.URL + "/loop/" + server.repoName,
+		expectedError: ErrRedirectLoop,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+				ConfigFieldMaxRedirects: tc.maxRedirects,
+			})
+			resolver := Resolver{}
+			resource, err := resolver.Resolve(ctx, map[string]string{
+				URLParam:  tc.url,
+				PathParam: "task.yaml",
+			})
+			if tc.expectedError != nil {
+				if !errors.Is(err, tc.expectedError) {
+					t.Fatalf("expected error %v, received %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != "content" {
+				t.Errorf("unexpected content %q", resource.Data())
+			}
+		})
+	}
+}
+
+func TestParseMaxRedirects(t *testing.T) {
+	if _, err := parseMaxRedirects(map[string]string{ConfigFieldMaxRedirects: "-1"}); err == nil || !strings.Contains(err.Error(), `invalid "max-redirects" config "-1"`) {
+		t.Errorf("expected invalid config error, received %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// comparingRefs returns whether params request a file at two refs.
+func comparingRefs(params map[string]string) bool {
+	return params[RefAParam] != "" || params[RefBParam] != ""
+}
+
+// validateRefPair returns an error if RefAParam and RefBParam aren't
+// given together or are combined with params that select a single
+// commit.
+func validateRefPair(params map[string]string) error {
+	if !comparingRefs(params) {
+		for _, p := range []string{MergeBaseParam, DiffParam} {
+			if enabled, _ := parseBoolParam(params, p); enabled {
+				return fmt.Errorf("%q requires %q and %q", p, RefAParam, RefBParam)
+			}
+		}
+		return nil
+	}
+	if params[RefAParam] == "" || params[RefBParam] == "" {
+		return fmt.Errorf("%q and %q must be supplied together", RefAParam, RefBParam)
+	}
+	if resolvingMergeBase(params) && params[PathParam] != "" {
+		return fmt.Errorf("supplied both %q and %q", PathParam, MergeBaseParam)
+	}
+	if resolvingMergeBase(params) && resolvingDiff(params) {
+		return fmt.Errorf("supplied both %q and %q", MergeBaseParam, DiffParam)
+	}
+	for _, p := range []string{CommitParam, BranchParam, AsOfParam, StartLineParam, EndLineParam, ResolveIncludesParam, OutputFormatParam, SchemaParam, WellKnownParam, TemplateParam, BlobParam, NormalizeYAMLParam, WorktreeParam, RevisionParam, TektonBundleDirParam, TagMessageParam, EnvironmentParam, CommitMessageFilterParam} {
+		if params[p] != "" {
+			return fmt.Errorf("supplied both %q and %q", RefAParam, p)
+		}
+	}
+	return nil
+}
+
+// refContent is a file's content at one of the refs it was resolved at.
+type refContent struct {
+	Ref     string `json:"ref"`
+	Commit  string `json:"commit"`
+	Content string `json:"content"`
+}
+
+// refPair is the content of a file at RefAParam and at RefBParam, which
+// is returned as json so that a consumer can reliably separate the two.
+type refPair struct {
+	Path string     `json:"path"`
+	A    refContent `json:"a"`
+	B    refContent `json:"b"`
+}
+
+// resolveRefPair reads the requested file at both RefAParam and
+// RefBParam from co.
+func (r *Resolver) resolveRefPair(co *checkout, params map[string]string) (*ResolvedGitRefPair, error) {
+	path := params[PathParam]
+	pair := refPair{Path: path}
+	for _, side := range []struct {
+		param   string
+		content *refContent
+	}{
+		{RefAParam, &pair.A},
+		{RefBParam, &pair.B},
+	} {
+		ref := params[side.param]
+		commit, err := resolveRef(co.repository, ref)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving %q %q: %w", side.param, ref, err)
+		}
+		content, err := readFileAtCommit(commit, path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %q at %q: %w", path, ref, err)
+		}
+		*side.content = refContent{
+			Ref:     ref,
+			Commit:  commit.Hash.String(),
+			Content: string(content),
+		}
+	}
+	data, err := json.Marshal(pair)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing %q at two refs: %w", path, err)
+	}
+
+	annotations, err := refAnnotations(co, params)
+	if err != nil {
+		return nil, err
+	}
+	return &ResolvedGitRefPair{
+		CommitA:          pair.A.Commit,
+		CommitB:          pair.B.Commit,
+		Content:          data,
+		ExtraAnnotations: annotations,
+	}, nil
+}
+
+// refAnnotations returns the annotations of a resource resolved from
+// co at RefAParam and RefBParam, or TagMessageParam, recording the
+// params it was resolved with and the commits of the tags among them.
+func refAnnotations(co *checkout, params map[string]string) (map[string]string, error) {
+	effective := map[string]string{}
+	for key, val := range params {
+		if val != "" {
+			effective[key] = val
+		}
+	}
+	effective[URLParam] = co.url
+	effectiveParams, err := json.Marshal(effective)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing params: %w", err)
+	}
+	annotations := map[string]string{
+		framework.AnnotationKeyParams: string(effectiveParams),
+	}
+	for key, val := range co.annotations {
+		annotations[key] = val
+	}
+	tagCommits, err := refTagCommits(co.repository, params[RefAParam], params[RefBParam], params[TagMessageParam])
+	if err != nil {
+		return nil, err
+	}
+	if len(tagCommits) > 0 {
+		encoded, err := json.Marshal(tagCommits)
+		if err != nil {
+			return nil, fmt.Errorf("error serializing tag commits: %w", err)
+		}
+		annotations[framework.AnnotationKeyTagCommits] = string(encoded)
+		annotations[framework.AnnotationKeyTagRepository] = co.url
+	}
+	return annotations, nil
+}
+
+// refTagCommits returns the commit that each of refs that names a tag
+// in repository points to, for the reconciler to detect tags that move
+// between resolutions of a request.
+func refTagCommits(repository *git.Repository, refs ...string) (map[string]string, error) {
+	commits := map[string]string{}
+	for _, ref := range refs {
+		if ref == "" {
+			continue
+		}
+		name := plumbing.NewTagReferenceName(ref)
+		if strings.HasPrefix(ref, "refs/") {
+			name = plumbing.ReferenceName(ref)
+		}
+		if !name.IsTag() {
+			continue
+		}
+		reference, err := repository.Reference(name, true)
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading tag %q: %w", ref, err)
+		}
+		commit, err := peelToCommit(repository, reference.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("error reading commit of tag %q: %w", ref, err)
+		}
+		commits[ref] = commit.Hash.String()
+	}
+	return commits, nil
+}
+
+// resolveRef returns the commit that ref, a tag, branch or commit hash,
+// refers to in repository. Tags take precedence over branches of the
+// same name, as they do in git.
+func resolveRef(repository *git.Repository, ref string) (*object.Commit, error) {
+	candidates := []plumbing.ReferenceName{
+		plumbing.NewTagReferenceName(ref),
+		plumbing.NewRemoteReferenceName(git.DefaultRemoteName, ref),
+		plumbing.NewBranchReferenceName(ref),
+	}
+	if strings.HasPrefix(ref, "refs/") {
+		candidates = []plumbing.ReferenceName{plumbing.ReferenceName(ref)}
+	}
+	for _, name := range candidates {
+		reference, err := repository.Reference(name, true)
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return peelToCommit(repository, reference.Hash())
+	}
+	if plumbing.IsHash(ref) {
+		commit, err := repository.CommitObject(plumbing.NewHash(ref))
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			return nil, ErrCommitNotFound
+		}
+		return commit, err
+	}
+	return nil, errors.New("no tag, branch or commit by that name")
+}
+
+// peelToCommit returns the commit hash refers to, following annotated
+// tags.
+func peelToCommit(repository *git.Repository, hash plumbing.Hash) (*object.Commit, error) {
+	tag, err := repository.TagObject(hash)
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return repository.CommitObject(hash)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tag.Commit()
+}
+
+// readFileAtCommit returns the content of the file at path in commit's
+// tree.
+func readFileAtCommit(commit *object.Commit, path string) ([]byte, error) {
+	file, err := commit.File(strings.TrimPrefix(path, "/"))
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
+
+// ResolvedGitRefPair implements framework.ResolvedResource and returns
+// the content of a file at two refs, as json.
+type ResolvedGitRefPair struct {
+	CommitA string
+	CommitB string
+	Content []byte
+	// ExtraAnnotations are any additional annotations recorded
+	// while the file was resolved.
+	ExtraAnnotations map[string]string
+}
+
+var _ framework.ResolvedResource = &ResolvedGitRefPair{}
+
+// Data returns the file's content at both refs.
+func (r *ResolvedGitRefPair) Data() []byte {
+	return r.Content
+}
+
+// Annotations returns the metadata that accompanies the file fetched
+// at two refs, including the commits each ref resolved to.
+func (r *ResolvedGitRefPair) Annotations() map[string]string {
+	digest := sha256.Sum256(r.Content)
+	annotations := map[string]string{}
+	for key, val := range r.ExtraAnnotations {
+		annotations[key] = val
+	}
+	annotations[AnnotationKeyRefACommit] = r.CommitA
+	annotations[AnnotationKeyRefBCommit] = r.CommitB
+	annotations[AnnotationKeyContentSHA256] = hex.EncodeToString(digest[:])
+	annotations[resolutioncommon.AnnotationKeyContentType] = JSONContentType
+	return annotations
+}
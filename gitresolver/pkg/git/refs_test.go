@@ -0,0 +1,155 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+func TestResolveRefPair(t *testing.T) {
+	repo, hashes := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "version: 1\n"},
+		Tag:   "v1",
+	}, {
+		Files:      map[string]string{"task.yaml": "version: 2\n"},
+		Tag:        "v2",
+		TagMessage: "release v2",
+	}, {
+		Files: map[string]string{"task.yaml": "version: 3\n"},
+	}})
+	r, err := git.PlainOpen(repo)
+	if err != nil {
+		t.Fatalf("error opening test repo: %v", err)
+	}
+	if err := r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("release"), plumbing.NewHash(hashes[1]))); err != nil {
+		t.Fatalf("error creating branch: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		refA     string
+		refB     string
+		expected refPair
+	}{{
+		name: "two tags",
+		refA: "v1",
+		refB: "v2",
+		expected: refPair{
+			Path: "task.yaml",
+			A:    refContent{Ref: "v1", Commit: hashes[0], Content: "version: 1\n"},
+			B:    refContent{Ref: "v2", Commit: hashes[1], Content: "version: 2\n"},
+		},
+	}, {
+		name: "two branches",
+		refA: "release",
+		refB: "master",
+		expected: refPair{
+			Path: "task.yaml",
+			A:    refContent{Ref: "release", Commit: hashes[1], Content: "version: 2\n"},
+			B:    refContent{Ref: "master", Commit: hashes[2], Content: "version: 3\n"},
+		},
+	}, {
+		name: "branch and commit",
+		refA: hashes[0],
+		refB: "master",
+		expected: refPair{
+			Path: "task.yaml",
+			A:    refContent{Ref: hashes[0], Commit: hashes[0], Content: "version: 1\n"},
+			B:    refContent{Ref: "master", Commit: hashes[2], Content: "version: 3\n"},
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := Resolver{}
+			params := map[string]string{
+				URLParam:  repo,
+				PathParam: "task.yaml",
+				RefAParam: tc.refA,
+				RefBParam: tc.refB,
+			}
+			if err := resolver.ValidateParams(context.Background(), params); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			resource, err := resolver.Resolve(context.Background(), params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			pair := refPair{}
+			if err := json.Unmarshal(resource.Data(), &pair); err != nil {
+				t.Fatalf("invalid resolved content %s: %v", resource.Data(), err)
+			}
+			if pair != tc.expected {
+				t.Errorf("expected %+v, received %+v", tc.expected, pair)
+			}
+			annotations := resource.Annotations()
+			if annotations[AnnotationKeyRefACommit] != tc.expected.A.Commit || annotations[AnnotationKeyRefBCommit] != tc.expected.B.Commit {
+				t.Errorf("expected ref commit annotations %s and %s, received %s and %s", tc.expected.A.Commit, tc.expected.B.Commit, annotations[AnnotationKeyRefACommit], annotations[AnnotationKeyRefBCommit])
+			}
+		})
+	}
+}
+
+func TestValidateRefPair(t *testing.T) {
+	for _, params := range []map[string]string{
+		{RefAParam: "v1"},
+		{RefBParam: "v2"},
+		{RefAParam: "v1", RefBParam: "v2", BranchParam: "main"},
+		{RefAParam: "v1", RefBParam: "v2", CommitParam: "abc"},
+	} {
+		params[URLParam] = "https://example.com/repo.git"
+		params[PathParam] = "task.yaml"
+		resolver := Resolver{}
+		if err := resolver.ValidateParams(context.Background(), params); err == nil {
+			t.Errorf("expected params %v to be rejected", params)
+		}
+	}
+}
+
+func TestResolveRecordsTagCommits(t *testing.T) {
+	repo, hashes := createTestRepo(t, []commitForRepo{{
+		Files:      map[string]string{"task.yaml": "version: 1\n"},
+		Tag:        "v1",
+		TagMessage: "Release v1\n",
+	}, {
+		Files: map[string]string{"task.yaml": "version: 2\n"},
+		Tag:   "v2",
+	}})
+	for _, tc := range []struct {
+		name     string
+		params   map[string]string
+		expected string
+	}{{
+		name:     "compared tags",
+		params:   map[string]string{RefAParam: "v1", RefBParam: "refs/tags/v2", PathParam: "task.yaml"},
+		expected: `{"refs/tags/v2":"` + hashes[1] + `","v1":"` + hashes[0] + `"}`,
+	}, {
+		name:     "tag and branch",
+		params:   map[string]string{RefAParam: "v1", RefBParam: "master", MergeBaseParam: "true"},
+		expected: `{"v1":"` + hashes[0] + `"}`,
+	}, {
+		name:     "tag message",
+		params:   map[string]string{TagMessageParam: "v1"},
+		expected: `{"v1":"` + hashes[0] + `"}`,
+	}, {
+		name:   "no tags",
+		params: map[string]string{RefAParam: hashes[0], RefBParam: "master", PathParam: "task.yaml"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.params[URLParam] = repo
+			resolver := Resolver{}
+			resource, err := resolver.Resolve(context.Background(), tc.params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := resource.Annotations()[framework.AnnotationKeyTagCommits]; got != tc.expected {
+				t.Errorf("expected tag commits %q but got %q", tc.expected, got)
+			}
+			if got, tagged := resource.Annotations()[framework.AnnotationKeyTagRepository], tc.expected != ""; tagged != (got == repo) {
+				t.Errorf("expected tag repository %q only with tag commits but got %q", repo, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// readReplica maps the clone urls under a primary url prefix to the
+// same paths under a read replica's url prefix.
+type readReplica struct {
+	primary string
+	replica string
+}
+
+// parseReadReplicas parses the comma- or newline-separated
+// <primary>=<replica> url prefix pairs in ConfigFieldReadReplicas.
+func parseReadReplicas(conf map[string]string) ([]readReplica, error) {
+	val := strings.TrimSpace(conf[ConfigFieldReadReplicas])
+	if val == "" {
+		return nil, nil
+	}
+	var replicas []readReplica
+	for _, field := range strings.FieldsFunc(val, func(r rune) bool { return r == ',' || r == '\n' }) {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 || normalizeRepoURL(parts[0]) == "" || normalizeRepoURL(parts[1]) == "" {
+			return nil, fmt.Errorf("invalid %q config %q: %q must be of the form <primary url>=<replica url>", ConfigFieldReadReplicas, val, field)
+		}
+		replicas = append(replicas, readReplica{
+			primary: normalizeRepoURL(parts[0]),
+			replica: normalizeRepoURL(parts[1]),
+		})
+	}
+	return replicas, nil
+}
+
+// replicaURL returns the read replica url that repo, an expanded clone
+// url, should be cloned from or "" if no replica is configured for it.
+// The longest matching primary prefix wins.
+func replicaURL(replicas []readReplica, repo string) string {
+	match := -1
+	for i, r := range replicas {
+		if repo != r.primary && !strings.HasPrefix(repo, r.primary+"/") {
+			continue
+		}
+		if match == -1 || len(r.primary) > len(replicas[match].primary) {
+			match = i
+		}
+	}
+	if match == -1 {
+		return ""
+	}
+	return replicas[match].replica + strings.TrimPrefix(repo, replicas[match].primary)
+}
+
+// cloneFromReplicaOrPrimary clones the repo described by params from
+// its ConfigFieldReadReplicas replica when one is configured, falling
+// back to the primary url if the replica hasn't caught up with the
+// requested commit yet. The checkout otherwise describes the primary,
+// with the url that served it recorded in AnnotationKeyServedBy.
+func (r *Resolver) cloneFromReplicaOrPrimary(ctx context.Context, params map[string]string) (*checkout, error) {
+	replicas, err := parseReadReplicas(framework.GetResolverConfigFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	primary, err := expandRepoURL(normalizeRepoURL(params[URLParam]))
+	if err != nil {
+		return nil, err
+	}
+	replica := replicaURL(replicas, primary)
+	if replica == "" {
+		return r.cloneAndCheckout(ctx, params)
+	}
+
+	replicaParams := make(map[string]string, len(params))
+	for k, v := range params {
+		replicaParams[k] = v
+	}
+	replicaParams[URLParam] = replica
+	co, err := r.cloneAndCheckout(ctx, replicaParams)
+	switch {
+	case err == nil:
+		delete(co.annotations, AnnotationKeyExpandedURL)
+		if primary != normalizeRepoURL(params[URLParam]) {
+			co.annotations[AnnotationKeyExpandedURL] = primary
+		}
+		co.annotations[AnnotationKeyServedBy] = co.url
+		co.url = primary
+		return co, nil
+	case !errors.Is(err, ErrCommitNotFound):
+		return nil, fmt.Errorf("error cloning from read replica %q: %w", replica, err)
+	}
+
+	co, err = r.cloneAndCheckout(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	co.annotations[AnnotationKeyServedBy] = co.url
+	return co, nil
+}
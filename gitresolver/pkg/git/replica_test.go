@@ -0,0 +1,98 @@
+package git
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+func TestParseReadReplicas(t *testing.T) {
+	replicas, err := parseReadReplicas(map[string]string{
+		ConfigFieldReadReplicas: "https://github.com/=https://mirror.example.com/github\n https://github.com/tektoncd = https://tekton-mirror.example.com ,",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tc := range []struct {
+		repo     string
+		expected string
+	}{{
+		repo:     "https://github.com/org/repo",
+		expected: "https://mirror.example.com/github/org/repo",
+	}, {
+		repo:     "https://github.com/tektoncd/catalog",
+		expected: "https://tekton-mirror.example.com/catalog",
+	}, {
+		repo:     "https://github.com/tektoncd-extra/repo",
+		expected: "https://mirror.example.com/github/tektoncd-extra/repo",
+	}, {
+		repo:     "https://gitlab.com/org/repo",
+		expected: "",
+	}} {
+		if replica := replicaURL(replicas, tc.repo); replica != tc.expected {
+			t.Errorf("expected %q to be cloned from %q, received %q", tc.repo, tc.expected, replica)
+		}
+	}
+
+	for _, val := range []string{"https://github.com", "=https://mirror.example.com", "https://github.com="} {
+		if _, err := parseReadReplicas(map[string]string{ConfigFieldReadReplicas: val}); err == nil {
+			t.Errorf("expected an error parsing %q", val)
+		}
+	}
+}
+
+func TestResolveFromReadReplica(t *testing.T) {
+	first := commitForRepo{Files: map[string]string{"task.yaml": "first"}}
+	second := commitForRepo{Files: map[string]string{"task.yaml": "second"}}
+	// The replica lags behind the primary by one commit.
+	primary, commits := createTestRepo(t, []commitForRepo{first, second})
+	replica, _ := createTestRepo(t, []commitForRepo{first})
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldReadReplicas: primary + "=" + replica,
+	})
+
+	for _, tc := range []struct {
+		name     string
+		commit   string
+		content  string
+		servedBy string
+	}{{
+		name:     "replica has commit",
+		commit:   commits[0],
+		content:  "first",
+		servedBy: replica,
+	}, {
+		name:     "replica lags behind",
+		commit:   commits[1],
+		content:  "second",
+		servedBy: primary,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := Resolver{}
+			resource, err := resolver.Resolve(ctx, map[string]string{
+				URLParam:        primary,
+				PathParam:       "task.yaml",
+				CommitParam:     tc.commit,
+				ProvenanceParam: "true",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.content {
+				t.Errorf("expected content %q, received %q", tc.content, resource.Data())
+			}
+			annotations := resource.Annotations()
+			if annotations[AnnotationKeyServedBy] != tc.servedBy {
+				t.Errorf("expected to be served by %q, received %q", tc.servedBy, annotations[AnnotationKeyServedBy])
+			}
+			if _, ok := annotations[AnnotationKeyExpandedURL]; ok {
+				t.Errorf("unexpected %s annotation %q", AnnotationKeyExpandedURL, annotations[AnnotationKeyExpandedURL])
+			}
+			if !strings.Contains(annotations[AnnotationKeyMaterial], "git+"+primary+"@") {
+				t.Errorf("expected material to describe the primary, received %s", annotations[AnnotationKeyMaterial])
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// maxConcurrentClonesPerRepo parses ConfigFieldMaxConcurrentClonesPerRepo
+// from conf, returning 0 if it isn't set.
+func maxConcurrentClonesPerRepo(conf map[string]string) (int64, error) {
+	val := strings.TrimSpace(conf[ConfigFieldMaxConcurrentClonesPerRepo])
+	if val == "" {
+		return 0, nil
+	}
+	size, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid %q config %q: must be a non-negative integer", ConfigFieldMaxConcurrentClonesPerRepo, val)
+	}
+	return size, nil
+}
+
+// repoSemaphore bounds the clones of a single repo in flight.
+type repoSemaphore struct {
+	size int64
+	sem  *semaphore.Weighted
+	// holders is the number of clones holding or waiting for a slot,
+	// so the semaphore can be dropped once the repo is idle.
+	holders int
+}
+
+// repoLimiter caps the number of concurrent clones of each repo, so a
+// burst of requests for one repo can't take every clone slot while
+// requests for other repos starve. The zero value is ready to use.
+type repoLimiter struct {
+	mu    sync.Mutex
+	repos map[string]*repoSemaphore
+}
+
+// acquire waits until a clone of repo is allowed under a cap of size,
+// or ctx is done. It returns a func that releases the slot. A size of 0
+// means no cap.
+func (l *repoLimiter) acquire(ctx context.Context, repo string, size int64) (func(), error) {
+	if size <= 0 {
+		return func() {}, nil
+	}
+	l.mu.Lock()
+	if l.repos == nil {
+		l.repos = map[string]*repoSemaphore{}
+	}
+	// A semaphore is replaced when its configured size changes;
+	// clones holding the old one release it as normal.
+	rs, ok := l.repos[repo]
+	if !ok || rs.size != size {
+		rs = &repoSemaphore{size: size, sem: semaphore.NewWeighted(size)}
+		l.repos[repo] = rs
+	}
+	rs.holders++
+	l.mu.Unlock()
+
+	done := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		rs.holders--
+		if rs.holders == 0 && l.repos[repo] == rs {
+			delete(l.repos, repo)
+		}
+	}
+	if err := rs.sem.Acquire(ctx, 1); err != nil {
+		done()
+		return nil, fmt.Errorf("error waiting for one of the %d clone slots of %s: %w", size, repo, err)
+	}
+	return func() {
+		rs.sem.Release(1)
+		done()
+	}, nil
+}
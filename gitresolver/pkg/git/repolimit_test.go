@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+func TestResolveLimitsConcurrentClonesPerRepo(t *testing.T) {
+	busyDir, _ := createTestRepo(t, []commitForRepo{{Files: map[string]string{"task.yaml": "kind: Task\n"}}})
+	busy := newFakeGitHTTPServer(t, busyDir)
+	otherDir, _ := createTestRepo(t, []commitForRepo{{Files: map[string]string{"task.yaml": "kind: Task\n"}}})
+	other := newFakeGitHTTPServer(t, otherDir)
+
+	// Clones of the busy repo hang until unblock is closed.
+	var mu sync.Mutex
+	clones := 0
+	started := make(chan struct{}, 16)
+	unblock := make(chan struct{})
+	busy.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+		if strings.HasSuffix(r.URL.Path, "/info/refs") {
+			mu.Lock()
+			clones++
+			mu.Unlock()
+			started <- struct{}{}
+			<-unblock
+		}
+		return false
+	})
+	busyClones := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return clones
+	}
+
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldMaxConcurrentClonesPerRepo: "1",
+	})
+	resolver := &Resolver{}
+	resolve := func(ctx context.Context, url string) error {
+		_, err := resolver.Resolve(ctx, map[string]string{
+			URLParam:  url,
+			PathParam: "task.yaml",
+		})
+		return err
+	}
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() { errs <- resolve(ctx, busy.repoURL()) }()
+	}
+	<-started
+
+	if err := resolve(ctx, other.repoURL()); err != nil {
+		t.Fatalf("expected other repo to resolve while the busy repo is at its cap, received %v", err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	if err := resolve(waitCtx, busy.repoURL()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected waiting for a clone slot to respect the context, received %v", err)
+	}
+	if n := busyClones(); n != 1 {
+		t.Errorf("expected only 1 clone of the busy repo to have started, received %d", n)
+	}
+
+	close(unblock)
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("unexpected error resolving from busy repo: %v", err)
+		}
+	}
+}
+
+func TestMaxConcurrentClonesPerRepo(t *testing.T) {
+	for _, tc := range []struct {
+		val         string
+		expected    int64
+		expectedErr bool
+	}{
+		{val: "", expected: 0},
+		{val: "0", expected: 0},
+		{val: "4", expected: 4},
+		{val: "-1", expectedErr: true},
+		{val: "lots", expectedErr: true},
+	} {
+		size, err := maxConcurrentClonesPerRepo(map[string]string{ConfigFieldMaxConcurrentClonesPerRepo: tc.val})
+		if tc.expectedErr {
+			if err == nil {
+				t.Errorf("expected error parsing %q", tc.val)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %v", tc.val, err)
+		}
+		if size != tc.expected {
+			t.Errorf("expected %q to parse as %d, received %d", tc.val, tc.expected, size)
+		}
+	}
+}
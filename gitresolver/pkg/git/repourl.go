@@ -0,0 +1,59 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// shortURLHosts are the git hosts whose repos may be given as a url
+// without a scheme, e.g. github.com/tektoncd/catalog, which is expanded
+// to an https clone url.
+var shortURLHosts = []string{
+	"github.com",
+	"gitlab.com",
+	"bitbucket.org",
+}
+
+// expandRepoURL returns the clone url for url, which must already be
+// normalized. Urls with a scheme, scp-like ssh urls and local paths are
+// returned unchanged, while scheme-less urls of repos on one of the
+// shortURLHosts are expanded to https urls. Any other scheme-less url
+// with a path, e.g. org/repo, is ambiguous and rejected. Relative local
+// paths with more than one component must start with "./".
+func expandRepoURL(url string) (string, error) {
+	if strings.Contains(url, "://") || filepath.IsAbs(url) || strings.HasPrefix(url, ".") || !strings.Contains(url, "/") {
+		return url, nil
+	}
+	// scp-like ssh urls, e.g. git@github.com:tektoncd/catalog.git.
+	if colon := strings.Index(url, ":"); colon > 0 && !strings.Contains(url[:colon], "/") {
+		return url, nil
+	}
+	parts := strings.Split(url, "/")
+	for _, host := range shortURLHosts {
+		if !strings.EqualFold(parts[0], host) {
+			continue
+		}
+		if len(parts) < 3 || parts[1] == "" || parts[2] == "" {
+			return "", fmt.Errorf("invalid %q %q: must name a repo, e.g. %s/<org>/<repo>", URLParam, url, host)
+		}
+		return "https://" + url, nil
+	}
+	return "", fmt.Errorf("invalid %q %q: must include a scheme such as https://, unless it's a repo on one of %s", URLParam, url, strings.Join(shortURLHosts, ", "))
+}
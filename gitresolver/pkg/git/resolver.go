@@ -19,17 +19,27 @@ package git
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/memfs"
 	git "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/storage/memory"
 	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
 	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	"k8s.io/client-go/kubernetes"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
 )
 
 // LabelValueGitResolverType is the value to use for the
@@ -43,13 +53,34 @@ const GitResolverName string = "Git"
 // YAMLContentType is the content type to use when returning yaml
 const YAMLContentType string = "application/x-yaml"
 
+// JSONContentType is the content type to use when returning json
+const JSONContentType string = "application/json"
+
+// TextContentType is the content type to use when returning plain text
+const TextContentType string = "text/plain"
+
+// DiffContentType is the content type to use when returning a unified
+// diff
+const DiffContentType string = "text/x-diff"
+
 var _ framework.Resolver = &Resolver{}
 
 // Resolver implements a framework.Resolver that can fetch files from git.
-type Resolver struct{}
+type Resolver struct {
+	kubeClientSet   kubernetes.Interface
+	githubAppTokens githubAppTokenCache
+	defaultBranches defaultBranchCache
+	staleResults    staleResultCache
+	results         resultCache
+	repoClones      repoLimiter
+	hostKeys        acceptedHostKeys
+	httpResponses   httpResponseCache
+	negotiators     negotiatorCache
+}
 
 // Initialize performs any setup required by the gitresolver.
 func (r *Resolver) Initialize(ctx context.Context) error {
+	r.kubeClientSet = kubeclient.Get(ctx)
 	return nil
 }
 
@@ -69,10 +100,12 @@ func (r *Resolver) GetSelector(_ context.Context) map[string]string {
 
 // ValidateParams returns an error if the given parameter map is not
 // valid for a resource request targeting the gitresolver.
-func (r *Resolver) ValidateParams(_ context.Context, params map[string]string) error {
+func (r *Resolver) ValidateParams(ctx context.Context, params map[string]string) error {
 	required := []string{
 		URLParam,
-		PathParam,
+	}
+	if resolvingPath(params) {
+		required = append(required, PathParam)
 	}
 	missing := []string{}
 	if params == nil {
@@ -89,34 +122,524 @@ func (r *Resolver) ValidateParams(_ context.Context, params map[string]string) e
 		return fmt.Errorf("missing %v", strings.Join(missing, ", "))
 	}
 
-	if params[CommitParam] != "" && params[BranchParam] != "" {
-		return fmt.Errorf("supplied both %q and %q", CommitParam, BranchParam)
+	if err := validateWellKnown(params); err != nil {
+		return err
+	}
+
+	if err := validateBlob(params); err != nil {
+		return err
+	}
+
+	if err := validateWorktree(params); err != nil {
+		return err
+	}
+
+	if err := validateExpectedDigest(params); err != nil {
+		return err
+	}
+
+	if err := validateRefSelectors(params); err != nil {
+		return err
+	}
+
+	if revision := params[RevisionParam]; revision != "" {
+		if _, _, err := parseRevision(revision); err != nil {
+			return err
+		}
+	}
+
+	if err := validateTektonBundleDir(params); err != nil {
+		return err
+	}
+
+	if err := validatePacEvent(params); err != nil {
+		return err
+	}
+
+	if err := validateEnvironment(params); err != nil {
+		return err
+	}
+
+	if err := validateCommitMessageFilter(params); err != nil {
+		return err
+	}
+
+	if err := validateTagMessage(params); err != nil {
+		return err
+	}
+
+	if err := validateHeadOnly(params); err != nil {
+		return err
+	}
+
+	if err := validateFetchTags(params); err != nil {
+		return err
+	}
+
+	if err := validateStrictHostKeyChecking(params); err != nil {
+		return err
+	}
+
+	if resolvingPath(params) {
+		if err := validatePath(params[PathParam]); err != nil {
+			return err
+		}
+	}
+
+	if params[GitHubAppSecretParam] != "" && params[BearerTokenSecretParam] != "" {
+		return fmt.Errorf("supplied both %q and %q", GitHubAppSecretParam, BearerTokenSecretParam)
+	}
+
+	if asOf := params[AsOfParam]; asOf != "" {
+		if _, err := time.Parse(time.RFC3339, asOf); err != nil {
+			return fmt.Errorf("invalid %q: must be an RFC3339 timestamp: %w", AsOfParam, err)
+		}
+	}
+
+	for _, p := range boolParams {
+		if _, err := parseBoolParam(params, p); err != nil {
+			return err
+		}
+	}
+
+	if _, _, err := parseLineRange(params); err != nil {
+		return err
+	}
+	switch mode := framework.GetResolverConfigFromContext(ctx)[ConfigFieldLineRangeMode]; mode {
+	case "", lineRangeModeClamp, lineRangeModeError:
+	default:
+		return fmt.Errorf("invalid %q config %q: must be %q or %q", ConfigFieldLineRangeMode, mode, lineRangeModeClamp, lineRangeModeError)
+	}
+
+	if _, err := resultCacheTTL(framework.GetResolverConfigFromContext(ctx)); err != nil {
+		return err
 	}
 
-	// TODO(sbwsg): validate repo url is well-formed, git:// or https://
-	// TODO(sbwsg): validate path is valid relative path
+	if _, err := maxConcurrentClonesPerRepo(framework.GetResolverConfigFromContext(ctx)); err != nil {
+		return err
+	}
+
+	if err := validateRefPair(params); err != nil {
+		return err
+	}
+
+	if err := validateOutputFormat(params); err != nil {
+		return err
+	}
+
+	if err := validateSplitDocuments(params); err != nil {
+		return err
+	}
+
+	if err := validateExpectFormat(params); err != nil {
+		return err
+	}
+
+	if err := validateSchemaParam(params); err != nil {
+		return err
+	}
+
+	if err := validateTemplateParams(params); err != nil {
+		return err
+	}
+
+	if _, err := proxyURL(ctx, params); err != nil {
+		return err
+	}
+
+	repo, err := expandRepoURL(normalizeRepoURL(params[URLParam]))
+	if err != nil {
+		return err
+	}
+	if err := validateScheme(repo); err != nil {
+		return err
+	}
+	if err := checkGitProtocol(framework.GetResolverConfigFromContext(ctx), repo); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// resolvingPath returns whether params resolve the file at PathParam,
+// rather than a well-known file, blob, Tekton bundle, Pipelines-as-Code
+// config, merge-base, tag message or only the commit in its place.
+func resolvingPath(params map[string]string) bool {
+	return params[WellKnownParam] == "" && params[BlobParam] == "" && params[TektonBundleDirParam] == "" && params[PacEventParam] == "" && params[TagMessageParam] == "" && !resolvingMergeBase(params) && !resolvingHead(params)
+}
 
+// validatePath returns an error if path can't name a file in a repo,
+// e.g. because it's only whitespace or directory separators.
+func validatePath(path string) error {
+	if strings.TrimSpace(path) == "" {
+		return fmt.Errorf("invalid %q: must not be empty or whitespace", PathParam)
+	}
+	if strings.Trim(path, "/\\ \t") == "" {
+		return fmt.Errorf("invalid %q %q: must name a file, not only directory separators", PathParam, path)
+	}
 	return nil
 }
 
 // Resolve performs the work of fetching a file from git given a map of
-// parameters.
-func (r *Resolver) Resolve(_ context.Context, params map[string]string) (framework.ResolvedResource, error) {
-	repo := params[URLParam]
+// parameters. Requests for repos whose url has a scheme registered with
+// RegisterScheme are resolved by its handler instead.
+func (r *Resolver) Resolve(ctx context.Context, params map[string]string) (framework.ResolvedResource, error) {
+	if handler, ok := customSchemeHandler(params); ok {
+		return handler.Resolve(withResolver(ctx, r), params)
+	}
+	return r.resolveWithGit(ctx, params)
+}
+
+// resolveWithGit fetches the file that params request by cloning its
+// repo with git.
+func (r *Resolver) resolveWithGit(ctx context.Context, params map[string]string) (framework.ResolvedResource, error) {
+	if cached, ok := r.cachedResult(ctx, params); ok {
+		return cached, nil
+	}
+	co, err := r.checkout(ctx, params)
+	if err != nil {
+		return r.withStaleFallback(ctx, params, nil, err)
+	}
+	resource, err := r.resolveFromCheckout(ctx, co, params)
+	resource, err = r.withStaleFallback(ctx, params, resource, err)
+	return r.cacheResult(ctx, params, resource, err)
+}
+
+// resolveFromCheckout reads the file that params request from co and
+// checks it against any ExpectedDigestParam.
+func (r *Resolver) resolveFromCheckout(ctx context.Context, co *checkout, params map[string]string) (framework.ResolvedResource, error) {
+	resource, err := r.resolveResource(ctx, co, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyDigest(resource.Data(), params[ExpectedDigestParam]); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// resolveResource reads the file, tag message, merge-base, diff, pair
+// of files or commit that params request from co.
+func (r *Resolver) resolveResource(ctx context.Context, co *checkout, params map[string]string) (framework.ResolvedResource, error) {
+	if resolvingHead(params) {
+		head, err := r.resolveHead(co, params)
+		if err != nil {
+			return nil, err
+		}
+		return head, nil
+	}
+	if params[TagMessageParam] != "" {
+		tagMessage, err := r.resolveTagMessage(ctx, co, params)
+		if err != nil {
+			return nil, err
+		}
+		return tagMessage, nil
+	}
+	if resolvingMergeBase(params) {
+		mergeBase, err := r.resolveMergeBase(co, params)
+		if err != nil {
+			return nil, err
+		}
+		return mergeBase, nil
+	}
+	if resolvingDiff(params) {
+		diff, err := r.resolveDiff(co, params)
+		if err != nil {
+			return nil, err
+		}
+		return diff, nil
+	}
+	if comparingRefs(params) {
+		pair, err := r.resolveRefPair(co, params)
+		if err != nil {
+			return nil, err
+		}
+		return pair, nil
+	}
+	resource, err := r.resolveFile(ctx, co, params)
+	if err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// checkout is a clone of a repo that has been checked out at the commit
+// a request resolves to.
+type checkout struct {
+	repository *git.Repository
+	filesystem billy.Filesystem
+	// url is the normalized url the repo was cloned from.
+	url string
+	// branch is the requested branch or, when neither a branch nor a
+	// commit was requested, the repo's default branch.
+	branch string
+	commit string
+	// annotations are recorded while cloning and apply to every
+	// file resolved from the checkout.
+	annotations map[string]string
+	// auth and transport are what the repo was cloned with, for
+	// fetching Git LFS objects from the same host.
+	auth      transport.AuthMethod
+	transport *requestTransport
+}
+
+// commitPropagationPollInterval is how often a missing commit is looked
+// for again during ConfigFieldCommitPropagationGrace.
+var commitPropagationPollInterval = 2 * time.Second
+
+// checkout clones the repo described by params and checks out the
+// requested commit. When the commit is requested by hash and
+// ConfigFieldCommitPropagationGrace is set, a missing commit is looked
+// for again until the grace period ends, in case it's only just been
+// pushed and hasn't reached every replica of the git host yet. Every
+// retry made while cloning shares a single ConfigFieldRetryBudget. Repos
+// with a ConfigFieldReadReplicas replica are cloned from it when it has
+// the requested commit. A WorktreeParam is resolved to the commit its
+// worktree has checked out and a RevisionParam clones the branch it's
+// relative to.
+func (r *Resolver) checkout(ctx context.Context, params map[string]string) (*checkout, error) {
+	conf := framework.GetResolverConfigFromContext(ctx)
+	grace, err := commitPropagationGrace(conf)
+	if err != nil {
+		return nil, err
+	}
+	retries, err := parseRetryBudget(conf)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withRetryBudget(ctx, newRetryBudget(ctx, retries))
+	if params, err = worktreeParams(params); err != nil {
+		return nil, err
+	}
+	if params, err = revisionParams(params); err != nil {
+		return nil, err
+	}
+	co, err := r.cloneFromReplicaOrPrimary(ctx, params)
+	if grace == 0 || params[CommitParam] == "" || !errors.Is(err, ErrCommitNotFound) {
+		return co, err
+	}
+	deadline := time.Now().Add(grace)
+	for time.Now().Before(deadline) {
+		timer := time.NewTimer(commitPropagationPollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		co, err = r.cloneFromReplicaOrPrimary(ctx, params)
+		if !errors.Is(err, ErrCommitNotFound) {
+			return co, err
+		}
+	}
+	return nil, fmt.Errorf("still missing after waiting %s for it to propagate: %w", grace, err)
+}
+
+// commitPropagationGrace parses ConfigFieldCommitPropagationGrace from
+// conf, returning 0 if it isn't set.
+func commitPropagationGrace(conf map[string]string) (time.Duration, error) {
+	val, ok := conf[ConfigFieldCommitPropagationGrace]
+	if !ok || val == "" {
+		return 0, nil
+	}
+	grace, err := time.ParseDuration(val)
+	if err != nil || grace < 0 {
+		return 0, fmt.Errorf("invalid %q config %q: must be a non-negative duration", ConfigFieldCommitPropagationGrace, val)
+	}
+	return grace, nil
+}
+
+// cloneAndCheckout clones the repo described by params and checks out the
+// requested commit, or the tip of the requested branch or HEAD.
+func (r *Resolver) cloneAndCheckout(ctx context.Context, params map[string]string) (*checkout, error) {
+	repo, err := expandRepoURL(normalizeRepoURL(params[URLParam]))
+	if err != nil {
+		return nil, err
+	}
+	conf := framework.GetResolverConfigFromContext(ctx)
+	if err := checkGitProtocol(conf, repo); err != nil {
+		return nil, err
+	}
+	maxClones, err := maxConcurrentClonesPerRepo(conf)
+	if err != nil {
+		return nil, err
+	}
+	release, err := r.repoClones.acquire(ctx, repo, maxClones)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	co, err := r.cloneAndCheckoutOnce(ctx, repo, params)
+	var stale *staleDefaultBranchError
+	if errors.As(err, &stale) {
+		// The repo's default branch has changed since it was cached,
+		// so look it up again. The clone slot is still held, so it's
+		// retried here rather than by calling cloneAndCheckout again.
+		r.defaultBranches.forget(repo)
+		co, err = r.cloneAndCheckoutOnce(ctx, repo, params)
+	}
+	return co, err
+}
+
+// staleDefaultBranchError is a failure to clone a repo's default branch
+// that was looked up from the cache, which may have changed since.
+type staleDefaultBranchError struct {
+	err error
+}
+
+func (e *staleDefaultBranchError) Error() string {
+	return e.err.Error()
+}
+
+func (e *staleDefaultBranchError) Unwrap() error {
+	return e.err
+}
+
+// cloneAndCheckoutOnce clones repo and checks out the commit requested by
+// params, while the caller holds one of the repo's clone slots. It
+// returns a *staleDefaultBranchError if the default branch it cloned was
+// cached and no longer exists.
+func (r *Resolver) cloneAndCheckoutOnce(ctx context.Context, repo string, params map[string]string) (*checkout, error) {
+	conf := framework.GetResolverConfigFromContext(ctx)
 	commit := params[CommitParam]
 	branch := params[BranchParam]
-	path := params[PathParam]
+	auth, err := r.cloneAuth(ctx, repo, params)
+	if err != nil {
+		return nil, &authError{err: fmt.Errorf("auth error: %w", err)}
+	}
+	fetchTags := fetchTagsMode(params)
 	cloneOpts := &git.CloneOptions{
-		URL: repo,
+		URL:  repo,
+		Auth: auth,
+		Tags: tagMode(fetchTags),
 	}
-	filesystem := memfs.New()
+	disk := newDiskBudget(resolutioncommon.RequestMaxDisk(ctx))
+	filesystem := disk.filesystem(memfs.New())
 	if branch != "" {
 		cloneOpts.SingleBranch = true
 		cloneOpts.ReferenceName = plumbing.NewBranchReferenceName(branch)
 	}
-	repository, err := git.Clone(memory.NewStorage(), filesystem, cloneOpts)
+	envTag := ""
+	if env := params[EnvironmentParam]; env != "" {
+		// Cloning a tag leaves HEAD detached at the commit it points
+		// to.
+		envTag = environmentTag(conf, env)
+		cloneOpts.SingleBranch = true
+		cloneOpts.ReferenceName = plumbing.NewTagReferenceName(envTag)
+	}
+	maxDepth, err := maxCommitFetchDepth(conf)
+	if err != nil {
+		return nil, err
+	}
+	if commit != "" && maxDepth > 0 {
+		cloneOpts.Depth = initialCommitFetchDepth
+		if maxDepth < cloneOpts.Depth {
+			cloneOpts.Depth = maxDepth
+		}
+	}
+	retryStatusCodes, err := parseRetryStatusCodes(conf)
+	if err != nil {
+		return nil, err
+	}
+	redirectPolicy, err := parseRedirectPolicy(conf)
+	if err != nil {
+		return nil, err
+	}
+	maxRedirects, err := parseMaxRedirects(conf)
+	if err != nil {
+		return nil, err
+	}
+	rt := &requestTransport{
+		userAgent:        defaultUserAgent(),
+		retryStatusCodes: retryStatusCodes,
+		budget:           retryBudgetFromContext(ctx),
+		redirectPolicy:   redirectPolicy,
+		maxRedirects:     maxRedirects,
+		disk:             disk,
+	}
+	if userAgent := conf[ConfigFieldUserAgent]; userAgent != "" {
+		rt.userAgent = userAgent
+	}
+	if rt.idleTimeout, err = requestHTTPIdleTimeout(ctx, conf); err != nil {
+		return nil, err
+	}
+	rt.cacheControl = strings.TrimSpace(conf[ConfigFieldHTTPCacheControl])
+	revalidate, err := conditionalRequests(conf)
+	if err != nil {
+		return nil, err
+	}
+	if revalidate {
+		rt.responses = &r.httpResponses
+	}
+	if auth == nil {
+		if rt.negotiate, err = r.negotiators.get(conf); err != nil {
+			return nil, &authError{err: fmt.Errorf("auth error: %w", err)}
+		}
+		rt.negotiateHosts = parseKerberosHosts(conf)
+	}
+	pool, err := parsePoolSettings(conf)
 	if err != nil {
-		return nil, fmt.Errorf("clone error: %w", err)
+		return nil, err
+	}
+	rt.base = pooledTransport(pool)
+	rt.minTLSVersion = pool.minTLSVersion
+	proxied, err := r.proxyTransport(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("proxy error: %w", err)
+	}
+	if proxied != nil {
+		defer proxied.CloseIdleConnections()
+		proxied.TLSClientConfig = tlsClientConfig(pool.minTLSVersion)
+		rt.base = proxied
+	}
+	ctx = withRequestTransport(ctx, rt)
+	cachedBranch := false
+	// Comparing refs needs every branch, so the default branch isn't
+	// looked up to clone only it.
+	if branch == "" && commit == "" && envTag == "" && !comparingRefs(params) {
+		ttl, err := defaultBranchCacheTTL(conf)
+		if err != nil {
+			return nil, err
+		}
+		branch, cachedBranch, err = r.defaultBranches.get(ctx, repo, auth, ttl)
+		if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+			return nil, fmt.Errorf("clone error: %q: %w", repo, ErrEmptyRepository)
+		}
+		if err != nil {
+			return nil, wrapAuthError(fmt.Errorf("error finding default branch of %q: %w", repo, err))
+		}
+		if branch != "" {
+			cloneOpts.SingleBranch = true
+			cloneOpts.ReferenceName = plumbing.NewBranchReferenceName(branch)
+		}
+	}
+	repository, err := git.CloneContext(ctx, memory.NewStorage(), filesystem, cloneOpts)
+	if rt.BytesFetched() > 0 {
+		recordBytesFetched(ctx, rt.BytesFetched())
+	}
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return nil, fmt.Errorf("clone error: %q: %w", repo, ErrEmptyRepository)
+	}
+	if err != nil && disk.exceeded() {
+		return nil, fmt.Errorf("clone error: %q: %w", repo, disk.err())
+	}
+	if err != nil {
+		if errors.Is(err, git.NoMatchingRefSpecError{}) && envTag != "" {
+			return nil, fmt.Errorf("clone error: %w: environment tag %q: %v", ErrTagNotFound, envTag, err)
+		}
+		if errors.Is(err, git.NoMatchingRefSpecError{}) {
+			err = fmt.Errorf("clone error: %w: %q: %v", ErrBranchNotFound, branch, err)
+			if cachedBranch {
+				return nil, &staleDefaultBranchError{err: err}
+			}
+			return nil, err
+		}
+		return nil, wrapAuthError(fmt.Errorf("clone error: %w", err))
+	}
+	if cloneOpts.Depth > 0 {
+		if err := deepenUntilCommit(ctx, repository, cloneOpts, plumbing.NewHash(commit), maxDepth); err != nil {
+			return nil, err
+		}
 	}
 	if commit == "" {
 		headRef, err := repository.Head()
@@ -124,35 +647,511 @@ func (r *Resolver) Resolve(_ context.Context, params map[string]string) (framewo
 			return nil, fmt.Errorf("error reading repository HEAD value: %w", err)
 		}
 		commit = headRef.Hash().String()
+		if branch == "" && headRef.Name().IsBranch() {
+			branch = headRef.Name().Short()
+		}
+		if asOf := params[AsOfParam]; asOf != "" {
+			commit, err = commitAsOf(repository, headRef.Hash(), asOf)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if revision := params[RevisionParam]; revision != "" {
+			commit, err = commitAtRevision(repository, headRef.Hash(), revision)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if filter := params[CommitMessageFilterParam]; filter != "" {
+			commit, err = commitMatchingMessage(repository, headRef.Hash(), filter)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	notes, err := parseBoolParam(params, NotesParam)
+	if err != nil {
+		return nil, err
+	}
+	if notes {
+		if err := fetchNotes(ctx, repository, auth); err != nil {
+			return nil, err
+		}
+	}
+
+	maxCheckoutRetries, err := checkoutRetries(conf)
+	if err != nil {
+		return nil, err
+	}
+	retries, err := checkoutWithRetries(ctx, repository, commit, maxCheckoutRetries, func() error {
+		return repository.FetchContext(ctx, &git.FetchOptions{
+			RemoteName: git.DefaultRemoteName,
+			Auth:       auth,
+			Depth:      cloneOpts.Depth,
+			Tags:       git.NoTags,
+			Force:      true,
+		})
+	})
+	if err != nil {
+		if disk.exceeded() {
+			return nil, fmt.Errorf("checkout error: %q: %w", repo, disk.err())
+		}
+		return nil, err
+	}
+
+	annotations := map[string]string{
+		AnnotationKeyFetchTags: fetchTags,
+	}
+	if retries > 0 {
+		annotations[AnnotationKeyCheckoutRetries] = strconv.Itoa(retries)
 	}
+	if repo != normalizeRepoURL(params[URLParam]) {
+		annotations[AnnotationKeyExpandedURL] = repo
+	}
+	if envTag != "" {
+		annotations[AnnotationKeyEnvironmentTag] = envTag
+	}
+	if rt.BytesFetched() > 0 {
+		annotations[AnnotationKeyBytesFetched] = strconv.FormatInt(rt.BytesFetched(), 10)
+	}
+	if rt.CacheHits() > 0 {
+		annotations[AnnotationKeyHTTPCacheHits] = strconv.FormatInt(rt.CacheHits(), 10)
+	}
+
+	return &checkout{
+		repository:  repository,
+		filesystem:  filesystem,
+		url:         repo,
+		branch:      branch,
+		commit:      commit,
+		annotations: annotations,
+		auth:        auth,
+		transport:   rt,
+	}, nil
+}
 
+// checkoutCommit detaches the worktree of repository at commit. The
+// checkout is forced so that files left behind by an earlier checkout
+// of the same clone, on a branch or another commit, don't carry over.
+func checkoutCommit(repository *git.Repository, commit string) error {
+	hash := plumbing.NewHash(commit)
+	if _, err := repository.CommitObject(hash); errors.Is(err, plumbing.ErrObjectNotFound) {
+		return fmt.Errorf("checkout error: %w: %s", ErrCommitNotFound, commit)
+	}
 	w, err := repository.Worktree()
 	if err != nil {
-		return nil, fmt.Errorf("worktree error: %v", err)
+		return fmt.Errorf("worktree error: %w", err)
+	}
+	err = checkoutWorktree(w, &git.CheckoutOptions{
+		Hash:  hash,
+		Force: true,
+	})
+	if err != nil {
+		return &checkoutError{err: err}
+	}
+	return nil
+}
+
+// recheckout checks co out again at commit, reusing its clone. The
+// files of co's previous commit are no longer readable afterwards.
+func (co *checkout) recheckout(commit string) error {
+	if err := checkoutCommit(co.repository, commit); err != nil {
+		return err
+	}
+	co.commit = commit
+	return nil
+}
+
+// normalizeRepoURL strips the surrounding whitespace and trailing
+// slashes that are easy to include by accident in a repo url.
+func normalizeRepoURL(url string) string {
+	return strings.TrimRight(strings.TrimSpace(url), "/")
+}
+
+// initialCommitFetchDepth is the depth of the initial shallow clone made
+// when looking for a commit with ConfigFieldMaxCommitFetchDepth set.
+const initialCommitFetchDepth = 16
+
+// maxCommitFetchDepth parses ConfigFieldMaxCommitFetchDepth from conf,
+// returning 0 if it isn't set.
+func maxCommitFetchDepth(conf map[string]string) (int, error) {
+	val, ok := conf[ConfigFieldMaxCommitFetchDepth]
+	if !ok || val == "" {
+		return 0, nil
+	}
+	depth, err := strconv.Atoi(val)
+	if err != nil || depth < 1 {
+		return 0, fmt.Errorf("invalid %q config %q: must be a positive integer", ConfigFieldMaxCommitFetchDepth, val)
+	}
+	return depth, nil
+}
+
+// deepenUntilCommit repeatedly doubles the depth of a shallow clone until
+// it contains commit or maxDepth commits of history have been fetched.
+func deepenUntilCommit(ctx context.Context, repository *git.Repository, cloneOpts *git.CloneOptions, commit plumbing.Hash, maxDepth int) error {
+	depth := cloneOpts.Depth
+	for {
+		if _, err := repository.CommitObject(commit); err == nil {
+			return nil
+		}
+		if depth >= maxDepth {
+			return fmt.Errorf("%w within %d commits of deepening: %s", ErrCommitNotFound, maxDepth, commit)
+		}
+		depth *= 2
+		if depth > maxDepth {
+			depth = maxDepth
+		}
+		err := repository.FetchContext(ctx, &git.FetchOptions{
+			RemoteName: cloneOpts.RemoteName,
+			Auth:       cloneOpts.Auth,
+			Depth:      depth,
+			Tags:       git.NoTags,
+		})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return fmt.Errorf("error deepening clone to %d commits: %w", depth, err)
+		}
 	}
+}
 
-	err = w.Checkout(&git.CheckoutOptions{
-		Hash: plumbing.NewHash(commit),
+// commitAsOf returns the newest commit reachable from tip that was
+// committed at or before the RFC3339 timestamp asOf.
+func commitAsOf(repository *git.Repository, tip plumbing.Hash, asOf string) (string, error) {
+	asOfTime, err := time.Parse(time.RFC3339, asOf)
+	if err != nil {
+		return "", fmt.Errorf("invalid %q: %w", AsOfParam, err)
+	}
+	commits, err := repository.Log(&git.LogOptions{
+		From:  tip,
+		Order: git.LogOrderCommitterTime,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("checkout error: %v", err)
+		return "", fmt.Errorf("error reading commit history: %w", err)
+	}
+	defer commits.Close()
+	for {
+		c, err := commits.Next()
+		if err == io.EOF {
+			return "", fmt.Errorf("no commit found at or before %s", asOfTime.Format(time.RFC3339))
+		}
+		if err != nil {
+			return "", fmt.Errorf("error reading commit history: %w", err)
+		}
+		if !c.Committer.When.After(asOfTime) {
+			return c.Hash.String(), nil
+		}
+	}
+}
+
+// commitParents returns the comma-separated hashes of commit's parents,
+// in order, or an empty string for a root commit. The parents are read
+// from the commit itself, so they needn't have been fetched.
+func commitParents(repository *git.Repository, commit plumbing.Hash) (string, error) {
+	c, err := repository.CommitObject(commit)
+	if err != nil {
+		return "", err
+	}
+	parents := []string{}
+	for _, parent := range c.ParentHashes {
+		parents = append(parents, parent.String())
+	}
+	return strings.Join(parents, ","), nil
+}
+
+// resolveFile reads the file requested by params out of a checkout.
+func (r *Resolver) resolveFile(ctx context.Context, co *checkout, params map[string]string) (*ResolvedGitResource, error) {
+	annotations := map[string]string{}
+	for key, val := range co.annotations {
+		annotations[key] = val
+	}
+
+	path := params[PathParam]
+	var content []byte
+	var err error
+	if kind := params[WellKnownParam]; kind != "" {
+		path, content, err = readWellKnown(co.filesystem, kind)
+		annotations[AnnotationKeyPath] = path
+	} else if dir := params[TektonBundleDirParam]; dir != "" {
+		var manifest string
+		path = dir
+		content, manifest, err = readTektonBundle(co.filesystem, dir)
+		annotations[AnnotationKeyManifest] = manifest
+	} else if event := params[PacEventParam]; event != "" {
+		targetBranch := params[PacTargetBranchParam]
+		if targetBranch == "" {
+			targetBranch = co.branch
+		}
+		if targetBranch == "" {
+			return nil, fmt.Errorf("%q is required when resolving a commit rather than a branch", PacTargetBranchParam)
+		}
+		var manifest string
+		path = pacDir
+		content, manifest, err = readPacConfig(co.filesystem, event, targetBranch)
+		annotations[AnnotationKeyManifest] = manifest
+	} else if blob := params[BlobParam]; blob != "" {
+		path = "blob " + blob
+		content, err = readBlob(co.repository, blob)
+	} else {
+		content, err = readFile(co.filesystem, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Only a single file from the commit's tree has a mode of its own.
+	if params[WellKnownParam] != wellKnownTekton && params[TektonBundleDirParam] == "" && params[PacEventParam] == "" && params[BlobParam] == "" {
+		mode, err := fileMode(co.repository, co.commit, path)
+		if err != nil {
+			return nil, err
+		}
+		annotations[AnnotationKeyFileMode] = mode
+	}
+
+	lfs, err := parseBoolParam(params, LFSParam)
+	if err != nil {
+		return nil, err
+	}
+	if pointer, ok := parseLFSPointer(content); lfs && ok {
+		content, err = fetchLFSObject(ctx, co, pointer)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching %q from git lfs: %w", path, err)
+		}
+		annotations[AnnotationKeyLFSOID] = "sha256:" + pointer.OID
+		annotations[AnnotationKeyLFSSize] = strconv.FormatInt(pointer.Size, 10)
+	}
+
+	rejectEmpty, err := rejectEmptyContent(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if rejectEmpty && len(bytes.TrimSpace(content)) == 0 {
+		return nil, resolutioncommon.NewError(resolutioncommon.ReasonResolvedContentEmpty, fmt.Errorf("file %q at commit %s is empty", path, co.commit))
+	}
+
+	if err := checkFormat(content, params[ExpectFormatParam]); err != nil {
+		return nil, fmt.Errorf("file %q at commit %s: %w", path, co.commit, err)
+	}
+
+	lr, selectRange, err := parseLineRange(params)
+	if err != nil {
+		return nil, err
+	}
+	if selectRange {
+		clamp := framework.GetResolverConfigFromContext(ctx)[ConfigFieldLineRangeMode] == lineRangeModeClamp
+		content, lr, err = selectLines(content, lr, clamp)
+		if err != nil {
+			return nil, fmt.Errorf("error selecting lines of %q: %w", path, err)
+		}
+		annotations[AnnotationKeyLineRange] = lr.String()
+	}
+
+	resolveIncludes, err := parseBoolParam(params, ResolveIncludesParam)
+	if err != nil {
+		return nil, err
 	}
+	if resolveIncludes {
+		content, err = inlineIncludes(co.filesystem, path, content, []string{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	render, err := parseBoolParam(params, TemplateParam)
+	if err != nil {
+		return nil, err
+	}
+	if render {
+		content, err = renderTemplate(content, templateVars(params))
+		if err != nil {
+			return nil, fmt.Errorf("error rendering %q as a template: %w", path, err)
+		}
+	}
+
+	normalize, err := parseBoolParam(params, NormalizeYAMLParam)
+	if err != nil {
+		return nil, err
+	}
+	if normalize {
+		content, err = normalizeYAML(content)
+		if err != nil {
+			return nil, fmt.Errorf("error normalizing %q: %w", path, err)
+		}
+	}
+
+	trailingNewline, err := parseBoolParam(params, TrailingNewlineParam)
+	if err != nil {
+		return nil, err
+	}
+	if trailingNewline {
+		content = normalizeTrailingNewline(content)
+	}
+
+	if schemaParam := params[SchemaParam]; schemaParam != "" {
+		schema, err := loadSchema(co.filesystem, schemaParam)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateAgainstSchema(schema, content); err != nil {
+			return nil, fmt.Errorf("file %q at commit %s doesn't match schema: %w", path, co.commit, err)
+		}
+	}
+
+	var warnings []string
+	dependencies, err := parseBoolParam(params, DependenciesParam)
+	if err != nil {
+		return nil, err
+	}
+	if dependencies {
+		// Content that can't be analyzed is still returned, just
+		// without its dependencies.
+		deps, err := taskDependencies(content)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("error listing the dependencies of %q: %v", path, err))
+		} else {
+			annotations[AnnotationKeyDependencies] = deps
+		}
+	}
+
+	split, err := parseBoolParam(params, SplitDocumentsParam)
+	if err != nil {
+		return nil, err
+	}
+	if split {
+		documents, err := splitDocuments(content)
+		if err != nil {
+			return nil, err
+		}
+		annotations[AnnotationKeyDocuments] = documents
+	}
+
+	contentType := ""
+	if params[OutputFormatParam] == outputFormatJSON {
+		content, err = yamlToJSON(content)
+		if err != nil {
+			return nil, fmt.Errorf("error converting %q to json: %w", path, err)
+		}
+		contentType = JSONContentType
+		annotations[AnnotationKeyOutputFormat] = outputFormatJSON
+	}
+
+	effectiveParams, err := json.Marshal(effectiveParams(co, params))
+	if err != nil {
+		return nil, fmt.Errorf("error serializing params: %w", err)
+	}
+	annotations[framework.AnnotationKeyParams] = string(effectiveParams)
+
+	describeCommit, err := parseBoolParam(params, DescribeParam)
+	if err != nil {
+		return nil, err
+	}
+	if describeCommit {
+		description, err := describe(co.repository, plumbing.NewHash(co.commit))
+		if err != nil {
+			return nil, fmt.Errorf("error describing commit %s: %w", co.commit, err)
+		}
+		annotations[AnnotationKeyDescribe] = description
+	}
+
+	treeHash, err := parseBoolParam(params, TreeHashParam)
+	if err != nil {
+		return nil, err
+	}
+	if treeHash {
+		c, err := co.repository.CommitObject(plumbing.NewHash(co.commit))
+		if err != nil {
+			return nil, fmt.Errorf("error reading commit %s: %w", co.commit, err)
+		}
+		annotations[AnnotationKeyTreeHash] = c.TreeHash.String()
+	}
+
+	parents, err := commitParents(co.repository, plumbing.NewHash(co.commit))
+	if err != nil {
+		return nil, fmt.Errorf("error reading parents of commit %s: %w", co.commit, err)
+	}
+	if parents != "" {
+		annotations[AnnotationKeyCommitParents] = parents
+	}
+
+	notes, err := parseBoolParam(params, NotesParam)
+	if err != nil {
+		return nil, err
+	}
+	if notes {
+		note, err := readNote(co.repository, plumbing.NewHash(co.commit))
+		if err != nil {
+			return nil, fmt.Errorf("error reading git note of commit %s: %w", co.commit, err)
+		}
+		if note != "" {
+			annotations[AnnotationKeyNotes] = note
+		}
+	}
+
+	resolved := &ResolvedGitResource{
+		Commit:           co.commit,
+		Content:          content,
+		ContentType:      contentType,
+		ExtraAnnotations: annotations,
+		ResolveWarnings:  warnings,
+	}
+	provenance, err := parseBoolParam(params, ProvenanceParam)
+	if err != nil {
+		return nil, err
+	}
+	if provenance {
+		resolved.MaterialURI = materialURI(co.url, co.commit, path)
+	}
+	return resolved, nil
+}
 
+// rejectEmptyContent returns whether an empty file should fail the
+// request, from RejectEmptyParam or else ConfigFieldRejectEmpty.
+func rejectEmptyContent(ctx context.Context, params map[string]string) (bool, error) {
+	if params[RejectEmptyParam] != "" {
+		return parseBoolParam(params, RejectEmptyParam)
+	}
+	conf := framework.GetResolverConfigFromContext(ctx)
+	if conf[ConfigFieldRejectEmpty] == "" {
+		return false, nil
+	}
+	reject, err := strconv.ParseBool(conf[ConfigFieldRejectEmpty])
+	if err != nil {
+		return false, fmt.Errorf("invalid %q config %q: must be true or false", ConfigFieldRejectEmpty, conf[ConfigFieldRejectEmpty])
+	}
+	return reject, nil
+}
+
+// effectiveParams returns params with the url normalized and the branch
+// defaulted as they were when resolving from co.
+func effectiveParams(co *checkout, params map[string]string) map[string]string {
+	effective := map[string]string{}
+	for key, val := range params {
+		if val != "" {
+			effective[key] = val
+		}
+	}
+	effective[URLParam] = co.url
+	if co.branch != "" {
+		effective[BranchParam] = co.branch
+	}
+	return effective
+}
+
+// readFile returns the content of the file at path in filesystem.
+func readFile(filesystem billy.Filesystem, path string) ([]byte, error) {
 	f, err := filesystem.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error opening file %q: %w", path, ErrFileNotFound)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("error opening file %q: %v", path, err)
+		return nil, fmt.Errorf("error opening file %q: %w", path, err)
 	}
+	defer f.Close()
 
 	buf := &bytes.Buffer{}
 	_, err = io.Copy(buf, f)
 	if err != nil {
-		return nil, fmt.Errorf("error reading file %q: %v", path, err)
+		return nil, fmt.Errorf("error reading file %q: %w", path, err)
 	}
-
-	return &ResolvedGitResource{
-		Commit:  commit,
-		Content: buf.Bytes(),
-	}, nil
+	return buf.Bytes(), nil
 }
 
 var _ framework.ConfigWatcher = &Resolver{}
@@ -162,6 +1161,18 @@ func (r *Resolver) GetConfigName(context.Context) string {
 	return "git-resolver-config"
 }
 
+var _ framework.CapabilityAdvertiser = &Resolver{}
+
+// Capabilities returns the optional framework features the git resolver
+// supports.
+func (r *Resolver) Capabilities(context.Context) []string {
+	return []string{
+		framework.CapabilityConfigWatcher,
+		framework.CapabilityTimedResolution,
+		framework.CapabilityBatch,
+	}
+}
+
 var _ framework.TimedResolution = &Resolver{}
 
 // GetResolutionTimeout returns a time.Duration for the amount of time a
@@ -183,9 +1194,21 @@ func (r *Resolver) GetResolutionTimeout(ctx context.Context, defaultTimeout time
 type ResolvedGitResource struct {
 	Commit  string
 	Content []byte
+	// ContentType is the content type of Content. Defaults to
+	// YAMLContentType.
+	ContentType string
+	// MaterialURI, when set, adds the file as an in-toto material with
+	// this uri and the digest of Content to the annotations.
+	MaterialURI string
+	// ExtraAnnotations are any additional annotations recorded
+	// while the file was resolved.
+	ExtraAnnotations map[string]string
+	// ResolveWarnings are any non-fatal issues encountered while the
+	// file was resolved.
+	ResolveWarnings []string
 }
 
-var _ framework.ResolvedResource = &ResolvedGitResource{}
+var _ framework.ResolvedResourceWithWarnings = &ResolvedGitResource{}
 
 // Data returns the bytes of the file resolved from git.
 func (r *ResolvedGitResource) Data() []byte {
@@ -193,10 +1216,27 @@ func (r *ResolvedGitResource) Data() []byte {
 }
 
 // Annotations returns the metadata that accompanies the file fetched
-// from git.
+// from git. The content digest is computed over the final Content.
 func (r *ResolvedGitResource) Annotations() map[string]string {
-	return map[string]string{
-		AnnotationKeyCommitHash:                   r.Commit,
-		resolutioncommon.AnnotationKeyContentType: YAMLContentType,
+	digest := sha256.Sum256(r.Content)
+	annotations := map[string]string{}
+	for key, val := range r.ExtraAnnotations {
+		annotations[key] = val
+	}
+	annotations[AnnotationKeyCommitHash] = r.Commit
+	annotations[AnnotationKeyContentSHA256] = hex.EncodeToString(digest[:])
+	if r.MaterialURI != "" {
+		annotations[AnnotationKeyMaterial] = materialAnnotation(r.MaterialURI, annotations[AnnotationKeyContentSHA256])
 	}
+	annotations[resolutioncommon.AnnotationKeyContentType] = YAMLContentType
+	if r.ContentType != "" {
+		annotations[resolutioncommon.AnnotationKeyContentType] = r.ContentType
+	}
+	return annotations
+}
+
+// Warnings returns the non-fatal issues encountered while the file was
+// resolved.
+func (r *ResolvedGitResource) Warnings() []string {
+	return r.ResolveWarnings
 }
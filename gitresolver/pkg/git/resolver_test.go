@@ -2,9 +2,17 @@ package git
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
 	"github.com/tektoncd/resolution/pkg/resolver/framework"
 )
@@ -65,6 +73,22 @@ func TestValidateParamsMissing(t *testing.T) {
 	}
 }
 
+func TestValidateParamsDegeneratePath(t *testing.T) {
+	resolver := Resolver{}
+	for _, path := range []string{" ", "\t\n", "/", "///", "\\", " / "} {
+		params := map[string]string{
+			URLParam:  "foo",
+			PathParam: path,
+		}
+		err := resolver.ValidateParams(context.Background(), params)
+		if err == nil {
+			t.Errorf("expected error for path %q", path)
+		} else if !strings.Contains(err.Error(), PathParam) {
+			t.Errorf("expected error for path %q to name the path param: %v", path, err)
+		}
+	}
+}
+
 func TestValidateParamsConflictingGitRef(t *testing.T) {
 	resolver := Resolver{}
 	params := map[string]string{
@@ -101,3 +125,895 @@ func TestGetResolutionTimeoutCustom(t *testing.T) {
 		t.Fatalf("expected timeout from config to be returned")
 	}
 }
+
+func TestResolve(t *testing.T) {
+	repo, commits := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"foo/bar.yaml": "first"},
+	}, {
+		Files: map[string]string{"foo/bar.yaml": "second"},
+	}})
+	resolver := Resolver{}
+
+	resource, err := resolver.Resolve(context.Background(), map[string]string{
+		URLParam:  repo,
+		PathParam: "foo/bar.yaml",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resource.Data()) != "second" {
+		t.Errorf("expected content from HEAD, received %q", resource.Data())
+	}
+	if commit := resource.Annotations()[AnnotationKeyCommitHash]; commit != commits[1] {
+		t.Errorf("expected commit %q, received %q", commits[1], commit)
+	}
+
+	resource, err = resolver.Resolve(context.Background(), map[string]string{
+		URLParam:    repo,
+		PathParam:   "foo/bar.yaml",
+		CommitParam: commits[0],
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resource.Data()) != "first" {
+		t.Errorf("expected content from first commit, received %q", resource.Data())
+	}
+}
+
+func TestResolveEmptyRepository(t *testing.T) {
+	repo, _ := createTestRepo(t, nil)
+	resolver := Resolver{}
+	_, err := resolver.Resolve(context.Background(), map[string]string{
+		URLParam:  repo,
+		PathParam: "foo.yaml",
+	})
+	if !errors.Is(err, ErrEmptyRepository) {
+		t.Fatalf("expected ErrEmptyRepository, received %v", err)
+	}
+}
+
+func TestResolvedGitResourceContentDigest(t *testing.T) {
+	resource := &ResolvedGitResource{
+		Commit:  "abc123",
+		Content: []byte("hello"),
+	}
+	expected := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if digest := resource.Annotations()[AnnotationKeyContentSHA256]; digest != expected {
+		t.Fatalf("expected digest %q, received %q", expected, digest)
+	}
+}
+
+func TestResolveIncludes(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{
+			"pipeline/pipeline.yaml":    "kind: Pipeline\nspec:\n  tasks:\n  # @include tasks/build.yaml\n",
+			"pipeline/tasks/build.yaml": "- name: build\n  taskRef:\n    name: build\n",
+		},
+	}})
+	resolver := Resolver{}
+	resource, err := resolver.Resolve(context.Background(), map[string]string{
+		URLParam:             repo,
+		PathParam:            "pipeline/pipeline.yaml",
+		ResolveIncludesParam: "true",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "kind: Pipeline\nspec:\n  tasks:\n  - name: build\n    taskRef:\n      name: build\n"
+	if string(resource.Data()) != expected {
+		t.Fatalf("expected included content %q, received %q", expected, resource.Data())
+	}
+}
+
+func TestResolveIncludesCycle(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{
+			"a.yaml": "# @include b.yaml\n",
+			"b.yaml": "# @include ./a.yaml\n",
+		},
+	}})
+	resolver := Resolver{}
+	_, err := resolver.Resolve(context.Background(), map[string]string{
+		URLParam:             repo,
+		PathParam:            "a.yaml",
+		ResolveIncludesParam: "true",
+	})
+	if err == nil || !strings.Contains(err.Error(), "include cycle detected") {
+		t.Fatalf("expected include cycle error, received %v", err)
+	}
+}
+
+func TestValidateParamsInvalidBool(t *testing.T) {
+	resolver := Resolver{}
+	err := resolver.ValidateParams(context.Background(), map[string]string{
+		URLParam:             "foo",
+		PathParam:            "bar",
+		ResolveIncludesParam: "maybe",
+	})
+	if err == nil {
+		t.Fatalf("expected error for non-boolean param value")
+	}
+}
+
+func TestResolveAsOf(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2022, time.March, d, 12, 0, 0, 0, time.UTC)
+	}
+	repo, commits := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "first"},
+		When:  day(1),
+	}, {
+		Files: map[string]string{"task.yaml": "second"},
+		When:  day(10),
+	}, {
+		Files: map[string]string{"task.yaml": "third"},
+		When:  day(20),
+	}})
+	resolver := Resolver{}
+	for _, tc := range []struct {
+		asOf           string
+		expectedCommit string
+	}{{
+		asOf:           day(1).Format(time.RFC3339),
+		expectedCommit: commits[0],
+	}, {
+		asOf:           day(15).Format(time.RFC3339),
+		expectedCommit: commits[1],
+	}, {
+		asOf:           day(20).Format(time.RFC3339),
+		expectedCommit: commits[2],
+	}, {
+		asOf:           "2023-01-01T00:00:00Z",
+		expectedCommit: commits[2],
+	}} {
+		t.Run(tc.asOf, func(t *testing.T) {
+			resource, err := resolver.Resolve(context.Background(), map[string]string{
+				URLParam:  repo,
+				PathParam: "task.yaml",
+				AsOfParam: tc.asOf,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if commit := resource.Annotations()[AnnotationKeyCommitHash]; commit != tc.expectedCommit {
+				t.Errorf("expected commit %q, received %q", tc.expectedCommit, commit)
+			}
+		})
+	}
+
+	if _, err := resolver.Resolve(context.Background(), map[string]string{
+		URLParam:  repo,
+		PathParam: "task.yaml",
+		AsOfParam: "2021-01-01T00:00:00Z",
+	}); err == nil {
+		t.Errorf("expected error when no commit precedes asOf")
+	}
+}
+
+func TestValidateParamsAsOf(t *testing.T) {
+	resolver := Resolver{}
+	for _, params := range []map[string]string{{
+		URLParam:  "foo",
+		PathParam: "bar",
+		AsOfParam: "yesterday",
+	}, {
+		URLParam:    "foo",
+		PathParam:   "bar",
+		CommitParam: "baz",
+		AsOfParam:   "2022-01-01T00:00:00Z",
+	}} {
+		if err := resolver.ValidateParams(context.Background(), params); err == nil {
+			t.Errorf("expected error validating %v", params)
+		}
+	}
+}
+
+func TestResolveMaxCommitFetchDepth(t *testing.T) {
+	history := []commitForRepo{}
+	for i := 0; i < 40; i++ {
+		history = append(history, commitForRepo{
+			Files: map[string]string{"task.yaml": fmt.Sprintf("version %d", i)},
+		})
+	}
+	repo, commits := createTestRepo(t, history)
+	resolver := Resolver{}
+	params := map[string]string{
+		URLParam:    repo,
+		PathParam:   "task.yaml",
+		CommitParam: commits[5],
+	}
+
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldMaxCommitFetchDepth: "20",
+	})
+	_, err := resolver.Resolve(ctx, params)
+	if !errors.Is(err, ErrCommitNotFound) {
+		t.Fatalf("expected ErrCommitNotFound, received %v", err)
+	}
+	if !strings.Contains(err.Error(), "within 20 commits of deepening") {
+		t.Errorf("expected error to name the max depth, received %v", err)
+	}
+
+	ctx = framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldMaxCommitFetchDepth: "64",
+	})
+	resource, err := resolver.Resolve(ctx, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resource.Data()) != "version 5" {
+		t.Errorf("unexpected content %q", resource.Data())
+	}
+}
+
+func TestResolveNotFound(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+	for _, tc := range []struct {
+		name     string
+		params   map[string]string
+		expected error
+	}{{
+		name: "branch",
+		params: map[string]string{
+			BranchParam: "does-not-exist",
+			PathParam:   "task.yaml",
+		},
+		expected: ErrBranchNotFound,
+	}, {
+		name: "commit",
+		params: map[string]string{
+			CommitParam: "0123456789abcdef0123456789abcdef01234567",
+			PathParam:   "task.yaml",
+		},
+		expected: ErrCommitNotFound,
+	}, {
+		name: "file",
+		params: map[string]string{
+			PathParam: "does-not-exist.yaml",
+		},
+		expected: ErrFileNotFound,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.params[URLParam] = repo
+			resolver := Resolver{}
+			_, err := resolver.Resolve(context.Background(), tc.params)
+			if !errors.Is(err, tc.expected) {
+				t.Fatalf("expected %v, received %v", tc.expected, err)
+			}
+			if !errors.Is(err, framework.ErrorResourceNotFound) {
+				t.Errorf("expected %v to match framework.ErrorResourceNotFound", err)
+			}
+		})
+	}
+}
+
+func TestResolveDescribe(t *testing.T) {
+	repo, commits := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "v0"},
+		Tag:   "v1.0.0",
+	}, {
+		Files: map[string]string{"task.yaml": "v1"},
+	}, {
+		Files:      map[string]string{"task.yaml": "v2"},
+		Tag:        "v1.1.0",
+		TagMessage: "release v1.1.0",
+	}, {
+		Files: map[string]string{"task.yaml": "v3"},
+	}, {
+		Files: map[string]string{"task.yaml": "v4"},
+	}})
+	untaggedRepo, untaggedCommits := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+
+	for _, tc := range []struct {
+		name     string
+		repo     string
+		commit   string
+		expected string
+	}{{
+		name:     "tip is two commits past annotated tag",
+		repo:     repo,
+		expected: "v1.1.0-2-g" + commits[4][:7],
+	}, {
+		name:     "tagged commit",
+		repo:     repo,
+		commit:   commits[2],
+		expected: "v1.1.0",
+	}, {
+		name:     "one commit past lightweight tag",
+		repo:     repo,
+		commit:   commits[1],
+		expected: "v1.0.0-1-g" + commits[1][:7],
+	}, {
+		name:     "no reachable tag",
+		repo:     untaggedRepo,
+		expected: untaggedCommits[0][:7],
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := Resolver{}
+			resource, err := resolver.Resolve(context.Background(), map[string]string{
+				URLParam:      tc.repo,
+				PathParam:     "task.yaml",
+				CommitParam:   tc.commit,
+				DescribeParam: "true",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if description := resource.Annotations()[AnnotationKeyDescribe]; description != tc.expected {
+				t.Errorf("expected describe %q, received %q", tc.expected, description)
+			}
+		})
+	}
+}
+
+func TestResolveTreeHash(t *testing.T) {
+	repo, commits := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "v1"},
+	}, {
+		Files: map[string]string{"task.yaml": "v2"},
+	}, {
+		Files:   map[string]string{"task.yaml": "v1"},
+		Message: "revert to v1",
+	}})
+	otherRepo, otherCommits := createTestRepo(t, []commitForRepo{{
+		Files:   map[string]string{"task.yaml": "v1"},
+		Message: "copy of v1",
+	}})
+
+	treeHash := func(repo, commit string) string {
+		t.Helper()
+		resource, err := (&Resolver{}).Resolve(context.Background(), map[string]string{
+			URLParam:      repo,
+			PathParam:     "task.yaml",
+			CommitParam:   commit,
+			TreeHashParam: "true",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		hash := resource.Annotations()[AnnotationKeyTreeHash]
+		if len(hash) != 40 {
+			t.Fatalf("expected tree hash annotation, received %q", hash)
+		}
+		return hash
+	}
+
+	v1 := treeHash(repo, commits[0])
+	if reverted := treeHash(repo, commits[2]); reverted != v1 {
+		t.Errorf("expected commits with identical trees to report the same tree hash, received %s and %s", v1, reverted)
+	}
+	if copied := treeHash(otherRepo, otherCommits[0]); copied != v1 {
+		t.Errorf("expected commits of other repos with identical trees to report the same tree hash, received %s and %s", v1, copied)
+	}
+	if v2 := treeHash(repo, commits[1]); v2 == v1 {
+		t.Errorf("expected commits with different trees to report different tree hashes, both received %s", v1)
+	}
+}
+
+func TestResolveLineRange(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "one\ntwo\nthree\nfour\nfive\n"},
+	}})
+	for _, tc := range []struct {
+		name          string
+		conf          map[string]string
+		start         string
+		end           string
+		expected      string
+		expectedRange string
+		expectedErr   string
+	}{{
+		name:          "middle lines",
+		start:         "2",
+		end:           "4",
+		expected:      "two\nthree\nfour\n",
+		expectedRange: "2-4",
+	}, {
+		name:          "start only",
+		start:         "4",
+		expected:      "four\nfive\n",
+		expectedRange: "4-5",
+	}, {
+		name:          "single line",
+		start:         "3",
+		end:           "3",
+		expected:      "three\n",
+		expectedRange: "3-3",
+	}, {
+		name:        "reversed",
+		start:       "4",
+		end:         "2",
+		expectedErr: "invalid line range",
+	}, {
+		name:        "out of bounds errors by default",
+		start:       "4",
+		end:         "9",
+		expectedErr: "out of bounds",
+	}, {
+		name:          "out of bounds clamped",
+		conf:          map[string]string{ConfigFieldLineRangeMode: "clamp"},
+		start:         "4",
+		end:           "9",
+		expected:      "four\nfive\n",
+		expectedRange: "4-5",
+	}, {
+		name:        "invalid mode",
+		conf:        map[string]string{ConfigFieldLineRangeMode: "truncate"},
+		start:       "1",
+		expectedErr: ConfigFieldLineRangeMode,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			params := map[string]string{
+				URLParam:       repo,
+				PathParam:      "task.yaml",
+				StartLineParam: tc.start,
+				EndLineParam:   tc.end,
+			}
+			ctx := framework.InjectResolverConfigToContext(context.Background(), tc.conf)
+			resolver := Resolver{}
+			err := resolver.ValidateParams(ctx, params)
+			var resource framework.ResolvedResource
+			if err == nil {
+				resource, err = resolver.Resolve(ctx, params)
+			}
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q, received %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.expected {
+				t.Errorf("expected content %q, received %q", tc.expected, resource.Data())
+			}
+			if lr := resource.Annotations()[AnnotationKeyLineRange]; lr != tc.expectedRange {
+				t.Errorf("expected line range %q, received %q", tc.expectedRange, lr)
+			}
+		})
+	}
+}
+
+func TestResolveCommitPropagationGrace(t *testing.T) {
+	oldInterval := commitPropagationPollInterval
+	commitPropagationPollInterval = 50 * time.Millisecond
+	t.Cleanup(func() { commitPropagationPollInterval = oldInterval })
+
+	first := commitForRepo{Files: map[string]string{"task.yaml": "first"}}
+	pushed := commitForRepo{Files: map[string]string{"task.yaml": "pushed"}}
+	// Commits are deterministic so a second repo tells us the hash the
+	// pushed commit will have.
+	_, expectedCommits := createTestRepo(t, []commitForRepo{first, pushed})
+	repo, _ := createTestRepo(t, []commitForRepo{first})
+
+	params := map[string]string{
+		URLParam:    repo,
+		PathParam:   "task.yaml",
+		CommitParam: expectedCommits[1],
+	}
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldCommitPropagationGrace: "10s",
+	})
+
+	type result struct {
+		resource framework.ResolvedResource
+		err      error
+	}
+	results := make(chan result, 1)
+	go func() {
+		resolver := Resolver{}
+		resource, err := resolver.Resolve(ctx, params)
+		results <- result{resource, err}
+	}()
+	time.Sleep(200 * time.Millisecond)
+	if pushedHashes := appendTestCommits(t, repo, []commitForRepo{pushed}); pushedHashes[0] != expectedCommits[1] {
+		t.Fatalf("expected pushed commit %s, created %s", expectedCommits[1], pushedHashes[0])
+	}
+
+	res := <-results
+	if res.err != nil {
+		t.Fatalf("unexpected error: %v", res.err)
+	}
+	if string(res.resource.Data()) != "pushed" {
+		t.Errorf("unexpected content %q", res.resource.Data())
+	}
+}
+
+func TestResolveCommitPropagationGraceExpires(t *testing.T) {
+	oldInterval := commitPropagationPollInterval
+	commitPropagationPollInterval = 50 * time.Millisecond
+	t.Cleanup(func() { commitPropagationPollInterval = oldInterval })
+
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldCommitPropagationGrace: "200ms",
+	})
+	resolver := Resolver{}
+	_, err := resolver.Resolve(ctx, map[string]string{
+		URLParam:    repo,
+		PathParam:   "task.yaml",
+		CommitParam: "0123456789abcdef0123456789abcdef01234567",
+	})
+	if !errors.Is(err, ErrCommitNotFound) {
+		t.Fatalf("expected ErrCommitNotFound, received %v", err)
+	}
+	if !strings.Contains(err.Error(), "to propagate") {
+		t.Errorf("expected error to mention the propagation grace period, received %v", err)
+	}
+}
+
+func TestResolveRecordsEffectiveParams(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+	resolver := Resolver{}
+	resource, err := resolver.Resolve(context.Background(), map[string]string{
+		URLParam:  " " + repo + "/ ",
+		PathParam: "task.yaml",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	params := map[string]string{}
+	if err := json.Unmarshal([]byte(resource.Annotations()[framework.AnnotationKeyParams]), &params); err != nil {
+		t.Fatalf("error parsing params annotation: %v", err)
+	}
+	expected := map[string]string{
+		URLParam:    repo,
+		PathParam:   "task.yaml",
+		BranchParam: "master",
+	}
+	if len(params) != len(expected) {
+		t.Errorf("expected params %v, received %v", expected, params)
+	}
+	for key, val := range expected {
+		if params[key] != val {
+			t.Errorf("expected param %q to be %q, received %q", key, val, params[key])
+		}
+	}
+}
+
+func TestResolveRejectEmpty(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"empty.yaml": "", "task.yaml": "content"},
+	}})
+	for _, tc := range []struct {
+		name      string
+		conf      map[string]string
+		params    map[string]string
+		expectErr bool
+	}{{
+		name:   "empty file resolves by default",
+		params: map[string]string{PathParam: "empty.yaml"},
+	}, {
+		name:      "empty file rejected by param",
+		params:    map[string]string{PathParam: "empty.yaml", RejectEmptyParam: "true"},
+		expectErr: true,
+	}, {
+		name:      "empty file rejected by config",
+		conf:      map[string]string{ConfigFieldRejectEmpty: "true"},
+		params:    map[string]string{PathParam: "empty.yaml"},
+		expectErr: true,
+	}, {
+		name:   "param overrides config",
+		conf:   map[string]string{ConfigFieldRejectEmpty: "true"},
+		params: map[string]string{PathParam: "empty.yaml", RejectEmptyParam: "false"},
+	}, {
+		name:   "non-empty file resolves when rejecting empty",
+		params: map[string]string{PathParam: "task.yaml", RejectEmptyParam: "true"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.params[URLParam] = repo
+			ctx := framework.InjectResolverConfigToContext(context.Background(), tc.conf)
+			resolver := Resolver{}
+			_, err := resolver.Resolve(ctx, tc.params)
+			if !tc.expectErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if reason, _ := resolutioncommon.ReasonError(err); reason != resolutioncommon.ReasonResolvedContentEmpty {
+				t.Fatalf("expected reason %q, received %q from %v", resolutioncommon.ReasonResolvedContentEmpty, reason, err)
+			}
+		})
+	}
+}
+
+func TestResolveOutputFormatJSON(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{
+			"task.yaml":    "kind: Task\nmetadata:\n  name: foo\n",
+			"multi.yaml":   "kind: Task\n---\nkind: Pipeline\n---\n",
+			"invalid.yaml": "kind: Task\n  name: [foo\n",
+		},
+	}})
+	for _, tc := range []struct {
+		name        string
+		path        string
+		expected    string
+		expectedErr string
+	}{{
+		name:     "single document",
+		path:     "task.yaml",
+		expected: `{"kind":"Task","metadata":{"name":"foo"}}`,
+	}, {
+		name:     "multiple documents",
+		path:     "multi.yaml",
+		expected: `[{"kind":"Task"},{"kind":"Pipeline"}]`,
+	}, {
+		name:        "invalid yaml",
+		path:        "invalid.yaml",
+		expectedErr: "error converting",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := Resolver{}
+			params := map[string]string{
+				URLParam:          repo,
+				PathParam:         tc.path,
+				OutputFormatParam: "json",
+			}
+			if err := resolver.ValidateParams(context.Background(), params); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			resource, err := resolver.Resolve(context.Background(), params)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q, received %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.expected {
+				t.Errorf("expected %s, received %s", tc.expected, resource.Data())
+			}
+			annotations := resource.Annotations()
+			if annotations[AnnotationKeyOutputFormat] != "json" {
+				t.Errorf("expected output format annotation, received %q", annotations[AnnotationKeyOutputFormat])
+			}
+			if annotations[resolutioncommon.AnnotationKeyContentType] != JSONContentType {
+				t.Errorf("expected content type %q, received %q", JSONContentType, annotations[resolutioncommon.AnnotationKeyContentType])
+			}
+		})
+	}
+}
+
+func TestResolveProvenanceMaterial(t *testing.T) {
+	content := "kind: Task\n"
+	repo, hashes := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task/foo.yaml": content},
+	}})
+	resolver := Resolver{}
+	resource, err := resolver.Resolve(context.Background(), map[string]string{
+		URLParam:        repo,
+		PathParam:       "/task/foo.yaml",
+		ProvenanceParam: "true",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m := material{}
+	if err := json.Unmarshal([]byte(resource.Annotations()[AnnotationKeyMaterial]), &m); err != nil {
+		t.Fatalf("invalid material annotation: %v", err)
+	}
+	expectedURI := "git+" + repo + "@" + hashes[0] + "#task/foo.yaml"
+	if m.URI != expectedURI {
+		t.Errorf("expected material uri %q, received %q", expectedURI, m.URI)
+	}
+	digest := sha256.Sum256([]byte(content))
+	if m.Digest["sha256"] != hex.EncodeToString(digest[:]) {
+		t.Errorf("expected material sha256 digest %x, received %q", digest, m.Digest["sha256"])
+	}
+	if m.Digest["sha256"] != resource.Annotations()[AnnotationKeyContentSHA256] {
+		t.Errorf("expected material digest to match the content digest annotation")
+	}
+}
+
+func TestExpandRepoURL(t *testing.T) {
+	for _, tc := range []struct {
+		url         string
+		expected    string
+		expectedErr bool
+	}{{
+		url:      "github.com/tektoncd/catalog",
+		expected: "https://github.com/tektoncd/catalog",
+	}, {
+		url:      "gitlab.com/group/subgroup/repo.git",
+		expected: "https://gitlab.com/group/subgroup/repo.git",
+	}, {
+		url:      "https://github.com/tektoncd/catalog.git",
+		expected: "https://github.com/tektoncd/catalog.git",
+	}, {
+		url:      "git@github.com:tektoncd/catalog.git",
+		expected: "git@github.com:tektoncd/catalog.git",
+	}, {
+		url:      "/var/repos/catalog",
+		expected: "/var/repos/catalog",
+	}, {
+		url:         "tektoncd/catalog",
+		expectedErr: true,
+	}, {
+		url:         "git.example.com/tektoncd/catalog",
+		expectedErr: true,
+	}, {
+		url:         "github.com/tektoncd",
+		expectedErr: true,
+	}} {
+		t.Run(tc.url, func(t *testing.T) {
+			expanded, err := expandRepoURL(tc.url)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("expected %q to be rejected, expanded to %q", tc.url, expanded)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if expanded != tc.expected {
+				t.Errorf("expected %q to expand to %q, received %q", tc.url, tc.expected, expanded)
+			}
+		})
+	}
+}
+
+func TestValidateParamsShortURL(t *testing.T) {
+	resolver := Resolver{}
+	if err := resolver.ValidateParams(context.Background(), map[string]string{
+		URLParam:  "github.com/tektoncd/catalog",
+		PathParam: "task.yaml",
+	}); err != nil {
+		t.Errorf("unexpected error validating short url: %v", err)
+	}
+	err := resolver.ValidateParams(context.Background(), map[string]string{
+		URLParam:  "tektoncd/catalog",
+		PathParam: "task.yaml",
+	})
+	if err == nil || !strings.Contains(err.Error(), "scheme") {
+		t.Errorf("expected url without a host to be rejected for its missing scheme, received %v", err)
+	}
+}
+
+func TestResolveNotes(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		note     string
+		expected string
+	}{{
+		name:     "commit with a note",
+		note:     "Approved-by: reviewer@example.com",
+		expected: "Approved-by: reviewer@example.com",
+	}, {
+		name: "repo without notes",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, hashes := createTestRepo(t, []commitForRepo{{
+				Files: map[string]string{"task.yaml": "content"},
+			}})
+			if tc.note != "" {
+				addTestNote(t, repo, hashes[0], tc.note)
+			}
+			resolver := Resolver{}
+			resource, err := resolver.Resolve(context.Background(), map[string]string{
+				URLParam:   repo,
+				PathParam:  "task.yaml",
+				NotesParam: "true",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			note, ok := resource.Annotations()[AnnotationKeyNotes]
+			if ok != (tc.expected != "") || note != tc.expected {
+				t.Errorf("expected note %q, received %q", tc.expected, note)
+			}
+		})
+	}
+}
+
+func TestResolveFromDetachedHead(t *testing.T) {
+	repo, commits := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "first"},
+	}, {
+		Files: map[string]string{"task.yaml": "second"},
+	}})
+	// A repo served with a detached HEAD, e.g. by a CI system that
+	// checked out its latest commit.
+	detachTestHead(t, repo, commits[1])
+	server := newFakeGitHTTPServer(t, repo)
+
+	resolver := Resolver{}
+	for _, tc := range []struct {
+		commit   string
+		expected string
+	}{{
+		commit:   commits[1],
+		expected: "second",
+	}, {
+		commit:   commits[0],
+		expected: "first",
+	}, {
+		expected: "second",
+	}} {
+		resource, err := resolver.Resolve(context.Background(), map[string]string{
+			URLParam:    server.repoURL(),
+			PathParam:   "task.yaml",
+			CommitParam: tc.commit,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error resolving commit %q: %v", tc.commit, err)
+		}
+		if string(resource.Data()) != tc.expected {
+			t.Errorf("expected %q at commit %q, received %q", tc.expected, tc.commit, resource.Data())
+		}
+	}
+}
+
+func TestResolveCommitParents(t *testing.T) {
+	repo, master := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "root"},
+	}, {
+		Files: map[string]string{"task.yaml": "second"},
+	}})
+	r, err := git.PlainOpen(repo)
+	if err != nil {
+		t.Fatalf("error opening test repo: %v", err)
+	}
+	if err := r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("feature"), plumbing.NewHash(master[0]))); err != nil {
+		t.Fatalf("error creating branch: %v", err)
+	}
+	setTestHead(t, repo, "feature")
+	feature := appendTestCommits(t, repo, []commitForRepo{{
+		Files: map[string]string{"feature.yaml": "feature"},
+	}})
+	setTestHead(t, repo, "master")
+	merge := appendTestCommits(t, repo, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "merged"},
+		Merge: []string{feature[0]},
+	}})
+
+	resolver := Resolver{}
+	for _, tc := range []struct {
+		name     string
+		commit   string
+		expected string
+	}{{
+		name:     "merge commit",
+		commit:   merge[0],
+		expected: master[1] + "," + feature[0],
+	}, {
+		name:     "single parent",
+		commit:   master[1],
+		expected: master[0],
+	}, {
+		name:   "root commit",
+		commit: master[0],
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resource, err := resolver.Resolve(context.Background(), map[string]string{
+				URLParam:    repo,
+				PathParam:   "task.yaml",
+				CommitParam: tc.commit,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			parents, ok := resource.Annotations()[AnnotationKeyCommitParents]
+			if tc.expected == "" && ok {
+				t.Errorf("expected no parents, received %q", parents)
+			}
+			if parents != tc.expected {
+				t.Errorf("expected parents %q, received %q", tc.expected, parents)
+			}
+		})
+	}
+}
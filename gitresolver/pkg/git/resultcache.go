@@ -0,0 +1,152 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// resultCacheSize is the number of resolved resources that are kept
+// when ConfigFieldResultCacheTTL is set. The oldest is dropped to make
+// room for a new one.
+const resultCacheSize = 256
+
+// resultCacheTTL parses ConfigFieldResultCacheTTL from conf, returning
+// 0 if it isn't set.
+func resultCacheTTL(conf map[string]string) (time.Duration, error) {
+	val := strings.TrimSpace(conf[ConfigFieldResultCacheTTL])
+	if val == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(val)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("invalid %q config %q: must be a non-negative duration", ConfigFieldResultCacheTTL, val)
+	}
+	return ttl, nil
+}
+
+// resultCacheKey returns the key of the resource resolved with params
+// under conf for a request in namespace. Empty params are dropped and
+// the url is expanded, so that requests that resolve the same way share
+// a key, while every other param, including those that only post-process
+// the content, and the config are part of it. The namespace is part of
+// it since secrets named by params are read from the request's
+// namespace, so a request mustn't be served content fetched with
+// another namespace's credentials.
+func resultCacheKey(namespace string, conf map[string]string, params map[string]string) string {
+	normalized := map[string]string{}
+	for key, val := range params {
+		if val != "" {
+			normalized[key] = val
+		}
+	}
+	if url, err := expandRepoURL(normalizeRepoURL(params[URLParam])); err == nil && url != "" {
+		normalized[URLParam] = url
+	}
+	// Maps are marshalled with sorted keys, so the key doesn't depend
+	// on the order params were given in.
+	key, _ := json.Marshal(struct {
+		Namespace string            `json:"namespace"`
+		Params    map[string]string `json:"params"`
+		Config    map[string]string `json:"config"`
+	}{namespace, normalized, conf})
+	return string(key)
+}
+
+// cachedResource is a resolved resource and when it expires.
+type cachedResource struct {
+	resource framework.ResolvedResource
+	expires  time.Time
+}
+
+// resultCache holds the fully processed resources most recently
+// resolved, keyed by resultCacheKey.
+type resultCache struct {
+	mu        sync.Mutex
+	resources map[string]cachedResource
+	// order is the keys of resources, oldest first.
+	order []string
+}
+
+func (c *resultCache) put(key string, resource framework.ResolvedResource, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resources == nil {
+		c.resources = map[string]cachedResource{}
+	}
+	if _, ok := c.resources[key]; !ok {
+		if len(c.order) == resultCacheSize {
+			delete(c.resources, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, key)
+	}
+	c.resources[key] = cachedResource{resource: resource, expires: expires}
+}
+
+// get returns the resource cached under key, unless it has expired by
+// now.
+func (c *resultCache) get(key string, now time.Time) (framework.ResolvedResource, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.resources[key]
+	if !ok || !now.Before(cached.expires) {
+		return nil, false
+	}
+	return cached.resource, true
+}
+
+// cachedResult returns the resource resolved for identical params
+// within the last ConfigFieldResultCacheTTL, if there is one.
+func (r *Resolver) cachedResult(ctx context.Context, params map[string]string) (framework.ResolvedResource, bool) {
+	conf := framework.GetResolverConfigFromContext(ctx)
+	ttl, err := resultCacheTTL(conf)
+	if err != nil || ttl == 0 {
+		return nil, false
+	}
+	return r.results.get(resultCacheKey(resolutioncommon.RequestNamespace(ctx), conf, params), time.Now())
+}
+
+// cacheResult returns resource and err, the result of resolving params,
+// caching resource for ConfigFieldResultCacheTTL when it was resolved
+// afresh. Resources served stale aren't cached.
+func (r *Resolver) cacheResult(ctx context.Context, params map[string]string, resource framework.ResolvedResource, err error) (framework.ResolvedResource, error) {
+	if err != nil {
+		return nil, err
+	}
+	if _, stale := resource.(*staleResource); stale {
+		return resource, nil
+	}
+	conf := framework.GetResolverConfigFromContext(ctx)
+	ttl, confErr := resultCacheTTL(conf)
+	if confErr != nil {
+		return nil, confErr
+	}
+	if ttl > 0 {
+		now := time.Now()
+		r.results.put(resultCacheKey(resolutioncommon.RequestNamespace(ctx), conf, params), resource, now.Add(ttl))
+	}
+	return resource, nil
+}
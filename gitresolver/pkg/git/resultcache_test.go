@@ -0,0 +1,149 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+func TestResolveServesCachedResult(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "name: first\n"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	resolver := Resolver{}
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldResultCacheTTL: "1h",
+	})
+	params := map[string]string{
+		URLParam:          server.repoURL(),
+		PathParam:         "task.yaml",
+		OutputFormatParam: outputFormatJSON,
+	}
+
+	first, err := resolver.Resolve(ctx, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(first.Data()) != `{"name":"first"}` {
+		t.Fatalf("unexpected data %q", first.Data())
+	}
+	requests := len(server.receivedHeaders())
+
+	appendTestCommits(t, repo, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "name: second\n"},
+	}})
+	second, err := resolver.Resolve(ctx, map[string]string{
+		URLParam:          server.repoURL() + "/",
+		PathParam:         "task.yaml",
+		OutputFormatParam: outputFormatJSON,
+		CommitParam:       "",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := len(server.receivedHeaders()); got != requests {
+		t.Errorf("expected identical request not to clone, received %d more requests", got-requests)
+	}
+	// The same resource being returned shows that it wasn't converted
+	// to json again.
+	if second != first {
+		t.Errorf("expected identical request to return the cached resource")
+	}
+
+	params[OutputFormatParam] = "yaml"
+	third, err := resolver.Resolve(ctx, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(third.Data()) != "name: second\n" {
+		t.Errorf("expected request with different post-processing to be resolved afresh, received %q", third.Data())
+	}
+}
+
+func TestResultCacheIsPerNamespace(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "name: first\n"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	resolver := Resolver{}
+	conf := map[string]string{ConfigFieldResultCacheTTL: "1h"}
+	params := map[string]string{
+		URLParam:  server.repoURL(),
+		PathParam: "task.yaml",
+	}
+
+	ctx := framework.InjectResolverConfigToContext(resolutioncommon.InjectRequestNamespace(context.Background(), "foo"), conf)
+	if _, err := resolver.Resolve(ctx, params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	requests := len(server.receivedHeaders())
+
+	ctx = framework.InjectResolverConfigToContext(resolutioncommon.InjectRequestNamespace(context.Background(), "bar"), conf)
+	if _, err := resolver.Resolve(ctx, params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := len(server.receivedHeaders()); got == requests {
+		t.Errorf("expected request from another namespace not to be served the cached result")
+	}
+}
+
+func TestResultCacheExpires(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "first"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	resolver := Resolver{}
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldResultCacheTTL: "50ms",
+	})
+	params := map[string]string{
+		URLParam:  server.repoURL(),
+		PathParam: "task.yaml",
+	}
+	if _, err := resolver.Resolve(ctx, params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	appendTestCommits(t, repo, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "second"},
+	}})
+	time.Sleep(100 * time.Millisecond)
+
+	resource, err := resolver.Resolve(ctx, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resource.Data()) != "second" {
+		t.Errorf("expected expired result to be resolved afresh, received %q", resource.Data())
+	}
+}
+
+func TestResultCacheTTLConfig(t *testing.T) {
+	for _, val := range []string{"soon", "-1m"} {
+		if _, err := resultCacheTTL(map[string]string{ConfigFieldResultCacheTTL: val}); err == nil {
+			t.Errorf("expected error for %s %q", ConfigFieldResultCacheTTL, val)
+		}
+	}
+	if ttl, err := resultCacheTTL(map[string]string{}); err != nil || ttl != 0 {
+		t.Errorf("expected cache to be disabled when unset, received %v, %v", ttl, err)
+	}
+}
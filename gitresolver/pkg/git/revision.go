@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// revisionHEAD is the base of a RevisionParam relative to the repo's
+// default branch.
+const revisionHEAD = "HEAD"
+
+// revisionStep is one ~N or ^N suffix of a RevisionParam.
+type revisionStep struct {
+	// ancestor is true for ~N, which follows the first parent n times,
+	// and false for ^N, which selects the nth parent.
+	ancestor bool
+	n        int
+}
+
+// refSelectorParams are the params that select the commit to resolve
+// from, in the order that conflicts between them are reported.
+var refSelectorParams = []string{BranchParam, CommitParam, RevisionParam, AsOfParam, EnvironmentParam}
+
+// validateRefSelectors rejects params that combine refSelectorParams
+// other than AsOfParam with BranchParam, naming every one of them that's
+// present, rather than only the first conflicting pair, so that a
+// request can be fixed in one go.
+func validateRefSelectors(params map[string]string) error {
+	present := []string{}
+	for _, p := range refSelectorParams {
+		if params[p] != "" {
+			present = append(present, p)
+		}
+	}
+	if len(present) < 2 || (len(present) == 2 && params[BranchParam] != "" && params[AsOfParam] != "") {
+		return nil
+	}
+	quoted := make([]string, len(present))
+	for i, p := range present {
+		quoted[i] = strconv.Quote(p)
+	}
+	msg := strings.Join(quoted[:len(quoted)-1], ", ") + " and " + quoted[len(quoted)-1] + " are mutually exclusive"
+	if params[BranchParam] != "" && params[AsOfParam] != "" {
+		msg += fmt.Sprintf(", except %q with %q", AsOfParam, BranchParam)
+	}
+	switch len(present) {
+	case 3:
+		msg += "; got all three"
+	case 4:
+		msg += "; got all four"
+	case 5:
+		msg += "; got all five"
+	}
+	return errors.New(msg)
+}
+
+// parseRevision splits a RevisionParam into the branch, or HEAD, that
+// it's relative to and the ~N and ^N steps taken from its tip. Any other
+// revision syntax, e.g. ranges, reflog entries or commit searches, is
+// rejected.
+func parseRevision(revision string) (string, []revisionStep, error) {
+	i := strings.IndexAny(revision, "~^")
+	if i == -1 {
+		i = len(revision)
+	}
+	base, suffix := revision[:i], revision[i:]
+	if base == "" {
+		return "", nil, fmt.Errorf("invalid %q %q: must start with a branch name or %s", RevisionParam, revision, revisionHEAD)
+	}
+	if strings.ContainsAny(base, " \t:@{}*?[\\") || strings.Contains(base, "..") {
+		return "", nil, fmt.Errorf("invalid %q %q: only <branch>~N and <branch>^N expressions are supported", RevisionParam, revision)
+	}
+
+	steps := []revisionStep{}
+	for suffix != "" {
+		step := revisionStep{ancestor: suffix[0] == '~', n: 1}
+		suffix = suffix[1:]
+		digits := 0
+		for digits < len(suffix) && suffix[digits] >= '0' && suffix[digits] <= '9' {
+			digits++
+		}
+		if digits > 0 {
+			n, err := strconv.Atoi(suffix[:digits])
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid %q %q: %w", RevisionParam, revision, err)
+			}
+			step.n = n
+			suffix = suffix[digits:]
+		}
+		if suffix != "" && suffix[0] != '~' && suffix[0] != '^' {
+			return "", nil, fmt.Errorf("invalid %q %q: only <branch>~N and <branch>^N expressions are supported", RevisionParam, revision)
+		}
+		steps = append(steps, step)
+	}
+	return base, steps, nil
+}
+
+// revisionParams returns a copy of params that clones the branch their
+// RevisionParam is relative to, or params unchanged if it isn't set or
+// is relative to HEAD.
+func revisionParams(params map[string]string) (map[string]string, error) {
+	if params[RevisionParam] == "" {
+		return params, nil
+	}
+	base, _, err := parseRevision(params[RevisionParam])
+	if err != nil {
+		return nil, err
+	}
+	if base == revisionHEAD {
+		return params, nil
+	}
+	resolved := make(map[string]string, len(params))
+	for k, v := range params {
+		resolved[k] = v
+	}
+	resolved[BranchParam] = base
+	return resolved, nil
+}
+
+// commitAtRevision returns the commit that RevisionParam revision names,
+// taking its steps from tip, the commit its branch or HEAD points to.
+func commitAtRevision(repository *git.Repository, tip plumbing.Hash, revision string) (string, error) {
+	_, steps, err := parseRevision(revision)
+	if err != nil {
+		return "", err
+	}
+	commit, err := repository.CommitObject(tip)
+	if err != nil {
+		return "", fmt.Errorf("error reading commit %s: %w", tip, err)
+	}
+	for _, step := range steps {
+		if step.ancestor {
+			for i := 0; i < step.n; i++ {
+				if commit, err = nthParent(commit, 1); err != nil {
+					return "", fmt.Errorf("error resolving %q %q: %w", RevisionParam, revision, err)
+				}
+			}
+		} else if step.n > 0 {
+			if commit, err = nthParent(commit, step.n); err != nil {
+				return "", fmt.Errorf("error resolving %q %q: %w", RevisionParam, revision, err)
+			}
+		}
+	}
+	return commit.Hash.String(), nil
+}
+
+// nthParent returns the nth parent, counting from 1, of commit.
+func nthParent(commit *object.Commit, n int) (*object.Commit, error) {
+	if n > commit.NumParents() {
+		return nil, fmt.Errorf("commit %s has %d parents, not %d: %w", commit.Hash, commit.NumParents(), n, ErrCommitNotFound)
+	}
+	parent, err := commit.Parent(n - 1)
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, fmt.Errorf("parent %d of commit %s wasn't fetched: %w", n, commit.Hash, ErrCommitNotFound)
+	}
+	return parent, err
+}
@@ -0,0 +1,180 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestResolveRevision(t *testing.T) {
+	repo, master := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "root"},
+	}, {
+		Files: map[string]string{"task.yaml": "second"},
+	}})
+	r, err := git.PlainOpen(repo)
+	if err != nil {
+		t.Fatalf("error opening test repo: %v", err)
+	}
+	if err := r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("feature"), plumbing.NewHash(master[0]))); err != nil {
+		t.Fatalf("error creating branch: %v", err)
+	}
+	setTestHead(t, repo, "feature")
+	feature := appendTestCommits(t, repo, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "feature"},
+	}})
+	setTestHead(t, repo, "master")
+	// master: root - second - merge - latest, with feature merged into
+	// merge as its second parent.
+	tip := appendTestCommits(t, repo, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "merged"},
+		Merge: []string{feature[0]},
+	}, {
+		Files: map[string]string{"task.yaml": "latest"},
+	}})
+
+	for _, tc := range []struct {
+		revision string
+		commit   string
+	}{
+		{revision: "master", commit: tip[1]},
+		{revision: "master~", commit: tip[0]},
+		{revision: "master~2", commit: master[1]},
+		{revision: "HEAD~3", commit: master[0]},
+		{revision: "master^", commit: tip[0]},
+		{revision: "master^0", commit: tip[1]},
+		{revision: "master~1^2", commit: feature[0]},
+		{revision: "master^^2", commit: feature[0]},
+		{revision: "master~~", commit: master[1]},
+		{revision: "feature~1", commit: master[0]},
+	} {
+		t.Run(tc.revision, func(t *testing.T) {
+			params := map[string]string{
+				URLParam:      repo,
+				PathParam:     "task.yaml",
+				RevisionParam: tc.revision,
+			}
+			resolver := Resolver{}
+			if err := resolver.ValidateParams(context.Background(), params); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			resource, err := resolver.Resolve(context.Background(), params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if commit := resource.Annotations()[AnnotationKeyCommitHash]; commit != tc.commit {
+				t.Errorf("expected %s to resolve to %s, received %s", tc.revision, tc.commit, commit)
+			}
+		})
+	}
+
+	t.Run("past the root commit", func(t *testing.T) {
+		resolver := Resolver{}
+		_, err := resolver.Resolve(context.Background(), map[string]string{
+			URLParam:      repo,
+			PathParam:     "task.yaml",
+			RevisionParam: "master~4",
+		})
+		if !errors.Is(err, ErrCommitNotFound) {
+			t.Errorf("expected ErrCommitNotFound, received %v", err)
+		}
+	})
+}
+
+func TestValidateRevision(t *testing.T) {
+	for _, revision := range []string{"~3", "^", "master..feature", "master@{1}", ":/fix", "master~x", "master^{tree}", "master~3 "} {
+		resolver := Resolver{}
+		err := resolver.ValidateParams(context.Background(), map[string]string{
+			URLParam:      "https://github.com/tektoncd/catalog",
+			PathParam:     "task.yaml",
+			RevisionParam: revision,
+		})
+		if err == nil {
+			t.Errorf("expected an error validating revision %q", revision)
+		}
+	}
+
+	resolver := Resolver{}
+	if err := resolver.ValidateParams(context.Background(), map[string]string{
+		URLParam:      "https://github.com/tektoncd/catalog",
+		PathParam:     "task.yaml",
+		RevisionParam: "main~3",
+		BranchParam:   "main",
+	}); err == nil {
+		t.Errorf("expected an error validating a revision with a branch")
+	}
+}
+
+func TestValidateRefSelectors(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		params      map[string]string
+		expectedErr string
+	}{{
+		name: "branch alone",
+		params: map[string]string{
+			BranchParam: "main",
+		},
+	}, {
+		name: "asOf on branch",
+		params: map[string]string{
+			BranchParam: "main",
+			AsOfParam:   "2022-03-01T00:00:00Z",
+		},
+	}, {
+		name: "two-way conflict",
+		params: map[string]string{
+			CommitParam:   "abc123",
+			RevisionParam: "main~1",
+		},
+		expectedErr: `"commit" and "revision" are mutually exclusive`,
+	}, {
+		name: "three-way conflict",
+		params: map[string]string{
+			BranchParam:   "main",
+			CommitParam:   "abc123",
+			RevisionParam: "main~1",
+		},
+		expectedErr: `"branch", "commit" and "revision" are mutually exclusive; got all three`,
+	}, {
+		name: "three-way conflict with asOf on branch",
+		params: map[string]string{
+			BranchParam: "main",
+			CommitParam: "abc123",
+			AsOfParam:   "2022-03-01T00:00:00Z",
+		},
+		expectedErr: `"branch", "commit" and "asOf" are mutually exclusive, except "asOf" with "branch"; got all three`,
+	}, {
+		name: "four-way conflict",
+		params: map[string]string{
+			BranchParam:   "main",
+			CommitParam:   "abc123",
+			RevisionParam: "main~1",
+			AsOfParam:     "2022-03-01T00:00:00Z",
+		},
+		expectedErr: `"branch", "commit", "revision" and "asOf" are mutually exclusive, except "asOf" with "branch"; got all four`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			params := map[string]string{
+				URLParam:  "https://github.com/tektoncd/catalog",
+				PathParam: "task.yaml",
+			}
+			for k, v := range tc.params {
+				params[k] = v
+			}
+			err := (&Resolver{}).ValidateParams(context.Background(), params)
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.expectedErr {
+				t.Fatalf("expected error %q, received %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/xeipuuv/gojsonschema"
+	"sigs.k8s.io/yaml"
+)
+
+// inlineSchema returns whether SchemaParam holds a schema rather than
+// the path of one.
+func inlineSchema(schema string) bool {
+	return strings.HasPrefix(strings.TrimSpace(schema), "{")
+}
+
+// validateSchemaParam returns an error if SchemaParam is an inline
+// schema that isn't valid json or a path that isn't allowed.
+func validateSchemaParam(params map[string]string) error {
+	schema := params[SchemaParam]
+	if schema == "" {
+		return nil
+	}
+	if !inlineSchema(schema) {
+		return validatePath(schema)
+	}
+	if _, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(schema)); err != nil {
+		return fmt.Errorf("invalid %q: %w", SchemaParam, err)
+	}
+	return nil
+}
+
+// loadSchema returns the json schema that SchemaParam gives inline or
+// reads it, converting it from yaml if needed, from filesystem.
+func loadSchema(filesystem billy.Filesystem, schema string) (*gojsonschema.Schema, error) {
+	source := []byte(schema)
+	if !inlineSchema(schema) {
+		content, err := readFile(filesystem, schema)
+		if err != nil {
+			return nil, fmt.Errorf("error reading schema: %w", err)
+		}
+		if source, err = yaml.YAMLToJSON(content); err != nil {
+			return nil, fmt.Errorf("error parsing schema %q: %w", schema, err)
+		}
+	}
+	loaded, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(source))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %q: %w", SchemaParam, err)
+	}
+	return loaded, nil
+}
+
+// validateAgainstSchema checks that every yaml document in content
+// matches schema, returning an error describing the first failure.
+func validateAgainstSchema(schema *gojsonschema.Schema, content []byte) error {
+	docs, err := yamlDocuments(content)
+	if err != nil {
+		return fmt.Errorf("error parsing content: %w", err)
+	}
+	for i, doc := range docs {
+		result, err := schema.Validate(gojsonschema.NewBytesLoader(doc))
+		if err != nil {
+			return fmt.Errorf("document %d: %w", i+1, err)
+		}
+		if result.Valid() {
+			continue
+		}
+		failures := result.Errors()
+		msg := fmt.Sprintf("document %d: %s", i+1, failures[0])
+		if len(failures) > 1 {
+			msg += fmt.Sprintf(" (and %d more)", len(failures)-1)
+		}
+		return errors.New(msg)
+	}
+	return nil
+}
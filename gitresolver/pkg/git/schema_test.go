@@ -0,0 +1,94 @@
+package git
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const testTaskSchema = `{
+  "type": "object",
+  "required": ["apiVersion", "kind"],
+  "properties": {
+    "kind": {"enum": ["Task", "Pipeline"]}
+  }
+}`
+
+func TestResolveSchema(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		content       string
+		schema        string
+		expectedError string
+	}{{
+		name:    "valid content with inline schema",
+		content: "apiVersion: tekton.dev/v1beta1\nkind: Task\n",
+		schema:  testTaskSchema,
+	}, {
+		name:    "valid content with schema in repo",
+		content: "apiVersion: tekton.dev/v1beta1\nkind: Pipeline\n",
+		schema:  "schemas/task.yaml",
+	}, {
+		name:    "every document is valid",
+		content: "apiVersion: tekton.dev/v1beta1\nkind: Task\n---\napiVersion: tekton.dev/v1beta1\nkind: Pipeline\n",
+		schema:  testTaskSchema,
+	}, {
+		name:          "invalid content",
+		content:       "apiVersion: tekton.dev/v1beta1\nkind: Deployment\n",
+		schema:        testTaskSchema,
+		expectedError: "document 1: kind: kind must be one of the following",
+	}, {
+		name:          "invalid second document",
+		content:       "apiVersion: tekton.dev/v1beta1\nkind: Task\n---\nkind: Service\n",
+		schema:        "schemas/task.yaml",
+		expectedError: "document 2: (root): apiVersion is required (and 1 more)",
+	}, {
+		name:          "missing schema file",
+		content:       "kind: Task\n",
+		schema:        "schemas/missing.yaml",
+		expectedError: "error reading schema",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, _ := createTestRepo(t, []commitForRepo{{
+				Files: map[string]string{
+					"task.yaml":         tc.content,
+					"schemas/task.yaml": "type: object\nrequired: [apiVersion, kind]\nproperties:\n  kind:\n    enum: [Task, Pipeline]\n",
+				},
+			}})
+			params := map[string]string{
+				URLParam:    repo,
+				PathParam:   "task.yaml",
+				SchemaParam: tc.schema,
+			}
+			resolver := Resolver{}
+			if err := resolver.ValidateParams(context.Background(), params); err != nil {
+				t.Fatalf("unexpected error validating params: %v", err)
+			}
+			resource, err := resolver.Resolve(context.Background(), params)
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, received %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.content {
+				t.Errorf("expected content %q, received %q", tc.content, resource.Data())
+			}
+		})
+	}
+}
+
+func TestValidateParamsInvalidInlineSchema(t *testing.T) {
+	resolver := Resolver{}
+	err := resolver.ValidateParams(context.Background(), map[string]string{
+		URLParam:    "https://github.com/tektoncd/catalog.git",
+		PathParam:   "task.yaml",
+		SchemaParam: `{"type": 7}`,
+	})
+	if err == nil || !strings.Contains(err.Error(), SchemaParam) {
+		t.Fatalf("expected error naming %q, received %v", SchemaParam, err)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// SchemeHandler resolves requests for repos whose url has a scheme it's
+// registered for with RegisterScheme, producing the resource in place
+// of the resolver cloning the repo with git.
+type SchemeHandler interface {
+	Resolve(ctx context.Context, params map[string]string) (framework.ResolvedResource, error)
+}
+
+// SchemeHandlerFunc is a func that resolves requests as a SchemeHandler.
+type SchemeHandlerFunc func(ctx context.Context, params map[string]string) (framework.ResolvedResource, error)
+
+// Resolve calls f.
+func (f SchemeHandlerFunc) Resolve(ctx context.Context, params map[string]string) (framework.ResolvedResource, error) {
+	return f(ctx, params)
+}
+
+// gitSchemeHandler is registered for the schemes that the resolver
+// clones with git. Resolvers clone with their own caches and limits
+// rather than calling it, but it resolves the same way for code that
+// looks it up with RegisteredScheme to wrap it, with the Resolver that's
+// handling the request.
+type gitSchemeHandler struct{}
+
+func (gitSchemeHandler) Resolve(ctx context.Context, params map[string]string) (framework.ResolvedResource, error) {
+	r, ok := ctx.Value(resolverKey{}).(*Resolver)
+	if !ok {
+		return nil, errors.New("the git scheme handler can only resolve requests handled by a git Resolver")
+	}
+	return r.resolveWithGit(ctx, params)
+}
+
+// resolverKey is the context key for the Resolver handling a request.
+type resolverKey struct{}
+
+// withResolver returns a new context carrying r, for a gitSchemeHandler
+// wrapped by a custom scheme's handler to resolve with.
+func withResolver(ctx context.Context, r *Resolver) context.Context {
+	return context.WithValue(ctx, resolverKey{}, r)
+}
+
+// schemeHandlers are the handlers registered for each url scheme.
+var schemeHandlers = struct {
+	mu       sync.RWMutex
+	handlers map[string]SchemeHandler
+}{handlers: map[string]SchemeHandler{
+	"http":  gitSchemeHandler{},
+	"https": gitSchemeHandler{},
+	"ssh":   gitSchemeHandler{},
+	"git":   gitSchemeHandler{},
+	"file":  gitSchemeHandler{},
+}}
+
+// RegisterScheme registers handler to resolve requests for repos whose
+// url has scheme, e.g. "internal" for internal://repo, replacing any
+// handler already registered for it, including the built-in git
+// handler of the http, https, ssh, git and file schemes. It's meant to
+// be called from an init func, so that forks can add schemes without
+// changing the resolver.
+func RegisterScheme(scheme string, handler SchemeHandler) {
+	schemeHandlers.mu.Lock()
+	defer schemeHandlers.mu.Unlock()
+	schemeHandlers.handlers[strings.ToLower(scheme)] = handler
+}
+
+// RegisteredScheme returns the handler registered for scheme, if any.
+func RegisteredScheme(scheme string) (SchemeHandler, bool) {
+	schemeHandlers.mu.RLock()
+	defer schemeHandlers.mu.RUnlock()
+	handler, ok := schemeHandlers.handlers[strings.ToLower(scheme)]
+	return handler, ok
+}
+
+// urlScheme returns the scheme of url, or "" if it has none, as for
+// local paths and scp-like ssh urls.
+func urlScheme(url string) string {
+	if i := strings.Index(url, "://"); i > 0 {
+		return strings.ToLower(url[:i])
+	}
+	return ""
+}
+
+// customSchemeHandler returns the handler registered for the scheme of
+// the repo url in params, unless the resolver clones it with git.
+func customSchemeHandler(params map[string]string) (SchemeHandler, bool) {
+	scheme := urlScheme(params[URLParam])
+	if scheme == "" {
+		return nil, false
+	}
+	handler, ok := RegisteredScheme(scheme)
+	if _, git := handler.(gitSchemeHandler); !ok || git {
+		return nil, false
+	}
+	return handler, true
+}
+
+// validateScheme returns an error if url has a scheme that no handler
+// is registered for.
+func validateScheme(url string) error {
+	scheme := urlScheme(url)
+	if scheme == "" {
+		return nil
+	}
+	if _, ok := RegisteredScheme(scheme); !ok {
+		return fmt.Errorf("invalid %q %q: unsupported scheme %q", URLParam, url, scheme)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResolveCustomScheme(t *testing.T) {
+	var requested []string
+	RegisterScheme("internal", SchemeHandlerFunc(func(_ context.Context, params map[string]string) (framework.ResolvedResource, error) {
+		requested = append(requested, params[PathParam])
+		return &ResolvedGitResource{
+			Commit:  "abc123",
+			Content: []byte("path: " + params[PathParam] + "\n"),
+		}, nil
+	}))
+	t.Cleanup(func() {
+		schemeHandlers.mu.Lock()
+		defer schemeHandlers.mu.Unlock()
+		delete(schemeHandlers.handlers, "internal")
+	})
+
+	resolver := Resolver{}
+	params := map[string]string{
+		URLParam:  "internal://catalog",
+		PathParam: "task.yaml",
+	}
+	if err := resolver.ValidateParams(context.Background(), params); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	resource, err := resolver.Resolve(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error resolving: %v", err)
+	}
+	if d := string(resource.Data()); d != "path: task.yaml\n" {
+		t.Errorf("expected content from the registered handler, received %q", d)
+	}
+
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"pipeline.yaml": "from: git\n"},
+	}})
+	resources, errs := resolver.ResolveBatch(context.Background(), []map[string]string{
+		{URLParam: "internal://catalog", PathParam: "other.yaml"},
+		{URLParam: repo, PathParam: "pipeline.yaml"},
+	})
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error resolving request %d of batch: %v", i, err)
+		}
+	}
+	if d := string(resources[0].Data()); d != "path: other.yaml\n" {
+		t.Errorf("expected batch content from the registered handler, received %q", d)
+	}
+	if d := string(resources[1].Data()); d != "from: git\n" {
+		t.Errorf("expected batch content cloned with git, received %q", d)
+	}
+	if len(requested) != 2 || requested[0] != "task.yaml" || requested[1] != "other.yaml" {
+		t.Errorf("expected the registered handler to resolve exactly the internal requests, received %v", requested)
+	}
+}
+
+func TestWrappedGitSchemeUsesResolver(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return true
+		}
+		return false
+	})
+	git, ok := RegisteredScheme("http")
+	if !ok {
+		t.Fatalf("expected the http scheme to be registered")
+	}
+	// The mirror scheme rewrites its urls to the fake server and
+	// clones them with git.
+	RegisterScheme("mirror", SchemeHandlerFunc(func(ctx context.Context, params map[string]string) (framework.ResolvedResource, error) {
+		rewritten := map[string]string{}
+		for key, val := range params {
+			rewritten[key] = val
+		}
+		rewritten[URLParam] = server.repoURL()
+		return git.Resolve(ctx, rewritten)
+	}))
+	t.Cleanup(func() {
+		schemeHandlers.mu.Lock()
+		defer schemeHandlers.mu.Unlock()
+		delete(schemeHandlers.handlers, "mirror")
+	})
+
+	// The token can only be read with the resolver's kube client.
+	resolver := Resolver{kubeClientSet: fakekube.NewSimpleClientset(bearerTokenSecret("s3cr3t"))}
+	ctx := resolutioncommon.InjectRequestNamespace(context.Background(), "foo")
+	params := map[string]string{
+		URLParam:               "mirror://catalog",
+		PathParam:              "task.yaml",
+		BearerTokenSecretParam: "git-token",
+	}
+	resource, err := resolver.Resolve(ctx, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d := string(resource.Data()); d != "content" {
+		t.Errorf("expected content cloned with the resolver, received %q", d)
+	}
+	_, errs := resolver.ResolveBatch(ctx, []map[string]string{params})
+	if errs[0] != nil {
+		t.Errorf("unexpected error resolving batch: %v", errs[0])
+	}
+
+	if _, err := git.Resolve(ctx, params); err == nil {
+		t.Errorf("expected the git handler to fail outside of a resolver")
+	}
+}
+
+func TestBuiltinSchemesRegistered(t *testing.T) {
+	for _, scheme := range []string{"http", "https", "ssh", "git", "file"} {
+		handler, ok := RegisteredScheme(scheme)
+		if !ok {
+			t.Errorf("expected scheme %q to be registered", scheme)
+			continue
+		}
+		if _, git := handler.(gitSchemeHandler); !git {
+			t.Errorf("expected scheme %q to be cloned with git, received handler %T", scheme, handler)
+		}
+	}
+}
+
+func TestValidateUnsupportedScheme(t *testing.T) {
+	err := (&Resolver{}).ValidateParams(context.Background(), map[string]string{
+		URLParam:  "gopher://example.com/repo",
+		PathParam: "task.yaml",
+	})
+	if err == nil || !strings.Contains(err.Error(), `unsupported scheme "gopher"`) {
+		t.Errorf("expected unsupported scheme error, received %v", err)
+	}
+}
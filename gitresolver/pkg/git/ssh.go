@@ -0,0 +1,160 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// sshAuthSockEnv is the environment variable holding the path of the
+// ssh agent's socket.
+const sshAuthSockEnv = "SSH_AUTH_SOCK"
+
+// defaultSSHUser is the user that ssh repos are cloned as when their url
+// doesn't name one.
+const defaultSSHUser = "git"
+
+// sshUser returns the user to clone the repo at url as, if url is an
+// ssh url, either ssh://[user@]host/path or scp-like [user@]host:path.
+func sshUser(url string) (string, bool) {
+	if strings.HasPrefix(url, "ssh://") {
+		parsed, err := neturl.Parse(url)
+		if err != nil {
+			return "", false
+		}
+		if parsed.User != nil && parsed.User.Username() != "" {
+			return parsed.User.Username(), true
+		}
+		return defaultSSHUser, true
+	}
+	if strings.Contains(url, "://") {
+		return "", false
+	}
+	colon := strings.Index(url, ":")
+	if colon <= 0 || strings.Contains(url[:colon], "/") {
+		return "", false
+	}
+	if at := strings.Index(url[:colon], "@"); at > 0 {
+		return url[:at], true
+	}
+	return defaultSSHUser, true
+}
+
+// sshAuth returns the credentials to clone the ssh repo at url with as
+// user. When ConfigFieldSSHAgent is set and an agent with keys is
+// listening on SSH_AUTH_SOCK its keys are used, so that they never touch
+// the pod's filesystem. Otherwise the key at ConfigFieldSSHPrivateKey is
+// used if it's set, or else nil. Host keys are checked under the
+// request's StrictHostKeyCheckingParam policy.
+func (r *Resolver) sshAuth(ctx context.Context, user string, params map[string]string) (transport.AuthMethod, error) {
+	conf := framework.GetResolverConfigFromContext(ctx)
+	useAgent := false
+	if val := strings.TrimSpace(conf[ConfigFieldSSHAgent]); val != "" {
+		var err error
+		if useAgent, err = strconv.ParseBool(val); err != nil {
+			return nil, fmt.Errorf("invalid %q config %q: must be true or false", ConfigFieldSSHAgent, val)
+		}
+	}
+	if useAgent {
+		if sock := os.Getenv(sshAuthSockEnv); sock != "" && agentHasKeys(sock) {
+			hostKeyCallback, err := r.hostKeyCallback(ctx, params)
+			if err != nil {
+				return nil, err
+			}
+			auth := &gitssh.PublicKeysCallback{
+				User:     user,
+				Callback: agentSigners(sock),
+			}
+			auth.HostKeyCallback = hostKeyCallback
+			return auth, nil
+		}
+	}
+	if keyPath := strings.TrimSpace(conf[ConfigFieldSSHPrivateKey]); keyPath != "" {
+		auth, err := gitssh.NewPublicKeysFromFile(user, keyPath, "")
+		if err != nil {
+			return nil, fmt.Errorf("error reading ssh private key %s: %w", keyPath, err)
+		}
+		if auth.HostKeyCallback, err = r.hostKeyCallback(ctx, params); err != nil {
+			return nil, err
+		}
+		return auth, nil
+	}
+	return nil, nil
+}
+
+// agentHasKeys returns whether an ssh agent holding at least one key is
+// listening on sock.
+func agentHasKeys(sock string) bool {
+	signers, err := agentSigners(sock)()
+	return err == nil && len(signers) > 0
+}
+
+// agentSigners returns a func listing the keys of the ssh agent at sock
+// as signers. The agent is dialled for each listing and signature
+// rather than keeping a connection to it open for the process's life.
+func agentSigners(sock string) func() ([]ssh.Signer, error) {
+	return func() ([]ssh.Signer, error) {
+		conn, err := net.Dial("unix", sock)
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to ssh agent: %w", err)
+		}
+		defer conn.Close()
+		keys, err := agent.NewClient(conn).List()
+		if err != nil {
+			return nil, fmt.Errorf("error listing ssh agent keys: %w", err)
+		}
+		signers := make([]ssh.Signer, 0, len(keys))
+		for _, key := range keys {
+			signers = append(signers, &agentSigner{sock: sock, key: key})
+		}
+		return signers, nil
+	}
+}
+
+// agentSigner signs with one of the keys of the ssh agent at sock.
+type agentSigner struct {
+	sock string
+	key  ssh.PublicKey
+}
+
+var _ ssh.Signer = &agentSigner{}
+
+func (s *agentSigner) PublicKey() ssh.PublicKey {
+	return s.key
+}
+
+func (s *agentSigner) Sign(_ io.Reader, data []byte) (*ssh.Signature, error) {
+	conn, err := net.Dial("unix", s.sock)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to ssh agent: %w", err)
+	}
+	defer conn.Close()
+	return agent.NewClient(conn).Sign(s.key, data)
+}
@@ -0,0 +1,218 @@
+package git
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// startTestSSHAgent serves an ssh agent holding a freshly generated key
+// on a unix socket, points SSH_AUTH_SOCK at it and returns the key.
+func startTestSSHAgent(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: priv}); err != nil {
+		t.Fatalf("error adding key to agent: %v", err)
+	}
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("error listening on agent socket: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+	t.Setenv(sshAuthSockEnv, sock)
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("error converting public key: %v", err)
+	}
+	return sshPub
+}
+
+// writeTestSSHKey writes a freshly generated private key to a temporary
+// file and returns its path.
+func writeTestSSHKey(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("error marshalling key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "id_ecdsa")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("error writing key: %v", err)
+	}
+	return path
+}
+
+func TestSSHUser(t *testing.T) {
+	for _, tc := range []struct {
+		url  string
+		user string
+		ssh  bool
+	}{
+		{url: "git@github.com:tektoncd/catalog.git", user: "git", ssh: true},
+		{url: "deploy@git.example.com:repo.git", user: "deploy", ssh: true},
+		{url: "github.com:tektoncd/catalog.git", user: "git", ssh: true},
+		{url: "ssh://git.example.com/repo.git", user: "git", ssh: true},
+		{url: "ssh://deploy@git.example.com:2222/repo.git", user: "deploy", ssh: true},
+		{url: "https://github.com/tektoncd/catalog.git"},
+		{url: "/tmp/repo"},
+		{url: "./dir:with/colon"},
+	} {
+		t.Run(tc.url, func(t *testing.T) {
+			user, ok := sshUser(tc.url)
+			if ok != tc.ssh || user != tc.user {
+				t.Errorf("expected (%q, %t) but got (%q, %t)", tc.user, tc.ssh, user, ok)
+			}
+		})
+	}
+}
+
+func TestCloneAuthWithSSHAgent(t *testing.T) {
+	key := startTestSSHAgent(t)
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldSSHAgent:      "true",
+		ConfigFieldSSHPrivateKey: writeTestSSHKey(t),
+	})
+	resolver := Resolver{}
+
+	auth, err := resolver.cloneAuth(ctx, "deploy@git.example.com:repo.git", map[string]string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	callback, ok := auth.(*gitssh.PublicKeysCallback)
+	if !ok {
+		t.Fatalf("expected agent auth but got %T", auth)
+	}
+	if callback.User != "deploy" {
+		t.Errorf("expected user %q but got %q", "deploy", callback.User)
+	}
+	signers, err := callback.Callback()
+	if err != nil {
+		t.Fatalf("unexpected error listing signers: %v", err)
+	}
+	if len(signers) != 1 || string(signers[0].PublicKey().Marshal()) != string(key.Marshal()) {
+		t.Fatalf("expected the agent's key as the only signer but got %v", signers)
+	}
+	data := []byte("session")
+	sig, err := signers[0].Sign(rand.Reader, data)
+	if err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+	if err := key.Verify(data, sig); err != nil {
+		t.Errorf("expected the agent's signature to verify: %v", err)
+	}
+}
+
+func TestCloneAuthFallsBackToSSHPrivateKey(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		agent bool
+		sock  string
+	}{{
+		name: "agent disabled",
+		// An agent is listening but the config doesn't opt in to it.
+		agent: true,
+	}, {
+		name: "no agent listening",
+		sock: filepath.Join(os.TempDir(), "no-such-agent.sock"),
+	}, {
+		name: "no socket",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			useAgent := "true"
+			if tc.agent {
+				startTestSSHAgent(t)
+				useAgent = "false"
+			} else {
+				t.Setenv(sshAuthSockEnv, tc.sock)
+			}
+			ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+				ConfigFieldSSHAgent:      useAgent,
+				ConfigFieldSSHPrivateKey: writeTestSSHKey(t),
+			})
+			resolver := Resolver{}
+
+			auth, err := resolver.cloneAuth(ctx, "ssh://git.example.com/repo.git", map[string]string{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			keys, ok := auth.(*gitssh.PublicKeys)
+			if !ok {
+				t.Fatalf("expected private key auth but got %T", auth)
+			}
+			if keys.User != "git" {
+				t.Errorf("expected user %q but got %q", "git", keys.User)
+			}
+		})
+	}
+}
+
+func TestCloneAuthSSHWithoutCredentials(t *testing.T) {
+	t.Setenv(sshAuthSockEnv, "")
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldSSHAgent: "true",
+	})
+	resolver := Resolver{}
+
+	auth, err := resolver.cloneAuth(ctx, "git@github.com:tektoncd/catalog.git", map[string]string{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if auth != nil {
+		t.Errorf("expected no auth but got %T", auth)
+	}
+}
+
+func TestCloneAuthInvalidSSHConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config map[string]string
+	}{{
+		name:   "invalid agent",
+		config: map[string]string{ConfigFieldSSHAgent: "sometimes"},
+	}, {
+		name:   "missing private key",
+		config: map[string]string{ConfigFieldSSHPrivateKey: filepath.Join(t.TempDir(), "missing")},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := framework.InjectResolverConfigToContext(context.Background(), tc.config)
+			resolver := Resolver{}
+			if _, err := resolver.cloneAuth(ctx, "git@github.com:tektoncd/catalog.git", map[string]string{}); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
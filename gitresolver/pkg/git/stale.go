@@ -0,0 +1,212 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// staleCacheSize is the number of resolved resources that are kept to
+// serve stale when ConfigFieldServeStaleOnError is set. The oldest is
+// dropped to make room for a new one.
+const staleCacheSize = 256
+
+// serveStaleOnError parses ConfigFieldServeStaleOnError from conf.
+func serveStaleOnError(conf map[string]string) (bool, error) {
+	val := strings.TrimSpace(conf[ConfigFieldServeStaleOnError])
+	if val == "" {
+		return false, nil
+	}
+	serve, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("invalid %q config %q: must be true or false", ConfigFieldServeStaleOnError, val)
+	}
+	return serve, nil
+}
+
+// cachedResult is a resource resolved for a request and when.
+type cachedResult struct {
+	resource   framework.ResolvedResource
+	resolvedAt time.Time
+}
+
+// staleResultCache holds the resources most recently resolved for
+// requests pinned to a commit, keyed by their namespace and params, to
+// fall back to when the repo can't be reached.
+type staleResultCache struct {
+	mu      sync.Mutex
+	results map[string]cachedResult
+	// order is the keys of results, oldest first.
+	order []string
+}
+
+// staleCacheKey returns the key of the resources resolved with params
+// for a request in namespace, or false if they're not pinned to a
+// commit, since only then is a cached resource the same content that a
+// fresh resolve would return. Secrets named by params are read from the
+// request's namespace, so resources are only served stale to requests
+// from the namespace that resolved them.
+func staleCacheKey(namespace string, params map[string]string) (string, bool) {
+	if params[CommitParam] == "" {
+		return "", false
+	}
+	key, err := json.Marshal(struct {
+		Namespace string            `json:"namespace"`
+		Params    map[string]string `json:"params"`
+	}{namespace, params})
+	if err != nil {
+		return "", false
+	}
+	return string(key), true
+}
+
+func (c *staleResultCache) put(key string, resource framework.ResolvedResource, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.results == nil {
+		c.results = map[string]cachedResult{}
+	}
+	if _, ok := c.results[key]; !ok {
+		if len(c.order) == staleCacheSize {
+			delete(c.results, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, key)
+	}
+	c.results[key] = cachedResult{resource: resource, resolvedAt: now}
+}
+
+func (c *staleResultCache) get(key string) (cachedResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.results[key]
+	return result, ok
+}
+
+// withStaleFallback returns resource and err, the result of resolving
+// params, caching resource when ConfigFieldServeStaleOnError is set.
+// In its place it returns the resource last resolved for the same
+// params, marked as stale, when err shows the repo was unreachable.
+// Timeouts that the request imposed on itself with its timeout or
+// http-timeout annotations don't show that.
+func (r *Resolver) withStaleFallback(ctx context.Context, params map[string]string, resource framework.ResolvedResource, err error) (framework.ResolvedResource, error) {
+	serve, confErr := serveStaleOnError(framework.GetResolverConfigFromContext(ctx))
+	if confErr != nil {
+		return nil, confErr
+	}
+	key, ok := staleCacheKey(resolutioncommon.RequestNamespace(ctx), params)
+	if !serve || !ok {
+		return resource, err
+	}
+	if err == nil {
+		r.staleResults.put(key, resource, time.Now())
+		return resource, nil
+	}
+	if !unreachableError(err) || (timeoutError(err) && selfImposedTimeout(ctx)) {
+		return nil, err
+	}
+	cached, ok := r.staleResults.get(key)
+	if !ok {
+		return nil, err
+	}
+	return &staleResource{ResolvedResource: cached.resource, resolvedAt: cached.resolvedAt, cause: err}, nil
+}
+
+// unreachableError returns whether err shows that the repo couldn't be
+// reached, such as a connection failure, a stalled connection, a
+// timeout or a server error, rather than a problem with the request.
+func unreachableError(err error) bool {
+	if errors.Is(err, ErrConnectionStalled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	// go-git wraps the errors of its http transport without letting
+	// them be unwrapped.
+	var unexpected *plumbing.UnexpectedError
+	if errors.As(err, &unexpected) {
+		var httpErr *githttp.Err
+		if errors.As(unexpected.Err, &httpErr) {
+			return httpErr.Response.StatusCode >= http.StatusInternalServerError
+		}
+		return unreachableError(unexpected.Err)
+	}
+	return false
+}
+
+// timeoutError returns whether err is the result of a request or the
+// resolution taking too long.
+func timeoutError(err error) bool {
+	if errors.Is(err, ErrConnectionStalled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var unexpected *plumbing.UnexpectedError
+	if errors.As(err, &unexpected) {
+		return timeoutError(unexpected.Err)
+	}
+	return false
+}
+
+// selfImposedTimeout returns whether the request being resolved set its
+// own timeout or http timeout, in which case timing out says nothing
+// about whether the repo can be reached.
+func selfImposedTimeout(ctx context.Context) bool {
+	return resolutioncommon.RequestTimeoutOverride(ctx) > 0 || resolutioncommon.RequestHTTPTimeout(ctx) > 0
+}
+
+// staleResource is a resource served from the cache because the repo
+// couldn't be reached to resolve it afresh.
+type staleResource struct {
+	framework.ResolvedResource
+	resolvedAt time.Time
+	cause      error
+}
+
+var _ framework.ResolvedResourceWithWarnings = &staleResource{}
+
+// Annotations returns the annotations of the cached resource, marked
+// with AnnotationKeyServedStale.
+func (r *staleResource) Annotations() map[string]string {
+	annotations := map[string]string{}
+	for key, val := range r.ResolvedResource.Annotations() {
+		annotations[key] = val
+	}
+	annotations[AnnotationKeyServedStale] = r.resolvedAt.UTC().Format(time.RFC3339)
+	return annotations
+}
+
+// Warnings returns the warnings of the cached resource followed by why
+// it was served stale.
+func (r *staleResource) Warnings() []string {
+	return append(framework.Warnings(r.ResolvedResource), fmt.Sprintf("served content cached at %s because the repo couldn't be reached: %v", r.resolvedAt.UTC().Format(time.RFC3339), r.cause))
+}
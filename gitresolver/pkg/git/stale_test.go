@@ -0,0 +1,218 @@
+package git
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+func TestResolveServesStaleWhenUnreachable(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config map[string]string
+		// takeDown makes the server unreachable after the first resolve.
+		takeDown func(server *fakeGitHTTPServer)
+		// expectStale is whether the second resolve is served from the
+		// cache rather than failing.
+		expectStale bool
+	}{{
+		name:   "server error",
+		config: map[string]string{ConfigFieldServeStaleOnError: "true"},
+		takeDown: func(server *fakeGitHTTPServer) {
+			server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return true
+			})
+		},
+		expectStale: true,
+	}, {
+		name:   "server down",
+		config: map[string]string{ConfigFieldServeStaleOnError: "true"},
+		takeDown: func(server *fakeGitHTTPServer) {
+			server.Close()
+		},
+		expectStale: true,
+	}, {
+		name:   "repo not found",
+		config: map[string]string{ConfigFieldServeStaleOnError: "true"},
+		takeDown: func(server *fakeGitHTTPServer) {
+			server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+				w.WriteHeader(http.StatusNotFound)
+				return true
+			})
+		},
+	}, {
+		name:   "disabled",
+		config: map[string]string{},
+		takeDown: func(server *fakeGitHTTPServer) {
+			server.Close()
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, hashes := createTestRepo(t, []commitForRepo{{
+				Files: map[string]string{"task.yaml": "content"},
+			}})
+			server := newFakeGitHTTPServer(t, repo)
+			resolver := Resolver{}
+			ctx := framework.InjectResolverConfigToContext(context.Background(), tc.config)
+			params := map[string]string{
+				URLParam:    server.repoURL(),
+				CommitParam: hashes[0],
+				PathParam:   "task.yaml",
+			}
+
+			fresh, err := resolver.Resolve(ctx, params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, ok := fresh.Annotations()[AnnotationKeyServedStale]; ok {
+				t.Errorf("unexpected %s annotation on fresh resource", AnnotationKeyServedStale)
+			}
+			if len(framework.Warnings(fresh)) != 0 {
+				t.Errorf("unexpected warnings on fresh resource: %v", framework.Warnings(fresh))
+			}
+
+			tc.takeDown(server)
+			stale, err := resolver.Resolve(ctx, params)
+			if !tc.expectStale {
+				if err == nil {
+					t.Fatalf("expected error resolving from unreachable repo")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(stale.Data()) != "content" {
+				t.Errorf("unexpected content %q", stale.Data())
+			}
+			servedStale := stale.Annotations()[AnnotationKeyServedStale]
+			if _, err := time.Parse(time.RFC3339, servedStale); err != nil {
+				t.Errorf("expected %s annotation to be a time, received %q", AnnotationKeyServedStale, servedStale)
+			}
+			if stale.Annotations()[AnnotationKeyCommitHash] != hashes[0] {
+				t.Errorf("expected annotations of cached resource, received %v", stale.Annotations())
+			}
+			warnings := framework.Warnings(stale)
+			if len(warnings) != 1 || !strings.Contains(warnings[0], "couldn't be reached") {
+				t.Errorf("unexpected warnings %v", warnings)
+			}
+		})
+	}
+}
+
+func TestResolveOnlyServesStalePinnedCommits(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	resolver := Resolver{}
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldServeStaleOnError: "true",
+	})
+	params := map[string]string{
+		URLParam:  server.repoURL(),
+		PathParam: "task.yaml",
+	}
+	if _, err := resolver.Resolve(ctx, params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.Close()
+	if _, err := resolver.Resolve(ctx, params); err == nil {
+		t.Fatalf("expected error resolving a branch from unreachable repo")
+	}
+}
+
+func TestResolveOnlyServesStaleToSameNamespace(t *testing.T) {
+	repo, hashes := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "content"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	resolver := Resolver{}
+	conf := map[string]string{ConfigFieldServeStaleOnError: "true"}
+	params := map[string]string{
+		URLParam:    server.repoURL(),
+		CommitParam: hashes[0],
+		PathParam:   "task.yaml",
+	}
+	ctx := framework.InjectResolverConfigToContext(resolutioncommon.InjectRequestNamespace(context.Background(), "foo"), conf)
+	if _, err := resolver.Resolve(ctx, params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.Close()
+
+	other := framework.InjectResolverConfigToContext(resolutioncommon.InjectRequestNamespace(context.Background(), "bar"), conf)
+	if _, err := resolver.Resolve(other, params); err == nil {
+		t.Fatalf("expected request from another namespace not to be served stale")
+	}
+	if _, err := resolver.Resolve(ctx, params); err != nil {
+		t.Fatalf("expected request from the same namespace to be served stale, received %v", err)
+	}
+}
+
+func TestResolveDoesNotServeStaleOnSelfImposedTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// withTimeout adds the request's own timeouts to ctx.
+		withTimeout func(ctx context.Context) context.Context
+		expectStale bool
+	}{{
+		name:        "config timeout",
+		withTimeout: func(ctx context.Context) context.Context { return ctx },
+		expectStale: true,
+	}, {
+		name: "http-timeout annotation",
+		withTimeout: func(ctx context.Context) context.Context {
+			return resolutioncommon.InjectRequestHTTPTimeout(ctx, 200*time.Millisecond)
+		},
+	}, {
+		name: "timeout annotation",
+		withTimeout: func(ctx context.Context) context.Context {
+			return resolutioncommon.InjectRequestTimeout(ctx, time.Minute)
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, hashes := createTestRepo(t, []commitForRepo{{
+				Files: map[string]string{"task.yaml": "content"},
+			}})
+			server := newFakeGitHTTPServer(t, repo)
+			resolver := Resolver{}
+			ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+				ConfigFieldServeStaleOnError: "true",
+				ConfigFieldHTTPIdleTimeout:   "200ms",
+			})
+			params := map[string]string{
+				URLParam:    server.repoURL(),
+				CommitParam: hashes[0],
+				PathParam:   "task.yaml",
+			}
+			if _, err := resolver.Resolve(ctx, params); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// The server stops responding, so requests stall.
+			server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+				<-r.Context().Done()
+				return true
+			})
+			_, err := resolver.Resolve(tc.withTimeout(ctx), params)
+			if tc.expectStale && err != nil {
+				t.Fatalf("expected to be served stale, received %v", err)
+			}
+			if !tc.expectStale && err == nil {
+				t.Fatalf("expected a self-imposed timeout not to be served stale")
+			}
+		})
+	}
+}
+
+func TestServeStaleOnErrorConfig(t *testing.T) {
+	if _, err := serveStaleOnError(map[string]string{ConfigFieldServeStaleOnError: "sometimes"}); err == nil {
+		t.Fatalf("expected error for invalid %s", ConfigFieldServeStaleOnError)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+)
+
+// defaultMaxHTTPTimeout is the ConfigFieldMaxHTTPTimeout used when it
+// isn't set.
+const defaultMaxHTTPTimeout = 5 * time.Minute
+
+// parseHTTPIdleTimeout parses ConfigFieldHTTPIdleTimeout from conf,
+// returning 0 if it isn't set.
+func parseHTTPIdleTimeout(conf map[string]string) (time.Duration, error) {
+	val := strings.TrimSpace(conf[ConfigFieldHTTPIdleTimeout])
+	if val == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(val)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid %q config %q: must be a positive duration", ConfigFieldHTTPIdleTimeout, val)
+	}
+	return timeout, nil
+}
+
+// requestHTTPIdleTimeout returns the idle timeout for the http requests
+// of the resolution in ctx: the request's http-timeout annotation,
+// clamped to ConfigFieldMaxHTTPTimeout, or ConfigFieldHTTPIdleTimeout if
+// the request doesn't set one.
+func requestHTTPIdleTimeout(ctx context.Context, conf map[string]string) (time.Duration, error) {
+	timeout, err := parseHTTPIdleTimeout(conf)
+	if err != nil {
+		return 0, err
+	}
+	maxTimeout := defaultMaxHTTPTimeout
+	if val := strings.TrimSpace(conf[ConfigFieldMaxHTTPTimeout]); val != "" {
+		if maxTimeout, err = time.ParseDuration(val); err != nil || maxTimeout <= 0 {
+			return 0, fmt.Errorf("invalid %q config %q: must be a positive duration", ConfigFieldMaxHTTPTimeout, val)
+		}
+	}
+	requested := resolutioncommon.RequestHTTPTimeout(ctx)
+	if requested <= 0 {
+		return timeout, nil
+	}
+	if requested > maxTimeout {
+		return maxTimeout, nil
+	}
+	return requested, nil
+}
+
+// stallTransport aborts requests that go without receiving any data,
+// either while waiting for the response headers or while reading the
+// body, for longer than timeout.
+type stallTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+var _ http.RoundTripper = &stallTransport{}
+
+// RoundTrip sends req, cancelling it once it has stalled.
+func (t *stallTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	watchdog := &stallWatchdog{timeout: t.timeout}
+	watchdog.timer = time.AfterFunc(t.timeout, func() {
+		atomic.StoreInt32(&watchdog.stalled, 1)
+		cancel()
+	})
+	res, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		watchdog.timer.Stop()
+		cancel()
+		return nil, watchdog.wrap(err, req.URL.Host)
+	}
+	watchdog.timer.Reset(t.timeout)
+	res.Body = &stallReadCloser{ReadCloser: res.Body, watchdog: watchdog, cancel: cancel, host: req.URL.Host}
+	return res, nil
+}
+
+// stallWatchdog records whether a request was cancelled for stalling.
+type stallWatchdog struct {
+	timer   *time.Timer
+	timeout time.Duration
+	stalled int32
+}
+
+// wrap replaces err with ErrConnectionStalled when the request failed
+// because it stalled.
+func (w *stallWatchdog) wrap(err error, host string) error {
+	if atomic.LoadInt32(&w.stalled) == 0 {
+		return err
+	}
+	return fmt.Errorf("no data received from %q for %s: %w", host, w.timeout, ErrConnectionStalled)
+}
+
+// stallReadCloser restarts its watchdog whenever data is read and stops
+// it once closed.
+type stallReadCloser struct {
+	io.ReadCloser
+	watchdog *stallWatchdog
+	cancel   context.CancelFunc
+	host     string
+}
+
+func (s *stallReadCloser) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	if n > 0 {
+		s.watchdog.timer.Reset(s.watchdog.timeout)
+	}
+	if err != nil && err != io.EOF {
+		err = s.watchdog.wrap(err, s.host)
+	}
+	return n, err
+}
+
+func (s *stallReadCloser) Close() error {
+	s.watchdog.timer.Stop()
+	s.cancel()
+	return s.ReadCloser.Close()
+}
@@ -0,0 +1,179 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// newStalledListener returns the address of a listener that accepts
+// connections but never responds on them.
+func newStalledListener(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	conns := make(chan net.Conn, 16)
+	t.Cleanup(func() {
+		listener.Close()
+		for {
+			select {
+			case conn := <-conns:
+				conn.Close()
+			default:
+				return
+			}
+		}
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestResolveAbortsStalledConnection(t *testing.T) {
+	addr := newStalledListener(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	ctx = framework.InjectResolverConfigToContext(ctx, map[string]string{
+		ConfigFieldHTTPIdleTimeout: "200ms",
+	})
+	resolver := Resolver{}
+	start := time.Now()
+	_, err := resolver.Resolve(ctx, map[string]string{
+		URLParam:    "http://" + addr + "/repo.git",
+		BranchParam: "main",
+		PathParam:   "task.yaml",
+	})
+	if !errors.Is(err, ErrConnectionStalled) {
+		t.Fatalf("expected ErrConnectionStalled, received %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected the stalled connection to be aborted promptly, took %s", elapsed)
+	}
+	if ctx.Err() != nil {
+		t.Errorf("expected the resolution not to have timed out, received %v", ctx.Err())
+	}
+}
+
+func TestResolveAppliesRequestHTTPTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		conf        map[string]string
+		httpTimeout time.Duration
+	}{{
+		name: "annotation within bounds",
+		conf: map[string]string{
+			ConfigFieldHTTPIdleTimeout: "1h",
+		},
+		httpTimeout: 200 * time.Millisecond,
+	}, {
+		name: "annotation clamped to max",
+		conf: map[string]string{
+			ConfigFieldMaxHTTPTimeout: "200ms",
+		},
+		httpTimeout: time.Hour,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			addr := newStalledListener(t)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			ctx = framework.InjectResolverConfigToContext(ctx, tc.conf)
+			ctx = resolutioncommon.InjectRequestHTTPTimeout(ctx, tc.httpTimeout)
+			resolver := Resolver{}
+			start := time.Now()
+			_, err := resolver.Resolve(ctx, map[string]string{
+				URLParam:    "http://" + addr + "/repo.git",
+				BranchParam: "main",
+				PathParam:   "task.yaml",
+			})
+			if !errors.Is(err, ErrConnectionStalled) {
+				t.Fatalf("expected ErrConnectionStalled, received %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Errorf("expected the stalled connection to be aborted promptly, took %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestRequestHTTPIdleTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		conf            map[string]string
+		httpTimeout     time.Duration
+		expectedTimeout time.Duration
+		expectedErr     string
+	}{{
+		name:            "config without annotation",
+		conf:            map[string]string{ConfigFieldHTTPIdleTimeout: "15s"},
+		expectedTimeout: 15 * time.Second,
+	}, {
+		name:            "annotation overrides config",
+		conf:            map[string]string{ConfigFieldHTTPIdleTimeout: "15s"},
+		httpTimeout:     2 * time.Minute,
+		expectedTimeout: 2 * time.Minute,
+	}, {
+		name:            "annotation clamped to default max",
+		httpTimeout:     time.Hour,
+		expectedTimeout: defaultMaxHTTPTimeout,
+	}, {
+		name:            "annotation clamped to configured max",
+		conf:            map[string]string{ConfigFieldMaxHTTPTimeout: "1m"},
+		httpTimeout:     2 * time.Minute,
+		expectedTimeout: time.Minute,
+	}, {
+		name:        "invalid max",
+		conf:        map[string]string{ConfigFieldMaxHTTPTimeout: "forever"},
+		expectedErr: `invalid "max-http-timeout" config "forever"`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.httpTimeout > 0 {
+				ctx = resolutioncommon.InjectRequestHTTPTimeout(ctx, tc.httpTimeout)
+			}
+			timeout, err := requestHTTPIdleTimeout(ctx, tc.conf)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q, received %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if timeout != tc.expectedTimeout {
+				t.Errorf("expected a %s timeout, received %s", tc.expectedTimeout, timeout)
+			}
+		})
+	}
+}
+
+func TestParseHTTPIdleTimeout(t *testing.T) {
+	if timeout, err := parseHTTPIdleTimeout(map[string]string{}); err != nil || timeout != 0 {
+		t.Errorf("expected no timeout by default, received %s, %v", timeout, err)
+	}
+	if timeout, err := parseHTTPIdleTimeout(map[string]string{ConfigFieldHTTPIdleTimeout: "15s"}); err != nil || timeout != 15*time.Second {
+		t.Errorf("expected a 15s timeout, received %s, %v", timeout, err)
+	}
+	for _, val := range []string{"0", "-1s", "soon"} {
+		if _, err := parseHTTPIdleTimeout(map[string]string{ConfigFieldHTTPIdleTimeout: val}); err == nil {
+			t.Errorf("expected an error parsing %q", val)
+		}
+	}
+}
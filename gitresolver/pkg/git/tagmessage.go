@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// validateTagMessage returns an error if TagMessageParam is combined
+// with params that select a file or a commit, which don't apply to a
+// tag's message.
+func validateTagMessage(params map[string]string) error {
+	if params[TagMessageParam] == "" {
+		return nil
+	}
+	for _, p := range []string{PathParam, CommitParam, AsOfParam, RevisionParam, WorktreeParam, WellKnownParam, BlobParam, TektonBundleDirParam, RefAParam, RefBParam, MergeBaseParam, StartLineParam, EndLineParam, ResolveIncludesParam, TemplateParam, SchemaParam, OutputFormatParam, NormalizeYAMLParam, EnvironmentParam, CommitMessageFilterParam} {
+		if params[p] != "" {
+			return fmt.Errorf("supplied both %q and %q", TagMessageParam, p)
+		}
+	}
+	return nil
+}
+
+// resolveTagMessage reads the message of the annotated tag named by
+// TagMessageParam. The tag is fetched from the repo if the clone in co
+// doesn't already have it, since only the tags of the cloned branches'
+// history are cloned with them.
+func (r *Resolver) resolveTagMessage(ctx context.Context, co *checkout, params map[string]string) (*ResolvedGitResource, error) {
+	name := params[TagMessageParam]
+	refName := plumbing.NewTagReferenceName(name)
+	ref, err := co.repository.Reference(refName, false)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		if co.transport != nil {
+			ctx = withRequestTransport(ctx, co.transport)
+		}
+		err = co.repository.FetchContext(ctx, &git.FetchOptions{
+			RemoteName: git.DefaultRemoteName,
+			RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("+%s:%s", refName, refName))},
+			Auth:       co.auth,
+			Tags:       git.NoTags,
+		})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			if errors.Is(err, git.NoMatchingRefSpecError{}) {
+				return nil, fmt.Errorf("%w: %q", ErrTagNotFound, name)
+			}
+			return nil, fmt.Errorf("error fetching tag %q: %w", name, err)
+		}
+		ref, err = co.repository.Reference(refName, false)
+	}
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, fmt.Errorf("%w: %q", ErrTagNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading tag %q: %w", name, err)
+	}
+
+	tag, err := co.repository.TagObject(ref.Hash())
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, fmt.Errorf("tag %q: %w", name, ErrLightweightTag)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading tag %q: %w", name, err)
+	}
+	commit, err := tag.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("error reading commit of tag %q: %w", name, err)
+	}
+
+	annotations, err := refAnnotations(co, params)
+	if err != nil {
+		return nil, err
+	}
+	annotations[AnnotationKeyTagger] = fmt.Sprintf("%s <%s>", tag.Tagger.Name, tag.Tagger.Email)
+	annotations[AnnotationKeyTaggedAt] = tag.Tagger.When.UTC().Format(time.RFC3339)
+	return &ResolvedGitResource{
+		Commit:           commit.Hash.String(),
+		Content:          []byte(tag.Message),
+		ContentType:      TextContentType,
+		ExtraAnnotations: annotations,
+	}, nil
+}
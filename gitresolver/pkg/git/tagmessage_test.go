@@ -0,0 +1,125 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+func TestResolveTagMessage(t *testing.T) {
+	taggedAt := time.Date(2022, time.March, 4, 12, 30, 0, 0, time.UTC)
+	repo, hashes := createTestRepo(t, []commitForRepo{{
+		Files:      map[string]string{"task.yaml": "version: 1\n"},
+		Tag:        "v1.0.0",
+		TagMessage: "Release v1.0.0\n\n- Add the build task\n",
+		When:       taggedAt,
+	}, {
+		Files: map[string]string{"task.yaml": "version: 2\n"},
+		Tag:   "nightly",
+	}})
+	setTestHead(t, repo, "hotfix")
+	hotfix := appendTestCommits(t, repo, []commitForRepo{{
+		Files:      map[string]string{"task.yaml": "version: 1.0.1\n"},
+		Tag:        "v1.0.1",
+		TagMessage: "Release v1.0.1\n",
+	}})
+	setTestHead(t, repo, "master")
+	// Drop the branch so that v1.0.1 is only reachable from its tag, and
+	// so isn't cloned along with the default branch.
+	r, err := git.PlainOpen(repo)
+	if err != nil {
+		t.Fatalf("error opening test repo: %v", err)
+	}
+	if err := r.Storer.RemoveReference(plumbing.NewBranchReferenceName("hotfix")); err != nil {
+		t.Fatalf("error removing branch: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name            string
+		tag             string
+		expectedMessage string
+		expectedCommit  string
+		expectedTagger  string
+		expectedWhen    string
+		expectedErr     error
+	}{{
+		name:            "annotated tag",
+		tag:             "v1.0.0",
+		expectedMessage: "Release v1.0.0\n\n- Add the build task\n",
+		expectedCommit:  hashes[0],
+		expectedTagger:  "Test <test@example.com>",
+		expectedWhen:    "2022-03-04T12:30:00Z",
+	}, {
+		name:            "tag outside the cloned branch",
+		tag:             "v1.0.1",
+		expectedMessage: "Release v1.0.1\n",
+		expectedCommit:  hotfix[0],
+		expectedTagger:  "Test <test@example.com>",
+		expectedWhen:    "2022-01-01T00:00:00Z",
+	}, {
+		name:        "lightweight tag",
+		tag:         "nightly",
+		expectedErr: ErrLightweightTag,
+	}, {
+		name:        "missing tag",
+		tag:         "v9.9.9",
+		expectedErr: framework.ErrorResourceNotFound,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := Resolver{}
+			resource, err := resolver.Resolve(context.Background(), map[string]string{
+				URLParam:        repo,
+				TagMessageParam: tc.tag,
+			})
+			if tc.expectedErr != nil {
+				if !errors.Is(err, tc.expectedErr) {
+					t.Fatalf("expected error %v but got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.expectedMessage {
+				t.Errorf("expected message %q but got %q", tc.expectedMessage, resource.Data())
+			}
+			annotations := resource.Annotations()
+			for key, expected := range map[string]string{
+				AnnotationKeyCommitHash:                   tc.expectedCommit,
+				AnnotationKeyTagger:                       tc.expectedTagger,
+				AnnotationKeyTaggedAt:                     tc.expectedWhen,
+				resolutioncommon.AnnotationKeyContentType: TextContentType,
+			} {
+				if annotations[key] != expected {
+					t.Errorf("expected annotation %s to be %q but got %q", key, expected, annotations[key])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateParamsTagMessage(t *testing.T) {
+	resolver := Resolver{}
+	if err := resolver.ValidateParams(context.Background(), map[string]string{
+		URLParam:        "https://github.com/tektoncd/catalog",
+		TagMessageParam: "v1.0.0",
+		BranchParam:     "main",
+	}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, p := range []string{PathParam, CommitParam, BlobParam, RefAParam} {
+		if err := resolver.ValidateParams(context.Background(), map[string]string{
+			URLParam:        "https://github.com/tektoncd/catalog",
+			TagMessageParam: "v1.0.0",
+			p:               "x",
+		}); err == nil {
+			t.Errorf("expected error combining %q with %q", TagMessageParam, p)
+		}
+	}
+}
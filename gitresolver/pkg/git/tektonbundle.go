@@ -0,0 +1,168 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// tektonAPIGroup is the api group, or suffix of the api groups, of
+// Tekton resources, e.g. tekton.dev/v1beta1 or triggers.tekton.dev/v1beta1.
+const tektonAPIGroup = "tekton.dev"
+
+// manifestEntry describes one document of a TektonBundleDirParam bundle.
+type manifestEntry struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// validateTektonBundleDir returns an error if TektonBundleDirParam is
+// combined with params that name a single file.
+func validateTektonBundleDir(params map[string]string) error {
+	if params[TektonBundleDirParam] == "" {
+		return nil
+	}
+	for _, p := range []string{PathParam, WellKnownParam, BlobParam, ResolveIncludesParam} {
+		if params[p] != "" {
+			return fmt.Errorf("supplied both %q and %q", TektonBundleDirParam, p)
+		}
+	}
+	return nil
+}
+
+// readTektonBundle returns every Tekton resource in the yaml files under
+// dir, searched recursively in order of their paths, as a single stream
+// of yaml documents along with a json manifest of their kinds and names.
+// Documents that aren't Tekton resources are skipped.
+func readTektonBundle(filesystem billy.Filesystem, dir string) ([]byte, string, error) {
+	return readTektonResources(filesystem, dir, func([]byte, manifestEntry) bool { return true })
+}
+
+// readTektonResources returns the Tekton resources in the yaml files
+// under dir for which keep returns true, as readTektonBundle does.
+func readTektonResources(filesystem billy.Filesystem, dir string, keep func(doc []byte, entry manifestEntry) bool) ([]byte, string, error) {
+	dir = path.Clean("/" + dir)
+	files, err := yamlFilesUnder(filesystem, dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", fmt.Errorf("no %s directory found: %w", dir, ErrFileNotFound)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading %s directory: %w", dir, err)
+	}
+	sort.Strings(files)
+
+	buf := &bytes.Buffer{}
+	manifest := []manifestEntry{}
+	for _, file := range files {
+		content, err := readFile(filesystem, file)
+		if err != nil {
+			return nil, "", err
+		}
+		reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(content)))
+		for {
+			doc, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				// Files that aren't valid yaml can't hold a Tekton
+				// resource.
+				break
+			}
+			entry, ok := tektonResource(doc)
+			if !ok || !keep(doc, entry) {
+				continue
+			}
+			entry.Path = strings.TrimPrefix(file, "/")
+			manifest = append(manifest, entry)
+			doc = stripLeadingComments(doc)
+			if buf.Len() > 0 {
+				buf.WriteString("---\n")
+			}
+			buf.Write(doc)
+			if doc[len(doc)-1] != '\n' {
+				buf.WriteByte('\n')
+			}
+		}
+	}
+	if len(manifest) == 0 {
+		return nil, "", fmt.Errorf("no Tekton resources found in %s directory: %w", dir, ErrFileNotFound)
+	}
+	encoded, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, "", fmt.Errorf("error serializing manifest: %w", err)
+	}
+	return buf.Bytes(), string(encoded), nil
+}
+
+// tektonResource returns the kind and name of doc if it's a Tekton
+// resource.
+func tektonResource(doc []byte) (manifestEntry, bool) {
+	var resource struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Metadata   struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}
+	if err := yaml.Unmarshal(doc, &resource); err != nil || resource.Kind == "" {
+		return manifestEntry{}, false
+	}
+	group := strings.SplitN(resource.APIVersion, "/", 2)[0]
+	if group != tektonAPIGroup && !strings.HasSuffix(group, "."+tektonAPIGroup) {
+		return manifestEntry{}, false
+	}
+	return manifestEntry{Kind: resource.Kind, Name: resource.Metadata.Name}, true
+}
+
+// yamlFilesUnder returns the paths of every yaml file under dir.
+func yamlFilesUnder(filesystem billy.Filesystem, dir string) ([]string, error) {
+	entries, err := filesystem.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	for _, entry := range entries {
+		name := path.Join(dir, entry.Name())
+		if entry.IsDir() {
+			nested, err := yamlFilesUnder(filesystem, name)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, nested...)
+			continue
+		}
+		if ext := path.Ext(name); ext == ".yaml" || ext == ".yml" {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,111 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResolveTektonBundle(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{
+			"ci/pipeline.yaml": `# The build pipeline.
+apiVersion: tekton.dev/v1beta1
+kind: Pipeline
+metadata:
+  name: build
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+`,
+			"ci/tasks/lint.yml": `apiVersion: tekton.dev/v1beta1
+kind: Task
+metadata:
+  name: lint
+`,
+			"ci/triggers.yaml": `apiVersion: triggers.tekton.dev/v1beta1
+kind: TriggerTemplate
+metadata:
+  name: on-push
+`,
+			"ci/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+`,
+			"ci/broken.yaml": "kind: [Task\n",
+			"ci/README.md":   "apiVersion: tekton.dev/v1beta1\nkind: Task\n",
+			"other/task.yaml": `apiVersion: tekton.dev/v1beta1
+kind: Task
+metadata:
+  name: elsewhere
+`,
+		},
+	}})
+
+	params := map[string]string{
+		URLParam:             repo,
+		TektonBundleDirParam: "ci",
+	}
+	resolver := Resolver{}
+	if err := resolver.ValidateParams(context.Background(), params); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	resource, err := resolver.Resolve(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedContent := `apiVersion: tekton.dev/v1beta1
+kind: Pipeline
+metadata:
+  name: build
+---
+apiVersion: tekton.dev/v1beta1
+kind: Task
+metadata:
+  name: lint
+---
+apiVersion: triggers.tekton.dev/v1beta1
+kind: TriggerTemplate
+metadata:
+  name: on-push
+`
+	if string(resource.Data()) != expectedContent {
+		t.Errorf("expected content:\n%s\nreceived:\n%s", expectedContent, resource.Data())
+	}
+
+	var manifest []manifestEntry
+	if err := json.Unmarshal([]byte(resource.Annotations()[AnnotationKeyManifest]), &manifest); err != nil {
+		t.Fatalf("error parsing manifest annotation %q: %v", resource.Annotations()[AnnotationKeyManifest], err)
+	}
+	expectedManifest := []manifestEntry{
+		{Path: "ci/pipeline.yaml", Kind: "Pipeline", Name: "build"},
+		{Path: "ci/tasks/lint.yml", Kind: "Task", Name: "lint"},
+		{Path: "ci/triggers.yaml", Kind: "TriggerTemplate", Name: "on-push"},
+	}
+	if !reflect.DeepEqual(manifest, expectedManifest) {
+		t.Errorf("expected manifest %+v, received %+v", expectedManifest, manifest)
+	}
+}
+
+func TestResolveTektonBundleWithoutTektonResources(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{
+			"deploy/deployment.yaml": "apiVersion: apps/v1\nkind: Deployment\n",
+		},
+	}})
+	resolver := Resolver{}
+	for _, dir := range []string{"deploy", "missing"} {
+		_, err := resolver.Resolve(context.Background(), map[string]string{
+			URLParam:             repo,
+			TektonBundleDirParam: dir,
+		})
+		if !errors.Is(err, ErrFileNotFound) {
+			t.Errorf("expected ErrFileNotFound resolving %s, received %v", dir, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// templateVarPrefix prefixes the params whose values TemplateParam
+// substitutes into the resolved file, e.g. "var.env" is rendered by
+// {{ .env }}.
+const templateVarPrefix = "var."
+
+// templateVars returns the variables given by params for TemplateParam,
+// keyed by their names without templateVarPrefix.
+func templateVars(params map[string]string) map[string]string {
+	vars := map[string]string{}
+	for key, val := range params {
+		if strings.HasPrefix(key, templateVarPrefix) {
+			vars[strings.TrimPrefix(key, templateVarPrefix)] = val
+		}
+	}
+	return vars
+}
+
+// validateTemplateParams returns an error if TemplateParam is set to an
+// invalid value, or if template variables are supplied without it.
+func validateTemplateParams(params map[string]string) error {
+	render, err := parseBoolParam(params, TemplateParam)
+	if err != nil {
+		return err
+	}
+	vars := templateVars(params)
+	if render || len(vars) == 0 {
+		return nil
+	}
+	names := []string{}
+	for name := range vars {
+		names = append(names, templateVarPrefix+name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("supplied %s without setting %q to true", strings.Join(names, ", "), TemplateParam)
+}
+
+// renderTemplate renders content as a go template, substituting the
+// given variables into its {{ .name }} placeholders. Referring to a
+// variable that wasn't supplied is an error. Tekton's own $(...)
+// variables are left as they are.
+func renderTemplate(content []byte, vars map[string]string) ([]byte, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, vars); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,84 @@
+package git
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestResolveTemplate(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		content         string
+		params          map[string]string
+		expectedContent string
+		expectedError   string
+	}{{
+		name:            "variables substituted",
+		content:         "kind: Task\nmetadata:\n  name: build-{{ .env }}\nspec:\n  steps:\n  - image: {{ .image }}\n",
+		params:          map[string]string{TemplateParam: "true", "var.env": "prod", "var.image": "golang:1.17"},
+		expectedContent: "kind: Task\nmetadata:\n  name: build-prod\nspec:\n  steps:\n  - image: golang:1.17\n",
+	}, {
+		name:            "tekton variables left as they are",
+		content:         "script: echo $(params.message) {{ .greeting }}\n",
+		params:          map[string]string{TemplateParam: "true", "var.greeting": "hello"},
+		expectedContent: "script: echo $(params.message) hello\n",
+	}, {
+		name:            "placeholders kept when not rendering",
+		content:         "name: build-{{ .env }}\n",
+		params:          map[string]string{},
+		expectedContent: "name: build-{{ .env }}\n",
+	}, {
+		name:          "missing variable",
+		content:       "name: build-{{ .env }}\n",
+		params:        map[string]string{TemplateParam: "true"},
+		expectedError: `map has no entry for key "env"`,
+	}, {
+		name:          "malformed template",
+		content:       "name: build-{{ .env\n",
+		params:        map[string]string{TemplateParam: "true", "var.env": "prod"},
+		expectedError: `error rendering "task.yaml" as a template`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, _ := createTestRepo(t, []commitForRepo{{
+				Files: map[string]string{"task.yaml": tc.content},
+			}})
+			params := map[string]string{
+				URLParam:  repo,
+				PathParam: "task.yaml",
+			}
+			for key, val := range tc.params {
+				params[key] = val
+			}
+			resolver := Resolver{}
+			if err := resolver.ValidateParams(context.Background(), params); err != nil {
+				t.Fatalf("unexpected error validating params: %v", err)
+			}
+			resource, err := resolver.Resolve(context.Background(), params)
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, received %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.expectedContent {
+				t.Errorf("expected content %q, received %q", tc.expectedContent, resource.Data())
+			}
+		})
+	}
+}
+
+func TestValidateParamsTemplateVarsWithoutTemplate(t *testing.T) {
+	resolver := Resolver{}
+	err := resolver.ValidateParams(context.Background(), map[string]string{
+		URLParam:  "https://github.com/tektoncd/catalog.git",
+		PathParam: "task.yaml",
+		"var.env": "prod",
+	})
+	if err == nil || !strings.Contains(err.Error(), TemplateParam) {
+		t.Fatalf("expected error naming %q, received %v", TemplateParam, err)
+	}
+}
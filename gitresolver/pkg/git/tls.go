@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// tlsVersions maps the values of ConfigFieldMinTLSVersion to the TLS
+// versions they name.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsRootCAs, when set, replaces the system's root CAs as the roots
+// that git hosts' certificates are verified against. It's set by tests.
+var tlsRootCAs *x509.CertPool
+
+// parseMinTLSVersion returns the TLS version set by
+// ConfigFieldMinTLSVersion, or 0 to use Go's default minimum when it's
+// not set.
+func parseMinTLSVersion(conf map[string]string) (uint16, error) {
+	val := strings.TrimSpace(conf[ConfigFieldMinTLSVersion])
+	if val == "" {
+		return 0, nil
+	}
+	version, ok := tlsVersions[val]
+	if !ok {
+		names := []string{}
+		for name := range tlsVersions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return 0, fmt.Errorf("invalid %q config %q: must be one of %s", ConfigFieldMinTLSVersion, val, strings.Join(names, ", "))
+	}
+	return version, nil
+}
+
+// tlsVersionName returns the ConfigFieldMinTLSVersion value naming
+// version.
+func tlsVersionName(version uint16) string {
+	for name, v := range tlsVersions {
+		if v == version {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
+
+// tlsClientConfig returns the TLS config of connections to git hosts
+// that requires at least minVersion, or nil to use Go's defaults.
+func tlsClientConfig(minVersion uint16) *tls.Config {
+	if minVersion == 0 && tlsRootCAs == nil {
+		return nil
+	}
+	return &tls.Config{
+		MinVersion: minVersion,
+		RootCAs:    tlsRootCAs,
+	}
+}
+
+// wrapTLSVersionError explains an error handshaking with host when it
+// was caused by the host not supporting minVersion or later.
+func wrapTLSVersionError(err error, host string, minVersion uint16) error {
+	if minVersion == 0 || !strings.Contains(err.Error(), "protocol version") {
+		return err
+	}
+	return fmt.Errorf("%s doesn't support TLS %s or later, the minimum set by %q: %w", host, tlsVersionName(minVersion), ConfigFieldMinTLSVersion, err)
+}
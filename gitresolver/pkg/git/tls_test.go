@@ -0,0 +1,94 @@
+package git
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+// trustTestServer makes the resolver trust server's certificate, and
+// only it, until the test completes.
+func trustTestServer(t *testing.T, server *fakeGitHTTPServer) {
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	resetPooledTransports := func() {
+		pooledTransports.mu.Lock()
+		defer pooledTransports.mu.Unlock()
+		pooledTransports.transports = map[poolSettings]*http.Transport{}
+	}
+	tlsRootCAs = roots
+	resetPooledTransports()
+	t.Cleanup(func() {
+		tlsRootCAs = nil
+		resetPooledTransports()
+	})
+}
+
+func TestResolveMinTLSVersion(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		serverMaxVersion uint16
+		minTLSVersion    string
+		expectedError    string
+	}{{
+		name:             "server at the minimum version",
+		serverMaxVersion: tls.VersionTLS12,
+		minTLSVersion:    "1.2",
+	}, {
+		name:             "server above the minimum version",
+		serverMaxVersion: tls.VersionTLS13,
+		minTLSVersion:    "1.2",
+	}, {
+		name:             "server below the minimum version",
+		serverMaxVersion: tls.VersionTLS12,
+		minTLSVersion:    "1.3",
+		expectedError:    `doesn't support TLS 1.3 or later, the minimum set by "min-tls-version"`,
+	}, {
+		name:             "old server below the minimum version",
+		serverMaxVersion: tls.VersionTLS11,
+		minTLSVersion:    "1.2",
+		expectedError:    `doesn't support TLS 1.2 or later, the minimum set by "min-tls-version"`,
+	}, {
+		name:             "invalid version",
+		serverMaxVersion: tls.VersionTLS13,
+		minTLSVersion:    "1.4",
+		expectedError:    `invalid "min-tls-version" config "1.4"`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, _ := createTestRepo(t, []commitForRepo{{
+				Files: map[string]string{"task.yaml": "content"},
+			}})
+			server := newFakeGitHTTPSServer(t, repo, &tls.Config{
+				MinVersion: tls.VersionTLS10,
+				MaxVersion: tc.serverMaxVersion,
+			})
+			trustTestServer(t, server)
+
+			ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+				ConfigFieldMinTLSVersion: tc.minTLSVersion,
+			})
+			resolver := Resolver{}
+			resource, err := resolver.Resolve(ctx, map[string]string{
+				URLParam:  server.repoURL(),
+				PathParam: "task.yaml",
+			})
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, received %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != "content" {
+				t.Errorf("unexpected content %q", resource.Data())
+			}
+		})
+	}
+}
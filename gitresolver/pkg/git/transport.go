@@ -0,0 +1,296 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// httpClient is used by go-git for all git-over-http traffic. go-git
+// only allows a single client per protocol so its transport reads the
+// settings for each individual resolution out of the request context.
+var httpClient = &http.Client{
+	Transport:     &requestScopedTransport{base: pooledTransport(defaultPoolSettings)},
+	CheckRedirect: checkRedirect,
+}
+
+func init() {
+	client.InstallProtocol("http", githttp.NewClient(httpClient))
+	client.InstallProtocol("https", githttp.NewClient(httpClient))
+}
+
+// requestTransportKey is the context key for a resolution's
+// requestTransport.
+type requestTransportKey struct{}
+
+// requestTransport holds the http transport settings and stats of a
+// single resolution.
+type requestTransport struct {
+	// userAgent replaces go-git's user-agent when set.
+	userAgent string
+	// retryStatusCodes are the response status codes that cause a
+	// request to be retried.
+	retryStatusCodes map[int]bool
+	// base replaces the shared transport's base when set, e.g. to dial
+	// through a proxy or pool connections differently.
+	base http.RoundTripper
+	// negotiate, when set, adds a SPNEGO Authorization header to
+	// requests to negotiateHosts and to requests challenged for it.
+	// Redirected requests are never sent it.
+	negotiate negotiator
+	// negotiateHosts are the ConfigFieldKerberosHosts that are sent
+	// Negotiate auth without waiting to be challenged.
+	negotiateHosts map[string]bool
+	// budget bounds the retries of every request of the resolution.
+	budget *retryBudget
+	// redirectPolicy is the ConfigFieldFollowRedirects policy that
+	// redirects are checked against.
+	redirectPolicy string
+	// maxRedirects is the ConfigFieldMaxRedirects number of redirects
+	// a request may follow.
+	maxRedirects int
+	// minTLSVersion is the ConfigFieldMinTLSVersion that the
+	// transport was built with, used to explain handshake failures.
+	minTLSVersion uint16
+	// idleTimeout is the ConfigFieldHTTPIdleTimeout, or the request's
+	// http-timeout annotation, after which a request that hasn't
+	// received any data is aborted, or 0 for none.
+	idleTimeout time.Duration
+	// disk, when set, bounds the bytes that may be fetched.
+	disk *diskBudget
+	// cacheControl is the ConfigFieldHTTPCacheControl header sent with
+	// requests for a repo's refs.
+	cacheControl string
+	// responses, when set, keeps responses to revalidate with
+	// conditional requests.
+	responses *httpResponseCache
+
+	bytesFetched int64
+	cacheHits    int64
+}
+
+// maxHTTPRetries is the number of times a request that receives one of
+// the configured retry status codes is retried before its response is
+// returned as-is.
+const maxHTTPRetries = 3
+
+// retryBackoff is the delay before the first retry of a request whose
+// response has no Retry-After header. It doubles with each retry.
+var retryBackoff = time.Second
+
+// parseRetryStatusCodes parses the comma-separated list of status codes
+// in ConfigFieldRetryStatusCodes.
+func parseRetryStatusCodes(conf map[string]string) (map[int]bool, error) {
+	val := strings.TrimSpace(conf[ConfigFieldRetryStatusCodes])
+	if val == "" {
+		return nil, nil
+	}
+	codes := map[int]bool{}
+	for _, field := range strings.Split(val, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid %q config %q: %q is not an http status code", ConfigFieldRetryStatusCodes, val, field)
+		}
+		codes[code] = true
+	}
+	return codes, nil
+}
+
+// withRequestTransport returns a context that http requests made by
+// go-git will read rt from.
+func withRequestTransport(ctx context.Context, rt *requestTransport) context.Context {
+	return context.WithValue(ctx, requestTransportKey{}, rt)
+}
+
+// requestTransportFromContext returns the requestTransport stored in
+// ctx or nil if there isn't one.
+func requestTransportFromContext(ctx context.Context) *requestTransport {
+	rt, _ := ctx.Value(requestTransportKey{}).(*requestTransport)
+	return rt
+}
+
+// BytesFetched returns the number of response body bytes received so
+// far during the resolution.
+func (rt *requestTransport) BytesFetched() int64 {
+	return atomic.LoadInt64(&rt.bytesFetched)
+}
+
+// requestScopedTransport is an http.RoundTripper that applies the
+// requestTransport found in each request's context.
+type requestScopedTransport struct {
+	base http.RoundTripper
+}
+
+var _ http.RoundTripper = &requestScopedTransport{}
+
+// RoundTrip applies the request's requestTransport settings and sends
+// it using the base transport, counting the bytes of the response body.
+func (t *requestScopedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt := requestTransportFromContext(req.Context())
+	if rt == nil {
+		return t.base.RoundTrip(req)
+	}
+	// The cache key is taken before kerberos auth is added, since each
+	// SPNEGO token is different.
+	var cacheKey string
+	var cached *cachedResponse
+	if cacheableRequest(req) {
+		cacheKey = httpResponseCacheKey(req)
+		if rt.responses != nil {
+			if res, ok := rt.responses.get(cacheKey); ok {
+				cached = &res
+			}
+		}
+		req = rt.withCacheHeaders(req, cached)
+	}
+	// Requests created by following a redirect have their Response
+	// set, and aren't given a ticket meant for the original host.
+	negotiate := rt.negotiate != nil && req.Response == nil
+	preemptive := negotiate && rt.negotiateHosts[strings.ToLower(req.URL.Hostname())]
+	if rt.userAgent != "" || preemptive {
+		req = req.Clone(req.Context())
+	}
+	if rt.userAgent != "" {
+		req.Header.Set("User-Agent", rt.userAgent)
+	}
+	if preemptive {
+		if err := rt.negotiate(req); err != nil {
+			return nil, fmt.Errorf("error negotiating kerberos auth with %q: %w", req.URL.Host, err)
+		}
+	}
+	base := t.base
+	if rt.base != nil {
+		base = rt.base
+	}
+	if rt.idleTimeout > 0 {
+		base = &stallTransport{base: base, timeout: rt.idleTimeout}
+	}
+	res, err := roundTripWithRetries(base, req, rt.retryStatusCodes, rt.budget)
+	if err == nil && negotiate && !preemptive && negotiateChallenged(req, res) {
+		res, err = rt.roundTripNegotiated(base, req, res)
+	}
+	if err != nil {
+		return res, wrapTLSVersionError(err, req.URL.Host, rt.minTLSVersion)
+	}
+	res.Body = &countingReadCloser{ReadCloser: res.Body, count: &rt.bytesFetched, disk: rt.disk}
+	if cached != nil && res.StatusCode == http.StatusNotModified {
+		return rt.fromCache(req, res, *cached), nil
+	}
+	if rt.responses != nil && cacheKey != "" {
+		return rt.keep(cacheKey, res)
+	}
+	return res, nil
+}
+
+// roundTripWithRetries sends req, retrying it while the response has one
+// of the given status codes and budget allows. Requests whose body can't
+// be replayed are never retried.
+func roundTripWithRetries(base http.RoundTripper, req *http.Request, retryStatusCodes map[int]bool, budget *retryBudget) (*http.Response, error) {
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		res, err := base.RoundTrip(req)
+		if err != nil || !retryStatusCodes[res.StatusCode] || attempt == maxHTTPRetries {
+			return res, err
+		}
+		if req.Body != nil && req.GetBody == nil {
+			return res, nil
+		}
+		delay := backoff
+		if res.StatusCode == http.StatusTooManyRequests {
+			if after, ok := parseRetryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+				delay = after
+			}
+		}
+		if !budget.allow(delay) {
+			return res, nil
+		}
+		_, _ = io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+
+		req = req.Clone(req.Context())
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number
+// of seconds or an http date.
+func parseRetryAfter(val string, now time.Time) (time.Duration, bool) {
+	if val == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(val); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if when, err := http.ParseTime(val); err == nil {
+		if delay := when.Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// countingReadCloser adds the number of bytes read through it to count,
+// failing reads once they exceed disk.
+type countingReadCloser struct {
+	io.ReadCloser
+	count *int64
+	disk  *diskBudget
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	atomic.AddInt64(c.count, int64(n))
+	if budgetErr := c.disk.use(int64(n)); budgetErr != nil {
+		return n, budgetErr
+	}
+	return n, err
+}
+
+// defaultUserAgent identifies the resolver to git hosts when
+// ConfigFieldUserAgent isn't set.
+func defaultUserAgent() string {
+	version := "devel"
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+	return "tekton-resolution/" + version
+}
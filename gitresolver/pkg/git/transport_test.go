@@ -0,0 +1,193 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+)
+
+func TestResolveRecordsBytesFetched(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "kind: Task\n"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	resolver := Resolver{}
+	resource, err := resolver.Resolve(context.Background(), map[string]string{
+		URLParam:  server.repoURL(),
+		PathParam: "task.yaml",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fetched, err := strconv.ParseInt(resource.Annotations()[AnnotationKeyBytesFetched], 10, 64)
+	if err != nil {
+		t.Fatalf("invalid bytes fetched annotation: %v", err)
+	}
+	// A single commit repo is a few hundred bytes of refs and pack
+	// data. Anything in the megabytes means counting is broken.
+	if fetched <= 0 || fetched > 1024*1024 {
+		t.Fatalf("implausible bytes fetched: %d", fetched)
+	}
+}
+
+func TestResolveUserAgent(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		config   map[string]string
+		expected string
+	}{{
+		name:     "default",
+		config:   map[string]string{},
+		expected: defaultUserAgent(),
+	}, {
+		name: "configured",
+		config: map[string]string{
+			ConfigFieldUserAgent: "my-resolver/1.0",
+		},
+		expected: "my-resolver/1.0",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, _ := createTestRepo(t, []commitForRepo{{
+				Files: map[string]string{"task.yaml": "kind: Task\n"},
+			}})
+			server := newFakeGitHTTPServer(t, repo)
+			resolver := Resolver{}
+			ctx := framework.InjectResolverConfigToContext(context.Background(), tc.config)
+			if _, err := resolver.Resolve(ctx, map[string]string{
+				URLParam:  server.repoURL(),
+				PathParam: "task.yaml",
+			}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			headers := server.receivedHeaders()
+			if len(headers) == 0 {
+				t.Fatalf("expected server to receive requests")
+			}
+			for _, h := range headers {
+				if ua := h.Get("User-Agent"); ua != tc.expected {
+					t.Errorf("expected user-agent %q, received %q", tc.expected, ua)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveRetriesStatusCodes(t *testing.T) {
+	oldBackoff := retryBackoff
+	retryBackoff = 10 * time.Millisecond
+	t.Cleanup(func() { retryBackoff = oldBackoff })
+
+	for _, tc := range []struct {
+		name       string
+		status     int
+		retryAfter string
+		minElapsed time.Duration
+	}{{
+		name:       "429 honors retry-after",
+		status:     http.StatusTooManyRequests,
+		retryAfter: "1",
+		minElapsed: time.Second,
+	}, {
+		name:       "500 backs off",
+		status:     http.StatusInternalServerError,
+		minElapsed: retryBackoff + 2*retryBackoff,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo, _ := createTestRepo(t, []commitForRepo{{
+				Files: map[string]string{"task.yaml": "kind: Task\n"},
+			}})
+			server := newFakeGitHTTPServer(t, repo)
+			var mu sync.Mutex
+			failures := 0
+			server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+				mu.Lock()
+				defer mu.Unlock()
+				if failures < 2 {
+					failures++
+					if tc.retryAfter != "" {
+						w.Header().Set("Retry-After", tc.retryAfter)
+					}
+					w.WriteHeader(tc.status)
+					return true
+				}
+				return false
+			})
+
+			resolver := Resolver{}
+			ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+				ConfigFieldRetryStatusCodes: "429, 500, 502, 503",
+			})
+			start := time.Now()
+			resource, err := resolver.Resolve(ctx, map[string]string{
+				URLParam:  server.repoURL(),
+				PathParam: "task.yaml",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != "kind: Task\n" {
+				t.Errorf("unexpected content %q", resource.Data())
+			}
+			if elapsed := time.Since(start); elapsed < tc.minElapsed {
+				t.Errorf("expected retries to wait at least %s, took %s", tc.minElapsed, elapsed)
+			}
+			if failures != 2 {
+				t.Errorf("expected 2 failed attempts before success, received %d", failures)
+			}
+		})
+	}
+}
+
+func TestResolveDoesNotRetryUnconfiguredStatusCodes(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "kind: Task\n"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	attempts := 0
+	server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	})
+	resolver := Resolver{}
+	ctx := framework.InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigFieldRetryStatusCodes: "429",
+	})
+	if _, err := resolver.Resolve(ctx, map[string]string{
+		URLParam:  server.repoURL(),
+		PathParam: "task.yaml",
+	}); err == nil {
+		t.Fatalf("expected error from unavailable server")
+	}
+	if attempts != 1 {
+		t.Errorf("expected a single attempt, received %d", attempts)
+	}
+}
+
+func TestResolveUnauthorizedIsAuthFailure(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"task.yaml": "kind: Task\n"},
+	}})
+	server := newFakeGitHTTPServer(t, repo)
+	server.setIntercept(func(w http.ResponseWriter, r *http.Request) bool {
+		w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return true
+	})
+	resolver := Resolver{}
+	for _, params := range []map[string]string{
+		{URLParam: server.repoURL(), PathParam: "task.yaml"},
+		{URLParam: server.repoURL(), PathParam: "task.yaml", BranchParam: "master"},
+	} {
+		_, err := resolver.Resolve(context.Background(), params)
+		if !errors.Is(err, framework.ErrorAuthenticationFailed) {
+			t.Errorf("expected auth failure resolving %v, received %v", params, err)
+		}
+	}
+}
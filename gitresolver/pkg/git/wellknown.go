@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+)
+
+const (
+	// wellKnownLicense is the repo's license file.
+	wellKnownLicense = "license"
+	// wellKnownCodeowners is the repo's CODEOWNERS file.
+	wellKnownCodeowners = "codeowners"
+	// wellKnownTekton is every Tekton resource in the repo's .tekton
+	// directory.
+	wellKnownTekton = "tekton"
+)
+
+// wellKnownCandidates are the paths tried, in order, for each
+// WellKnownParam that names a single file.
+var wellKnownCandidates = map[string][]string{
+	wellKnownLicense: {
+		"LICENSE", "LICENSE.md", "LICENSE.txt",
+		"LICENCE", "LICENCE.md", "LICENCE.txt",
+		"COPYING", "COPYING.md", "COPYING.txt",
+	},
+	wellKnownCodeowners: {
+		"CODEOWNERS", ".github/CODEOWNERS", ".gitlab/CODEOWNERS", "docs/CODEOWNERS",
+	},
+}
+
+// tektonDir is the directory read for wellKnownTekton.
+const tektonDir = ".tekton"
+
+// validateWellKnown returns an error if WellKnownParam is set to an
+// unsupported value or given along with PathParam.
+func validateWellKnown(params map[string]string) error {
+	kind := params[WellKnownParam]
+	if kind == "" {
+		return nil
+	}
+	if params[PathParam] != "" {
+		return fmt.Errorf("supplied both %q and %q", PathParam, WellKnownParam)
+	}
+	switch kind {
+	case wellKnownLicense, wellKnownCodeowners, wellKnownTekton:
+		return nil
+	default:
+		return fmt.Errorf("invalid %q %q: must be %q, %q or %q", WellKnownParam, kind, wellKnownLicense, wellKnownCodeowners, wellKnownTekton)
+	}
+}
+
+// readWellKnown reads the well-known file of the given kind from
+// filesystem, returning the path it was found at along with its content.
+func readWellKnown(filesystem billy.Filesystem, kind string) (string, []byte, error) {
+	if kind == wellKnownTekton {
+		content, err := readTektonDir(filesystem)
+		return tektonDir + "/", content, err
+	}
+	for _, candidate := range wellKnownCandidates[kind] {
+		content, err := readFile(filesystem, candidate)
+		if errors.Is(err, ErrFileNotFound) {
+			continue
+		}
+		return candidate, content, err
+	}
+	return "", nil, fmt.Errorf("no %s file found at any of %s: %w", kind, strings.Join(wellKnownCandidates[kind], ", "), ErrFileNotFound)
+}
+
+// readTektonDir returns every yaml file directly in tektonDir, in order
+// of their names, as a single stream of yaml documents.
+func readTektonDir(filesystem billy.Filesystem) ([]byte, error) {
+	entries, err := filesystem.ReadDir(tektonDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no %s directory found: %w", tektonDir, ErrFileNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s directory: %w", tektonDir, err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if ext := path.Ext(entry.Name()); !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			names = append(names, entry.Name())
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no yaml files found in %s directory: %w", tektonDir, ErrFileNotFound)
+	}
+	sort.Strings(names)
+	buf := &bytes.Buffer{}
+	for i, name := range names {
+		content, err := readFile(filesystem, path.Join(tektonDir, name))
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(content)
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes(), nil
+}
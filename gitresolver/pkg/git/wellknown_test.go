@@ -0,0 +1,113 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestResolveWellKnown(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		kind            string
+		files           map[string]string
+		expectedPath    string
+		expectedContent string
+	}{{
+		name:            "license",
+		kind:            wellKnownLicense,
+		files:           map[string]string{"LICENSE": "Apache", "LICENSE.md": "markdown"},
+		expectedPath:    "LICENSE",
+		expectedContent: "Apache",
+	}, {
+		name:            "license with markdown extension",
+		kind:            wellKnownLicense,
+		files:           map[string]string{"LICENSE.md": "markdown", "LICENSE.txt": "text"},
+		expectedPath:    "LICENSE.md",
+		expectedContent: "markdown",
+	}, {
+		name:            "license with text extension",
+		kind:            wellKnownLicense,
+		files:           map[string]string{"LICENSE.txt": "text", "COPYING": "gpl"},
+		expectedPath:    "LICENSE.txt",
+		expectedContent: "text",
+	}, {
+		name:            "codeowners at root",
+		kind:            wellKnownCodeowners,
+		files:           map[string]string{"CODEOWNERS": "* @root", ".github/CODEOWNERS": "* @github"},
+		expectedPath:    "CODEOWNERS",
+		expectedContent: "* @root",
+	}, {
+		name:            "codeowners in .github",
+		kind:            wellKnownCodeowners,
+		files:           map[string]string{".github/CODEOWNERS": "* @github", "docs/CODEOWNERS": "* @docs"},
+		expectedPath:    ".github/CODEOWNERS",
+		expectedContent: "* @github",
+	}, {
+		name: "tekton directory",
+		kind: wellKnownTekton,
+		files: map[string]string{
+			".tekton/push.yaml":     "kind: PipelineRun\nmetadata:\n  name: push",
+			".tekton/pr.yml":        "kind: PipelineRun\nmetadata:\n  name: pr\n",
+			".tekton/README.md":     "not yaml",
+			".tekton/nested/x.yaml": "kind: Task",
+			"pipelines/other.yaml":  "kind: Pipeline",
+		},
+		expectedPath:    ".tekton/",
+		expectedContent: "kind: PipelineRun\nmetadata:\n  name: pr\n---\nkind: PipelineRun\nmetadata:\n  name: push\n",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			files := map[string]string{"README.md": "readme"}
+			for path, content := range tc.files {
+				files[path] = content
+			}
+			repo, _ := createTestRepo(t, []commitForRepo{{Files: files}})
+			params := map[string]string{
+				URLParam:       repo,
+				WellKnownParam: tc.kind,
+			}
+			resolver := Resolver{}
+			if err := resolver.ValidateParams(context.Background(), params); err != nil {
+				t.Fatalf("unexpected error validating params: %v", err)
+			}
+			resource, err := resolver.Resolve(context.Background(), params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.expectedContent {
+				t.Errorf("expected content %q, received %q", tc.expectedContent, resource.Data())
+			}
+			if path := resource.Annotations()[AnnotationKeyPath]; path != tc.expectedPath {
+				t.Errorf("expected path %q, received %q", tc.expectedPath, path)
+			}
+		})
+	}
+}
+
+func TestResolveWellKnownMissing(t *testing.T) {
+	repo, _ := createTestRepo(t, []commitForRepo{{
+		Files: map[string]string{"README.md": "readme"},
+	}})
+	resolver := Resolver{}
+	for _, kind := range []string{wellKnownLicense, wellKnownCodeowners, wellKnownTekton} {
+		_, err := resolver.Resolve(context.Background(), map[string]string{
+			URLParam:       repo,
+			WellKnownParam: kind,
+		})
+		if !errors.Is(err, ErrFileNotFound) {
+			t.Errorf("expected %s to be not found, received %v", kind, err)
+		}
+	}
+}
+
+func TestValidateParamsWellKnown(t *testing.T) {
+	resolver := Resolver{}
+	for _, params := range []map[string]string{
+		{URLParam: "https://github.com/tektoncd/catalog.git", WellKnownParam: "readme"},
+		{URLParam: "https://github.com/tektoncd/catalog.git", WellKnownParam: wellKnownLicense, PathParam: "LICENSE"},
+	} {
+		if err := resolver.ValidateParams(context.Background(), params); err == nil {
+			t.Errorf("expected error validating %v", params)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// validateWorktree returns an error if WorktreeParam is set for a repo
+// that isn't a local path or combined with a commit or branch.
+func validateWorktree(params map[string]string) error {
+	if params[WorktreeParam] == "" {
+		return nil
+	}
+	for _, p := range []string{CommitParam, BranchParam, AsOfParam, RevisionParam, EnvironmentParam, CommitMessageFilterParam} {
+		if params[p] != "" {
+			return fmt.Errorf("supplied both %q and %q", WorktreeParam, p)
+		}
+	}
+	if _, ok := localRepoPath(normalizeRepoURL(params[URLParam])); !ok {
+		return fmt.Errorf("invalid %q %q: %q is only supported for repos at a local path", URLParam, params[URLParam], WorktreeParam)
+	}
+	return nil
+}
+
+// localRepoPath returns the path of the repo at url if it's a local path
+// or file:// url.
+func localRepoPath(url string) (string, bool) {
+	if strings.HasPrefix(url, "file://") {
+		return strings.TrimPrefix(url, "file://"), true
+	}
+	if filepath.IsAbs(url) || strings.HasPrefix(url, ".") {
+		return url, true
+	}
+	return "", false
+}
+
+// worktreeParams returns a copy of params resolving from the commit that
+// their WorktreeParam has checked out, or params unchanged if it isn't
+// set.
+func worktreeParams(params map[string]string) (map[string]string, error) {
+	worktree := params[WorktreeParam]
+	if worktree == "" {
+		return params, nil
+	}
+	repoPath, _ := localRepoPath(normalizeRepoURL(params[URLParam]))
+	commit, err := worktreeCommit(repoPath, worktree)
+	if err != nil {
+		return nil, err
+	}
+	resolved := make(map[string]string, len(params))
+	for k, v := range params {
+		resolved[k] = v
+	}
+	resolved[CommitParam] = commit
+	return resolved, nil
+}
+
+// worktreeCommit returns the commit checked out in a linked worktree of
+// the repo at repoPath. The worktree is given either by its name, as
+// passed to `git worktree add`, or by the path of its checkout, relative
+// to repoPath if not absolute.
+func worktreeCommit(repoPath, worktree string) (string, error) {
+	gitDir := filepath.Join(repoPath, git.GitDirName)
+	if info, err := os.Stat(gitDir); err != nil || !info.IsDir() {
+		// A bare repo.
+		gitDir = repoPath
+	}
+	worktreesDir := filepath.Join(gitDir, "worktrees")
+
+	adminDir := filepath.Join(worktreesDir, worktree)
+	if strings.ContainsAny(worktree, `/\`) || !isDir(adminDir) {
+		checkout := worktree
+		if !filepath.IsAbs(checkout) {
+			checkout = filepath.Join(repoPath, checkout)
+		}
+		var err error
+		if adminDir, err = worktreeAdminDir(checkout); err != nil {
+			return "", fmt.Errorf("error finding worktree %q: %w", worktree, err)
+		}
+		if !sameDir(filepath.Dir(adminDir), worktreesDir) {
+			return "", fmt.Errorf("worktree %q belongs to another repo: %w", worktree, ErrWorktreeNotFound)
+		}
+	}
+
+	head, err := os.ReadFile(filepath.Join(adminDir, "HEAD"))
+	if err != nil {
+		return "", fmt.Errorf("error reading HEAD of worktree %q: %w", worktree, err)
+	}
+	target := strings.TrimSpace(string(head))
+	if !strings.HasPrefix(target, "ref: ") {
+		if !plumbing.IsHash(target) {
+			return "", fmt.Errorf("invalid HEAD %q of worktree %q", target, worktree)
+		}
+		return target, nil
+	}
+	repository, err := git.PlainOpen(repoPath)
+	if err != nil {
+		return "", fmt.Errorf("error opening %q: %w", repoPath, err)
+	}
+	ref, err := repository.Reference(plumbing.ReferenceName(strings.TrimPrefix(target, "ref: ")), true)
+	if err != nil {
+		return "", fmt.Errorf("error resolving HEAD %q of worktree %q: %w", target, worktree, err)
+	}
+	return ref.Hash().String(), nil
+}
+
+// worktreeAdminDir returns the directory in its repo's git dir that the
+// .git file in the linked worktree checkout at dir points to.
+func worktreeAdminDir(dir string) (string, error) {
+	path := filepath.Join(dir, git.GitDirName)
+	if isDir(path) {
+		return "", fmt.Errorf("%s is not a linked worktree: %w", dir, ErrWorktreeNotFound)
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", ErrWorktreeNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(content))
+	if !strings.HasPrefix(line, "gitdir: ") {
+		return "", fmt.Errorf("%s is not a worktree checkout: %w", dir, ErrWorktreeNotFound)
+	}
+	adminDir := strings.TrimPrefix(line, "gitdir: ")
+	if !filepath.IsAbs(adminDir) {
+		adminDir = filepath.Join(dir, adminDir)
+	}
+	return adminDir, nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// sameDir returns whether a and b are the same directory, following any
+// symlinks.
+func sameDir(a, b string) bool {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(aInfo, bInfo)
+}
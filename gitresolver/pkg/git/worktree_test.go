@@ -0,0 +1,125 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// addTestWorktree lays out a linked worktree of the repo at dir named
+// name with the given HEAD, as `git worktree add` would, returning the
+// path of its checkout.
+func addTestWorktree(t *testing.T, dir, name, head string) string {
+	t.Helper()
+	checkout := t.TempDir()
+	adminDir := filepath.Join(dir, ".git", "worktrees", name)
+	if err := os.MkdirAll(adminDir, 0755); err != nil {
+		t.Fatalf("error creating worktree admin dir: %v", err)
+	}
+	for file, content := range map[string]string{
+		filepath.Join(adminDir, "HEAD"):      head + "\n",
+		filepath.Join(adminDir, "commondir"): "../..\n",
+		filepath.Join(adminDir, "gitdir"):    filepath.Join(checkout, ".git") + "\n",
+		filepath.Join(checkout, ".git"):      "gitdir: " + adminDir + "\n",
+	} {
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("error writing %s: %v", file, err)
+		}
+	}
+	return checkout
+}
+
+func TestResolveFromWorktree(t *testing.T) {
+	dir, commits := createTestRepo(t, []commitForRepo{
+		{Files: map[string]string{"task.yaml": "feature"}},
+		{Files: map[string]string{"task.yaml": "main"}},
+	})
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("error opening test repo: %v", err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("feature"), plumbing.NewHash(commits[0]))); err != nil {
+		t.Fatalf("error creating feature branch: %v", err)
+	}
+	featureCheckout := addTestWorktree(t, dir, "feature", "ref: refs/heads/feature")
+	addTestWorktree(t, dir, "detached", commits[1])
+
+	for _, tc := range []struct {
+		name     string
+		worktree string
+		content  string
+		commit   string
+	}{{
+		name:     "worktree name",
+		worktree: "feature",
+		content:  "feature",
+		commit:   commits[0],
+	}, {
+		name:     "worktree checkout path",
+		worktree: featureCheckout,
+		content:  "feature",
+		commit:   commits[0],
+	}, {
+		name:     "detached worktree",
+		worktree: "detached",
+		content:  "main",
+		commit:   commits[1],
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			params := map[string]string{
+				URLParam:      dir,
+				PathParam:     "task.yaml",
+				WorktreeParam: tc.worktree,
+			}
+			resolver := Resolver{}
+			if err := resolver.ValidateParams(context.Background(), params); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			resource, err := resolver.Resolve(context.Background(), params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(resource.Data()) != tc.content {
+				t.Errorf("expected content %q, received %q", tc.content, resource.Data())
+			}
+			if commit := resource.Annotations()[AnnotationKeyCommitHash]; commit != tc.commit {
+				t.Errorf("expected commit %s, received %s", tc.commit, commit)
+			}
+		})
+	}
+
+	t.Run("unknown worktree", func(t *testing.T) {
+		resolver := Resolver{}
+		_, err := resolver.Resolve(context.Background(), map[string]string{
+			URLParam:      dir,
+			PathParam:     "task.yaml",
+			WorktreeParam: "missing",
+		})
+		if !errors.Is(err, ErrWorktreeNotFound) {
+			t.Fatalf("expected ErrWorktreeNotFound, received %v", err)
+		}
+	})
+}
+
+func TestValidateWorktree(t *testing.T) {
+	for _, params := range []map[string]string{{
+		URLParam:      "https://github.com/tektoncd/catalog",
+		PathParam:     "task.yaml",
+		WorktreeParam: "feature",
+	}, {
+		URLParam:      "/src/catalog",
+		PathParam:     "task.yaml",
+		WorktreeParam: "feature",
+		BranchParam:   "main",
+	}} {
+		resolver := Resolver{}
+		if err := resolver.ValidateParams(context.Background(), params); err == nil {
+			t.Errorf("expected an error validating %v", params)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"sigs.k8s.io/yaml"
+)
+
+// maxChartSize bounds the uncompressed size of a chart's archive, to
+// protect the resolver from archives that expand without limit.
+const maxChartSize = 20 << 20
+
+// loadChart reads the chart in the gzipped tar archive archive, as
+// written by helm package, with helm's own loader. Like helm template,
+// it rejects library charts and charts whose Chart.yaml lists
+// dependencies that aren't in their charts/ directory.
+func loadChart(archive []byte) (*chart.Chart, error) {
+	if err := checkChartSize(archive); err != nil {
+		return nil, err
+	}
+	c, err := loader.LoadArchive(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("error reading chart archive: %w", err)
+	}
+	if c.Metadata.Type != "" && c.Metadata.Type != "application" {
+		return nil, fmt.Errorf("chart %s is a %s chart and can't be rendered", c.Name(), c.Metadata.Type)
+	}
+	if err := checkDependencies(c); err != nil {
+		return nil, fmt.Errorf("chart %s: %w", c.Name(), err)
+	}
+	return c, nil
+}
+
+// checkChartSize returns an error if archive expands to more than
+// maxChartSize bytes.
+func checkChartSize(archive []byte) error {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return fmt.Errorf("error reading chart archive: %w", err)
+	}
+	defer gz.Close()
+	n, err := io.Copy(io.Discard, io.LimitReader(gz, maxChartSize+1))
+	if err != nil {
+		return fmt.Errorf("error reading chart archive: %w", err)
+	}
+	if n > maxChartSize {
+		return fmt.Errorf("chart archive is larger than %d bytes", maxChartSize)
+	}
+	return nil
+}
+
+// checkDependencies returns an error naming the dependencies listed in
+// the Chart.yaml of c that aren't in its charts/ directory.
+func checkDependencies(c *chart.Chart) error {
+	missing := []string{}
+	for _, dep := range c.Metadata.Dependencies {
+		found := false
+		for _, sub := range c.Dependencies() {
+			if sub.Name() == dep.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, dep.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("found in Chart.yaml, but missing in charts/ directory: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// parseValues parses a YAML map of values.
+func parseValues(content string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(content), &values); err != nil {
+		return nil, err
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	return values, nil
+}
+
+// mergeValues deep merges overrides into base, with the values in
+// overrides taking precedence, and returns base, as helm merges the
+// files given to -f. A null override is kept, so that it removes the
+// key from the chart's own values when they're coalesced.
+func mergeValues(base, overrides map[string]interface{}) map[string]interface{} {
+	for key, override := range overrides {
+		overrideMap, overrideIsMap := override.(map[string]interface{})
+		baseMap, baseIsMap := base[key].(map[string]interface{})
+		if overrideIsMap && baseIsMap {
+			base[key] = mergeValues(baseMap, overrideMap)
+			continue
+		}
+		base[key] = override
+	}
+	return base
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	"sigs.k8s.io/yaml"
+)
+
+// ociScheme prefixes the ChartParam of charts stored in OCI registries.
+const ociScheme = "oci://"
+
+// chartLayerMediaType is the media type of the layer holding a chart's
+// archive in an OCI registry.
+const chartLayerMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+
+// maxIndexSize bounds the size of a chart repository's index.yaml,
+// which lists every version of every chart in the repository and so
+// can be far larger than any one chart.
+const maxIndexSize = 100 << 20
+
+// fetchedChart is the archive of a chart fetched from a repository or
+// registry.
+type fetchedChart struct {
+	archive []byte
+	version string
+	// digest is the sha256 digest of the archive or, for oci:// charts,
+	// of the manifest it was fetched with.
+	digest string
+}
+
+// repoIndex is the index.yaml of a chart repository.
+type repoIndex struct {
+	Entries map[string][]repoIndexEntry `json:"entries"`
+}
+
+// repoIndexEntry is a single version of a chart in a repoIndex.
+type repoIndexEntry struct {
+	Version string   `json:"version"`
+	URLs    []string `json:"urls"`
+	Digest  string   `json:"digest"`
+}
+
+// fetchFromRepo fetches version of the named chart, or its latest
+// version if version is empty, from the chart repository at repo.
+func fetchFromRepo(ctx context.Context, repo, chartName, version string) (*fetchedChart, error) {
+	base, err := url.Parse(strings.TrimSuffix(repo, "/") + "/")
+	if err != nil {
+		return nil, fmt.Errorf("invalid %q %q: %w", RepoParam, repo, err)
+	}
+	indexURL := base.ResolveReference(&url.URL{Path: "index.yaml"})
+	content, err := httpGet(ctx, indexURL.String(), maxIndexSize)
+	if err != nil {
+		return nil, err
+	}
+	index := repoIndex{}
+	if err := yaml.Unmarshal(content, &index); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", indexURL, err)
+	}
+	versions := index.Entries[chartName]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: no chart named %q in %s", framework.ErrorResourceNotFound, chartName, repo)
+	}
+	candidates := make([]string, 0, len(versions))
+	for _, entry := range versions {
+		candidates = append(candidates, entry.Version)
+	}
+	chosen, err := chooseVersion(candidates, version)
+	if err != nil {
+		return nil, fmt.Errorf("chart %q in %s: %w", chartName, repo, err)
+	}
+	var entry repoIndexEntry
+	for _, entry = range versions {
+		if entry.Version == chosen {
+			break
+		}
+	}
+	if len(entry.URLs) == 0 {
+		return nil, fmt.Errorf("version %s of chart %q in %s has no urls", chosen, chartName, repo)
+	}
+	archiveURL, err := base.Parse(entry.URLs[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %q of chart %q: %w", entry.URLs[0], chartName, err)
+	}
+	archive, err := httpGet(ctx, archiveURL.String(), maxChartSize)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(archive)
+	digest := hex.EncodeToString(sum[:])
+	if entry.Digest != "" && !strings.EqualFold(entry.Digest, digest) {
+		return nil, fmt.Errorf("digest of %s is sha256:%s but the repository's index lists %s", archiveURL, digest, entry.Digest)
+	}
+	return &fetchedChart{archive: archive, version: chosen, digest: "sha256:" + digest}, nil
+}
+
+// httpGet returns the body of a successful GET request to rawURL, which
+// must be no larger than limit bytes.
+func httpGet(ctx context.Context, rawURL string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error building request for %s: %w", rawURL, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err := fmt.Errorf("error fetching %s: %s", rawURL, resp.Status)
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			return nil, fmt.Errorf("%w: %v", framework.ErrorResourceNotFound, err)
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return nil, fmt.Errorf("%w: %v", framework.ErrorAuthenticationFailed, err)
+		default:
+			return nil, err
+		}
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", rawURL, err)
+	}
+	if int64(len(content)) > limit {
+		return nil, fmt.Errorf("%s is larger than %d bytes", rawURL, limit)
+	}
+	return content, nil
+}
+
+// fetchFromRegistry fetches version of the chart at ref, an oci://
+// reference without a tag, or its latest version if version is empty.
+func fetchFromRegistry(ctx context.Context, keychain authn.Keychain, ref, version string) (*fetchedChart, error) {
+	repo, err := name.NewRepository(strings.TrimPrefix(ref, ociScheme))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %q %q: %w", ChartParam, ref, err)
+	}
+	opts := []remote.Option{remote.WithAuthFromKeychain(keychain), remote.WithContext(ctx)}
+	if version == "" {
+		tags, err := remote.List(repo, opts...)
+		if err != nil {
+			return nil, registryError(fmt.Errorf("error listing versions of %s: %w", ref, err))
+		}
+		// OCI tags can't hold a "+", so helm pushes semver build
+		// metadata with a "_" in its place.
+		candidates := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			candidates = append(candidates, strings.ReplaceAll(tag, "_", "+"))
+		}
+		if version, err = chooseVersion(candidates, ""); err != nil {
+			return nil, fmt.Errorf("chart %s: %w", ref, err)
+		}
+	}
+
+	img, err := remote.Image(repo.Tag(strings.ReplaceAll(version, "+", "_")), opts...)
+	if err != nil {
+		return nil, registryError(fmt.Errorf("error fetching version %s of %s: %w", version, ref, err))
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest of %s: %w", ref, err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, fmt.Errorf("error reading digest of %s: %w", ref, err)
+	}
+	for _, desc := range manifest.Layers {
+		if string(desc.MediaType) != chartLayerMediaType {
+			continue
+		}
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching chart layer of %s: %w", ref, err)
+		}
+		rc, err := layer.Compressed()
+		if err != nil {
+			return nil, fmt.Errorf("error fetching chart layer of %s: %w", ref, err)
+		}
+		defer rc.Close()
+		archive, err := io.ReadAll(io.LimitReader(rc, maxChartSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("error reading chart layer of %s: %w", ref, err)
+		}
+		if len(archive) > maxChartSize {
+			return nil, fmt.Errorf("chart layer of %s is larger than %d bytes", ref, maxChartSize)
+		}
+		return &fetchedChart{archive: archive, version: version, digest: digest.String()}, nil
+	}
+	return nil, fmt.Errorf("version %s of %s has no %s layer", version, ref, chartLayerMediaType)
+}
+
+// registryError wraps err with framework.ErrorResourceNotFound or
+// framework.ErrorAuthenticationFailed when the registry's response
+// says that applies.
+func registryError(err error) error {
+	var terr *transport.Error
+	if !errors.As(err, &terr) {
+		return err
+	}
+	switch terr.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %v", framework.ErrorResourceNotFound, err)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %v", framework.ErrorAuthenticationFailed, err)
+	default:
+		return err
+	}
+}
+
+// chooseVersion returns version if it's one of candidates or, if
+// version is empty, the highest of candidates that's a semantic version
+// without a pre-release.
+func chooseVersion(candidates []string, version string) (string, error) {
+	if version != "" {
+		for _, candidate := range candidates {
+			if candidate == version {
+				return version, nil
+			}
+		}
+		return "", fmt.Errorf("%w: no version %s", framework.ErrorResourceNotFound, version)
+	}
+	var latest *semver.Version
+	chosen := ""
+	for _, candidate := range candidates {
+		parsed, err := semver.ParseTolerant(candidate)
+		if err != nil || len(parsed.Pre) > 0 {
+			continue
+		}
+		if latest == nil || parsed.GT(*latest) {
+			latest, chosen = &parsed, candidate
+		}
+	}
+	if latest == nil {
+		return "", fmt.Errorf("%w: no released versions", framework.ErrorResourceNotFound)
+	}
+	return chosen, nil
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+// ChartParam is the name of the chart to render in RepoParam, or the
+// oci:// reference of a chart in an OCI registry
+const ChartParam string = "chart"
+
+// RepoParam is the url of the chart repository to fetch ChartParam from.
+// Defaults to the ConfigFieldDefaultRepo config and is unused for oci://
+// charts
+const RepoParam string = "repo"
+
+// VersionParam is the exact version of the chart to render. Defaults to
+// the chart's latest version that isn't a pre-release
+const VersionParam string = "version"
+
+// ValuesParam is a YAML map of values to render the chart with, taking
+// precedence over ValuesSecretParam and the chart's own values
+const ValuesParam string = "values"
+
+// ValuesSecretParam is the name of a secret in the request's namespace
+// whose SecretKeyValues key holds a YAML map of values to render the
+// chart with
+const ValuesSecretParam string = "valuesSecret"
+
+// ReleaseNameParam is the name of the release the chart is rendered
+// for. Defaults to "release-name", as with helm template
+const ReleaseNameParam string = "releaseName"
+
+// PullSecretParam is the name of an image pull secret in the request's
+// namespace to fetch oci:// charts with, in addition to the pull secrets
+// of the namespace's default service account. oci:// charts are fetched
+// anonymously when it's not set
+const PullSecretParam string = "pullSecret"
@@ -0,0 +1,249 @@
+package helm
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+// newTestChart returns a chart named app with templates, keyed by their
+// paths in the chart.
+func newTestChart(templates map[string]string) *chart.Chart {
+	c := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "app", Version: "1.0.0"},
+		Files:    []*chart.File{{Name: "config/app.conf", Data: []byte("level=debug")}},
+	}
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c.Templates = append(c.Templates, &chart.File{Name: name, Data: []byte(templates[name])})
+	}
+	return c
+}
+
+func TestRenderChartFuncs(t *testing.T) {
+	values := map[string]interface{}{
+		"name":    "build",
+		"empty":   "",
+		"message": "hello {{ .Release.Name }}",
+		"labels":  map[string]interface{}{"team": "ci", "tier": "backend"},
+	}
+	for _, tc := range []struct {
+		name     string
+		template string
+		want     string
+	}{{
+		name:     "missing value",
+		template: "value: {{ .Values.missing }}",
+		want:     "value:",
+	}, {
+		name:     "default",
+		template: `value: {{ .Values.empty | default "fallback" }} {{ .Values.name | default "fallback" }}`,
+		want:     "value: fallback build",
+	}, {
+		name:     "toYaml and nindent",
+		template: "labels:{{ .Values.labels | toYaml | nindent 2 }}",
+		want:     "labels:\n  team: ci\n  tier: backend",
+	}, {
+		name:     "tpl",
+		template: "value: {{ tpl .Values.message . }}",
+		want:     "value: hello rel",
+	}, {
+		name:     "string funcs",
+		template: `value: {{ trunc 3 "abcdef" }} {{ .Values.name | upper | quote }} {{ "  x  " | trim | squote }} {{ replace "-" "_" "a-b" }}`,
+		want:     `value: abc "BUILD" 'x' a_b`,
+	}, {
+		name:     "dict and ternary",
+		template: `{{ $d := dict "a" 1 }}value: {{ hasKey $d "a" }} {{ ternary "yes" "no" (empty .Values.empty) }} {{ "ci" | b64enc }}`,
+		want:     "value: true yes Y2k=",
+	}, {
+		name:     "semver",
+		template: `value: {{ semverCompare ">=1.20-0" .Capabilities.KubeVersion.Version }} {{ (semver "1.2.3").Minor }}`,
+		want:     "value: true 2",
+	}, {
+		name:     "capabilities",
+		template: `value: {{ .Capabilities.APIVersions.Has "apps/v1" }}`,
+		want:     "value: true",
+	}, {
+		name:     "files",
+		template: `value: {{ .Files.Get "config/app.conf" }}`,
+		want:     "value: level=debug",
+	}, {
+		name:     "lookup",
+		template: `value: {{ len (lookup "v1" "Secret" "ns" "name") }}`,
+		want:     "value: 0",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestChart(map[string]string{"templates/test.yaml": tc.template})
+			out, err := renderChart(c, values, "rel", "ns")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := "---\n# Source: app/templates/test.yaml\n" + tc.want + "\n"
+			if string(out) != want {
+				t.Errorf("expected:\n%s\nbut got:\n%s", want, out)
+			}
+		})
+	}
+}
+
+func TestRenderChartErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		templates map[string]string
+		want      string
+	}{{
+		name:      "required",
+		templates: map[string]string{"templates/test.yaml": `{{ required "name is required" .Values.name }}`},
+		want:      "name is required",
+	}, {
+		name:      "fail",
+		templates: map[string]string{"templates/test.yaml": `{{ fail "unsupported" }}`},
+		want:      "unsupported",
+	}, {
+		name: "recursive include",
+		templates: map[string]string{
+			"templates/_helpers.tpl": `{{- define "loop" -}}{{ include "loop" . }}{{- end -}}`,
+			"templates/test.yaml":    `{{ include "loop" . }}`,
+		},
+		want: "nested reference",
+	}, {
+		name:      "parse error",
+		templates: map[string]string{"templates/test.yaml": `{{ .Values.name `},
+		want:      "app/templates/test.yaml",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := renderChart(newTestChart(tc.templates), map[string]interface{}{}, "rel", "ns")
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected error containing %q but got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestRenderChartSkipsEmptyTemplatesAndNotes(t *testing.T) {
+	c := newTestChart(map[string]string{
+		"templates/b.yaml":    "kind: B",
+		"templates/a.yaml":    "{{ if .Values.enabled }}kind: A{{ end }}\n",
+		"templates/NOTES.txt": "Installed {{ .Release.Name }}",
+	})
+	out, err := renderChart(c, map[string]interface{}{}, "rel", "ns")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "---\n# Source: app/templates/b.yaml\nkind: B\n"; string(out) != want {
+		t.Errorf("expected:\n%s\nbut got:\n%s", want, out)
+	}
+}
+
+func TestRenderChartWithSubchart(t *testing.T) {
+	c, err := loadChart(packageTestChart(t, map[string]string{
+		"Chart.yaml":                  "apiVersion: v2\nname: app\nversion: 1.0.0\ndependencies:\n- name: db\n  version: 1.0.0\n  condition: db.enabled\n",
+		"values.yaml":                 "db:\n  enabled: true\n  size: small\n",
+		"templates/app.yaml":          "kind: App",
+		"charts/db/Chart.yaml":        "apiVersion: v2\nname: db\nversion: 1.0.0\n",
+		"charts/db/values.yaml":       "size: large\n",
+		"charts/db/templates/db.yaml": "kind: DB\nsize: {{ .Values.size }}",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := renderChart(c, map[string]interface{}{}, "rel", "ns")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"# Source: app/templates/app.yaml\nkind: App\n",
+		"# Source: app/charts/db/templates/db.yaml\nkind: DB\nsize: small\n",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected rendered chart to contain %q but got:\n%s", want, out)
+		}
+	}
+
+	c, err = loadChart(packageTestChart(t, map[string]string{
+		"Chart.yaml":                  "apiVersion: v2\nname: app\nversion: 1.0.0\ndependencies:\n- name: db\n  version: 1.0.0\n  condition: db.enabled\n",
+		"templates/app.yaml":          "kind: App",
+		"charts/db/Chart.yaml":        "apiVersion: v2\nname: db\nversion: 1.0.0\n",
+		"charts/db/templates/db.yaml": "kind: DB",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err = renderChart(c, map[string]interface{}{"db": map[string]interface{}{"enabled": false}}, "rel", "ns")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(out), "kind: DB") {
+		t.Errorf("expected disabled subchart not to be rendered but got:\n%s", out)
+	}
+}
+
+func TestRenderChartNullOverrideRemovesDefault(t *testing.T) {
+	c := newTestChart(map[string]string{"templates/test.yaml": `debug: {{ hasKey .Values "debug" }}`})
+	c.Values = map[string]interface{}{"debug": true}
+	values := mergeValues(map[string]interface{}{}, map[string]interface{}{"debug": nil})
+	out, err := renderChart(c, values, "rel", "ns")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "---\n# Source: app/templates/test.yaml\ndebug: false\n"; string(out) != want {
+		t.Errorf("expected:\n%s\nbut got:\n%s", want, out)
+	}
+}
+
+func TestMergeValues(t *testing.T) {
+	base := map[string]interface{}{
+		"image":    map[string]interface{}{"repository": "app", "tag": "1.0"},
+		"replicas": 1.0,
+		"debug":    true,
+	}
+	merged := mergeValues(base, map[string]interface{}{
+		"image": map[string]interface{}{"tag": "2.0"},
+		"debug": nil,
+	})
+	image := merged["image"].(map[string]interface{})
+	if image["repository"] != "app" || image["tag"] != "2.0" {
+		t.Errorf("expected image to be deep merged but got %v", image)
+	}
+	if debug, ok := merged["debug"]; !ok || debug != nil {
+		t.Errorf("expected null override to be kept but got %v", debug)
+	}
+	if merged["replicas"] != 1.0 {
+		t.Errorf("expected replicas to be kept but got %v", merged["replicas"])
+	}
+}
+
+func TestLoadChartRejectsUnsupportedCharts(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{{
+		name:  "library",
+		files: map[string]string{"Chart.yaml": "apiVersion: v2\nname: app\nversion: 1.0.0\ntype: library\n"},
+		want:  "library chart",
+	}, {
+		name: "missing dependency",
+		files: map[string]string{
+			"Chart.yaml": "apiVersion: v2\nname: app\nversion: 1.0.0\ndependencies:\n- name: db\n  version: 1.0.0\n  repository: https://charts.example.com\n",
+		},
+		want: "missing in charts/ directory: db",
+	}, {
+		name:  "no Chart.yaml",
+		files: map[string]string{"values.yaml": "a: b"},
+		want:  "Chart.yaml file is missing",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := loadChart(packageTestChart(t, tc.files))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected error containing %q but got %v", tc.want, err)
+			}
+		})
+	}
+}
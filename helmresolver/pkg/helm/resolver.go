@@ -0,0 +1,253 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/authn/k8schain"
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+)
+
+// LabelValueHelmResolverType is the value to use for the
+// resolution.tekton.dev/type label on resource requests
+const LabelValueHelmResolverType string = "helm"
+
+// HelmResolverName is the name that the helm resolver should be
+// associated with
+const HelmResolverName string = "Helm"
+
+// AnnotationKeyChartVersion is the annotation key recording the version
+// of the rendered chart.
+const AnnotationKeyChartVersion = "resolution.tekton.dev/chart-version"
+
+// AnnotationKeyChartDigest is the annotation key recording the digest of
+// the rendered chart's archive or, for oci:// charts, its manifest.
+const AnnotationKeyChartDigest = "resolution.tekton.dev/chart-digest"
+
+// SecretKeyValues is the key in a ValuesSecretParam secret holding the
+// values to render the chart with.
+const SecretKeyValues = "values.yaml"
+
+// defaultReleaseName is the release that charts are rendered for when
+// ReleaseNameParam isn't set.
+const defaultReleaseName = "release-name"
+
+var _ framework.Resolver = &Resolver{}
+
+// Resolver implements a framework.Resolver that renders Helm charts
+// fetched from chart repositories or OCI registries.
+type Resolver struct {
+	kubeClientSet kubernetes.Interface
+}
+
+// Initialize performs any setup required by the helm resolver.
+func (r *Resolver) Initialize(ctx context.Context) error {
+	r.kubeClientSet = kubeclient.Get(ctx)
+	return nil
+}
+
+// GetName returns the string name that the helm resolver should be
+// associated with.
+func (r *Resolver) GetName(_ context.Context) string {
+	return HelmResolverName
+}
+
+// GetSelector returns the labels that resource requests are required to
+// have for the helm resolver to process them.
+func (r *Resolver) GetSelector(_ context.Context) map[string]string {
+	return map[string]string{
+		resolutioncommon.LabelKeyResolverType: LabelValueHelmResolverType,
+	}
+}
+
+// ValidateParams returns an error if the given parameter map is not
+// valid for a resource request targeting the helm resolver.
+func (r *Resolver) ValidateParams(ctx context.Context, params map[string]string) error {
+	chartName := strings.TrimSpace(params[ChartParam])
+	if chartName == "" {
+		return fmt.Errorf("missing %s", ChartParam)
+	}
+	if strings.HasPrefix(chartName, ociScheme) {
+		if params[RepoParam] != "" {
+			return fmt.Errorf("supplied both %q and an oci:// %q", RepoParam, ChartParam)
+		}
+	} else {
+		if strings.Contains(chartName, "/") {
+			return fmt.Errorf("invalid %q %q: must be a chart name or an oci:// reference", ChartParam, chartName)
+		}
+		repo := chartRepo(ctx, params)
+		if repo == "" {
+			return fmt.Errorf("missing %s", RepoParam)
+		}
+		if u, err := url.Parse(repo); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid %q %q: must be an http(s) url", RepoParam, repo)
+		}
+	}
+	if params[PullSecretParam] != "" && !strings.HasPrefix(chartName, ociScheme) {
+		return fmt.Errorf("%q is only supported for oci:// charts", PullSecretParam)
+	}
+	if _, err := parseValues(params[ValuesParam]); err != nil {
+		return fmt.Errorf("invalid %q: %w", ValuesParam, err)
+	}
+	return nil
+}
+
+// chartRepo returns the RepoParam of params, or the configured
+// ConfigFieldDefaultRepo if it's not set.
+func chartRepo(ctx context.Context, params map[string]string) string {
+	if repo := strings.TrimSpace(params[RepoParam]); repo != "" {
+		return repo
+	}
+	return strings.TrimSpace(framework.GetResolverConfigFromContext(ctx)[ConfigFieldDefaultRepo])
+}
+
+// Resolve fetches the requested chart and renders its templates.
+func (r *Resolver) Resolve(ctx context.Context, params map[string]string) (framework.ResolvedResource, error) {
+	if err := r.ValidateParams(ctx, params); err != nil {
+		return nil, err
+	}
+	chartName := strings.TrimSpace(params[ChartParam])
+	version := strings.TrimSpace(params[VersionParam])
+
+	var fetched *fetchedChart
+	var err error
+	if strings.HasPrefix(chartName, ociScheme) {
+		keychain, kerr := r.keychain(ctx, params[PullSecretParam])
+		if kerr != nil {
+			return nil, kerr
+		}
+		fetched, err = fetchFromRegistry(ctx, keychain, chartName, version)
+	} else {
+		fetched, err = fetchFromRepo(ctx, chartRepo(ctx, params), chartName, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c, err := loadChart(fetched.archive)
+	if err != nil {
+		return nil, fmt.Errorf("error loading chart %s: %w", chartName, err)
+	}
+
+	// The chart's own values are coalesced with these when rendering.
+	values := map[string]interface{}{}
+	if secretName := params[ValuesSecretParam]; secretName != "" {
+		secretValues, err := r.secretValues(ctx, secretName)
+		if err != nil {
+			return nil, err
+		}
+		values = mergeValues(values, secretValues)
+	}
+	paramValues, err := parseValues(params[ValuesParam])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %q: %w", ValuesParam, err)
+	}
+	values = mergeValues(values, paramValues)
+
+	release := params[ReleaseNameParam]
+	if release == "" {
+		release = defaultReleaseName
+	}
+	content, err := renderChart(c, values, release, resolutioncommon.RequestNamespace(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error rendering chart %s: %w", chartName, err)
+	}
+	return &ResolvedHelmResource{
+		Content:      content,
+		ChartVersion: fetched.version,
+		ChartDigest:  fetched.digest,
+	}, nil
+}
+
+// keychain returns the keychain to fetch oci:// charts with: anonymous
+// unless a pull secret is named.
+func (r *Resolver) keychain(ctx context.Context, pullSecret string) (authn.Keychain, error) {
+	if pullSecret == "" {
+		return authn.NewMultiKeychain(), nil
+	}
+	if r.kubeClientSet == nil {
+		return nil, errors.New("helm resolver has no kubernetes client to read secrets with")
+	}
+	kc, err := k8schain.New(ctx, r.kubeClientSet, k8schain.Options{
+		Namespace:        resolutioncommon.RequestNamespace(ctx),
+		ImagePullSecrets: []string{pullSecret},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error building keychain: %w", err)
+	}
+	return kc, nil
+}
+
+// secretValues reads the values in the named secret.
+func (r *Resolver) secretValues(ctx context.Context, secretName string) (map[string]interface{}, error) {
+	if r.kubeClientSet == nil {
+		return nil, errors.New("helm resolver has no kubernetes client to read secrets with")
+	}
+	namespace := resolutioncommon.RequestNamespace(ctx)
+	secret, err := r.kubeClientSet.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting secret %s/%s: %w", namespace, secretName, err)
+	}
+	content, ok := secret.Data[SecretKeyValues]
+	if !ok {
+		return nil, fmt.Errorf("invalid secret %q: missing %s", secretName, SecretKeyValues)
+	}
+	values, err := parseValues(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret %q: error parsing %s: %w", secretName, SecretKeyValues, err)
+	}
+	return values, nil
+}
+
+var _ framework.ConfigWatcher = &Resolver{}
+
+// GetConfigName returns the name of the helm resolver's configmap.
+func (r *Resolver) GetConfigName(context.Context) string {
+	return "helm-resolver-config"
+}
+
+// ResolvedHelmResource implements framework.ResolvedResource and
+// returns the manifests rendered from a chart.
+type ResolvedHelmResource struct {
+	Content      []byte
+	ChartVersion string
+	ChartDigest  string
+}
+
+var _ framework.ResolvedResource = &ResolvedHelmResource{}
+
+// Data returns the rendered manifests as a multi-document YAML stream.
+func (r *ResolvedHelmResource) Data() []byte {
+	return r.Content
+}
+
+// Annotations returns the metadata that accompanies the manifests.
+func (r *ResolvedHelmResource) Annotations() map[string]string {
+	return map[string]string{
+		AnnotationKeyChartVersion: r.ChartVersion,
+		AnnotationKeyChartDigest:  r.ChartDigest,
+	}
+}
@@ -0,0 +1,370 @@
+package helm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/pkg/resolver/framework"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+)
+
+const testChartValues = `
+replicas: 1
+image:
+  repository: example.com/app
+  tag: "1.0"
+`
+
+const testChartTemplates = `{{- define "app.fullname" -}}
+{{ .Release.Name }}-{{ .Chart.Name }}
+{{- end -}}
+`
+
+const testChartTask = `apiVersion: tekton.dev/v1beta1
+kind: Task
+metadata:
+  name: {{ include "app.fullname" . }}
+  namespace: {{ .Release.Namespace }}
+  labels:
+    chart: {{ .Chart.Name }}-{{ .Chart.Version }}
+spec:
+  params:
+  - name: replicas
+    default: {{ .Values.replicas | quote }}
+  steps:
+  - name: run
+    image: {{ .Values.image.repository }}:{{ .Values.image.tag }}
+`
+
+// testChartFiles returns the files of a chart named app at version.
+func testChartFiles(version string) map[string]string {
+	return map[string]string{
+		"Chart.yaml":             "apiVersion: v2\nname: app\nversion: " + version + "\n",
+		"values.yaml":            testChartValues,
+		"templates/_helpers.tpl": testChartTemplates,
+		"templates/task.yaml":    testChartTask,
+		"templates/NOTES.txt":    "Installed {{ .Release.Name }}\n",
+	}
+}
+
+// packageTestChart returns a chart archive holding files under the
+// directory app/, as written by helm package.
+func packageTestChart(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: "app/" + name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("error writing tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("error writing tar content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("error closing tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("error closing gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// newFakeChartRepo serves a chart repository holding the given archives
+// of the app chart, keyed by version.
+func newFakeChartRepo(t *testing.T, archives map[string][]byte) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	index := &strings.Builder{}
+	index.WriteString("apiVersion: v1\nentries:\n  app:\n")
+	for version, archive := range archives {
+		archive := archive
+		file := fmt.Sprintf("app-%s.tgz", version)
+		fmt.Fprintf(index, "  - version: %s\n    urls: [%s]\n    digest: %s\n", version, file, sha256Hex(archive))
+		mux.HandleFunc("/charts/"+file, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(archive)
+		})
+	}
+	mux.HandleFunc("/charts/index.yaml", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(index.String()))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// pushTestChart pushes archive to ref as helm push would and returns
+// the digest of its manifest.
+func pushTestChart(t *testing.T, ref string, archive []byte) string {
+	t.Helper()
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer: static.NewLayer(archive, chartLayerMediaType),
+	})
+	if err != nil {
+		t.Fatalf("error building chart image: %v", err)
+	}
+	img = mutate.MediaType(img, types.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, "application/vnd.cncf.helm.config.v1+json")
+	parsed, err := name.ParseReference(ref)
+	if err != nil {
+		t.Fatalf("error parsing reference: %v", err)
+	}
+	if err := remote.Write(parsed, img); err != nil {
+		t.Fatalf("error pushing chart: %v", err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatalf("error reading chart digest: %v", err)
+	}
+	return digest.String()
+}
+
+func testContext() context.Context {
+	return resolutioncommon.InjectRequestNamespace(context.Background(), "pipelines")
+}
+
+func TestResolveFromRepo(t *testing.T) {
+	archives := map[string][]byte{
+		"1.0.0":       packageTestChart(t, testChartFiles("1.0.0")),
+		"1.2.0":       packageTestChart(t, testChartFiles("1.2.0")),
+		"2.0.0-beta1": packageTestChart(t, testChartFiles("2.0.0-beta1")),
+	}
+	server := newFakeChartRepo(t, archives)
+
+	for _, tc := range []struct {
+		name    string
+		version string
+		want    string
+	}{{
+		name: "latest release",
+		want: "1.2.0",
+	}, {
+		name:    "exact version",
+		version: "1.0.0",
+		want:    "1.0.0",
+	}, {
+		name:    "pre-release",
+		version: "2.0.0-beta1",
+		want:    "2.0.0-beta1",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := Resolver{}
+			resource, err := resolver.Resolve(testContext(), map[string]string{
+				ChartParam:   "app",
+				RepoParam:    server.URL + "/charts",
+				VersionParam: tc.version,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := `---
+# Source: app/templates/task.yaml
+apiVersion: tekton.dev/v1beta1
+kind: Task
+metadata:
+  name: release-name-app
+  namespace: pipelines
+  labels:
+    chart: app-` + tc.want + `
+spec:
+  params:
+  - name: replicas
+    default: "1"
+  steps:
+  - name: run
+    image: example.com/app:1.0
+`
+			if string(resource.Data()) != expected {
+				t.Errorf("expected content:\n%s\nbut got:\n%s", expected, resource.Data())
+			}
+			annotations := resource.Annotations()
+			if annotations[AnnotationKeyChartVersion] != tc.want {
+				t.Errorf("expected version %q but got %q", tc.want, annotations[AnnotationKeyChartVersion])
+			}
+			if digest := "sha256:" + sha256Hex(archives[tc.want]); annotations[AnnotationKeyChartDigest] != digest {
+				t.Errorf("expected digest %q but got %q", digest, annotations[AnnotationKeyChartDigest])
+			}
+		})
+	}
+}
+
+func TestResolveSubstitutesValues(t *testing.T) {
+	server := newFakeChartRepo(t, map[string][]byte{
+		"1.0.0": packageTestChart(t, testChartFiles("1.0.0")),
+	})
+	client := fakekube.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-values", Namespace: "pipelines"},
+		Data: map[string][]byte{
+			SecretKeyValues: []byte("replicas: 3\nimage:\n  tag: \"2.0\"\n"),
+		},
+	})
+	resolver := Resolver{kubeClientSet: client}
+
+	resource, err := resolver.Resolve(testContext(), map[string]string{
+		ChartParam:        "app",
+		RepoParam:         server.URL + "/charts",
+		ValuesSecretParam: "app-values",
+		// The param takes precedence over the secret, which takes
+		// precedence over the chart's own values.
+		ValuesParam:      "image:\n  tag: \"3.0\"\n",
+		ReleaseNameParam: "ci",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"name: ci-app\n",
+		`default: "3"`,
+		"image: example.com/app:3.0\n",
+	} {
+		if !strings.Contains(string(resource.Data()), want) {
+			t.Errorf("expected rendered chart to contain %q but got:\n%s", want, resource.Data())
+		}
+	}
+}
+
+func TestResolveWithDefaultRepo(t *testing.T) {
+	server := newFakeChartRepo(t, map[string][]byte{
+		"1.0.0": packageTestChart(t, testChartFiles("1.0.0")),
+	})
+	ctx := framework.InjectResolverConfigToContext(testContext(), map[string]string{
+		ConfigFieldDefaultRepo: server.URL + "/charts/",
+	})
+	resolver := Resolver{}
+
+	resource, err := resolver.Resolve(ctx, map[string]string{ChartParam: "app"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := resource.Annotations()[AnnotationKeyChartVersion]; got != "1.0.0" {
+		t.Errorf("expected version 1.0.0 but got %q", got)
+	}
+}
+
+func TestResolveFromRegistry(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+	host := strings.TrimPrefix(server.URL, "http://")
+	pushTestChart(t, host+"/charts/app:1.0.0", packageTestChart(t, testChartFiles("1.0.0")))
+	digest := pushTestChart(t, host+"/charts/app:1.1.0_build.5", packageTestChart(t, testChartFiles("1.1.0+build.5")))
+	pushTestChart(t, host+"/charts/app:2.0.0-rc.1", packageTestChart(t, testChartFiles("2.0.0-rc.1")))
+	resolver := Resolver{}
+
+	resource, err := resolver.Resolve(testContext(), map[string]string{
+		ChartParam:  "oci://" + host + "/charts/app",
+		ValuesParam: "replicas: 2",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"chart: app-1.1.0+build.5\n", `default: "2"`} {
+		if !strings.Contains(string(resource.Data()), want) {
+			t.Errorf("expected rendered chart to contain %q but got:\n%s", want, resource.Data())
+		}
+	}
+	annotations := resource.Annotations()
+	if annotations[AnnotationKeyChartVersion] != "1.1.0+build.5" {
+		t.Errorf("expected version 1.1.0+build.5 but got %q", annotations[AnnotationKeyChartVersion])
+	}
+	if annotations[AnnotationKeyChartDigest] != digest {
+		t.Errorf("expected digest %q but got %q", digest, annotations[AnnotationKeyChartDigest])
+	}
+}
+
+func TestResolveNotFound(t *testing.T) {
+	server := newFakeChartRepo(t, map[string][]byte{
+		"1.0.0": packageTestChart(t, testChartFiles("1.0.0")),
+	})
+	for _, params := range []map[string]string{
+		{ChartParam: "other"},
+		{ChartParam: "app", VersionParam: "9.9.9"},
+	} {
+		params[RepoParam] = server.URL + "/charts"
+		resolver := Resolver{}
+		_, err := resolver.Resolve(testContext(), params)
+		if !errors.Is(err, framework.ErrorResourceNotFound) {
+			t.Errorf("expected %v to be not found but got %v", params, err)
+		}
+	}
+}
+
+func TestResolveRejectsDigestMismatch(t *testing.T) {
+	archive := packageTestChart(t, testChartFiles("1.0.0"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/index.yaml" {
+			fmt.Fprintf(w, "entries:\n  app:\n  - version: 1.0.0\n    urls: [app-1.0.0.tgz]\n    digest: %s\n", strings.Repeat("0", 64))
+			return
+		}
+		_, _ = w.Write(archive)
+	}))
+	t.Cleanup(server.Close)
+	resolver := Resolver{}
+
+	_, err := resolver.Resolve(testContext(), map[string]string{ChartParam: "app", RepoParam: server.URL})
+	if err == nil || !strings.Contains(err.Error(), "digest") {
+		t.Fatalf("expected digest mismatch error but got %v", err)
+	}
+}
+
+func TestValidateParams(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		params map[string]string
+	}{{
+		name:   "missing chart",
+		params: map[string]string{RepoParam: "https://charts.example.com"},
+	}, {
+		name:   "missing repo",
+		params: map[string]string{ChartParam: "app"},
+	}, {
+		name:   "invalid repo",
+		params: map[string]string{ChartParam: "app", RepoParam: "ftp://charts.example.com"},
+	}, {
+		name:   "chart path",
+		params: map[string]string{ChartParam: "stable/app", RepoParam: "https://charts.example.com"},
+	}, {
+		name:   "repo with oci chart",
+		params: map[string]string{ChartParam: "oci://registry.example.com/app", RepoParam: "https://charts.example.com"},
+	}, {
+		name:   "pull secret without oci chart",
+		params: map[string]string{ChartParam: "app", RepoParam: "https://charts.example.com", PullSecretParam: "creds"},
+	}, {
+		name:   "invalid values",
+		params: map[string]string{ChartParam: "app", RepoParam: "https://charts.example.com", ValuesParam: "- not a map"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := Resolver{}
+			if err := resolver.ValidateParams(context.Background(), tc.params); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
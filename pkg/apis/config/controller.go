@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/configmap"
+)
+
+// ControllerConfigName is the name of the configmap that configures the
+// ResolutionRequest controller.
+const ControllerConfigName = "config-controller"
+
+// ConfigFieldMinRequeueInterval is the key in the ControllerConfigName
+// configmap for the shortest interval, as a duration such as "5s", that
+// the controller requeues a request after, so that requests close to
+// their timeout don't churn the API server with rapid requeues.
+// Defaults to DefaultMinRequeueInterval.
+const ConfigFieldMinRequeueInterval = "min-requeue-interval"
+
+// DefaultMinRequeueInterval is the shortest interval that a request is
+// requeued after when ConfigFieldMinRequeueInterval isn't set.
+const DefaultMinRequeueInterval = 1 * time.Second
+
+// ConfigFieldMaxConcurrentResolutions is the key in the
+// ControllerConfigName configmap that caps the number of resolutions
+// each resolver has in flight at once. Every resolver reads the cap
+// from this one configmap so that it's the same for all of them. When
+// unset or 0 the number of resolutions is only limited by the
+// resolvers' workers.
+const ConfigFieldMaxConcurrentResolutions = "max-concurrent-resolutions"
+
+// Controller is the configuration of the ResolutionRequest controller.
+type Controller struct {
+	// MinRequeueInterval is the shortest interval that a request is
+	// requeued after.
+	MinRequeueInterval time.Duration
+
+	// MaxConcurrentResolutions caps the number of resolutions each
+	// resolver has in flight at once, or is 0 for no cap.
+	MaxConcurrentResolutions int64
+}
+
+// NewControllerFromMap returns the Controller configuration in data,
+// with defaults for the keys it doesn't set.
+func NewControllerFromMap(data map[string]string) (*Controller, error) {
+	c := &Controller{MinRequeueInterval: DefaultMinRequeueInterval}
+	if val := strings.TrimSpace(data[ConfigFieldMinRequeueInterval]); val != "" {
+		interval, err := time.ParseDuration(val)
+		if err != nil || interval < 0 {
+			return nil, fmt.Errorf("invalid %q config %q: must be a non-negative duration", ConfigFieldMinRequeueInterval, val)
+		}
+		c.MinRequeueInterval = interval
+	}
+	if val := strings.TrimSpace(data[ConfigFieldMaxConcurrentResolutions]); val != "" {
+		size, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid %q config %q: must be a non-negative integer", ConfigFieldMaxConcurrentResolutions, val)
+		}
+		c.MaxConcurrentResolutions = size
+	}
+	return c, nil
+}
+
+// NewControllerFromConfigMap returns the Controller configuration in
+// the data of config.
+func NewControllerFromConfigMap(config *corev1.ConfigMap) (*Controller, error) {
+	if config == nil {
+		return NewControllerFromMap(nil)
+	}
+	return NewControllerFromMap(config.Data)
+}
+
+// controllerKey is the context key the Controller configuration is
+// stored under.
+type controllerKey struct{}
+
+// ControllerToContext returns a new context with c stored in it.
+func ControllerToContext(ctx context.Context, c *Controller) context.Context {
+	return context.WithValue(ctx, controllerKey{}, c)
+}
+
+// ControllerFromContext returns the Controller configuration stored in
+// ctx, or the default configuration if there isn't one.
+func ControllerFromContext(ctx context.Context) *Controller {
+	if c, ok := ctx.Value(controllerKey{}).(*Controller); ok && c != nil {
+		return c
+	}
+	c, _ := NewControllerFromMap(nil)
+	return c
+}
+
+// ControllerStore wraps a knative untyped store that watches the
+// ControllerConfigName configmap.
+type ControllerStore struct {
+	untyped *configmap.UntypedStore
+}
+
+// NewControllerStore returns a ControllerStore whose configuration is
+// kept up to date once WatchConfigs is called.
+func NewControllerStore(logger configmap.Logger) *ControllerStore {
+	return &ControllerStore{
+		untyped: configmap.NewUntypedStore(
+			"controller",
+			logger,
+			configmap.Constructors{
+				ControllerConfigName: NewControllerFromConfigMap,
+			},
+		),
+	}
+}
+
+// WatchConfigs starts watching the ControllerConfigName configmap.
+func (s *ControllerStore) WatchConfigs(cmw configmap.Watcher) {
+	s.untyped.WatchConfigs(cmw)
+}
+
+// ToContext returns a new context with the latest Controller
+// configuration stored in it.
+func (s *ControllerStore) ToContext(ctx context.Context) context.Context {
+	c, _ := s.untyped.UntypedLoad(ControllerConfigName).(*Controller)
+	return ControllerToContext(ctx, c)
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewControllerFromMap(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		data        map[string]string
+		expected    Controller
+		expectedErr string
+	}{{
+		name:     "default",
+		expected: Controller{MinRequeueInterval: DefaultMinRequeueInterval},
+	}, {
+		name:     "min requeue interval",
+		data:     map[string]string{ConfigFieldMinRequeueInterval: "5s"},
+		expected: Controller{MinRequeueInterval: 5 * time.Second},
+	}, {
+		name:     "no minimum",
+		data:     map[string]string{ConfigFieldMinRequeueInterval: "0s"},
+		expected: Controller{},
+	}, {
+		name:        "invalid min requeue interval",
+		data:        map[string]string{ConfigFieldMinRequeueInterval: "soon"},
+		expectedErr: `invalid "min-requeue-interval" config`,
+	}, {
+		name:        "negative min requeue interval",
+		data:        map[string]string{ConfigFieldMinRequeueInterval: "-1s"},
+		expectedErr: `invalid "min-requeue-interval" config`,
+	}, {
+		name:     "max concurrent resolutions",
+		data:     map[string]string{ConfigFieldMaxConcurrentResolutions: "10"},
+		expected: Controller{MinRequeueInterval: DefaultMinRequeueInterval, MaxConcurrentResolutions: 10},
+	}, {
+		name:        "invalid max concurrent resolutions",
+		data:        map[string]string{ConfigFieldMaxConcurrentResolutions: "lots"},
+		expectedErr: `invalid "max-concurrent-resolutions" config`,
+	}, {
+		name:        "negative max concurrent resolutions",
+		data:        map[string]string{ConfigFieldMaxConcurrentResolutions: "-1"},
+		expectedErr: `invalid "max-concurrent-resolutions" config`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewControllerFromMap(tc.data)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q, received %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *c != tc.expected {
+				t.Errorf("expected config %+v, received %+v", tc.expected, *c)
+			}
+		})
+	}
+}
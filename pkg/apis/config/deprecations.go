@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/configmap"
+)
+
+// DeprecationsConfigName is the name of the configmap that lists the
+// deprecated resolver types.
+const DeprecationsConfigName = "config-deprecated-resolvers"
+
+const (
+	// DeprecationPolicyReject makes the webhook reject new requests of
+	// a deprecated resolver type.
+	DeprecationPolicyReject = "reject"
+	// DeprecationPolicyWarn makes the webhook admit new requests of a
+	// deprecated resolver type with a warning.
+	DeprecationPolicyWarn = "warn"
+)
+
+const (
+	// policySuffix is the suffix of the key of a resolver type's
+	// policy, e.g. "hub.policy".
+	policySuffix = ".policy"
+	// messageSuffix is the suffix of the key of the message shown
+	// for a resolver type, e.g. "hub.message".
+	messageSuffix = ".message"
+)
+
+// Deprecation describes how the webhook treats new requests of a
+// deprecated resolver type.
+type Deprecation struct {
+	// Policy is DeprecationPolicyReject or DeprecationPolicyWarn.
+	Policy string
+	// Message, when set, is shown alongside the deprecation, e.g. to
+	// point to the type replacing it.
+	Message string
+}
+
+// Explain returns why a request of resolverType was rejected or
+// warned about.
+func (d Deprecation) Explain(resolverType string) string {
+	explanation := fmt.Sprintf("resolver type %q is deprecated", resolverType)
+	if d.Message != "" {
+		explanation += ": " + d.Message
+	}
+	return explanation
+}
+
+// Deprecations maps deprecated resolver types to their Deprecation.
+type Deprecations map[string]Deprecation
+
+// NewDeprecationsFromMap returns the Deprecations listed in data, where
+// "<type>.policy" sets the policy of a resolver type and the optional
+// "<type>.message" its message. Keys starting with an underscore, such
+// as "_example", are ignored.
+func NewDeprecationsFromMap(data map[string]string) (Deprecations, error) {
+	deprecations := Deprecations{}
+	for key, val := range data {
+		if strings.HasPrefix(key, "_") {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(key, policySuffix):
+			resolverType := strings.TrimSuffix(key, policySuffix)
+			policy := strings.TrimSpace(val)
+			if policy != DeprecationPolicyReject && policy != DeprecationPolicyWarn {
+				return nil, fmt.Errorf("invalid %q config %q: must be %q or %q", key, val, DeprecationPolicyReject, DeprecationPolicyWarn)
+			}
+			d := deprecations[resolverType]
+			d.Policy = policy
+			deprecations[resolverType] = d
+		case strings.HasSuffix(key, messageSuffix):
+			resolverType := strings.TrimSuffix(key, messageSuffix)
+			d := deprecations[resolverType]
+			d.Message = strings.TrimSpace(val)
+			deprecations[resolverType] = d
+		default:
+			return nil, fmt.Errorf("invalid config %q: keys must be <type>%s or <type>%s", key, policySuffix, messageSuffix)
+		}
+	}
+	for resolverType, d := range deprecations {
+		if d.Policy == "" {
+			return nil, fmt.Errorf("invalid config %q: resolver type %q has a message but no %q", resolverType+messageSuffix, resolverType, resolverType+policySuffix)
+		}
+	}
+	return deprecations, nil
+}
+
+// NewDeprecationsFromConfigMap returns the Deprecations listed in the
+// data of config.
+func NewDeprecationsFromConfigMap(config *corev1.ConfigMap) (Deprecations, error) {
+	if config == nil {
+		return Deprecations{}, nil
+	}
+	return NewDeprecationsFromMap(config.Data)
+}
+
+// deprecationsKey is the context key the Deprecations are stored under.
+type deprecationsKey struct{}
+
+// ToContext returns a new context with deprecations stored in it.
+func ToContext(ctx context.Context, deprecations Deprecations) context.Context {
+	return context.WithValue(ctx, deprecationsKey{}, deprecations)
+}
+
+// FromContext returns the Deprecations stored in ctx, or none if there
+// aren't any.
+func FromContext(ctx context.Context) Deprecations {
+	deprecations, _ := ctx.Value(deprecationsKey{}).(Deprecations)
+	return deprecations
+}
+
+// Store wraps a knative untyped store that watches the
+// DeprecationsConfigName configmap.
+type Store struct {
+	untyped *configmap.UntypedStore
+}
+
+// NewStore returns a Store whose Deprecations are kept up to date once
+// WatchConfigs is called.
+func NewStore(logger configmap.Logger) *Store {
+	return &Store{
+		untyped: configmap.NewUntypedStore(
+			"deprecated-resolvers",
+			logger,
+			configmap.Constructors{
+				DeprecationsConfigName: NewDeprecationsFromConfigMap,
+			},
+		),
+	}
+}
+
+// WatchConfigs starts watching the DeprecationsConfigName configmap.
+func (s *Store) WatchConfigs(cmw configmap.Watcher) {
+	s.untyped.WatchConfigs(cmw)
+}
+
+// ToContext returns a new context with the latest Deprecations stored
+// in it.
+func (s *Store) ToContext(ctx context.Context) context.Context {
+	deprecations, _ := s.untyped.UntypedLoad(DeprecationsConfigName).(Deprecations)
+	return ToContext(ctx, deprecations)
+}
@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"knative.dev/pkg/apis"
 )
@@ -40,16 +41,55 @@ func (s *ResolutionRequestStatus) InitializeConditions() {
 // MarkFailed sets the Succeeded condition to False with an accompanying
 // error message.
 func (s *ResolutionRequestStatus) MarkFailed(reason, message string) {
-	resolutionRequestCondSet.Manage(s).MarkFalse(apis.ConditionSucceeded, reason, message)
+	s.recordTransition(func() {
+		resolutionRequestCondSet.Manage(s).MarkFalse(apis.ConditionSucceeded, reason, message)
+	})
 }
 
 // MarkSucceeded sets the Succeeded condition to True.
 func (s *ResolutionRequestStatus) MarkSucceeded() {
-	resolutionRequestCondSet.Manage(s).MarkTrue(apis.ConditionSucceeded)
+	s.recordTransition(func() {
+		resolutionRequestCondSet.Manage(s).MarkTrue(apis.ConditionSucceeded)
+	})
 }
 
 // MarkInProgress updates the Succeeded condition to Unknown with an
 // accompanying message.
 func (s *ResolutionRequestStatus) MarkInProgress(message string) {
-	resolutionRequestCondSet.Manage(s).MarkUnknown(apis.ConditionSucceeded, resolutioncommon.ReasonResolutionInProgress, message)
+	s.recordTransition(func() {
+		resolutionRequestCondSet.Manage(s).MarkUnknown(apis.ConditionSucceeded, resolutioncommon.ReasonResolutionInProgress, message)
+	})
+}
+
+// recordTransition makes change to the Succeeded condition and appends
+// it to History if it changed the condition's reason or status,
+// dropping the oldest transitions beyond MaxHistory.
+func (s *ResolutionRequestStatus) recordTransition(change func()) {
+	from := conditionState(s.GetCondition(apis.ConditionSucceeded))
+	change()
+	after := s.GetCondition(apis.ConditionSucceeded)
+	to := conditionState(after)
+	if after == nil || from == to {
+		return
+	}
+	when := after.LastTransitionTime.Inner
+	if when.IsZero() {
+		when = metav1.Now()
+	}
+	s.History = append(s.History, ConditionTransition{Time: when, From: from, To: to})
+	if len(s.History) > MaxHistory {
+		s.History = append([]ConditionTransition{}, s.History[len(s.History)-MaxHistory:]...)
+	}
+}
+
+// conditionState returns the reason of cond, or its status if it has
+// no reason, for recording in a ConditionTransition.
+func conditionState(cond *apis.Condition) string {
+	if cond == nil {
+		return ""
+	}
+	if cond.Reason != "" {
+		return cond.Reason
+	}
+	return string(cond.Status)
 }
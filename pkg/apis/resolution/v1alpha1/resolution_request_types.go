@@ -78,6 +78,37 @@ type ResolutionRequestStatusFields struct {
 	// of the requested resource in-lined into the ResolutionRequest
 	// object.
 	Data string `json:"data"`
+
+	// Warnings are the non-fatal issues that the resolver reported
+	// while resolving the requested resource.
+	// +optional
+	Warnings []string `json:"warnings,omitempty"`
+
+	// History records the most recent changes of the request's
+	// Succeeded condition, oldest first, for debugging requests whose
+	// resolution flaps. At most MaxHistory transitions are kept.
+	// +optional
+	History []ConditionTransition `json:"history,omitempty"`
+}
+
+// MaxHistory is the number of condition transitions kept in a
+// ResolutionRequest's status. Older ones are dropped.
+const MaxHistory = 10
+
+// ConditionTransition is a change of a ResolutionRequest's Succeeded
+// condition.
+type ConditionTransition struct {
+	// Time is when the condition changed.
+	Time metav1.Time `json:"time"`
+
+	// From is the reason the condition had before the change, or its
+	// status if it had no reason.
+	// +optional
+	From string `json:"from,omitempty"`
+
+	// To is the reason the condition has after the change, or its
+	// status if it has no reason.
+	To string `json:"to"`
 }
 
 // GetStatus implements KRShaped.
@@ -19,6 +19,7 @@ package v1alpha1
 import (
 	"context"
 
+	"github.com/tektoncd/resolution/pkg/apis/config"
 	"github.com/tektoncd/resolution/pkg/common"
 	"knative.dev/pkg/apis"
 )
@@ -27,6 +28,7 @@ import (
 // sound before the controller receives it.
 func (rr *ResolutionRequest) Validate(ctx context.Context) (errs *apis.FieldError) {
 	errs = errs.Also(validateTypeLabel(rr))
+	errs = errs.Also(validateDeprecatedType(ctx, rr))
 	return errs.Also(rr.Spec.Validate(ctx).ViaField("spec"))
 }
 
@@ -43,6 +45,21 @@ func validateTypeLabel(rr *ResolutionRequest) *apis.FieldError {
 	return nil
 }
 
+// validateDeprecatedType rejects new requests of a resolver type whose
+// deprecation policy is config.DeprecationPolicyReject. Requests created
+// before their type was deprecated can still be updated.
+func validateDeprecatedType(ctx context.Context, rr *ResolutionRequest) *apis.FieldError {
+	if !apis.IsInCreate(ctx) {
+		return nil
+	}
+	typeLabel := getTypeLabel(rr.ObjectMeta.Labels)
+	deprecation, ok := config.FromContext(ctx)[typeLabel]
+	if !ok || deprecation.Policy != config.DeprecationPolicyReject {
+		return nil
+	}
+	return apis.ErrInvalidValue(typeLabel, common.LabelKeyResolverType, deprecation.Explain(typeLabel)).ViaField("labels").ViaField("meta")
+}
+
 func getTypeLabel(labels map[string]string) string {
 	if labels == nil {
 		return ""
@@ -25,6 +25,23 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConditionTransition) DeepCopyInto(out *ConditionTransition) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConditionTransition.
+func (in *ConditionTransition) DeepCopy() *ConditionTransition {
+	if in == nil {
+		return nil
+	}
+	out := new(ConditionTransition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResolutionRequest) DeepCopyInto(out *ResolutionRequest) {
 	*out = *in
@@ -113,7 +130,7 @@ func (in *ResolutionRequestSpec) DeepCopy() *ResolutionRequestSpec {
 func (in *ResolutionRequestStatus) DeepCopyInto(out *ResolutionRequestStatus) {
 	*out = *in
 	in.Status.DeepCopyInto(&out.Status)
-	out.ResolutionRequestStatusFields = in.ResolutionRequestStatusFields
+	in.ResolutionRequestStatusFields.DeepCopyInto(&out.ResolutionRequestStatusFields)
 	return
 }
 
@@ -130,6 +147,18 @@ func (in *ResolutionRequestStatus) DeepCopy() *ResolutionRequestStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResolutionRequestStatusFields) DeepCopyInto(out *ResolutionRequestStatusFields) {
 	*out = *in
+	if in.Warnings != nil {
+		in, out := &in.Warnings, &out.Warnings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]ConditionTransition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -0,0 +1,62 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// AnnotationKeyMaxDisk is the annotation on a ResolutionRequest that
+// bounds how much storage resolving it may use, such as for a clone.
+// Its value is a quantity such as "100Mi". Resolvers that honour it
+// read the bound with RequestMaxDisk.
+const AnnotationKeyMaxDisk = "resolution.tekton.dev/max-disk"
+
+// requestMaxDiskContextKey is the key stored in a context alongside the
+// AnnotationKeyMaxDisk bound of a resolution request, in bytes.
+type requestMaxDiskContextKey struct{}
+
+// RequestMaxDiskBytes returns the number of bytes that a request with
+// the given annotations asks to be bounded to with AnnotationKeyMaxDisk,
+// or 0 if it doesn't ask for a bound.
+func RequestMaxDiskBytes(annotations map[string]string) (int64, error) {
+	val, ok := annotations[AnnotationKeyMaxDisk]
+	if !ok || val == "" {
+		return 0, nil
+	}
+	quantity, err := resource.ParseQuantity(val)
+	if err != nil || quantity.Sign() <= 0 {
+		return 0, fmt.Errorf("invalid %s annotation %q: must be a positive quantity such as 100Mi", AnnotationKeyMaxDisk, val)
+	}
+	return quantity.Value(), nil
+}
+
+// InjectRequestMaxDisk returns a new context with the request-scoped
+// number of bytes of storage that resolving the request may use.
+func InjectRequestMaxDisk(ctx context.Context, maxBytes int64) context.Context {
+	return context.WithValue(ctx, requestMaxDiskContextKey{}, maxBytes)
+}
+
+// RequestMaxDisk returns the number of bytes of storage that resolving
+// the request currently being processed may use, or 0 if it's unbounded.
+func RequestMaxDisk(ctx context.Context) int64 {
+	maxBytes, _ := ctx.Value(requestMaxDiskContextKey{}).(int64)
+	return maxBytes
+}
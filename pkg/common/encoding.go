@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// AnnotationKeyDataEncoding is the annotation recording how the data of
+// a ResolutionRequest's status was encoded. Data without it is plain
+// base64, which is how every consumer can read it.
+const AnnotationKeyDataEncoding = "resolution.tekton.dev/data-encoding"
+
+const (
+	// DataEncodingBase64 is data encoded as base64, the default.
+	DataEncodingBase64 = "base64"
+	// DataEncodingGzip is data compressed with gzip and then encoded
+	// as base64.
+	DataEncodingGzip = "gzip+base64"
+	// DataEncodingZstd is data compressed with zstd and then encoded
+	// as base64. It's smaller and faster to compress than gzip for
+	// typical Tekton YAML, but not every consumer can decompress it.
+	DataEncodingZstd = "zstd+base64"
+)
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+// zstdCodec returns the zstd encoder and decoder shared by every
+// request, creating them the first time they're needed. Both are safe
+// for concurrent use with EncodeAll and DecodeAll.
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		if zstdEncoder, zstdErr = zstd.NewWriter(nil); zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+	return zstdEncoder, zstdDecoder, zstdErr
+}
+
+// EncodeData returns data encoded with encoding, one of
+// DataEncodingBase64, DataEncodingGzip or DataEncodingZstd, for storing
+// in a ResolutionRequest's status.
+func EncodeData(data []byte, encoding string) (string, error) {
+	switch encoding {
+	case "", DataEncodingBase64:
+	case DataEncodingGzip:
+		buf := &bytes.Buffer{}
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(data); err != nil {
+			return "", fmt.Errorf("error compressing data with gzip: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return "", fmt.Errorf("error compressing data with gzip: %w", err)
+		}
+		data = buf.Bytes()
+	case DataEncodingZstd:
+		encoder, _, err := zstdCodec()
+		if err != nil {
+			return "", fmt.Errorf("error compressing data with zstd: %w", err)
+		}
+		data = encoder.EncodeAll(data, nil)
+	default:
+		return "", fmt.Errorf("unsupported data encoding %q", encoding)
+	}
+	return base64.StdEncoding.Strict().EncodeToString(data), nil
+}
+
+// DecodeData returns the data that EncodeData encoded as encoded with
+// encoding.
+func DecodeData(encoded string, encoding string) ([]byte, error) {
+	data, err := base64.StdEncoding.Strict().DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding data from base64: %w", err)
+	}
+	switch encoding {
+	case "", DataEncodingBase64:
+		return data, nil
+	case DataEncodingGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing data with gzip: %w", err)
+		}
+		defer r.Close()
+		decompressed, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing data with gzip: %w", err)
+		}
+		return decompressed, nil
+	case DataEncodingZstd:
+		_, decoder, err := zstdCodec()
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing data with zstd: %w", err)
+		}
+		decompressed, err := decoder.DecodeAll(data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing data with zstd: %w", err)
+		}
+		return decompressed, nil
+	default:
+		return nil, fmt.Errorf("unsupported data encoding %q", encoding)
+	}
+}
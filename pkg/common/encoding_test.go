@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// samplePipeline returns a pipeline of n similar tasks, typical of the
+// Tekton YAML that resolvers return.
+func samplePipeline(n int) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("apiVersion: tekton.dev/v1beta1\nkind: Pipeline\nmetadata:\n  name: build\nspec:\n  tasks:\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(buf, "  - name: step-%d\n    taskRef:\n      resolver: git\n      params:\n      - name: url\n        value: https://github.com/tektoncd/catalog.git\n      - name: path\n        value: task/golang-build/0.%d/golang-build.yaml\n", i, i%4)
+	}
+	return buf.Bytes()
+}
+
+func TestEncodeDataRoundTrip(t *testing.T) {
+	data := samplePipeline(20)
+	for _, encoding := range []string{"", DataEncodingBase64, DataEncodingGzip, DataEncodingZstd} {
+		t.Run(encoding, func(t *testing.T) {
+			encoded, err := EncodeData(data, encoding)
+			if err != nil {
+				t.Fatalf("unexpected error encoding: %v", err)
+			}
+			decoded, err := DecodeData(encoded, encoding)
+			if err != nil {
+				t.Fatalf("unexpected error decoding: %v", err)
+			}
+			if !bytes.Equal(decoded, data) {
+				t.Errorf("expected data to round trip, received %q", decoded)
+			}
+		})
+	}
+}
+
+func TestEncodeDataBase64IsPlain(t *testing.T) {
+	encoded, err := EncodeData([]byte("resolved"), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if encoded != base64.StdEncoding.EncodeToString([]byte("resolved")) {
+		t.Errorf("expected data without an encoding to be plain base64, received %q", encoded)
+	}
+}
+
+func TestZstdNoLargerThanGzip(t *testing.T) {
+	data := samplePipeline(50)
+	gzipped, err := EncodeData(data, DataEncodingGzip)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zstded, err := EncodeData(data, DataEncodingZstd)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(zstded) > len(gzipped) {
+		t.Errorf("expected zstd output (%d bytes) to be no larger than gzip output (%d bytes)", len(zstded), len(gzipped))
+	}
+	if len(zstded) >= len(base64.StdEncoding.EncodeToString(data)) {
+		t.Errorf("expected zstd to compress the sample content")
+	}
+}
+
+func TestUnsupportedDataEncoding(t *testing.T) {
+	if _, err := EncodeData([]byte("resolved"), "brotli+base64"); err == nil {
+		t.Errorf("expected error encoding with an unsupported encoding")
+	}
+	_, err := DecodeData("cmVzb2x2ZWQ=", "brotli+base64")
+	if err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("expected unsupported encoding error decoding, received %v", err)
+	}
+}
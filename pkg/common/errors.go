@@ -127,7 +127,8 @@ func (e *ErrorUpdatingRequest) Unwrap() error {
 
 // ReasonError extracts the reason and underlying error
 // embedded in a given error or returns some sane defaults
-// if the error isn't a common.Error.
+// if the error isn't a common.Error. The reason of a common.Error
+// wrapped by err, e.g. one returned by a resolver, is also found.
 func ReasonError(err error) (string, error) {
 	reason := ReasonResolutionFailed
 	resolutionError := err
@@ -135,6 +136,8 @@ func ReasonError(err error) (string, error) {
 	if e, ok := err.(*Error); ok {
 		reason = e.Reason
 		resolutionError = e.Unwrap()
+	} else if errors.As(err, &e) {
+		reason = e.Reason
 	}
 
 	return reason, resolutionError
@@ -44,3 +44,20 @@ func TestResolutionErrorMessage(t *testing.T) {
 		t.Errorf("resolution error message expected to equal that of original error")
 	}
 }
+
+func TestReasonErrorWrapped(t *testing.T) {
+	original := NewError(ReasonResolvedContentEmpty, errors.New("file is empty"))
+	wrapped := &ErrorGettingResource{ResolverName: "git", Key: "foo/bar", Original: original}
+	reason, err := ReasonError(wrapped)
+	if reason != ReasonResolvedContentEmpty {
+		t.Errorf("expected reason %q, received %q", ReasonResolvedContentEmpty, reason)
+	}
+	if err != wrapped {
+		t.Errorf("expected wrapping error to be returned with its context, received %v", err)
+	}
+
+	reason, _ = ReasonError(errors.New("plain error"))
+	if reason != ReasonResolutionFailed {
+		t.Errorf("expected default reason %q, received %q", ReasonResolutionFailed, reason)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AnnotationKeyHTTPTimeout is the annotation on a ResolutionRequest that
+// overrides, for that request alone, how long the http requests made
+// while resolving it may wait on a slow host. Its value is a duration
+// such as "2m". Resolvers that honour it read it with
+// RequestHTTPTimeout and cap it at a maximum of their own.
+const AnnotationKeyHTTPTimeout = "resolution.tekton.dev/http-timeout"
+
+// requestHTTPTimeoutContextKey is the key stored in a context alongside
+// the AnnotationKeyHTTPTimeout of a resolution request.
+type requestHTTPTimeoutContextKey struct{}
+
+// RequestHTTPTimeoutDuration returns the http timeout that a request
+// with the given annotations asks for with AnnotationKeyHTTPTimeout, or
+// 0 if it doesn't ask for one.
+func RequestHTTPTimeoutDuration(annotations map[string]string) (time.Duration, error) {
+	val, ok := annotations[AnnotationKeyHTTPTimeout]
+	if !ok || val == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(val)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid %s annotation %q: must be a positive duration", AnnotationKeyHTTPTimeout, val)
+	}
+	return timeout, nil
+}
+
+// InjectRequestHTTPTimeout returns a new context with the request-scoped
+// http timeout.
+func InjectRequestHTTPTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestHTTPTimeoutContextKey{}, timeout)
+}
+
+// RequestHTTPTimeout returns the http timeout that the request currently
+// being processed asks for, or 0 if it leaves it to the resolver's
+// config.
+func RequestHTTPTimeout(ctx context.Context) time.Duration {
+	timeout, _ := ctx.Value(requestHTTPTimeoutContextKey{}).(time.Duration)
+	return timeout
+}
@@ -40,4 +40,19 @@ const (
 	// ReasonResolutionTimedOut indicates that a resolver did not
 	// manage to respond to a ResolutionRequest within a timeout.
 	ReasonResolutionTimedOut = "ResolutionTimedOut"
+
+	// ReasonResolvedContentEmpty indicates that a resolver found the
+	// requested resource but it had no content, and the request asked
+	// for empty content to be rejected.
+	ReasonResolvedContentEmpty = "ResolvedContentEmpty"
+
+	// ReasonResolvedContentDigestMismatch indicates that a resolver
+	// found the requested resource but its content didn't have the
+	// digest that the request expected.
+	ReasonResolvedContentDigestMismatch = "ResolvedContentDigestMismatch"
+
+	// ReasonResolvedTagMoved indicates that a tag the request was
+	// resolved from before now points to a different commit, and the
+	// resolver is configured to fail requests whose tags have moved.
+	ReasonResolvedTagMoved = "ResolvedTagMoved"
 )
@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AnnotationKeyTimeout is the annotation on a ResolutionRequest that
+// overrides, for that request alone, how long its resolution may take.
+// Its value is a duration such as "3m", which is capped at
+// MaximumRequestTimeout.
+const AnnotationKeyTimeout = "resolution.tekton.dev/timeout"
+
+// MaximumRequestTimeout is the most time a request can ask for with
+// AnnotationKeyTimeout, so that a single request can't hold up a
+// resolver indefinitely.
+const MaximumRequestTimeout = 10 * time.Minute
+
+// requestTimeoutContextKey is the key stored in a context alongside the
+// AnnotationKeyTimeout of a resolution request that sets one.
+type requestTimeoutContextKey struct{}
+
+// RequestTimeout returns the timeout that a request with the given
+// annotations asks for with AnnotationKeyTimeout, capped at
+// MaximumRequestTimeout, or timeout if it doesn't ask for one.
+func RequestTimeout(annotations map[string]string, timeout time.Duration) (time.Duration, error) {
+	val, ok := annotations[AnnotationKeyTimeout]
+	if !ok || val == "" {
+		return timeout, nil
+	}
+	requested, err := time.ParseDuration(val)
+	if err != nil || requested <= 0 {
+		return 0, fmt.Errorf("invalid %s annotation %q: must be a positive duration", AnnotationKeyTimeout, val)
+	}
+	if requested > MaximumRequestTimeout {
+		return MaximumRequestTimeout, nil
+	}
+	return requested, nil
+}
+
+// InjectRequestTimeout returns a new context with the timeout that the
+// request being processed asked for with AnnotationKeyTimeout.
+func InjectRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutContextKey{}, timeout)
+}
+
+// RequestTimeoutOverride returns the timeout that the request currently
+// being processed asked for with AnnotationKeyTimeout, or 0 if it left
+// its timeout to the resolver.
+func RequestTimeoutOverride(ctx context.Context) time.Duration {
+	timeout, _ := ctx.Value(requestTimeoutContextKey{}).(time.Duration)
+	return timeout
+}
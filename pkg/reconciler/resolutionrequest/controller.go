@@ -18,23 +18,58 @@ package resolutionrequest
 
 import (
 	"context"
+	"time"
 
 	"k8s.io/utils/clock"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
 
+	"github.com/tektoncd/resolution/pkg/apis/config"
 	resolutionrequestinformer "github.com/tektoncd/resolution/pkg/client/injection/informers/resolution/v1alpha1/resolutionrequest"
 	resolutionrequestreconciler "github.com/tektoncd/resolution/pkg/client/injection/reconciler/resolution/v1alpha1/resolutionrequest"
 )
 
-// NewController returns a func that returns a knative controller for processing
-// ResolutionRequest objects.
-func NewController(clock clock.PassiveClock) func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+// Options configures the controller returned by NewController. The
+// zero value uses the real clock, doesn't jitter requeues and never
+// reports requests as stuck.
+type Options struct {
+	// Clock measures how long requests have been in progress.
+	// Defaults to the real clock.
+	Clock clock.PassiveClock
+
+	// RequeueJitter is the maximum fraction by which requeue
+	// intervals are randomly lengthened.
+	RequeueJitter float64
+
+	// StuckThreshold is how long a request may be in progress before
+	// it's counted in the resolution_request_stuck_count metric, or 0
+	// to never count requests.
+	StuckThreshold time.Duration
+
+	// OnStuck, when set, is called for each request counted as stuck.
+	OnStuck StuckHook
+}
+
+// NewController returns a func that returns a knative controller for
+// processing ResolutionRequest objects, configured by opts and by the
+// config.ControllerConfigName configmap.
+func NewController(opts Options) func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
 	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
 		r := &Reconciler{
-			clock: clock,
+			clock:          opts.Clock,
+			requeueJitter:  opts.RequeueJitter,
+			stuckThreshold: opts.StuckThreshold,
+			onStuck:        opts.OnStuck,
+		}
+		if r.clock == nil {
+			r.clock = clock.RealClock{}
 		}
-		impl := resolutionrequestreconciler.NewImpl(ctx, r)
+		configStore := config.NewControllerStore(logging.FromContext(ctx).Named("config-store"))
+		configStore.WatchConfigs(cmw)
+		impl := resolutionrequestreconciler.NewImpl(ctx, r, func(impl *controller.Impl) controller.Options {
+			return controller.Options{ConfigStore: configStore}
+		})
 
 		reqinformer := resolutionrequestinformer.Get(ctx)
 		reqinformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
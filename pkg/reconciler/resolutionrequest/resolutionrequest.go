@@ -21,9 +21,11 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/tektoncd/resolution/pkg/apis/config"
 	"github.com/tektoncd/resolution/pkg/apis/resolution/v1alpha1"
 	rrreconciler "github.com/tektoncd/resolution/pkg/client/injection/reconciler/resolution/v1alpha1/resolutionrequest"
 	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/utils/clock"
 	"knative.dev/pkg/apis"
 	"knative.dev/pkg/controller"
@@ -34,6 +36,20 @@ import (
 // objects
 type Reconciler struct {
 	clock clock.PassiveClock
+
+	// requeueJitter is the maximum fraction by which a requeue
+	// interval is randomly lengthened, so that requests created at the
+	// same time aren't all requeued at the same time.
+	requeueJitter float64
+
+	// stuckThreshold is how long a request may be in progress before
+	// it's reported as stuck, or 0 to never report requests.
+	stuckThreshold time.Duration
+
+	// onStuck, when set, is called for each request reported as stuck.
+	onStuck StuckHook
+
+	stuck stuckRequests
 }
 
 var _ rrreconciler.Interface = (*Reconciler)(nil)
@@ -42,6 +58,10 @@ var _ rrreconciler.Interface = (*Reconciler)(nil)
 // store similarly to Tekton Pipelines'.
 const defaultMaximumResolutionDuration = 1 * time.Minute
 
+// DefaultRequeueJitter is the default maximum fraction by which requeue
+// intervals are lengthened.
+const DefaultRequeueJitter = 0.1
+
 // ReconcileKind processes updates to ResolutionRequests, sets status
 // fields on it, and returns any errors experienced along the way.
 func (r *Reconciler) ReconcileKind(ctx context.Context, rr *v1alpha1.ResolutionRequest) reconciler.Event {
@@ -50,6 +70,7 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, rr *v1alpha1.ResolutionR
 	}
 
 	if rr.IsDone() {
+		r.stuck.forget(rr.UID)
 		return nil
 	}
 
@@ -57,23 +78,62 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, rr *v1alpha1.ResolutionR
 		rr.Status.InitializeConditions()
 	}
 
+	// An invalid timeout annotation fails the request in the resolver,
+	// so the global timeout is used until it does.
+	timeout, err := resolutioncommon.RequestTimeout(rr.Annotations, defaultMaximumResolutionDuration)
+	if err != nil {
+		timeout = defaultMaximumResolutionDuration
+	}
+
+	duration := r.requestDuration(rr)
 	switch {
 	case rr.Status.Data != "":
 		rr.Status.MarkSucceeded()
-	case requestDuration(rr) > defaultMaximumResolutionDuration:
-		message := fmt.Sprintf("resolution took longer than global timeout of %s", defaultMaximumResolutionDuration)
+	case duration > timeout:
+		message := fmt.Sprintf("resolution took longer than timeout of %s", timeout)
 		rr.Status.MarkFailed(resolutioncommon.ReasonResolutionTimedOut, message)
 	default:
 		rr.Status.MarkInProgress(resolutioncommon.MessageWaitingForResolver)
-		return controller.NewRequeueAfter(defaultMaximumResolutionDuration - requestDuration(rr))
+		remaining := timeout - duration
+		if r.stuckThreshold > 0 {
+			if duration >= r.stuckThreshold {
+				r.reportStuck(ctx, rr, duration)
+			} else if untilStuck := r.stuckThreshold - duration; untilStuck < remaining {
+				// The request is reconciled again once it would be
+				// stuck, to report it in time.
+				remaining = untilStuck
+			}
+		}
+		return controller.NewRequeueAfter(r.requeueAfter(ctx, remaining))
 	}
 
+	r.stuck.forget(rr.UID)
 	return nil
 }
 
-// requestDuration returns the amount of time that has passed since a
-// given ResolutionRequest was created.
-func requestDuration(rr *v1alpha1.ResolutionRequest) time.Duration {
+// requeueAfter returns the interval to requeue a request after for it
+// to be reconciled again in d: d jittered and then raised to the
+// MinRequeueInterval configured in ctx.
+func (r *Reconciler) requeueAfter(ctx context.Context, d time.Duration) time.Duration {
+	d = r.jitter(d)
+	if minInterval := config.ControllerFromContext(ctx).MinRequeueInterval; d < minInterval {
+		return minInterval
+	}
+	return d
+}
+
+// jitter randomly lengthens a requeue interval by up to the reconciler's
+// requeueJitter fraction of it.
+func (r *Reconciler) jitter(d time.Duration) time.Duration {
+	if r.requeueJitter <= 0 {
+		return d
+	}
+	return wait.Jitter(d, r.requeueJitter)
+}
+
+// requestDuration returns the amount of time that has passed, by the
+// reconciler's clock, since a given ResolutionRequest was created.
+func (r *Reconciler) requestDuration(rr *v1alpha1.ResolutionRequest) time.Duration {
 	creationTime := rr.ObjectMeta.CreationTimestamp.DeepCopy().Time.UTC()
-	return time.Now().UTC().Sub(creationTime)
+	return r.clock.Now().UTC().Sub(creationTime)
 }
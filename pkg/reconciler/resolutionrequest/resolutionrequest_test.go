@@ -0,0 +1,233 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolutionrequest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/resolution/pkg/apis/config"
+	"github.com/tektoncd/resolution/pkg/apis/resolution/v1alpha1"
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/test/helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/controller"
+)
+
+func TestReconcileKindJittersRequeues(t *testing.T) {
+	const jitter = 0.5
+	r := &Reconciler{
+		clock:         clock.RealClock{},
+		requeueJitter: jitter,
+	}
+	created := time.Now()
+	intervals := map[time.Duration]bool{}
+	for i := 0; i < 20; i++ {
+		rr := &v1alpha1.ResolutionRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "rr",
+				Namespace:         "foo",
+				CreationTimestamp: metav1.NewTime(created),
+			},
+		}
+		requeued, interval := controller.IsRequeueKey(r.ReconcileKind(context.Background(), rr))
+		if !requeued {
+			t.Fatalf("expected in-progress request to be requeued")
+		}
+		remaining := defaultMaximumResolutionDuration - time.Since(created)
+		if interval < remaining-time.Second || interval > time.Duration(float64(defaultMaximumResolutionDuration)*(1+jitter)) {
+			t.Fatalf("requeue interval %s outside jittered band starting at %s", interval, remaining)
+		}
+		intervals[interval] = true
+	}
+	if len(intervals) < 2 {
+		t.Errorf("expected jittered requeue intervals to vary, received %v", intervals)
+	}
+}
+
+func TestReconcileKindWithoutJitter(t *testing.T) {
+	r := &Reconciler{clock: clock.RealClock{}}
+	rr := &v1alpha1.ResolutionRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "rr",
+			Namespace:         "foo",
+			CreationTimestamp: metav1.NewTime(time.Now()),
+		},
+	}
+	requeued, interval := controller.IsRequeueKey(r.ReconcileKind(context.Background(), rr))
+	if !requeued {
+		t.Fatalf("expected in-progress request to be requeued")
+	}
+	if interval > defaultMaximumResolutionDuration {
+		t.Errorf("expected requeue interval of at most %s without jitter, received %s", defaultMaximumResolutionDuration, interval)
+	}
+}
+
+func TestReconcileKindRaisesRequeuesToMinimumInterval(t *testing.T) {
+	const minInterval = 10 * time.Second
+	r := &Reconciler{clock: clock.RealClock{}}
+	conf, err := config.NewControllerFromMap(map[string]string{config.ConfigFieldMinRequeueInterval: "10s"})
+	if err != nil {
+		t.Fatalf("unexpected error parsing config: %v", err)
+	}
+	ctx := config.ControllerToContext(context.Background(), conf)
+	// The request times out in about 2s, well under the floor.
+	rr := &v1alpha1.ResolutionRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "rr",
+			Namespace:         "foo",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(2*time.Second - defaultMaximumResolutionDuration)),
+		},
+	}
+	requeued, interval := controller.IsRequeueKey(r.ReconcileKind(ctx, rr))
+	if !requeued {
+		t.Fatalf("expected in-progress request to be requeued")
+	}
+	if interval != minInterval {
+		t.Errorf("expected requeue interval to be raised to %s, received %s", minInterval, interval)
+	}
+}
+
+func TestReconcileKindHonorsTimeoutAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		timeout    string
+		age        time.Duration
+		expectFail bool
+	}{{
+		name:    "override within bounds keeps request going past global timeout",
+		timeout: "5m",
+		age:     2 * time.Minute,
+	}, {
+		name:       "override within bounds still times out",
+		timeout:    "5m",
+		age:        6 * time.Minute,
+		expectFail: true,
+	}, {
+		name:       "override past cluster max is clamped",
+		timeout:    "1h",
+		age:        resolutioncommon.MaximumRequestTimeout + time.Minute,
+		expectFail: true,
+	}, {
+		name:       "invalid override falls back to global timeout",
+		timeout:    "soon",
+		age:        2 * time.Minute,
+		expectFail: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &Reconciler{clock: clock.RealClock{}}
+			rr := &v1alpha1.ResolutionRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "rr",
+					Namespace:         "foo",
+					CreationTimestamp: metav1.NewTime(time.Now().Add(-tc.age)),
+					Annotations: map[string]string{
+						resolutioncommon.AnnotationKeyTimeout: tc.timeout,
+					},
+				},
+			}
+			event := r.ReconcileKind(context.Background(), rr)
+			requeued, _ := controller.IsRequeueKey(event)
+			if requeued == tc.expectFail {
+				t.Fatalf("expected requeue to be %t, received %v", !tc.expectFail, event)
+			}
+			if tc.expectFail {
+				helpers.AssertCondition(t, rr, apis.ConditionSucceeded, corev1.ConditionFalse, resolutioncommon.ReasonResolutionTimedOut)
+			} else {
+				helpers.AssertCondition(t, rr, apis.ConditionSucceeded, corev1.ConditionUnknown, resolutioncommon.ReasonResolutionInProgress)
+			}
+		})
+	}
+}
+
+func TestReconcileKindRecordsConditionHistory(t *testing.T) {
+	r := &Reconciler{clock: clock.RealClock{}}
+	rr := &v1alpha1.ResolutionRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "rr",
+			Namespace:         "foo",
+			CreationTimestamp: metav1.NewTime(time.Now()),
+		},
+	}
+
+	// Requeued reconciles of a request still in progress don't add to
+	// its history.
+	_ = r.ReconcileKind(context.Background(), rr)
+	_ = r.ReconcileKind(context.Background(), rr)
+	// The resolver fails the request, which is then refreshed and
+	// resolved successfully.
+	rr.Status.MarkFailed(resolutioncommon.ReasonResolutionFailed, "git host unreachable")
+	rr.Status.MarkInProgress("refreshed")
+	rr.Status.Data = "c29tZSBkYXRh"
+	_ = r.ReconcileKind(context.Background(), rr)
+
+	helpers.AssertCondition(t, rr, apis.ConditionSucceeded, corev1.ConditionTrue, "")
+	expected := []struct{ from, to string }{
+		{string(corev1.ConditionUnknown), resolutioncommon.ReasonResolutionInProgress},
+		{resolutioncommon.ReasonResolutionInProgress, resolutioncommon.ReasonResolutionFailed},
+		{resolutioncommon.ReasonResolutionFailed, resolutioncommon.ReasonResolutionInProgress},
+		{resolutioncommon.ReasonResolutionInProgress, string(corev1.ConditionTrue)},
+	}
+	if len(rr.Status.History) != len(expected) {
+		t.Fatalf("expected %d transitions, received %+v", len(expected), rr.Status.History)
+	}
+	for i, transition := range rr.Status.History {
+		if transition.From != expected[i].from || transition.To != expected[i].to {
+			t.Errorf("expected transition %d from %q to %q, received from %q to %q", i, expected[i].from, expected[i].to, transition.From, transition.To)
+		}
+		if transition.Time.IsZero() {
+			t.Errorf("expected transition %d to record its time", i)
+		}
+	}
+}
+
+func TestConditionHistoryIsBounded(t *testing.T) {
+	rr := &v1alpha1.ResolutionRequest{}
+	rr.Status.InitializeConditions()
+	for i := 0; i < v1alpha1.MaxHistory; i++ {
+		rr.Status.MarkFailed(resolutioncommon.ReasonResolutionFailed, "failed")
+		rr.Status.MarkInProgress("retrying")
+	}
+	if len(rr.Status.History) != v1alpha1.MaxHistory {
+		t.Fatalf("expected history to be bounded to %d transitions, received %d", v1alpha1.MaxHistory, len(rr.Status.History))
+	}
+	if last := rr.Status.History[len(rr.Status.History)-1]; last.To != resolutioncommon.ReasonResolutionInProgress {
+		t.Errorf("expected the most recent transition to be kept, received %+v", last)
+	}
+}
+
+func TestReconcileKindUsesDefaultMinimumInterval(t *testing.T) {
+	r := &Reconciler{clock: clock.RealClock{}}
+	rr := &v1alpha1.ResolutionRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "rr",
+			Namespace:         "foo",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(100*time.Millisecond - defaultMaximumResolutionDuration)),
+		},
+	}
+	requeued, interval := controller.IsRequeueKey(r.ReconcileKind(context.Background(), rr))
+	if !requeued {
+		t.Fatalf("expected in-progress request to be requeued")
+	}
+	if interval != config.DefaultMinRequeueInterval {
+		t.Errorf("expected requeue interval to be raised to %s, received %s", config.DefaultMinRequeueInterval, interval)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolutionrequest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tektoncd/resolution/pkg/apis/resolution/v1alpha1"
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+)
+
+// DefaultStuckThreshold is the default time after which a request
+// that's still in progress is reported as stuck.
+const DefaultStuckThreshold = 30 * time.Second
+
+// StuckHook is called when a request has been in progress for longer
+// than the reconciler's stuck threshold, e.g. because no resolver is
+// watching for its type, so that operators can be alerted before it
+// times out. It's called once per request with how long it's been in
+// progress.
+type StuckHook func(ctx context.Context, rr *v1alpha1.ResolutionRequest, inProgress time.Duration)
+
+var (
+	resolverTypeTagKey = tag.MustNewKey("resolver_type")
+
+	stuckRequestCountMeasure = stats.Int64(
+		"resolution_request_stuck_count",
+		"Number of resolution requests that were in progress for longer than the stuck threshold",
+		stats.UnitDimensionless,
+	)
+)
+
+func init() {
+	if err := metrics.RegisterResourceView(&view.View{
+		Description: stuckRequestCountMeasure.Description(),
+		Measure:     stuckRequestCountMeasure,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{resolverTypeTagKey},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// stuckRequests remembers the requests already reported as stuck, by
+// uid, so that each is only reported once however often it's requeued.
+type stuckRequests struct {
+	mu sync.Mutex
+	// created is the creation time of each request reported.
+	created map[types.UID]time.Time
+}
+
+// mark remembers that the request with uid, created at created, has
+// been reported, returning false if it already had been. Requests that
+// must have timed out by now are forgotten, since those deleted while
+// in progress are never reconciled again.
+func (s *stuckRequests) mark(uid types.UID, created, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.created == nil {
+		s.created = map[types.UID]time.Time{}
+	}
+	for other, otherCreated := range s.created {
+		if now.Sub(otherCreated) > resolutioncommon.MaximumRequestTimeout {
+			delete(s.created, other)
+		}
+	}
+	if _, ok := s.created[uid]; ok {
+		return false
+	}
+	s.created[uid] = created
+	return true
+}
+
+// forget drops the request with uid once it's done.
+func (s *stuckRequests) forget(uid types.UID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.created, uid)
+}
+
+// reportStuck records rr in the stuck request metric, logs a warning
+// and calls the reconciler's StuckHook, unless rr was already reported.
+func (r *Reconciler) reportStuck(ctx context.Context, rr *v1alpha1.ResolutionRequest, inProgress time.Duration) {
+	if !r.stuck.mark(rr.UID, rr.CreationTimestamp.Time, r.clock.Now()) {
+		return
+	}
+	resolverType := rr.Labels[resolutioncommon.LabelKeyResolverType]
+	logging.FromContext(ctx).Warnf("resolution request %s/%s of type %q has been in progress for %s", rr.Namespace, rr.Name, resolverType, inProgress.Round(time.Second))
+	if tagged, err := tag.New(ctx, tag.Upsert(resolverTypeTagKey, resolverType)); err == nil {
+		metrics.Record(tagged, stuckRequestCountMeasure.M(1))
+	}
+	if r.onStuck != nil {
+		r.onStuck(ctx, rr, inProgress)
+	}
+}
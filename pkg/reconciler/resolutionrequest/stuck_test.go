@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolutionrequest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/resolution/pkg/apis/resolution/v1alpha1"
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"go.opencensus.io/stats/view"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/metrics"
+)
+
+// stuckCount returns the number of requests of resolverType counted in
+// the stuck request metric.
+func stuckCount(t *testing.T, resolverType string) int64 {
+	t.Helper()
+	rows, err := view.RetrieveData(stuckRequestCountMeasure.Name())
+	if err != nil {
+		t.Fatalf("error retrieving metric: %v", err)
+	}
+	for _, row := range rows {
+		for _, tg := range row.Tags {
+			if tg.Key == resolverTypeTagKey && tg.Value == resolverType {
+				return row.Data.(*view.CountData).Value
+			}
+		}
+	}
+	return 0
+}
+
+func TestReconcileKindReportsStuckRequests(t *testing.T) {
+	metrics.InitForTesting()
+	const threshold = 20 * time.Second
+	created := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := clocktesting.NewFakePassiveClock(created)
+	var hooked []time.Duration
+	r := &Reconciler{
+		clock:          clock,
+		stuckThreshold: threshold,
+		onStuck: func(_ context.Context, _ *v1alpha1.ResolutionRequest, inProgress time.Duration) {
+			hooked = append(hooked, inProgress)
+		},
+	}
+	rr := &v1alpha1.ResolutionRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "rr",
+			Namespace:         "foo",
+			UID:               "rr-uid",
+			CreationTimestamp: metav1.NewTime(created),
+			Labels: map[string]string{
+				resolutioncommon.LabelKeyResolverType: "unwatched",
+			},
+		},
+	}
+
+	// Before the threshold the request is requeued for when it would
+	// be stuck rather than its timeout.
+	clock.SetTime(created.Add(5 * time.Second))
+	requeued, interval := controller.IsRequeueKey(r.ReconcileKind(context.Background(), rr))
+	if !requeued || interval != threshold-5*time.Second {
+		t.Fatalf("expected request to be requeued after %s, received requeue %t after %s", threshold-5*time.Second, requeued, interval)
+	}
+	if count := stuckCount(t, "unwatched"); count != 0 {
+		t.Errorf("expected no stuck requests before the threshold, received %d", count)
+	}
+
+	// Past it the request is reported, once however often it's
+	// requeued, while it's still requeued for its timeout.
+	clock.SetTime(created.Add(threshold + time.Second))
+	requeued, interval = controller.IsRequeueKey(r.ReconcileKind(context.Background(), rr))
+	if !requeued || interval != defaultMaximumResolutionDuration-threshold-time.Second {
+		t.Fatalf("expected request to be requeued for its timeout, received requeue %t after %s", requeued, interval)
+	}
+	clock.SetTime(created.Add(threshold + 10*time.Second))
+	_ = r.ReconcileKind(context.Background(), rr)
+	if count := stuckCount(t, "unwatched"); count != 1 {
+		t.Errorf("expected a single stuck request, received %d", count)
+	}
+	if len(hooked) != 1 || hooked[0] != threshold+time.Second {
+		t.Errorf("expected the hook to be called once with %s, received %v", threshold+time.Second, hooked)
+	}
+
+	// Once the request is resolved it's forgotten.
+	rr.Status.Data = "c29tZSBkYXRh"
+	_ = r.ReconcileKind(context.Background(), rr)
+	if _, ok := r.stuck.created[rr.UID]; ok {
+		t.Errorf("expected resolved request to be forgotten")
+	}
+}
+
+func TestReconcileKindWithoutStuckThreshold(t *testing.T) {
+	metrics.InitForTesting()
+	created := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := clocktesting.NewFakePassiveClock(created.Add(50 * time.Second))
+	r := &Reconciler{clock: clock}
+	rr := &v1alpha1.ResolutionRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "rr",
+			Namespace:         "foo",
+			UID:               "rr-uid",
+			CreationTimestamp: metav1.NewTime(created),
+			Labels: map[string]string{
+				resolutioncommon.LabelKeyResolverType: "disabled",
+			},
+		},
+	}
+	_ = r.ReconcileKind(context.Background(), rr)
+	if count := stuckCount(t, "disabled"); count != 0 {
+		t.Errorf("expected no stuck requests without a threshold, received %d", count)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tektoncd/resolution/pkg/apis/resolution/v1alpha1"
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"knative.dev/pkg/logging"
+)
+
+// ConfigKeyAuditLog is the key in a resolver's ConfigMap that, when
+// "true", makes the reconciler send an AuditRecord of every request it
+// resolves or fails to its AuditSink.
+const ConfigKeyAuditLog = "audit-log"
+
+// auditCommitAnnotation is the annotation that resolvers conventionally
+// record the commit a resource was resolved from under, as the git
+// resolver does.
+const auditCommitAnnotation = "commit"
+
+// AuditRecord is a structured record of the outcome of a request, for
+// security audits of what was resolved, for whom and when.
+type AuditRecord struct {
+	Time         time.Time `json:"time"`
+	Namespace    string    `json:"namespace"`
+	Name         string    `json:"name"`
+	ResolverType string    `json:"resolverType"`
+	// ParamsHash is the sha256 of the params the request was
+	// resolved with, with any defaults applied, as a JSON object,
+	// which identifies the params without recording any that are
+	// sensitive.
+	ParamsHash string `json:"paramsHash"`
+	// Outcome is "succeeded" or "failed".
+	Outcome string `json:"outcome"`
+	// Commit is the commit the resource was resolved from, if its
+	// resolver recorded one.
+	Commit string `json:"commit,omitempty"`
+	// Digest is the sha256 of the resolved data.
+	Digest string `json:"digest,omitempty"`
+	// FailureCategory and Error describe why a request failed.
+	FailureCategory string `json:"failureCategory,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// AuditSink receives the AuditRecords of the requests a reconciler
+// resolves when ConfigKeyAuditLog is enabled. It's set on the reconciler
+// with a ReconcilerModifier and defaults to a JSONAuditSink writing to
+// stdout.
+type AuditSink interface {
+	// Audit records the outcome of a request. Sinks report their own
+	// failures, since they can't change the outcome.
+	Audit(context.Context, AuditRecord)
+}
+
+// JSONAuditSink is an AuditSink that writes each record to Writer as a
+// line of JSON.
+type JSONAuditSink struct {
+	mu     sync.Mutex
+	Writer io.Writer
+}
+
+var _ AuditSink = &JSONAuditSink{}
+
+// Audit writes record to s.Writer as a line of JSON.
+func (s *JSONAuditSink) Audit(ctx context.Context, record AuditRecord) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		logging.FromContext(ctx).Warnf("error serializing audit record of %s/%s: %v", record.Namespace, record.Name, err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.Writer.Write(append(line, '\n')); err != nil {
+		logging.FromContext(ctx).Warnf("error writing audit record of %s/%s: %v", record.Namespace, record.Name, err)
+	}
+}
+
+// defaultAuditSink writes audit records to stdout, apart from the
+// controller's logs on stderr.
+var defaultAuditSink = &JSONAuditSink{Writer: os.Stdout}
+
+// auditEnabled returns whether ConfigKeyAuditLog is set in the resolver
+// config in ctx.
+func auditEnabled(ctx context.Context) bool {
+	val := strings.TrimSpace(GetResolverConfigFromContext(ctx)[ConfigKeyAuditLog])
+	if val == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(val)
+	if err != nil {
+		logging.FromContext(ctx).Warnf("ignoring invalid %s %q: must be true or false", ConfigKeyAuditLog, val)
+		return false
+	}
+	return enabled
+}
+
+// auditParamsKey is the context key for the params, with defaults
+// applied, that a request is resolved with.
+type auditParamsKey struct{}
+
+// withAuditParams returns a new context carrying the params that a
+// request is resolved with, for its audit record.
+func withAuditParams(ctx context.Context, params map[string]string) context.Context {
+	return context.WithValue(ctx, auditParamsKey{}, params)
+}
+
+// auditParams returns the params stored in ctx with withAuditParams or,
+// for requests that failed before their defaults were applied, the
+// params of rr.
+func auditParams(ctx context.Context, rr *v1alpha1.ResolutionRequest) map[string]string {
+	if params, ok := ctx.Value(auditParamsKey{}).(map[string]string); ok {
+		return params
+	}
+	return rr.Spec.Parameters
+}
+
+// audit sends the record of rr being resolved to resource, or failing
+// with resolutionErr, to the reconciler's AuditSink if auditing is
+// enabled.
+func (r *Reconciler) audit(ctx context.Context, rr *v1alpha1.ResolutionRequest, resource ResolvedResource, resolutionErr error) {
+	if r.AuditSink == nil || !auditEnabled(ctx) {
+		return
+	}
+	params, _ := json.Marshal(auditParams(ctx, rr))
+	paramsHash := sha256.Sum256(params)
+	now := time.Now()
+	if r.Clock != nil {
+		now = r.Clock.Now()
+	}
+	record := AuditRecord{
+		Time:         now,
+		Namespace:    rr.Namespace,
+		Name:         rr.Name,
+		ResolverType: rr.Labels[resolutioncommon.LabelKeyResolverType],
+		ParamsHash:   "sha256:" + hex.EncodeToString(paramsHash[:]),
+		Outcome:      outcomeSucceeded,
+	}
+	if resolutionErr != nil {
+		record.Outcome = outcomeFailed
+		record.FailureCategory = failureCategory(resolutionErr)
+		record.Error = resolutionErr.Error()
+	} else {
+		digest := sha256.Sum256(resource.Data())
+		record.Digest = "sha256:" + hex.EncodeToString(digest[:])
+		record.Commit = resource.Annotations()[auditCommitAnnotation]
+	}
+	r.AuditSink.Audit(ctx, record)
+}
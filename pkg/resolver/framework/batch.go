@@ -0,0 +1,36 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import "context"
+
+// ResolveBatch resolves the given batch of params using the resolver's
+// own BatchResolver implementation if it has one and advertises
+// CapabilityBatch (or advertises no capabilities), otherwise by calling
+// Resolve for each params in turn. Results and errors are returned in
+// the same order as the given params.
+func ResolveBatch(ctx context.Context, resolver Resolver, params []map[string]string) ([]ResolvedResource, []error) {
+	if batcher, ok := resolver.(BatchResolver); hasCapability(ctx, resolver, CapabilityBatch, ok) {
+		return batcher.ResolveBatch(ctx, params)
+	}
+	resources := make([]ResolvedResource, len(params))
+	errs := make([]error, len(params))
+	for i, p := range params {
+		resources[i], errs[i] = resolver.Resolve(ctx, p)
+	}
+	return resources, errs
+}
@@ -0,0 +1,93 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// paramResolver resolves the value of its "name" param as data.
+type paramResolver struct {
+	fakeResolver
+}
+
+func (p *paramResolver) Resolve(_ context.Context, params map[string]string) (ResolvedResource, error) {
+	p.resolved++
+	if params["name"] == "" {
+		return nil, ErrorResourceNotFound
+	}
+	return &fakeResource{data: []byte(params["name"])}, nil
+}
+
+type batchingResolver struct {
+	paramResolver
+	batches int
+}
+
+func (b *batchingResolver) ResolveBatch(ctx context.Context, params []map[string]string) ([]ResolvedResource, []error) {
+	b.batches++
+	resources := []ResolvedResource{}
+	errs := []error{}
+	for _, p := range params {
+		resource, err := b.Resolve(ctx, p)
+		resources = append(resources, resource)
+		errs = append(errs, err)
+	}
+	return resources, errs
+}
+
+func TestResolveBatchDefault(t *testing.T) {
+	resolver := &paramResolver{}
+	resources, errs := ResolveBatch(context.Background(), resolver, []map[string]string{
+		{"name": "foo"},
+		{},
+		{"name": "bar"},
+	})
+	if resolver.resolved != 3 {
+		t.Fatalf("expected Resolve to be called for each params, called %d times", resolver.resolved)
+	}
+	if string(resources[0].Data()) != "foo" || string(resources[2].Data()) != "bar" {
+		t.Errorf("unexpected results out of order: %v", resources)
+	}
+	if !errors.Is(errs[1], ErrorResourceNotFound) || errs[0] != nil || errs[2] != nil {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
+
+func TestResolveBatchUsesBatchResolver(t *testing.T) {
+	resolver := &batchingResolver{}
+	resources, errs := ResolveBatch(context.Background(), resolver, []map[string]string{
+		{"name": "foo"},
+		{"name": "bar"},
+	})
+	if resolver.batches != 1 {
+		t.Fatalf("expected ResolveBatch to be used")
+	}
+	if len(resources) != 2 || len(errs) != 2 || string(resources[1].Data()) != "bar" {
+		t.Errorf("unexpected results: %v %v", resources, errs)
+	}
+}
+
+// unadvertisedBatchingResolver implements BatchResolver but doesn't
+// advertise CapabilityBatch.
+type unadvertisedBatchingResolver struct {
+	batchingResolver
+}
+
+func (u *unadvertisedBatchingResolver) Capabilities(context.Context) []string {
+	return []string{}
+}
+
+func TestResolveBatchSkipsUnadvertisedBatchResolver(t *testing.T) {
+	resolver := &unadvertisedBatchingResolver{}
+	ResolveBatch(context.Background(), resolver, []map[string]string{
+		{"name": "foo"},
+		{"name": "bar"},
+	})
+	if resolver.batches != 0 {
+		t.Errorf("expected ResolveBatch not to be used without CapabilityBatch")
+	}
+	if resolver.resolved != 2 {
+		t.Errorf("expected Resolve to be called for each params, called %d times", resolver.resolved)
+	}
+}
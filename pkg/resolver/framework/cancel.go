@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tektoncd/resolution/pkg/apis/resolution/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+// inFlightResolutions tracks the resolutions in flight by the UID of
+// their request, so that deleting a request cancels its resolution
+// rather than leaving it to run to completion. The zero value is ready
+// to use.
+type inFlightResolutions struct {
+	mu          sync.Mutex
+	resolutions map[types.UID]*inFlightResolution
+}
+
+// inFlightResolution is the resolution of a single request.
+type inFlightResolution struct {
+	cancel  context.CancelFunc
+	deleted int32
+}
+
+// wasDeleted returns whether the resolution was cancelled because its
+// request was deleted.
+func (f *inFlightResolution) wasDeleted() bool {
+	return atomic.LoadInt32(&f.deleted) == 1
+}
+
+// track records that the request with uid is being resolved with a
+// context cancelled by cancel, returning the resolution and a func to
+// stop tracking it once it completes.
+func (i *inFlightResolutions) track(uid types.UID, cancel context.CancelFunc) (*inFlightResolution, func()) {
+	flight := &inFlightResolution{cancel: cancel}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.resolutions == nil {
+		i.resolutions = map[types.UID]*inFlightResolution{}
+	}
+	i.resolutions[uid] = flight
+	return flight, func() {
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		if i.resolutions[uid] == flight {
+			delete(i.resolutions, uid)
+		}
+	}
+}
+
+// cancel cancels the resolution of the request with uid, if one is in
+// flight.
+func (i *inFlightResolutions) cancel(uid types.UID) {
+	i.mu.Lock()
+	flight, ok := i.resolutions[uid]
+	delete(i.resolutions, uid)
+	i.mu.Unlock()
+	if ok {
+		atomic.StoreInt32(&flight.deleted, 1)
+		flight.cancel()
+	}
+}
+
+// cancelDeletedResolution is an informer event handler that cancels the
+// in-flight resolution of a ResolutionRequest that has been deleted, or
+// is being deleted, given either the request or the tombstone left by a
+// missed delete event.
+func (r *Reconciler) cancelDeletedResolution(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if rr, ok := obj.(*v1alpha1.ResolutionRequest); ok {
+		r.inFlight.cancel(rr.UID)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import "context"
+
+const (
+	// CapabilityConfigWatcher is advertised by resolvers implementing
+	// ConfigWatcher.
+	CapabilityConfigWatcher = "config-watcher"
+
+	// CapabilityTimedResolution is advertised by resolvers implementing
+	// TimedResolution.
+	CapabilityTimedResolution = "timed-resolution"
+
+	// CapabilityBatch is advertised by resolvers implementing
+	// BatchResolver.
+	CapabilityBatch = "batch"
+)
+
+// CapabilityAdvertiser is an optional interface that a resolver can
+// implement to declare which of the framework's optional features it
+// supports. When a resolver implements it, the framework only calls the
+// optional interfaces whose capabilities are advertised, even if the
+// resolver's type happens to implement others (for example through an
+// embedded struct).
+type CapabilityAdvertiser interface {
+	// Capabilities returns the names of the capabilities the resolver
+	// supports, e.g. CapabilityBatch.
+	Capabilities(context.Context) []string
+}
+
+// Capabilities returns the capabilities advertised by the given
+// resolver. Resolvers that don't implement CapabilityAdvertiser
+// advertise none.
+func Capabilities(ctx context.Context, resolver Resolver) []string {
+	if advertiser, ok := resolver.(CapabilityAdvertiser); ok {
+		return advertiser.Capabilities(ctx)
+	}
+	return nil
+}
+
+// hasCapability reports whether the framework should use the optional
+// feature named by capability. Resolvers that advertise their
+// capabilities must list it; for those that don't, the framework falls
+// back to checking that the resolver implements the feature's interface,
+// which the caller passes as implemented.
+func hasCapability(ctx context.Context, resolver Resolver, capability string, implemented bool) bool {
+	if !implemented {
+		return false
+	}
+	advertiser, ok := resolver.(CapabilityAdvertiser)
+	if !ok {
+		return true
+	}
+	for _, c := range advertiser.Capabilities(ctx) {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AnnotationKeyResolverLayer is the annotation key added by a
+// CompositeResolver to record the name of the layer that served a
+// resolved resource.
+const AnnotationKeyResolverLayer = "resolution.tekton.dev/resolver-layer"
+
+// ErrorResourceNotFound is the error that a resolver should return,
+// or wrap, when the resource it was asked for does not exist. A
+// CompositeResolver treats this error as a miss and moves on to its
+// next layer.
+var ErrorResourceNotFound = errors.New("resource not found")
+
+// CompositeResolver implements Resolver by wrapping an ordered list of
+// other resolvers. Requests are tried against each layer in turn and the
+// first layer to successfully resolve the resource serves the result.
+// A layer is skipped if it rejects the request's params or returns
+// ErrorResourceNotFound; any other error stops resolution immediately.
+type CompositeResolver struct {
+	name     string
+	selector map[string]string
+	layers   []Resolver
+}
+
+var _ Resolver = &CompositeResolver{}
+
+// NewCompositeResolver returns a CompositeResolver with the given name
+// and selector that reads through the given resolvers in order.
+func NewCompositeResolver(name string, selector map[string]string, layers ...Resolver) *CompositeResolver {
+	return &CompositeResolver{
+		name:     name,
+		selector: selector,
+		layers:   layers,
+	}
+}
+
+// Initialize initializes every layer of the composite resolver.
+func (c *CompositeResolver) Initialize(ctx context.Context) error {
+	for _, layer := range c.layers {
+		if err := layer.Initialize(ctx); err != nil {
+			return fmt.Errorf("error initializing %q: %w", layer.GetName(ctx), err)
+		}
+	}
+	return nil
+}
+
+// GetName returns the name the composite resolver was created with.
+func (c *CompositeResolver) GetName(context.Context) string {
+	return c.name
+}
+
+// GetSelector returns the labels that requests must have to be routed
+// to the composite resolver. The selectors of individual layers are
+// ignored.
+func (c *CompositeResolver) GetSelector(context.Context) map[string]string {
+	return c.selector
+}
+
+// ValidateParams returns nil if at least one layer accepts the given
+// params, otherwise it returns every layer's validation error.
+func (c *CompositeResolver) ValidateParams(ctx context.Context, params map[string]string) error {
+	if len(c.layers) == 0 {
+		return errors.New("composite resolver has no layers")
+	}
+	messages := []string{}
+	for _, layer := range c.layers {
+		err := layer.ValidateParams(ctx, params)
+		if err == nil {
+			return nil
+		}
+		messages = append(messages, fmt.Sprintf("%s: %v", layer.GetName(ctx), err))
+	}
+	return fmt.Errorf("no resolver accepted params: %s", strings.Join(messages, "; "))
+}
+
+// Resolve tries each layer in order, returning the first successfully
+// resolved resource annotated with the name of the layer that served
+// it. ErrorResourceNotFound is returned if every layer misses.
+func (c *CompositeResolver) Resolve(ctx context.Context, params map[string]string) (ResolvedResource, error) {
+	for _, layer := range c.layers {
+		if err := layer.ValidateParams(ctx, params); err != nil {
+			continue
+		}
+		resource, err := layer.Resolve(ctx, params)
+		if errors.Is(err, ErrorResourceNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", layer.GetName(ctx), err)
+		}
+		return &layeredResource{
+			ResolvedResource: resource,
+			layer:            layer.GetName(ctx),
+		}, nil
+	}
+	return nil, ErrorResourceNotFound
+}
+
+// layeredResource decorates a ResolvedResource with the name of the
+// composite layer that resolved it.
+type layeredResource struct {
+	ResolvedResource
+	layer string
+}
+
+var _ ResolvedResourceWithWarnings = &layeredResource{}
+
+// Annotations returns the wrapped resource's annotations along with
+// the name of the layer that served it.
+func (r *layeredResource) Annotations() map[string]string {
+	annotations := map[string]string{}
+	for key, val := range r.ResolvedResource.Annotations() {
+		annotations[key] = val
+	}
+	annotations[AnnotationKeyResolverLayer] = r.layer
+	return annotations
+}
+
+// Warnings returns the warnings reported by the wrapped resource.
+func (r *layeredResource) Warnings() []string {
+	return Warnings(r.ResolvedResource)
+}
@@ -0,0 +1,151 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+)
+
+// fakeResolver is a Resolver that returns canned responses for
+// testing.
+type fakeResolver struct {
+	name        string
+	validateErr error
+	resource    ResolvedResource
+	resolveErr  error
+	resolved    int
+}
+
+var _ Resolver = &fakeResolver{}
+
+func (f *fakeResolver) Initialize(context.Context) error { return nil }
+
+func (f *fakeResolver) GetName(context.Context) string { return f.name }
+
+func (f *fakeResolver) GetSelector(context.Context) map[string]string {
+	return map[string]string{resolutioncommon.LabelKeyResolverType: f.name}
+}
+
+func (f *fakeResolver) ValidateParams(context.Context, map[string]string) error {
+	return f.validateErr
+}
+
+func (f *fakeResolver) Resolve(context.Context, map[string]string) (ResolvedResource, error) {
+	f.resolved++
+	return f.resource, f.resolveErr
+}
+
+// fakeResource is a ResolvedResource with fixed data and annotations.
+type fakeResource struct {
+	data        []byte
+	annotations map[string]string
+}
+
+var _ ResolvedResource = &fakeResource{}
+
+func (f *fakeResource) Data() []byte { return f.data }
+
+func (f *fakeResource) Annotations() map[string]string { return f.annotations }
+
+func TestCompositeResolverPrimaryHit(t *testing.T) {
+	primary := &fakeResolver{
+		name:     "primary",
+		resource: &fakeResource{data: []byte("from primary"), annotations: map[string]string{"foo": "bar"}},
+	}
+	secondary := &fakeResolver{
+		name:     "secondary",
+		resource: &fakeResource{data: []byte("from secondary")},
+	}
+	composite := NewCompositeResolver("composite", nil, primary, secondary)
+
+	resource, err := composite.Resolve(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resource.Data()) != "from primary" {
+		t.Errorf("expected primary data, received %q", resource.Data())
+	}
+	if layer := resource.Annotations()[AnnotationKeyResolverLayer]; layer != "primary" {
+		t.Errorf("expected layer annotation %q, received %q", "primary", layer)
+	}
+	if resource.Annotations()["foo"] != "bar" {
+		t.Errorf("expected primary's annotations to be retained")
+	}
+	if secondary.resolved != 0 {
+		t.Errorf("expected secondary not to be called on primary hit")
+	}
+}
+
+func TestCompositeResolverFallbackHit(t *testing.T) {
+	primary := &fakeResolver{
+		name:       "primary",
+		resolveErr: ErrorResourceNotFound,
+	}
+	invalid := &fakeResolver{
+		name:        "invalid",
+		validateErr: errors.New("bad params"),
+	}
+	secondary := &fakeResolver{
+		name:     "secondary",
+		resource: &fakeResource{data: []byte("from secondary")},
+	}
+	composite := NewCompositeResolver("composite", nil, primary, invalid, secondary)
+
+	if err := composite.ValidateParams(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	resource, err := composite.Resolve(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resource.Data()) != "from secondary" {
+		t.Errorf("expected secondary data, received %q", resource.Data())
+	}
+	if layer := resource.Annotations()[AnnotationKeyResolverLayer]; layer != "secondary" {
+		t.Errorf("expected layer annotation %q, received %q", "secondary", layer)
+	}
+	if invalid.resolved != 0 {
+		t.Errorf("expected layer with invalid params to be skipped")
+	}
+}
+
+func TestCompositeResolverAllMiss(t *testing.T) {
+	primary := &fakeResolver{
+		name:       "primary",
+		resolveErr: ErrorResourceNotFound,
+	}
+	secondary := &fakeResolver{
+		name:       "secondary",
+		resolveErr: ErrorResourceNotFound,
+	}
+	composite := NewCompositeResolver("composite", nil, primary, secondary)
+
+	_, err := composite.Resolve(context.Background(), nil)
+	if !errors.Is(err, ErrorResourceNotFound) {
+		t.Fatalf("expected ErrorResourceNotFound, received %v", err)
+	}
+	if primary.resolved != 1 || secondary.resolved != 1 {
+		t.Errorf("expected every layer to be tried once")
+	}
+}
+
+func TestCompositeResolverStopsOnError(t *testing.T) {
+	primary := &fakeResolver{
+		name:       "primary",
+		resolveErr: errors.New("connection refused"),
+	}
+	secondary := &fakeResolver{
+		name:     "secondary",
+		resource: &fakeResource{data: []byte("from secondary")},
+	}
+	composite := NewCompositeResolver("composite", nil, primary, secondary)
+
+	if _, err := composite.Resolve(context.Background(), nil); err == nil {
+		t.Fatalf("expected error from primary to be returned")
+	}
+	if secondary.resolved != 0 {
+		t.Errorf("expected secondary not to be called after a non-miss error")
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"fmt"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+)
+
+// ConfigKeyDataCompression is the key in a resolver's ConfigMap that
+// sets how resolved data is compressed before it's stored in a
+// request's status: "gzip", "zstd" or, when unset, "none". Compressed
+// data is marked with resolutioncommon.AnnotationKeyDataEncoding.
+const ConfigKeyDataCompression = "data-compression"
+
+// dataEncodings maps the values of ConfigKeyDataCompression to the data
+// encodings they select.
+var dataEncodings = map[string]string{
+	"":     resolutioncommon.DataEncodingBase64,
+	"none": resolutioncommon.DataEncodingBase64,
+	"gzip": resolutioncommon.DataEncodingGzip,
+	"zstd": resolutioncommon.DataEncodingZstd,
+}
+
+// dataEncoding returns the encoding that ConfigKeyDataCompression in
+// ctx's resolver config selects.
+func dataEncoding(ctx context.Context) (string, error) {
+	compression := GetResolverConfigFromContext(ctx)[ConfigKeyDataCompression]
+	encoding, ok := dataEncodings[compression]
+	if !ok {
+		return "", fmt.Errorf("invalid %q config %q: must be %q, %q or %q", ConfigKeyDataCompression, compression, "none", "gzip", "zstd")
+	}
+	return encoding, nil
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/tektoncd/resolution/pkg/apis/config"
 	"github.com/tektoncd/resolution/pkg/apis/resolution/v1alpha1"
 	rrclient "github.com/tektoncd/resolution/pkg/client/injection/client"
 	rrinformer "github.com/tektoncd/resolution/pkg/client/injection/informers/resolution/v1alpha1/resolutionrequest"
@@ -39,7 +40,7 @@ import (
 
 // ReconcilerModifier is a func that can access and modify a reconciler
 // in the moments before a resolver is started. It allows for
-// things like injecting a test clock.
+// things like injecting a test clock or an AnnotationEnricher.
 type ReconcilerModifier = func(reconciler *Reconciler)
 
 // NewController returns a knative controller for a Tekton Resolver.
@@ -69,6 +70,11 @@ func NewController(ctx context.Context, resolver Resolver, modifiers ...Reconcil
 
 		watchConfigChanges(ctx, r, cmw)
 
+		// Settings shared by every resolver, such as the cap on
+		// concurrent resolutions, come from the controller's config.
+		r.controllerConfigStore = config.NewControllerStore(logger.Named("controller-config-store"))
+		r.controllerConfigStore.WatchConfigs(cmw)
+
 		// TODO(sbwsg): Do better sanitize.
 		resolverName := resolver.GetName(ctx)
 		resolverName = strings.ReplaceAll(resolverName, "/", "")
@@ -86,11 +92,15 @@ func NewController(ctx context.Context, resolver Resolver, modifiers ...Reconcil
 			Handler: cache.ResourceEventHandlerFuncs{
 				AddFunc: impl.Enqueue,
 				UpdateFunc: func(oldObj, newObj interface{}) {
+					if rr, ok := newObj.(*v1alpha1.ResolutionRequest); ok && rr.DeletionTimestamp != nil {
+						r.cancelDeletedResolution(newObj)
+					}
 					impl.Enqueue(newObj)
 				},
-				// TODO(sbwsg): should we deliver delete events
-				// to the resolver?
-				// DeleteFunc: impl.Enqueue,
+				// Deleted requests aren't delivered to the resolver,
+				// but any resolution of them still in flight is
+				// cancelled.
+				DeleteFunc: r.cancelDeletedResolution,
 			},
 		})
 
@@ -98,8 +108,14 @@ func NewController(ctx context.Context, resolver Resolver, modifiers ...Reconcil
 	}
 }
 
+// filterResolutionRequestsBySelector returns a filter accepting the
+// ResolutionRequests labelled with selector, including those in the
+// tombstones left by missed delete events.
 func filterResolutionRequestsBySelector(selector map[string]string) func(obj interface{}) bool {
 	return func(obj interface{}) bool {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
 		rr, ok := obj.(*v1alpha1.ResolutionRequest)
 		if !ok {
 			return false
@@ -159,9 +175,11 @@ func validateResolver(ctx context.Context, r Resolver) error {
 
 // watchConfigChanges binds a framework.Resolver to updates on its
 // configmap, using knative's configmap helpers. This is only done if
-// the resolver implements the framework.ConfigWatcher interface.
+// the resolver implements the framework.ConfigWatcher interface and
+// doesn't leave CapabilityConfigWatcher out of its advertised
+// capabilities.
 func watchConfigChanges(ctx context.Context, reconciler *Reconciler, cmw configmap.Watcher) {
-	if configWatcher, ok := reconciler.resolver.(ConfigWatcher); ok {
+	if configWatcher, ok := reconciler.resolver.(ConfigWatcher); hasCapability(ctx, reconciler.resolver, CapabilityConfigWatcher, ok) {
 		logger := logging.FromContext(ctx)
 		resolverConfigName := configWatcher.GetConfigName(ctx)
 		if resolverConfigName == "" {
@@ -192,4 +210,12 @@ func applyModifiersAndDefaults(ctx context.Context, r *Reconciler, modifiers []R
 	if r.Clock == nil {
 		r.Clock = clock.RealClock{}
 	}
+
+	if r.AnnotationEnricher == nil {
+		r.AnnotationEnricher = NoopAnnotationEnricher{}
+	}
+
+	if r.AuditSink == nil {
+		r.AuditSink = defaultAuditSink
+	}
 }
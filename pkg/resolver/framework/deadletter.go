@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"errors"
+
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+)
+
+// ErrorAuthenticationFailed is the error that a resolver should return,
+// or wrap, when it couldn't authenticate to fetch a resource, so that
+// the request's failure is categorized as FailureCategoryAuth.
+var ErrorAuthenticationFailed = errors.New("authentication failed")
+
+const (
+	// AnnotationKeyFailureCategory is the annotation added to the
+	// status of a request that failed permanently, holding one of the
+	// FailureCategory values, so that tooling can route failed
+	// requests without parsing their condition message.
+	AnnotationKeyFailureCategory = "resolution.tekton.dev/failure-category"
+
+	// AnnotationKeyFailureDetail is the annotation added to the status
+	// of a request that failed permanently, holding the error it failed
+	// with.
+	AnnotationKeyFailureDetail = "resolution.tekton.dev/failure-detail"
+)
+
+// The categories of permanent failure recorded in
+// AnnotationKeyFailureCategory.
+const (
+	// FailureCategoryInvalidRequest is a request whose params were
+	// rejected by its resolver.
+	FailureCategoryInvalidRequest = "invalid-request"
+	// FailureCategoryAuth is a request whose resolver couldn't
+	// authenticate to fetch the resource.
+	FailureCategoryAuth = "auth"
+	// FailureCategoryNotFound is a request for a resource that doesn't
+	// exist.
+	FailureCategoryNotFound = "not-found"
+	// FailureCategoryTimeout is a request that wasn't resolved in time.
+	FailureCategoryTimeout = "timeout"
+	// FailureCategoryUpdate is a request that was resolved but couldn't
+	// be updated with the resolved resource.
+	FailureCategoryUpdate = "update"
+	// FailureCategoryResolution is any other failure to resolve a
+	// request.
+	FailureCategoryResolution = "resolution"
+)
+
+// failureCategory returns the FailureCategory of the error a request
+// failed permanently with.
+func failureCategory(err error) string {
+	var invalid *resolutioncommon.ErrorInvalidRequest
+	var updating *resolutioncommon.ErrorUpdatingRequest
+	switch {
+	case errors.As(err, &invalid):
+		return FailureCategoryInvalidRequest
+	case errors.Is(err, ErrorAuthenticationFailed):
+		return FailureCategoryAuth
+	case errors.Is(err, ErrorResourceNotFound):
+		return FailureCategoryNotFound
+	case errors.Is(err, context.DeadlineExceeded):
+		return FailureCategoryTimeout
+	case errors.As(err, &updating):
+		return FailureCategoryUpdate
+	default:
+		return FailureCategoryResolution
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tektoncd/resolution/pkg/apis/resolution/v1alpha1"
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultsConfigKey returns the key in a resolver's ConfigMap holding
+// the default params of requests of resolverType, e.g. "git.defaults",
+// as a YAML map of param names to values. They're merged into each
+// request's params before validation, with the request's own values
+// taking precedence.
+func DefaultsConfigKey(resolverType string) string {
+	return resolverType + ".defaults"
+}
+
+// paramsWithDefaults returns the params of rr merged over the defaults
+// configured in ctx for its resolver type.
+func paramsWithDefaults(ctx context.Context, rr *v1alpha1.ResolutionRequest) (map[string]string, error) {
+	key := DefaultsConfigKey(rr.Labels[resolutioncommon.LabelKeyResolverType])
+	val, ok := GetResolverConfigFromContext(ctx)[key]
+	if !ok || val == "" {
+		return rr.Spec.Parameters, nil
+	}
+	defaults := map[string]string{}
+	if err := yaml.Unmarshal([]byte(val), &defaults); err != nil {
+		return nil, fmt.Errorf("invalid %q config: must be a map of param names to string values: %w", key, err)
+	}
+	params := make(map[string]string, len(defaults)+len(rr.Spec.Parameters))
+	for k, v := range defaults {
+		params[k] = v
+	}
+	for k, v := range rr.Spec.Parameters {
+		params[k] = v
+	}
+	return params, nil
+}
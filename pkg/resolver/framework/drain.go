@@ -0,0 +1,62 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"time"
+)
+
+// defaultDrainTimeout is how long resolutions in flight when the
+// controller shuts down are given to finish if the Reconciler's
+// DrainTimeout isn't set. It matches Kubernetes' default termination
+// grace period.
+const defaultDrainTimeout = 30 * time.Second
+
+// detachedContext carries the values of its parent but is never
+// cancelled along with it.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (d detachedContext) Value(key interface{}) interface{} { return d.parent.Value(key) }
+
+// drainContext returns a context carrying ctx's values that is only
+// cancelled once drain has passed after ctx is, so that a resolution
+// started before the controller's context is cancelled on shutdown can
+// finish, and its result be written, rather than being cut off.
+func drainContext(ctx context.Context, drain time.Duration) (context.Context, context.CancelFunc) {
+	drainCtx, cancel := context.WithCancel(detachedContext{parent: ctx})
+	go func() {
+		select {
+		case <-drainCtx.Done():
+			return
+		case <-ctx.Done():
+		}
+		timer := time.NewTimer(drain)
+		defer timer.Stop()
+		select {
+		case <-drainCtx.Done():
+		case <-timer.C:
+			cancel()
+		}
+	}()
+	return drainCtx, cancel
+}
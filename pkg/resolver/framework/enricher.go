@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+
+	"github.com/tektoncd/resolution/pkg/apis/resolution/v1alpha1"
+)
+
+// AnnotationEnricher is called by the reconciler after every successful
+// resolution to add annotations of an operator's own, e.g. a cost
+// center or environment derived from the request's namespace, to the
+// resolved resource's. Annotations returned by the resolver take
+// precedence over enriched ones with the same key.
+type AnnotationEnricher interface {
+	// EnrichAnnotations receives the request and the resource it
+	// resolved to and returns the annotations to add. Returning an
+	// error fails the request.
+	EnrichAnnotations(context.Context, *v1alpha1.ResolutionRequest, ResolvedResource) (map[string]string, error)
+}
+
+// NoopAnnotationEnricher is the default AnnotationEnricher, which adds
+// no annotations.
+type NoopAnnotationEnricher struct{}
+
+var _ AnnotationEnricher = NoopAnnotationEnricher{}
+
+// EnrichAnnotations returns no annotations.
+func (NoopAnnotationEnricher) EnrichAnnotations(context.Context, *v1alpha1.ResolutionRequest, ResolvedResource) (map[string]string, error) {
+	return nil, nil
+}
+
+// enrichedAnnotations merges the annotations of resource over those
+// returned by enricher.
+func enrichedAnnotations(ctx context.Context, enricher AnnotationEnricher, rr *v1alpha1.ResolutionRequest, resource ResolvedResource) (map[string]string, error) {
+	annotations := map[string]string{}
+	if enricher != nil {
+		extra, err := enricher.EnrichAnnotations(ctx, rr, resource)
+		if err != nil {
+			return nil, err
+		}
+		for key, val := range extra {
+			annotations[key] = val
+		}
+	}
+	for key, val := range resource.Annotations() {
+		annotations[key] = val
+	}
+	return annotations, nil
+}
@@ -80,7 +80,8 @@ type ConfigWatcher interface {
 // duration of any single request to this resolver.
 //
 // The core ResolutionRequest reconciler's global timeout overrides any
-// resolver-specific timeout.
+// resolver-specific timeout. A request's resolution.tekton.dev/timeout
+// annotation overrides both, up to common.MaximumRequestTimeout.
 type TimedResolution interface {
 	// GetResolutionTimeout receives the current request's context
 	// object, which includes any request-scoped data like
@@ -89,9 +90,39 @@ type TimedResolution interface {
 	GetResolutionTimeout(context.Context, time.Duration) time.Duration
 }
 
+// BatchResolver is an optional interface that a resolver can implement
+// to resolve many requests in a single call, sharing any expensive
+// setup (auth, connections, clones) between them. Use
+// framework.ResolveBatch to resolve a batch with any Resolver.
+type BatchResolver interface {
+	// ResolveBatch receives the parameters of several requests and
+	// returns the resolved resources and errors in the same order,
+	// so that for each index exactly one of the resource or error
+	// is non-nil.
+	ResolveBatch(context.Context, []map[string]string) ([]ResolvedResource, []error)
+}
+
 // ResolvedResource returns the data and annotations of a successful
 // resource fetch.
 type ResolvedResource interface {
 	Data() []byte
 	Annotations() map[string]string
 }
+
+// ResolvedResourceWithWarnings is an optional interface that a
+// ResolvedResource can implement to report non-fatal issues, such as a
+// skipped or degraded step, encountered while resolving it. The
+// reconciler records them in the request's status.warnings.
+type ResolvedResourceWithWarnings interface {
+	ResolvedResource
+	Warnings() []string
+}
+
+// Warnings returns the warnings reported by resource, or nil if it
+// doesn't implement ResolvedResourceWithWarnings.
+func Warnings(resource ResolvedResource) []string {
+	if w, ok := resource.(ResolvedResourceWithWarnings); ok {
+		return w.Warnings()
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tektoncd/resolution/pkg/apis/config"
+)
+
+// concurrencyRequeueDelay is how long a request that couldn't start
+// because of config.ConfigFieldMaxConcurrentResolutions waits before
+// it's reconciled again.
+const concurrencyRequeueDelay = time.Second
+
+// resolutionLimiter bounds the number of resolutions in flight. It
+// counts every resolution, capped or not, and checks the count against
+// the cap in effect when a resolution starts, so changes to the cap
+// apply to the resolutions already in flight.
+type resolutionLimiter struct {
+	mu       sync.Mutex
+	inFlight int64
+}
+
+// globalResolutionLimiter is shared by the reconcilers of every resolver
+// in the process.
+var globalResolutionLimiter = &resolutionLimiter{}
+
+// tryAcquire reserves a slot for a resolution without blocking, given
+// the currently configured cap. It returns false if every slot is in
+// use, otherwise it returns a func that releases the slot. A limit of
+// 0 or less means no cap.
+func (l *resolutionLimiter) tryAcquire(limit int64) (func(), bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limit > 0 && l.inFlight >= limit {
+		return nil, false
+	}
+	l.inFlight++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			l.inFlight--
+			l.mu.Unlock()
+		})
+	}, true
+}
+
+// maxConcurrentResolutions returns the cap on in-flight resolutions
+// from the shared controller config in ctx, or 0 if none is configured.
+func maxConcurrentResolutions(ctx context.Context) int64 {
+	return config.ControllerFromContext(ctx).MaxConcurrentResolutions
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"os"
+
+	"github.com/tektoncd/resolution/pkg/common"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"knative.dev/pkg/metrics"
+)
+
+// replicaEnvKey is the environment variable holding the name of the pod
+// the resolver is running in, set with the downward API.
+const replicaEnvKey = "POD_NAME"
+
+const (
+	outcomeSucceeded = "succeeded"
+	outcomeFailed    = "failed"
+)
+
+var (
+	replicaTagKey      = tag.MustNewKey("replica")
+	resolverTypeTagKey = tag.MustNewKey("resolver_type")
+	outcomeTagKey      = tag.MustNewKey("outcome")
+
+	resolutionCountMeasure = stats.Int64(
+		"resolver_resolution_count",
+		"Number of resolution requests completed by a resolver replica",
+		stats.UnitDimensionless,
+	)
+)
+
+func init() {
+	if err := metrics.RegisterResourceView(&view.View{
+		Description: resolutionCountMeasure.Description(),
+		Measure:     resolutionCountMeasure,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{replicaTagKey, resolverTypeTagKey, outcomeTagKey},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// replicaIdentity returns the name of the replica resolving requests,
+// which is its pod's name when POD_NAME is set and otherwise its
+// hostname, which is the pod name by default in Kubernetes.
+func replicaIdentity() string {
+	if name := os.Getenv(replicaEnvKey); name != "" {
+		return name
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		return hostname
+	}
+	return "unknown"
+}
+
+// recordResolution records that a request was resolved by this replica
+// with the given outcome.
+func recordResolution(ctx context.Context, resolver Resolver, outcome string) {
+	ctx, err := tag.New(ctx,
+		tag.Upsert(replicaTagKey, replicaIdentity()),
+		tag.Upsert(resolverTypeTagKey, resolver.GetSelector(ctx)[common.LabelKeyResolverType]),
+		tag.Upsert(outcomeTagKey, outcome),
+	)
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, resolutionCountMeasure.M(1))
+}
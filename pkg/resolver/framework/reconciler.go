@@ -18,12 +18,12 @@ package framework
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/tektoncd/resolution/pkg/apis/config"
 	"github.com/tektoncd/resolution/pkg/apis/resolution/v1alpha1"
 	rrclient "github.com/tektoncd/resolution/pkg/client/clientset/versioned"
 	rrv1alpha1 "github.com/tektoncd/resolution/pkg/client/listers/resolution/v1alpha1"
@@ -49,12 +49,32 @@ type Reconciler struct {
 	// and can be overridden for tests.
 	Clock clock.PassiveClock
 
+	// AnnotationEnricher adds annotations of an operator's own to
+	// resolved resources and can be overridden with a
+	// ReconcilerModifier. Defaults to NoopAnnotationEnricher.
+	AnnotationEnricher AnnotationEnricher
+
+	// DrainTimeout bounds how long resolutions in flight when the
+	// controller shuts down are given to finish before they're
+	// cancelled, and can be overridden with a ReconcilerModifier.
+	// Defaults to 30 seconds.
+	DrainTimeout time.Duration
+
+	// AuditSink receives a record of every request resolved or
+	// failed when the resolver's config enables ConfigKeyAuditLog,
+	// and can be overridden with a ReconcilerModifier. Defaults to
+	// a JSONAuditSink writing to stdout.
+	AuditSink AuditSink
+
 	resolver                   Resolver
 	kubeClientSet              kubernetes.Interface
 	resolutionRequestLister    rrv1alpha1.ResolutionRequestLister
 	resolutionRequestClientSet rrclient.Interface
 
-	configStore *ConfigStore
+	configStore           *ConfigStore
+	controllerConfigStore *config.ControllerStore
+
+	inFlight inFlightResolutions
 }
 
 var _ reconciler.LeaderAware = &Reconciler{}
@@ -64,7 +84,8 @@ var _ reconciler.LeaderAware = &Reconciler{}
 
 // defaultMaximumResolutionDuration is the max time that a call to
 // Resolve() may take. It can be overridden by a resolver implementing
-// the framework.TimedResolution interface.
+// the framework.TimedResolution interface, or for a single request by
+// its resolution.tekton.dev/timeout annotation.
 const defaultMaximumResolutionDuration = time.Minute
 
 // Reconcile receives the string key of a ResolutionRequest object, looks
@@ -93,30 +114,101 @@ func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
 	// the namespace that the request originates from and the
 	// configuration from the configmap this resolver is watching.
 	ctx = resolutioncommon.InjectRequestNamespace(ctx, namespace)
+	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("replica", replicaIdentity()))
 	if r.configStore != nil {
 		ctx = r.configStore.ToContext(ctx)
 	}
+	if r.controllerConfigStore != nil {
+		ctx = r.controllerConfigStore.ToContext(ctx)
+	}
 
 	return r.resolve(ctx, key, rr)
 }
 
 func (r *Reconciler) resolve(ctx context.Context, key string, rr *v1alpha1.ResolutionRequest) error {
-	errChan := make(chan error)
-	resourceChan := make(chan ResolvedResource)
+	// No new resolutions are started once the controller is shutting
+	// down, but those already in flight are given DrainTimeout to
+	// finish and record their result.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	drainTimeout := r.DrainTimeout
+	if drainTimeout <= 0 {
+		drainTimeout = defaultDrainTimeout
+	}
+	ctx, cancelDrain := drainContext(ctx, drainTimeout)
+	defer cancelDrain()
+
+	// The channels are buffered so that the resolving goroutine can
+	// exit, releasing its concurrency slot, after a timeout.
+	errChan := make(chan error, 1)
+	resourceChan := make(chan ResolvedResource, 1)
 
 	timeoutDuration := defaultMaximumResolutionDuration
-	if timed, ok := r.resolver.(TimedResolution); ok {
+	if timed, ok := r.resolver.(TimedResolution); hasCapability(ctx, r.resolver, CapabilityTimedResolution, ok) {
 		timeoutDuration = timed.GetResolutionTimeout(ctx, defaultMaximumResolutionDuration)
 	}
+	timeoutDuration, err := resolutioncommon.RequestTimeout(rr.Annotations, timeoutDuration)
+	if err != nil {
+		return r.OnError(ctx, rr, &resolutioncommon.ErrorInvalidRequest{
+			ResolutionRequestKey: key,
+			Message:              err.Error(),
+		})
+	}
+	if rr.Annotations[resolutioncommon.AnnotationKeyTimeout] != "" {
+		ctx = resolutioncommon.InjectRequestTimeout(ctx, timeoutDuration)
+	}
+
+	maxDisk, err := resolutioncommon.RequestMaxDiskBytes(rr.Annotations)
+	if err != nil {
+		return r.OnError(ctx, rr, &resolutioncommon.ErrorInvalidRequest{
+			ResolutionRequestKey: key,
+			Message:              err.Error(),
+		})
+	}
+	if maxDisk > 0 {
+		ctx = resolutioncommon.InjectRequestMaxDisk(ctx, maxDisk)
+	}
+
+	httpTimeout, err := resolutioncommon.RequestHTTPTimeoutDuration(rr.Annotations)
+	if err != nil {
+		return r.OnError(ctx, rr, &resolutioncommon.ErrorInvalidRequest{
+			ResolutionRequestKey: key,
+			Message:              err.Error(),
+		})
+	}
+	if httpTimeout > 0 {
+		ctx = resolutioncommon.InjectRequestHTTPTimeout(ctx, httpTimeout)
+	}
+
+	params, err := paramsWithDefaults(ctx, rr)
+	if err != nil {
+		return r.OnError(ctx, rr, &resolutioncommon.ErrorInvalidRequest{
+			ResolutionRequestKey: key,
+			Message:              err.Error(),
+		})
+	}
+	ctx = withAuditParams(ctx, params)
 
 	// A new context is created for resolution so that timeouts can
 	// be enforced without affecting other uses of ctx (e.g. sending
 	// Updates to ResolutionRequest objects).
 	resolutionCtx, cancelFn := context.WithTimeout(ctx, timeoutDuration)
 	defer cancelFn()
+	// Deleting the request cancels its resolution.
+	flight, untrack := r.inFlight.track(rr.UID, cancelFn)
+	defer untrack()
+
+	// Requests that can't start because too many resolutions are
+	// already in flight are requeued rather than holding up a worker.
+	release, ok := globalResolutionLimiter.tryAcquire(maxConcurrentResolutions(ctx))
+	if !ok {
+		return controller.NewRequeueAfter(concurrencyRequeueDelay)
+	}
 
 	go func() {
-		validationError := r.resolver.ValidateParams(resolutionCtx, rr.Spec.Parameters)
+		defer release()
+		validationError := r.resolver.ValidateParams(resolutionCtx, params)
 		if validationError != nil {
 			errChan <- &resolutioncommon.ErrorInvalidRequest{
 				ResolutionRequestKey: key,
@@ -124,7 +216,7 @@ func (r *Reconciler) resolve(ctx context.Context, key string, rr *v1alpha1.Resol
 			}
 			return
 		}
-		resource, resolveErr := r.resolver.Resolve(resolutionCtx, rr.Spec.Parameters)
+		resource, resolveErr := r.resolver.Resolve(resolutionCtx, params)
 		if resolveErr != nil {
 			errChan <- &resolutioncommon.ErrorGettingResource{
 				ResolverName: r.resolver.GetName(resolutionCtx),
@@ -138,20 +230,41 @@ func (r *Reconciler) resolve(ctx context.Context, key string, rr *v1alpha1.Resol
 
 	select {
 	case err := <-errChan:
+		if flight.wasDeleted() {
+			return r.onDeleted(ctx, key)
+		}
 		if err != nil {
+			recordResolution(ctx, r.resolver, outcomeFailed)
 			return r.OnError(ctx, rr, err)
 		}
 	case <-resolutionCtx.Done():
+		if flight.wasDeleted() {
+			return r.onDeleted(ctx, key)
+		}
 		if err := resolutionCtx.Err(); err != nil {
+			recordResolution(ctx, r.resolver, outcomeFailed)
 			return r.OnError(ctx, rr, err)
 		}
 	case resource := <-resourceChan:
-		return r.writeResolvedData(ctx, rr, resource)
+		resource, err := r.checkMovedTags(ctx, rr, resource)
+		if err != nil {
+			recordResolution(ctx, r.resolver, outcomeFailed)
+			return r.OnError(ctx, rr, err)
+		}
+		recordResolution(ctx, r.resolver, outcomeSucceeded)
+		return r.writeResolvedData(ctx, rr, params, resource)
 	}
 
 	return errors.New("unknown error")
 }
 
+// onDeleted handles a resolution that was cancelled because its request
+// was deleted, leaving no request to record the result in.
+func (r *Reconciler) onDeleted(ctx context.Context, key string) error {
+	logging.FromContext(ctx).Infof("Cancelled resolution of deleted request %s", key)
+	return nil
+}
+
 // OnError is used to handle any situation where a ResolutionRequest has
 // reached a terminal situation that cannot be recovered from.
 func (r *Reconciler) OnError(ctx context.Context, rr *v1alpha1.ResolutionRequest, err error) error {
@@ -159,15 +272,17 @@ func (r *Reconciler) OnError(ctx context.Context, rr *v1alpha1.ResolutionRequest
 		return controller.NewPermanentError(err)
 	}
 	if err != nil {
+		r.audit(ctx, rr, nil, err)
 		_ = r.MarkFailed(ctx, rr, err)
 		return controller.NewPermanentError(err)
 	}
 	return nil
 }
 
-// MarkFailed updates a ResolutionRequest as having failed. It returns
-// errors that occur during the update process or nil if the update
-// appeared to succeed.
+// MarkFailed updates a ResolutionRequest as having failed, recording the
+// category and detail of its failure in its status annotations. It
+// returns errors that occur during the update process or nil if the
+// update appeared to succeed.
 func (r *Reconciler) MarkFailed(ctx context.Context, rr *v1alpha1.ResolutionRequest, resolutionErr error) error {
 	key := fmt.Sprintf("%s/%s", rr.Namespace, rr.Name)
 	reason, resolutionErr := resolutioncommon.ReasonError(resolutionErr)
@@ -180,6 +295,11 @@ func (r *Reconciler) MarkFailed(ctx context.Context, rr *v1alpha1.ResolutionRequ
 		return nil
 	}
 	latestGeneration.Status.MarkFailed(reason, resolutionErr.Error())
+	if latestGeneration.Status.Annotations == nil {
+		latestGeneration.Status.Annotations = map[string]string{}
+	}
+	latestGeneration.Status.Annotations[AnnotationKeyFailureCategory] = failureCategory(resolutionErr)
+	latestGeneration.Status.Annotations[AnnotationKeyFailureDetail] = resolutionErr.Error()
 	_, err = r.resolutionRequestClientSet.ResolutionV1alpha1().ResolutionRequests(rr.Namespace).UpdateStatus(ctx, latestGeneration, metav1.UpdateOptions{})
 	if err != nil {
 		logging.FromContext(ctx).Warnf("error marking resolutionrequest %q as failed: %v", key, err)
@@ -189,19 +309,62 @@ func (r *Reconciler) MarkFailed(ctx context.Context, rr *v1alpha1.ResolutionRequ
 }
 
 // statusDataPatch is the json structure that will be PATCHed into
-// a ResolutionRequest with its data and annotations once successfully
-// resolved.
+// a ResolutionRequest with its data, annotations and any warnings once
+// successfully resolved.
 type statusDataPatch struct {
 	Annotations map[string]string `json:"annotations"`
 	Data        string            `json:"data"`
+	Warnings    []string          `json:"warnings,omitempty"`
 }
 
-func (r *Reconciler) writeResolvedData(ctx context.Context, rr *v1alpha1.ResolutionRequest, resource ResolvedResource) error {
-	encodedData := base64.StdEncoding.Strict().EncodeToString(resource.Data())
+// AnnotationKeyParams is the annotation recording, as a JSON object, the
+// params that a request was resolved with. The reconciler records the
+// request's params after any defaulting by webhooks and DefaultsConfigKey
+// config; resolvers may set
+// this annotation themselves to record the params after their own
+// defaulting and normalization.
+const AnnotationKeyParams = "resolution.tekton.dev/params"
+
+func (r *Reconciler) writeResolvedData(ctx context.Context, rr *v1alpha1.ResolutionRequest, params map[string]string, resource ResolvedResource) error {
+	encoding, err := dataEncoding(ctx)
+	if err != nil {
+		return r.OnError(ctx, rr, &resolutioncommon.ErrorUpdatingRequest{
+			ResolutionRequestKey: fmt.Sprintf("%s/%s", rr.Namespace, rr.Name),
+			Original:             err,
+		})
+	}
+	encodedData, err := resolutioncommon.EncodeData(resource.Data(), encoding)
+	if err != nil {
+		return r.OnError(ctx, rr, &resolutioncommon.ErrorUpdatingRequest{
+			ResolutionRequestKey: fmt.Sprintf("%s/%s", rr.Namespace, rr.Name),
+			Original:             fmt.Errorf("error encoding data: %w", err),
+		})
+	}
+	annotations, err := enrichedAnnotations(ctx, r.AnnotationEnricher, rr, resource)
+	if err != nil {
+		return r.OnError(ctx, rr, &resolutioncommon.ErrorUpdatingRequest{
+			ResolutionRequestKey: fmt.Sprintf("%s/%s", rr.Namespace, rr.Name),
+			Original:             fmt.Errorf("error enriching annotations: %w", err),
+		})
+	}
+	if encoding != resolutioncommon.DataEncodingBase64 {
+		annotations[resolutioncommon.AnnotationKeyDataEncoding] = encoding
+	}
+	if _, ok := annotations[AnnotationKeyParams]; !ok {
+		encodedParams, err := json.Marshal(params)
+		if err != nil {
+			return r.OnError(ctx, rr, &resolutioncommon.ErrorUpdatingRequest{
+				ResolutionRequestKey: fmt.Sprintf("%s/%s", rr.Namespace, rr.Name),
+				Original:             fmt.Errorf("error serializing resolution params: %w", err),
+			})
+		}
+		annotations[AnnotationKeyParams] = string(encodedParams)
+	}
 	patchBytes, err := json.Marshal(map[string]statusDataPatch{
 		"status": {
 			Data:        encodedData,
-			Annotations: resource.Annotations(),
+			Annotations: annotations,
+			Warnings:    Warnings(resource),
 		},
 	})
 	if err != nil {
@@ -218,5 +381,6 @@ func (r *Reconciler) writeResolvedData(ctx context.Context, rr *v1alpha1.Resolut
 		})
 	}
 
+	r.audit(ctx, rr, resource, nil)
 	return nil
 }
@@ -0,0 +1,1193 @@
+package framework
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tektoncd/resolution/pkg/apis/config"
+	"github.com/tektoncd/resolution/pkg/apis/resolution/v1alpha1"
+	rrfake "github.com/tektoncd/resolution/pkg/client/clientset/versioned/fake"
+	rrv1alpha1 "github.com/tektoncd/resolution/pkg/client/listers/resolution/v1alpha1"
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"github.com/tektoncd/resolution/test/helpers"
+	"go.opencensus.io/stats/view"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/metrics"
+)
+
+// blockingResolver is a Resolver whose Resolve calls block until
+// unblocked, recording how many are in flight at once.
+type blockingResolver struct {
+	fakeResolver
+
+	unblock chan struct{}
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	started     chan struct{}
+}
+
+func (b *blockingResolver) Resolve(ctx context.Context, params map[string]string) (ResolvedResource, error) {
+	b.mu.Lock()
+	b.inFlight++
+	if b.inFlight > b.maxInFlight {
+		b.maxInFlight = b.inFlight
+	}
+	b.mu.Unlock()
+	b.started <- struct{}{}
+
+	<-b.unblock
+
+	b.mu.Lock()
+	b.inFlight--
+	b.mu.Unlock()
+	return &fakeResource{data: []byte("resolved")}, nil
+}
+
+func TestReconcilerCapsConcurrentResolutions(t *testing.T) {
+	const maxConcurrent = 2
+	requests := []*v1alpha1.ResolutionRequest{}
+	for i := 0; i < maxConcurrent+1; i++ {
+		requests = append(requests, helpers.NewResolutionRequest("blocking", fmt.Sprintf("rr-%d", i), "foo", nil))
+	}
+	objs := []runtime.Object{}
+	for _, rr := range requests {
+		objs = append(objs, rr)
+	}
+	resolver := &blockingResolver{
+		fakeResolver: fakeResolver{name: "blocking"},
+		unblock:      make(chan struct{}),
+		started:      make(chan struct{}, len(requests)),
+	}
+	r := &Reconciler{
+		resolver:                   resolver,
+		resolutionRequestClientSet: rrfake.NewSimpleClientset(objs...),
+	}
+	ctx := config.ControllerToContext(context.Background(), &config.Controller{
+		MaxConcurrentResolutions: maxConcurrent,
+	})
+
+	errs := make(chan error, maxConcurrent)
+	for _, rr := range requests[:maxConcurrent] {
+		rr := rr
+		go func() {
+			errs <- r.resolve(ctx, rr.Namespace+"/"+rr.Name, rr)
+		}()
+	}
+	for i := 0; i < maxConcurrent; i++ {
+		select {
+		case <-resolver.started:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timed out waiting for resolutions to start")
+		}
+	}
+
+	// Every slot is taken so the next request is requeued instead of
+	// waiting for one.
+	extra := requests[maxConcurrent]
+	err := r.resolve(ctx, extra.Namespace+"/"+extra.Name, extra)
+	if ok, _ := controller.IsRequeueKey(err); !ok {
+		t.Fatalf("expected request over the cap to be requeued, received %v", err)
+	}
+
+	close(resolver.unblock)
+	for i := 0; i < maxConcurrent; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := r.resolve(ctx, extra.Namespace+"/"+extra.Name, extra); err != nil {
+		t.Fatalf("expected requeued request to resolve once slots were free, received %v", err)
+	}
+	if resolver.maxInFlight != maxConcurrent {
+		t.Errorf("expected at most %d resolutions in flight, received %d", maxConcurrent, resolver.maxInFlight)
+	}
+}
+
+func TestResolutionLimiterKeepsCountAcrossCapChanges(t *testing.T) {
+	l := &resolutionLimiter{}
+	releases := []func(){}
+	for i := 0; i < 2; i++ {
+		release, ok := l.tryAcquire(2)
+		if !ok {
+			t.Fatalf("expected slot %d to be acquired", i)
+		}
+		releases = append(releases, release)
+	}
+	if _, ok := l.tryAcquire(2); ok {
+		t.Fatalf("expected no slot over the cap")
+	}
+	// Raising the cap only frees the slots it adds.
+	release, ok := l.tryAcquire(3)
+	if !ok {
+		t.Fatalf("expected slot under the raised cap")
+	}
+	releases = append(releases, release)
+	if _, ok := l.tryAcquire(3); ok {
+		t.Fatalf("expected no slot over the raised cap")
+	}
+	// Lowering the cap counts the resolutions still in flight.
+	releases[0]()
+	if _, ok := l.tryAcquire(2); ok {
+		t.Fatalf("expected no slot while in flight resolutions exceed the lowered cap")
+	}
+	releases[1]()
+	releases[1]()
+	if _, ok := l.tryAcquire(1); ok {
+		t.Fatalf("expected a release called twice to free only one slot")
+	}
+	releases[2]()
+	if _, ok := l.tryAcquire(1); !ok {
+		t.Fatalf("expected a slot once in flight resolutions fell under the cap")
+	}
+}
+
+func TestReconcilerRecordsParams(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		resource *fakeResource
+		expected string
+	}{{
+		name:     "request params",
+		resource: &fakeResource{data: []byte("resolved")},
+		expected: `{"branch":"main","url":"https://example.com/repo.git"}`,
+	}, {
+		name: "params recorded by resolver",
+		resource: &fakeResource{data: []byte("resolved"), annotations: map[string]string{
+			AnnotationKeyParams: `{"branch":"main","path":"task.yaml","url":"https://example.com/repo.git"}`,
+		}},
+		expected: `{"branch":"main","path":"task.yaml","url":"https://example.com/repo.git"}`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := helpers.NewResolutionRequest("fake", "rr", "foo", map[string]string{
+				"url":    "https://example.com/repo.git",
+				"branch": "main",
+			})
+			client := rrfake.NewSimpleClientset(rr)
+			r := &Reconciler{
+				resolver:                   &fakeResolver{name: "fake", resource: tc.resource},
+				resolutionRequestClientSet: client,
+			}
+			if err := r.resolve(context.Background(), "foo/rr", rr); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			updated, err := client.ResolutionV1alpha1().ResolutionRequests("foo").Get(context.Background(), "rr", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting updated request: %v", err)
+			}
+			if params := updated.Status.Annotations[AnnotationKeyParams]; params != tc.expected {
+				t.Errorf("expected params annotation %s, received %s", tc.expected, params)
+			}
+		})
+	}
+}
+
+// paramsResolver is a fakeResolver recording the params it's asked to
+// resolve.
+type paramsResolver struct {
+	fakeResolver
+	params map[string]string
+}
+
+func (p *paramsResolver) Resolve(_ context.Context, params map[string]string) (ResolvedResource, error) {
+	p.params = params
+	return &fakeResource{data: []byte("resolved")}, nil
+}
+
+func TestReconcilerAppliesDefaultParams(t *testing.T) {
+	ctx := InjectResolverConfigToContext(context.Background(), map[string]string{
+		DefaultsConfigKey("fake"):  "branch: main\npathInRepo: task.yaml\n",
+		DefaultsConfigKey("other"): "branch: other",
+	})
+	for _, tc := range []struct {
+		name     string
+		params   map[string]string
+		expected map[string]string
+	}{{
+		name:   "default branch applied",
+		params: map[string]string{"url": "https://example.com/repo.git"},
+		expected: map[string]string{
+			"url":        "https://example.com/repo.git",
+			"branch":     "main",
+			"pathInRepo": "task.yaml",
+		},
+	}, {
+		name:   "request branch wins",
+		params: map[string]string{"url": "https://example.com/repo.git", "branch": "release"},
+		expected: map[string]string{
+			"url":        "https://example.com/repo.git",
+			"branch":     "release",
+			"pathInRepo": "task.yaml",
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := helpers.NewResolutionRequest("fake", "rr", "foo", tc.params)
+			client := rrfake.NewSimpleClientset(rr)
+			resolver := &paramsResolver{fakeResolver: fakeResolver{name: "fake"}}
+			r := &Reconciler{
+				resolver:                   resolver,
+				resolutionRequestClientSet: client,
+			}
+			if err := r.resolve(ctx, "foo/rr", rr); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fmt.Sprint(resolver.params) != fmt.Sprint(tc.expected) {
+				t.Errorf("expected params %v, received %v", tc.expected, resolver.params)
+			}
+			if fmt.Sprint(rr.Spec.Parameters) == fmt.Sprint(tc.expected) {
+				t.Errorf("expected the request's own params to be left unchanged")
+			}
+			updated, err := client.ResolutionV1alpha1().ResolutionRequests("foo").Get(ctx, "rr", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting updated request: %v", err)
+			}
+			if !strings.Contains(updated.Status.Annotations[AnnotationKeyParams], `"pathInRepo":"task.yaml"`) {
+				t.Errorf("expected recorded params to include the defaults, received %s", updated.Status.Annotations[AnnotationKeyParams])
+			}
+		})
+	}
+
+	t.Run("invalid defaults", func(t *testing.T) {
+		ctx := InjectResolverConfigToContext(context.Background(), map[string]string{
+			DefaultsConfigKey("fake"): "[branch, main]",
+		})
+		rr := helpers.NewResolutionRequest("fake", "rr", "foo", map[string]string{})
+		r := &Reconciler{
+			resolver:                   &paramsResolver{fakeResolver: fakeResolver{name: "fake"}},
+			resolutionRequestClientSet: rrfake.NewSimpleClientset(rr),
+		}
+		err := r.resolve(ctx, "foo/rr", rr)
+		if err == nil || !strings.Contains(err.Error(), DefaultsConfigKey("fake")) {
+			t.Errorf("expected an error naming the invalid defaults, received %v", err)
+		}
+	})
+}
+
+// warningResource is a fakeResource that reports warnings.
+type warningResource struct {
+	fakeResource
+	warnings []string
+}
+
+func (w *warningResource) Warnings() []string { return w.warnings }
+
+func TestReconcilerRecordsWarnings(t *testing.T) {
+	warnings := []string{"shallow clone fell back to a full clone", "skipped invalid file 'broken.yaml'"}
+	for _, tc := range []struct {
+		name     string
+		resolver Resolver
+		expected []string
+	}{{
+		name:     "no warnings",
+		resolver: &fakeResolver{name: "fake", resource: &fakeResource{data: []byte("resolved")}},
+	}, {
+		name: "resolver warnings",
+		resolver: &fakeResolver{name: "fake", resource: &warningResource{
+			fakeResource: fakeResource{data: []byte("resolved")},
+			warnings:     warnings,
+		}},
+		expected: warnings,
+	}, {
+		name: "composite layer warnings",
+		resolver: NewCompositeResolver("composite", nil, &fakeResolver{name: "layer", resource: &warningResource{
+			fakeResource: fakeResource{data: []byte("resolved")},
+			warnings:     warnings,
+		}}),
+		expected: warnings,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := helpers.NewResolutionRequest("fake", "rr", "foo", map[string]string{})
+			client := rrfake.NewSimpleClientset(rr)
+			r := &Reconciler{
+				resolver:                   tc.resolver,
+				resolutionRequestClientSet: client,
+			}
+			if err := r.resolve(context.Background(), "foo/rr", rr); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			updated, err := client.ResolutionV1alpha1().ResolutionRequests("foo").Get(context.Background(), "rr", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting updated request: %v", err)
+			}
+			if fmt.Sprint(updated.Status.Warnings) != fmt.Sprint(tc.expected) {
+				t.Errorf("expected warnings %q, received %q", tc.expected, updated.Status.Warnings)
+			}
+		})
+	}
+}
+
+// timedResolver is a TimedResolution that records whether its timeout
+// was asked for.
+type timedResolver struct {
+	fakeResolver
+	timeoutRequested bool
+}
+
+func (t *timedResolver) GetResolutionTimeout(context.Context, time.Duration) time.Duration {
+	t.timeoutRequested = true
+	return time.Minute
+}
+
+// advertisingTimedResolver is a timedResolver that advertises a fixed
+// set of capabilities.
+type advertisingTimedResolver struct {
+	timedResolver
+	capabilities []string
+}
+
+func (a *advertisingTimedResolver) Capabilities(context.Context) []string {
+	return a.capabilities
+}
+
+func TestReconcilerChecksCapabilities(t *testing.T) {
+	for _, tc := range []struct {
+		name                  string
+		resolver              Resolver
+		expectTimeoutIsCalled bool
+	}{{
+		name:                  "capabilities not advertised",
+		resolver:              &timedResolver{},
+		expectTimeoutIsCalled: true,
+	}, {
+		name: "capability advertised",
+		resolver: &advertisingTimedResolver{
+			capabilities: []string{CapabilityTimedResolution},
+		},
+		expectTimeoutIsCalled: true,
+	}, {
+		name: "capability absent",
+		resolver: &advertisingTimedResolver{
+			capabilities: []string{CapabilityBatch},
+		},
+		expectTimeoutIsCalled: false,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			var timed *timedResolver
+			switch r := tc.resolver.(type) {
+			case *timedResolver:
+				timed = r
+			case *advertisingTimedResolver:
+				timed = &r.timedResolver
+			}
+			timed.name = "fake"
+			timed.resource = &fakeResource{data: []byte("resolved")}
+
+			rr := helpers.NewResolutionRequest("fake", "rr", "foo", nil)
+			r := &Reconciler{
+				resolver:                   tc.resolver,
+				resolutionRequestClientSet: rrfake.NewSimpleClientset(rr),
+			}
+			if err := r.resolve(context.Background(), "foo/rr", rr); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if timed.timeoutRequested != tc.expectTimeoutIsCalled {
+				t.Errorf("expected GetResolutionTimeout called to be %t, received %t", tc.expectTimeoutIsCalled, timed.timeoutRequested)
+			}
+			if timed.resolved != 1 {
+				t.Errorf("expected request to be resolved once, resolved %d times", timed.resolved)
+			}
+		})
+	}
+}
+
+func TestReconcilerRecordsReplicaIdentity(t *testing.T) {
+	metrics.InitForTesting()
+	t.Setenv(replicaEnvKey, "resolver-replica-1")
+
+	rr := helpers.NewResolutionRequest("fake", "rr", "foo", nil)
+	r := &Reconciler{
+		resolver:                   &fakeResolver{name: "fake", resource: &fakeResource{data: []byte("resolved")}},
+		resolutionRequestClientSet: rrfake.NewSimpleClientset(rr),
+	}
+	if err := r.resolve(context.Background(), "foo/rr", rr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows, err := view.RetrieveData(resolutionCountMeasure.Name())
+	if err != nil {
+		t.Fatalf("error retrieving metric: %v", err)
+	}
+	for _, row := range rows {
+		tags := map[string]string{}
+		for _, tg := range row.Tags {
+			tags[tg.Key.Name()] = tg.Value
+		}
+		if tags[replicaTagKey.Name()] == "resolver-replica-1" && tags[resolverTypeTagKey.Name()] == "fake" && tags[outcomeTagKey.Name()] == outcomeSucceeded {
+			return
+		}
+	}
+	t.Errorf("expected a resolution recorded for replica resolver-replica-1, received rows %v", rows)
+}
+
+// namespaceEnricher stamps the environment that a request's namespace
+// belongs to onto resolved resources.
+type namespaceEnricher struct {
+	environments map[string]string
+}
+
+func (n *namespaceEnricher) EnrichAnnotations(_ context.Context, rr *v1alpha1.ResolutionRequest, _ ResolvedResource) (map[string]string, error) {
+	return map[string]string{
+		"example.com/environment": n.environments[rr.Namespace],
+		"example.com/namespace":   rr.Namespace,
+	}, nil
+}
+
+func TestReconcilerEnrichesAnnotations(t *testing.T) {
+	for _, tc := range []struct {
+		name                string
+		resourceAnnotations map[string]string
+		expected            map[string]string
+	}{{
+		name: "namespace-derived annotations",
+		expected: map[string]string{
+			"example.com/environment": "production",
+			"example.com/namespace":   "foo",
+		},
+	}, {
+		name: "resolver annotations take precedence",
+		resourceAnnotations: map[string]string{
+			"example.com/environment": "staging",
+		},
+		expected: map[string]string{
+			"example.com/environment": "staging",
+			"example.com/namespace":   "foo",
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := helpers.NewResolutionRequest("fake", "rr", "foo", nil)
+			client := rrfake.NewSimpleClientset(rr)
+			r := &Reconciler{
+				AnnotationEnricher: &namespaceEnricher{
+					environments: map[string]string{"foo": "production"},
+				},
+				resolver: &fakeResolver{
+					name:     "fake",
+					resource: &fakeResource{data: []byte("resolved"), annotations: tc.resourceAnnotations},
+				},
+				resolutionRequestClientSet: client,
+			}
+			if err := r.resolve(context.Background(), "foo/rr", rr); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			updated, err := client.ResolutionV1alpha1().ResolutionRequests("foo").Get(context.Background(), "rr", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting updated request: %v", err)
+			}
+			for key, val := range tc.expected {
+				if received := updated.Status.Annotations[key]; received != val {
+					t.Errorf("expected annotation %s to be %q, received %q", key, val, received)
+				}
+			}
+		})
+	}
+}
+
+// deadlineResolver records how long it was given to resolve a request
+// and the timeout the request asked for.
+type deadlineResolver struct {
+	fakeResolver
+	timeout  time.Duration
+	override time.Duration
+}
+
+func (d *deadlineResolver) Resolve(ctx context.Context, params map[string]string) (ResolvedResource, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		d.timeout = time.Until(deadline)
+	}
+	d.override = resolutioncommon.RequestTimeoutOverride(ctx)
+	return d.fakeResolver.Resolve(ctx, params)
+}
+
+func TestReconcilerHonorsTimeoutAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		timeout          string
+		expectedTimeout  time.Duration
+		expectedOverride time.Duration
+		expectFail       bool
+	}{{
+		name:            "no override",
+		expectedTimeout: defaultMaximumResolutionDuration,
+	}, {
+		name:             "override within bounds",
+		timeout:          "3m",
+		expectedTimeout:  3 * time.Minute,
+		expectedOverride: 3 * time.Minute,
+	}, {
+		name:             "override past cluster max is clamped",
+		timeout:          "2h",
+		expectedTimeout:  resolutioncommon.MaximumRequestTimeout,
+		expectedOverride: resolutioncommon.MaximumRequestTimeout,
+	}, {
+		name:       "invalid override",
+		timeout:    "-1m",
+		expectFail: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := &deadlineResolver{fakeResolver: fakeResolver{
+				name:     "fake",
+				resource: &fakeResource{data: []byte("resolved")},
+			}}
+			rr := helpers.NewResolutionRequest("fake", "rr", "foo", nil)
+			if tc.timeout != "" {
+				rr.Annotations = map[string]string{resolutioncommon.AnnotationKeyTimeout: tc.timeout}
+			}
+			r := &Reconciler{
+				resolver:                   resolver,
+				resolutionRequestClientSet: rrfake.NewSimpleClientset(rr),
+			}
+			err := r.resolve(context.Background(), "foo/rr", rr)
+			if tc.expectFail {
+				if !controller.IsPermanentError(err) || resolver.resolved != 0 {
+					t.Fatalf("expected request to fail without resolving, received %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resolver.timeout > tc.expectedTimeout || resolver.timeout < tc.expectedTimeout-time.Second {
+				t.Errorf("expected timeout of %s, received %s", tc.expectedTimeout, resolver.timeout)
+			}
+			if resolver.override != tc.expectedOverride {
+				t.Errorf("expected timeout override of %s, received %s", tc.expectedOverride, resolver.override)
+			}
+		})
+	}
+}
+
+// maxDiskResolver records the disk bound it was given to resolve a
+// request within.
+type maxDiskResolver struct {
+	fakeResolver
+	maxDisk int64
+}
+
+func (m *maxDiskResolver) Resolve(ctx context.Context, params map[string]string) (ResolvedResource, error) {
+	m.maxDisk = resolutioncommon.RequestMaxDisk(ctx)
+	return m.fakeResolver.Resolve(ctx, params)
+}
+
+func TestReconcilerHonorsMaxDiskAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		maxDisk         string
+		expectedMaxDisk int64
+		expectFail      bool
+	}{{
+		name: "no bound",
+	}, {
+		name:            "bound",
+		maxDisk:         "100Mi",
+		expectedMaxDisk: 100 << 20,
+	}, {
+		name:       "invalid bound",
+		maxDisk:    "lots",
+		expectFail: true,
+	}, {
+		name:       "negative bound",
+		maxDisk:    "-1Gi",
+		expectFail: true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := &maxDiskResolver{fakeResolver: fakeResolver{
+				name:     "fake",
+				resource: &fakeResource{data: []byte("resolved")},
+			}}
+			rr := helpers.NewResolutionRequest("fake", "rr", "foo", nil)
+			if tc.maxDisk != "" {
+				rr.Annotations = map[string]string{resolutioncommon.AnnotationKeyMaxDisk: tc.maxDisk}
+			}
+			r := &Reconciler{
+				resolver:                   resolver,
+				resolutionRequestClientSet: rrfake.NewSimpleClientset(rr),
+			}
+			err := r.resolve(context.Background(), "foo/rr", rr)
+			if tc.expectFail {
+				if !controller.IsPermanentError(err) || resolver.resolved != 0 {
+					t.Fatalf("expected request to fail without resolving, received %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resolver.maxDisk != tc.expectedMaxDisk {
+				t.Errorf("expected max disk of %d, received %d", tc.expectedMaxDisk, resolver.maxDisk)
+			}
+		})
+	}
+}
+
+// httpTimeoutResolver records the http timeout it was given to resolve a
+// request with.
+type httpTimeoutResolver struct {
+	fakeResolver
+	httpTimeout time.Duration
+}
+
+func (h *httpTimeoutResolver) Resolve(ctx context.Context, params map[string]string) (ResolvedResource, error) {
+	h.httpTimeout = resolutioncommon.RequestHTTPTimeout(ctx)
+	return h.fakeResolver.Resolve(ctx, params)
+}
+
+func TestReconcilerHonorsHTTPTimeoutAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name                string
+		httpTimeout         string
+		expectedHTTPTimeout time.Duration
+		expectFail          bool
+	}{{
+		name: "no timeout",
+	}, {
+		name:                "timeout",
+		httpTimeout:         "2m",
+		expectedHTTPTimeout: 2 * time.Minute,
+	}, {
+		name:        "invalid timeout",
+		httpTimeout: "patiently",
+		expectFail:  true,
+	}, {
+		name:        "negative timeout",
+		httpTimeout: "-1s",
+		expectFail:  true,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			resolver := &httpTimeoutResolver{fakeResolver: fakeResolver{
+				name:     "fake",
+				resource: &fakeResource{data: []byte("resolved")},
+			}}
+			rr := helpers.NewResolutionRequest("fake", "rr", "foo", nil)
+			if tc.httpTimeout != "" {
+				rr.Annotations = map[string]string{resolutioncommon.AnnotationKeyHTTPTimeout: tc.httpTimeout}
+			}
+			r := &Reconciler{
+				resolver:                   resolver,
+				resolutionRequestClientSet: rrfake.NewSimpleClientset(rr),
+			}
+			err := r.resolve(context.Background(), "foo/rr", rr)
+			if tc.expectFail {
+				if !controller.IsPermanentError(err) || resolver.resolved != 0 {
+					t.Fatalf("expected request to fail without resolving, received %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resolver.httpTimeout != tc.expectedHTTPTimeout {
+				t.Errorf("expected http timeout of %s, received %s", tc.expectedHTTPTimeout, resolver.httpTimeout)
+			}
+		})
+	}
+}
+
+func TestReconcilerAnnotatesPermanentFailures(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		validateErr      error
+		resolveErr       error
+		expectedCategory string
+		expectedReason   string
+	}{{
+		name:             "validation failure",
+		validateErr:      errors.New("missing url"),
+		expectedCategory: FailureCategoryInvalidRequest,
+		expectedReason:   resolutioncommon.ReasonResolutionFailed,
+	}, {
+		name:             "auth failure",
+		resolveErr:       fmt.Errorf("clone error: %w", ErrorAuthenticationFailed),
+		expectedCategory: FailureCategoryAuth,
+		expectedReason:   resolutioncommon.ReasonResolutionFailed,
+	}, {
+		name:             "not found",
+		resolveErr:       fmt.Errorf("file %q: %w", "task.yaml", ErrorResourceNotFound),
+		expectedCategory: FailureCategoryNotFound,
+		expectedReason:   resolutioncommon.ReasonResolutionFailed,
+	}, {
+		name:             "other failure",
+		resolveErr:       resolutioncommon.NewError(resolutioncommon.ReasonResolvedContentEmpty, errors.New("file is empty")),
+		expectedCategory: FailureCategoryResolution,
+		expectedReason:   resolutioncommon.ReasonResolvedContentEmpty,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := helpers.NewResolutionRequest("fake", "rr", "foo", nil)
+			client := rrfake.NewSimpleClientset(rr)
+			r := &Reconciler{
+				resolver: &fakeResolver{
+					name:        "fake",
+					validateErr: tc.validateErr,
+					resolveErr:  tc.resolveErr,
+				},
+				resolutionRequestClientSet: client,
+			}
+			if err := r.resolve(context.Background(), "foo/rr", rr); !controller.IsPermanentError(err) {
+				t.Fatalf("expected permanent error, received %v", err)
+			}
+			updated, err := client.ResolutionV1alpha1().ResolutionRequests("foo").Get(context.Background(), "rr", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting updated request: %v", err)
+			}
+			helpers.AssertCondition(t, updated, apis.ConditionSucceeded, corev1.ConditionFalse, tc.expectedReason)
+			annotations := updated.Status.Annotations
+			if category := annotations[AnnotationKeyFailureCategory]; category != tc.expectedCategory {
+				t.Errorf("expected failure category %q, received %q", tc.expectedCategory, category)
+			}
+			cause := tc.validateErr
+			if cause == nil {
+				cause = tc.resolveErr
+			}
+			if detail := annotations[AnnotationKeyFailureDetail]; !strings.Contains(detail, cause.Error()) {
+				t.Errorf("expected failure detail to contain %q, received %q", cause, detail)
+			}
+		})
+	}
+}
+
+func TestReconcilerDrainsInFlightResolutionsOnShutdown(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		drainTimeout time.Duration
+		expectDone   bool
+	}{{
+		name:         "resolution finishing within drain timeout",
+		drainTimeout: 10 * time.Second,
+		expectDone:   true,
+	}, {
+		name:         "resolution outlasting drain timeout",
+		drainTimeout: 50 * time.Millisecond,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := helpers.NewResolutionRequest("blocking", "rr", "foo", nil)
+			client := rrfake.NewSimpleClientset(rr)
+			resolver := &blockingResolver{
+				fakeResolver: fakeResolver{name: "blocking"},
+				unblock:      make(chan struct{}),
+				started:      make(chan struct{}, 1),
+			}
+			defer close(resolver.unblock)
+			r := &Reconciler{
+				resolver:                   resolver,
+				resolutionRequestClientSet: client,
+				DrainTimeout:               tc.drainTimeout,
+			}
+			ctx, shutdown := context.WithCancel(context.Background())
+			errs := make(chan error, 1)
+			go func() {
+				errs <- r.resolve(ctx, "foo/rr", rr)
+			}()
+			select {
+			case <-resolver.started:
+			case <-time.After(10 * time.Second):
+				t.Fatalf("timed out waiting for resolution to start")
+			}
+
+			shutdown()
+			if err := r.resolve(ctx, "foo/rr", rr); err == nil {
+				t.Fatalf("expected no new resolution to start after shutdown")
+			}
+			if tc.expectDone {
+				time.Sleep(100 * time.Millisecond)
+				resolver.unblock <- struct{}{}
+			}
+
+			select {
+			case err := <-errs:
+				if tc.expectDone && err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !tc.expectDone && !errors.Is(err, context.Canceled) {
+					t.Fatalf("expected resolution to be cancelled after the drain timeout, received %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for resolution to finish")
+			}
+			if !tc.expectDone {
+				return
+			}
+			updated, err := client.ResolutionV1alpha1().ResolutionRequests("foo").Get(context.Background(), "rr", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting updated request: %v", err)
+			}
+			if updated.Status.Data == "" {
+				t.Errorf("expected drained resolution to be written to the request")
+			}
+		})
+	}
+}
+
+// cancellableResolver is a Resolver whose Resolve calls block until
+// their context is done, reporting why it was.
+type cancellableResolver struct {
+	fakeResolver
+
+	started chan struct{}
+	done    chan error
+}
+
+func (c *cancellableResolver) Resolve(ctx context.Context, params map[string]string) (ResolvedResource, error) {
+	c.started <- struct{}{}
+	<-ctx.Done()
+	c.done <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func TestFilterResolutionRequestsBySelectorUnwrapsTombstones(t *testing.T) {
+	filter := filterResolutionRequestsBySelector(map[string]string{resolutioncommon.LabelKeyResolverType: "fake"})
+	matching := helpers.NewResolutionRequest("fake", "rr", "foo", nil)
+	other := helpers.NewResolutionRequest("other", "rr", "foo", nil)
+	for _, tc := range []struct {
+		name     string
+		obj      interface{}
+		expected bool
+	}{{
+		name:     "matching request",
+		obj:      matching,
+		expected: true,
+	}, {
+		name:     "request of another type",
+		obj:      other,
+		expected: false,
+	}, {
+		name:     "tombstone of matching request",
+		obj:      cache.DeletedFinalStateUnknown{Key: "foo/rr", Obj: matching},
+		expected: true,
+	}, {
+		name:     "tombstone of request of another type",
+		obj:      cache.DeletedFinalStateUnknown{Key: "foo/rr", Obj: other},
+		expected: false,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := filter(tc.obj); got != tc.expected {
+				t.Errorf("expected filter to return %t, received %t", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestReconcilerCancelsResolutionOfDeletedRequest(t *testing.T) {
+	rr := helpers.NewResolutionRequest("cancellable", "rr", "foo", nil)
+	client := rrfake.NewSimpleClientset(rr)
+	resolver := &cancellableResolver{
+		fakeResolver: fakeResolver{name: "cancellable"},
+		started:      make(chan struct{}, 1),
+		done:         make(chan error, 1),
+	}
+	r := &Reconciler{
+		resolver:                   resolver,
+		resolutionRequestClientSet: client,
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- r.resolve(context.Background(), "foo/rr", rr)
+	}()
+	select {
+	case <-resolver.started:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timed out waiting for resolution to start")
+	}
+
+	if err := client.ResolutionV1alpha1().ResolutionRequests("foo").Delete(context.Background(), "rr", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("error deleting request: %v", err)
+	}
+	// The informer delivers the deletion to the event handler.
+	r.cancelDeletedResolution(cache.DeletedFinalStateUnknown{Key: "foo/rr", Obj: rr})
+
+	select {
+	case err := <-resolver.done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the resolve context to be cancelled, received %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the resolve context to be cancelled")
+	}
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Errorf("expected the cancelled resolution of a deleted request not to fail, received %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for resolution to finish")
+	}
+}
+
+func TestReconcilerHandlesMovedTags(t *testing.T) {
+	prior := `{"v1.0.0":"aaaa","v1.1.0":"bbbb"}`
+	for _, tc := range []struct {
+		name             string
+		policy           string
+		namespace        string
+		repository       string
+		current          string
+		expectFailure    bool
+		expectedWarnings []string
+	}{{
+		name:    "unmoved tags",
+		policy:  MovedTagPolicyFail,
+		current: `{"v1.0.0":"aaaa","v2.0.0":"cccc"}`,
+	}, {
+		name:             "re-resolve by default",
+		current:          `{"v1.0.0":"dddd"}`,
+		expectedWarnings: []string{`tag "v1.0.0" moved from aaaa to dddd`},
+	}, {
+		name:             "re-resolve",
+		policy:           MovedTagPolicyReResolve,
+		current:          `{"v1.0.0":"dddd","v1.1.0":"eeee"}`,
+		expectedWarnings: []string{`tag "v1.0.0" moved from aaaa to dddd`, `tag "v1.1.0" moved from bbbb to eeee`},
+	}, {
+		name:          "fail",
+		policy:        MovedTagPolicyFail,
+		current:       `{"v1.0.0":"dddd"}`,
+		expectFailure: true,
+	}, {
+		name:       "tag of another repository",
+		policy:     MovedTagPolicyFail,
+		repository: "https://example.com/other.git",
+		current:    `{"v1.0.0":"dddd"}`,
+	}, {
+		name:      "tag resolved for another namespace",
+		policy:    MovedTagPolicyFail,
+		namespace: "bar",
+		current:   `{"v1.0.0":"dddd"}`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			namespace, repository := "foo", "https://example.com/repo.git"
+			if tc.namespace != "" {
+				namespace = tc.namespace
+			}
+			if tc.repository != "" {
+				repository = tc.repository
+			}
+			rr := helpers.NewResolutionRequest("fake", "rr", namespace, map[string]string{})
+			rr.CreationTimestamp = metav1.NewTime(time.Unix(300, 0))
+			client := rrfake.NewSimpleClientset(rr)
+			// The tags were resolved by earlier requests, and the
+			// mapping of the most recent of them is compared against.
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for i, commits := range []string{`{"v1.0.0":"0000"}`, prior} {
+				earlier := helpers.NewResolutionRequest("fake", fmt.Sprintf("earlier-%d", i), "foo", map[string]string{})
+				earlier.UID = types.UID(earlier.Name)
+				earlier.CreationTimestamp = metav1.NewTime(time.Unix(int64(100*(i+1)), 0))
+				earlier.Status.Annotations = map[string]string{
+					AnnotationKeyTagCommits:    commits,
+					AnnotationKeyTagRepository: "https://example.com/repo.git",
+				}
+				if err := indexer.Add(earlier); err != nil {
+					t.Fatalf("error adding earlier request: %v", err)
+				}
+			}
+			if err := indexer.Add(rr); err != nil {
+				t.Fatalf("error adding request: %v", err)
+			}
+			r := &Reconciler{
+				resolver: &fakeResolver{name: "fake", resource: &fakeResource{
+					data: []byte("resolved"),
+					annotations: map[string]string{
+						AnnotationKeyTagCommits:    tc.current,
+						AnnotationKeyTagRepository: repository,
+					},
+				}},
+				resolutionRequestClientSet: client,
+				resolutionRequestLister:    rrv1alpha1.NewResolutionRequestLister(indexer),
+			}
+			ctx := InjectResolverConfigToContext(context.Background(), map[string]string{
+				ConfigKeyMovedTagPolicy: tc.policy,
+			})
+
+			err := r.resolve(ctx, namespace+"/rr", rr)
+			updated, getErr := client.ResolutionV1alpha1().ResolutionRequests(namespace).Get(context.Background(), "rr", metav1.GetOptions{})
+			if getErr != nil {
+				t.Fatalf("error getting updated request: %v", getErr)
+			}
+			if tc.expectFailure {
+				if !controller.IsPermanentError(err) || !errors.Is(err, ErrorTagMoved) {
+					t.Fatalf("expected permanent tag moved error, received %v", err)
+				}
+				helpers.AssertCondition(t, updated, apis.ConditionSucceeded, corev1.ConditionFalse, resolutioncommon.ReasonResolvedTagMoved)
+				if updated.Status.Data != "" {
+					t.Errorf("expected no data to be recorded, received %q", updated.Status.Data)
+				}
+				if commits := updated.Status.Annotations[AnnotationKeyTagCommits]; commits != "" {
+					t.Errorf("expected no tag mapping to be recorded, received %q", commits)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fmt.Sprint(updated.Status.Warnings) != fmt.Sprint(tc.expectedWarnings) {
+				t.Errorf("expected warnings %q, received %q", tc.expectedWarnings, updated.Status.Warnings)
+			}
+			if commits := updated.Status.Annotations[AnnotationKeyTagCommits]; commits != tc.current {
+				t.Errorf("expected the current tag mapping %q to be recorded in status, received %q", tc.current, commits)
+			}
+		})
+	}
+}
+
+func TestReconcilerCompressesData(t *testing.T) {
+	data := []byte("apiVersion: tekton.dev/v1beta1\nkind: Task\nmetadata:\n  name: build\n")
+	for _, tc := range []struct {
+		compression      string
+		expectedEncoding string
+	}{{
+		compression:      "",
+		expectedEncoding: "",
+	}, {
+		compression:      "gzip",
+		expectedEncoding: resolutioncommon.DataEncodingGzip,
+	}, {
+		compression:      "zstd",
+		expectedEncoding: resolutioncommon.DataEncodingZstd,
+	}} {
+		t.Run(tc.compression, func(t *testing.T) {
+			rr := helpers.NewResolutionRequest("fake", "rr", "foo", nil)
+			client := rrfake.NewSimpleClientset(rr)
+			r := &Reconciler{
+				resolver:                   &fakeResolver{name: "fake", resource: &fakeResource{data: data}},
+				resolutionRequestClientSet: client,
+			}
+			ctx := InjectResolverConfigToContext(context.Background(), map[string]string{
+				ConfigKeyDataCompression: tc.compression,
+			})
+			if err := r.resolve(ctx, "foo/rr", rr); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			updated, err := client.ResolutionV1alpha1().ResolutionRequests("foo").Get(context.Background(), "rr", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting updated request: %v", err)
+			}
+			encoding := updated.Status.Annotations[resolutioncommon.AnnotationKeyDataEncoding]
+			if encoding != tc.expectedEncoding {
+				t.Errorf("expected data encoding annotation %q, received %q", tc.expectedEncoding, encoding)
+			}
+			decoded, err := resolutioncommon.DecodeData(updated.Status.Data, encoding)
+			if err != nil {
+				t.Fatalf("unexpected error decoding data: %v", err)
+			}
+			if string(decoded) != string(data) {
+				t.Errorf("expected data to round trip, received %q", decoded)
+			}
+		})
+	}
+}
+
+func TestReconcilerRejectsInvalidCompression(t *testing.T) {
+	rr := helpers.NewResolutionRequest("fake", "rr", "foo", nil)
+	client := rrfake.NewSimpleClientset(rr)
+	r := &Reconciler{
+		resolver:                   &fakeResolver{name: "fake", resource: &fakeResource{data: []byte("resolved")}},
+		resolutionRequestClientSet: client,
+	}
+	ctx := InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigKeyDataCompression: "brotli",
+	})
+	if err := r.resolve(ctx, "foo/rr", rr); err == nil {
+		t.Fatalf("expected error with invalid %s", ConfigKeyDataCompression)
+	}
+}
+
+func TestReconcilerAuditsResolutions(t *testing.T) {
+	now := time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+	params := map[string]string{"url": "https://example.com/repo.git", "path": "task.yaml"}
+	for _, tc := range []struct {
+		name     string
+		resolver *fakeResolver
+		expected string
+	}{{
+		name: "successful resolution",
+		resolver: &fakeResolver{
+			name:     "fake",
+			resource: &fakeResource{data: []byte("resolved"), annotations: map[string]string{"commit": "abc123"}},
+		},
+		expected: `{"time":"2022-03-01T12:00:00Z","namespace":"foo","name":"rr","resolverType":"fake",` +
+			`"paramsHash":"sha256:197d542bc75cc5210dd5057f7d01fe6796b0bdfc34a88b5b2de641eeadd95477","outcome":"succeeded","commit":"abc123",` +
+			`"digest":"sha256:dc676b42859915c826f8bbaef6511998f7aedbd6e332565faeda17fc33ec6320"}`,
+	}, {
+		name: "failed resolution",
+		resolver: &fakeResolver{
+			name:       "fake",
+			resolveErr: fmt.Errorf("file %q: %w", "task.yaml", ErrorResourceNotFound),
+		},
+		expected: `{"time":"2022-03-01T12:00:00Z","namespace":"foo","name":"rr","resolverType":"fake",` +
+			`"paramsHash":"sha256:197d542bc75cc5210dd5057f7d01fe6796b0bdfc34a88b5b2de641eeadd95477","outcome":"failed",` +
+			`"failureCategory":"not-found","error":"error getting \"fake\" \"foo/rr\": file \"task.yaml\": resource not found"}`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := helpers.NewResolutionRequest("fake", "rr", "foo", params)
+			client := rrfake.NewSimpleClientset(rr)
+			out := &strings.Builder{}
+			r := &Reconciler{
+				Clock:                      clocktesting.NewFakePassiveClock(now),
+				AuditSink:                  &JSONAuditSink{Writer: out},
+				resolver:                   tc.resolver,
+				resolutionRequestClientSet: client,
+			}
+			ctx := InjectResolverConfigToContext(context.Background(), map[string]string{
+				ConfigKeyAuditLog: "true",
+			})
+			_ = r.resolve(ctx, "foo/rr", rr)
+			if received := out.String(); received != tc.expected+"\n" {
+				t.Errorf("expected audit record:\n%s\nreceived:\n%s", tc.expected, received)
+			}
+		})
+	}
+}
+
+func TestReconcilerAuditsParamsWithDefaults(t *testing.T) {
+	rr := helpers.NewResolutionRequest("fake", "rr", "foo", map[string]string{"path": "task.yaml"})
+	client := rrfake.NewSimpleClientset(rr)
+	out := &strings.Builder{}
+	r := &Reconciler{
+		AuditSink:                  &JSONAuditSink{Writer: out},
+		resolver:                   &fakeResolver{name: "fake", resource: &fakeResource{data: []byte("resolved")}},
+		resolutionRequestClientSet: client,
+	}
+	ctx := InjectResolverConfigToContext(context.Background(), map[string]string{
+		ConfigKeyAuditLog:         "true",
+		DefaultsConfigKey("fake"): `{"url":"https://example.com/repo.git"}`,
+	})
+	if err := r.resolve(ctx, "foo/rr", rr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	record := AuditRecord{}
+	if err := json.Unmarshal([]byte(out.String()), &record); err != nil {
+		t.Fatalf("error parsing audit record %q: %v", out.String(), err)
+	}
+	// The params with the default applied are those of
+	// TestReconcilerAuditsResolutions.
+	if expected := "sha256:197d542bc75cc5210dd5057f7d01fe6796b0bdfc34a88b5b2de641eeadd95477"; record.ParamsHash != expected {
+		t.Errorf("expected the hash of the params with defaults %s, received %s", expected, record.ParamsHash)
+	}
+}
+
+func TestReconcilerOnlyAuditsWhenEnabled(t *testing.T) {
+	rr := helpers.NewResolutionRequest("fake", "rr", "foo", nil)
+	client := rrfake.NewSimpleClientset(rr)
+	out := &strings.Builder{}
+	r := &Reconciler{
+		AuditSink:                  &JSONAuditSink{Writer: out},
+		resolver:                   &fakeResolver{name: "fake", resource: &fakeResource{data: []byte("resolved")}},
+		resolutionRequestClientSet: client,
+	}
+	if err := r.resolve(context.Background(), "foo/rr", rr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no audit records without %s, received %q", ConfigKeyAuditLog, out.String())
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tektoncd/resolution/pkg/apis/resolution/v1alpha1"
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// AnnotationKeyTagCommits is the annotation recording, as a JSON object,
+// the commit that each tag a resource was resolved from pointed to.
+// Resolvers that resolve tags set it, along with
+// AnnotationKeyTagRepository, on their resolved resource. It's recorded
+// in the request's status, and the reconciler compares it to the
+// commits recorded in the status of earlier requests for the same tags
+// to detect tags that have moved.
+const AnnotationKeyTagCommits = "resolution.tekton.dev/tag-commits"
+
+// AnnotationKeyTagRepository is the annotation naming the repository
+// that the tags in AnnotationKeyTagCommits belong to. Tags are only
+// checked for moves when it's set.
+const AnnotationKeyTagRepository = "resolution.tekton.dev/tag-repository"
+
+// ConfigKeyMovedTagPolicy is the key in a resolver's ConfigMap that
+// sets how a request is handled when a tag it was resolved from before
+// now points to a different commit: MovedTagPolicyReResolve, the
+// default, or MovedTagPolicyFail.
+const ConfigKeyMovedTagPolicy = "moved-tag-policy"
+
+const (
+	// MovedTagPolicyReResolve records the content that a moved tag now
+	// points to, along with a warning that the tag moved.
+	MovedTagPolicyReResolve = "re-resolve"
+	// MovedTagPolicyFail fails requests whose tags have moved, for
+	// tags that must stay immutable for builds to be reproducible.
+	MovedTagPolicyFail = "fail"
+)
+
+// ErrorTagMoved is returned, wrapped, when a tag that a request was
+// resolved from has moved and ConfigKeyMovedTagPolicy is
+// MovedTagPolicyFail.
+var ErrorTagMoved = errors.New("tag moved")
+
+// movedTagPolicy returns the ConfigKeyMovedTagPolicy in ctx's resolver
+// config.
+func movedTagPolicy(ctx context.Context) (string, error) {
+	switch policy := GetResolverConfigFromContext(ctx)[ConfigKeyMovedTagPolicy]; policy {
+	case "", MovedTagPolicyReResolve:
+		return MovedTagPolicyReResolve, nil
+	case MovedTagPolicyFail:
+		return MovedTagPolicyFail, nil
+	default:
+		return "", fmt.Errorf("invalid %q config %q: must be %q or %q", ConfigKeyMovedTagPolicy, policy, MovedTagPolicyReResolve, MovedTagPolicyFail)
+	}
+}
+
+// priorTagCommits returns the commits that the tags of repository
+// pointed to when they were last resolved for a request in rr's
+// namespace. They're read from the AnnotationKeyTagCommits status
+// annotations of the namespace's other resolved requests, so they're
+// shared by every replica and survive restarts for as long as those
+// requests are kept. Where several requests resolved a tag the most
+// recently created one wins.
+func (r *Reconciler) priorTagCommits(rr *v1alpha1.ResolutionRequest, repository string) (map[string]string, error) {
+	if r.resolutionRequestLister == nil {
+		return nil, nil
+	}
+	requests, err := r.resolutionRequestLister.ResolutionRequests(rr.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].CreationTimestamp.Before(&requests[j].CreationTimestamp)
+	})
+	prior := map[string]string{}
+	for _, other := range requests {
+		if other.UID == rr.UID || other.Status.Annotations[AnnotationKeyTagRepository] != repository {
+			continue
+		}
+		commits, err := tagCommits(other.Status.Annotations)
+		if err != nil {
+			// A mapping that can't be read is treated as not
+			// recorded, so that requests aren't stuck failing on it.
+			continue
+		}
+		for tag, commit := range commits {
+			prior[tag] = commit
+		}
+	}
+	return prior, nil
+}
+
+// tagCommits parses the AnnotationKeyTagCommits annotation of
+// annotations, if it has one.
+func tagCommits(annotations map[string]string) (map[string]string, error) {
+	val := annotations[AnnotationKeyTagCommits]
+	if val == "" {
+		return nil, nil
+	}
+	commits := map[string]string{}
+	if err := json.Unmarshal([]byte(val), &commits); err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+// checkMovedTags applies ctx's ConfigKeyMovedTagPolicy to the tags of
+// resource that have moved since they were last resolved for a request
+// in rr's namespace. It returns the resource to record, which carries a
+// warning for each moved tag, or an error if the request should fail.
+// Requests that fail record no mapping, so under MovedTagPolicyFail
+// every later request for a moved tag fails too.
+func (r *Reconciler) checkMovedTags(ctx context.Context, rr *v1alpha1.ResolutionRequest, resource ResolvedResource) (ResolvedResource, error) {
+	repository := resource.Annotations()[AnnotationKeyTagRepository]
+	if repository == "" {
+		return resource, nil
+	}
+	current, err := tagCommits(resource.Annotations())
+	if err != nil {
+		return nil, fmt.Errorf("invalid %q annotation: %w", AnnotationKeyTagCommits, err)
+	}
+	if len(current) == 0 {
+		return resource, nil
+	}
+	prior, err := r.priorTagCommits(rr, repository)
+	if err != nil {
+		return nil, fmt.Errorf("error listing earlier requests for moved tags: %w", err)
+	}
+	tags := make([]string, 0, len(current))
+	for tag := range current {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	moved := []string{}
+	for _, tag := range tags {
+		if before, ok := prior[tag]; ok && before != current[tag] {
+			moved = append(moved, fmt.Sprintf("tag %q moved from %s to %s", tag, before, current[tag]))
+		}
+	}
+	if len(moved) == 0 {
+		return resource, nil
+	}
+	policy, err := movedTagPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if policy == MovedTagPolicyFail {
+		return nil, resolutioncommon.NewError(resolutioncommon.ReasonResolvedTagMoved, fmt.Errorf("%w: %s", ErrorTagMoved, strings.Join(moved, "; ")))
+	}
+	return &movedTagResource{ResolvedResource: resource, moved: moved}, nil
+}
+
+// movedTagResource is a resource re-resolved from tags that had moved,
+// warning about each of them.
+type movedTagResource struct {
+	ResolvedResource
+	moved []string
+}
+
+var _ ResolvedResourceWithWarnings = &movedTagResource{}
+
+// Warnings returns the warnings of the wrapped resource followed by one
+// for each moved tag.
+func (r *movedTagResource) Warnings() []string {
+	return append(Warnings(r.ResolvedResource), r.moved...)
+}
@@ -18,9 +18,7 @@ package resource
 
 import (
 	"context"
-	"encoding/base64"
 	"errors"
-	"fmt"
 
 	"github.com/tektoncd/resolution/pkg/apis/resolution/v1alpha1"
 	rrclient "github.com/tektoncd/resolution/pkg/client/clientset/versioned"
@@ -146,9 +144,5 @@ func (r readOnlyResolutionRequest) Annotations() map[string]string {
 
 func (r readOnlyResolutionRequest) Data() ([]byte, error) {
 	encodedData := r.req.Status.ResolutionRequestStatusFields.Data
-	decodedBytes, err := base64.StdEncoding.Strict().DecodeString(encodedData)
-	if err != nil {
-		return nil, fmt.Errorf("error decoding data from base64: %w", err)
-	}
-	return decodedBytes, nil
+	return resolutioncommon.DecodeData(encodedData, r.req.Status.Annotations[resolutioncommon.AnnotationKeyDataEncoding])
 }
@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhook holds extensions to the knative admission controllers
+// that the webhook serves.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/controller"
+	pkgreconciler "knative.dev/pkg/reconciler"
+	"knative.dev/pkg/webhook"
+
+	"github.com/tektoncd/resolution/pkg/apis/config"
+	"github.com/tektoncd/resolution/pkg/common"
+)
+
+// admissionReconciler is the reconciler of a knative admission
+// controller, which both reconciles its webhook configuration and admits
+// requests.
+type admissionReconciler interface {
+	controller.Reconciler
+	pkgreconciler.LeaderAware
+	webhook.AdmissionController
+}
+
+// WithDeprecationWarnings makes the admission controller of impl warn
+// about each new ResolutionRequest that it admits whose resolver type's
+// deprecation policy is config.DeprecationPolicyWarn. withContext
+// returns a context with the latest config.Deprecations stored in it.
+//
+// The knative admission controllers can only reject requests, not warn
+// about them, so this wraps their responses.
+func WithDeprecationWarnings(impl *controller.Impl, withContext func(context.Context) context.Context) *controller.Impl {
+	if inner, ok := impl.Reconciler.(admissionReconciler); ok {
+		impl.Reconciler = &deprecationWarner{admissionReconciler: inner, withContext: withContext}
+	}
+	return impl
+}
+
+// deprecationWarner adds deprecation warnings to the responses of an
+// admissionReconciler.
+type deprecationWarner struct {
+	admissionReconciler
+	withContext func(context.Context) context.Context
+}
+
+var _ webhook.StatelessAdmissionController = &deprecationWarner{}
+
+// ThisTypeDoesNotDependOnInformerState marks the deprecationWarner as
+// stateless, like the knative admission controllers that it wraps.
+func (d *deprecationWarner) ThisTypeDoesNotDependOnInformerState() {}
+
+// Admit admits request with the wrapped admission controller, adding a
+// warning to its response if it's a new request of a deprecated type.
+func (d *deprecationWarner) Admit(ctx context.Context, request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	response := d.admissionReconciler.Admit(ctx, request)
+	if response == nil || !response.Allowed || request.Operation != admissionv1.Create {
+		return response
+	}
+	var object struct {
+		metav1.ObjectMeta `json:"metadata"`
+	}
+	if err := json.Unmarshal(request.Object.Raw, &object); err != nil {
+		return response
+	}
+	typeLabel := object.Labels[common.LabelKeyResolverType]
+	deprecation, ok := config.FromContext(d.withContext(ctx))[typeLabel]
+	if ok && deprecation.Policy == config.DeprecationPolicyWarn {
+		response.Warnings = append(response.Warnings, deprecation.Explain(typeLabel))
+	}
+	return response
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tektoncd/resolution/pkg/apis/config"
+	"github.com/tektoncd/resolution/test/helpers"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"knative.dev/pkg/apis"
+)
+
+// allowingAdmitter is an admissionReconciler that admits every request.
+type allowingAdmitter struct {
+	admissionReconciler
+}
+
+func (*allowingAdmitter) Admit(context.Context, *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{Allowed: true}
+}
+
+func testDeprecations(t *testing.T) config.Deprecations {
+	t.Helper()
+	deprecations, err := config.NewDeprecationsFromMap(map[string]string{
+		"hub.policy":     config.DeprecationPolicyReject,
+		"hub.message":    "use the bundles resolver instead",
+		"legacy.policy":  config.DeprecationPolicyWarn,
+		"legacy.message": "use the git resolver instead",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error parsing deprecations: %v", err)
+	}
+	return deprecations
+}
+
+func TestRejectedDeprecatedType(t *testing.T) {
+	ctx := config.ToContext(context.Background(), testDeprecations(t))
+	rr := helpers.NewResolutionRequest("hub", "rr", "foo", nil)
+
+	err := rr.Validate(apis.WithinCreate(ctx))
+	if err == nil {
+		t.Fatalf("expected new request of rejected deprecated type to be invalid")
+	}
+	if !strings.Contains(err.Error(), "use the bundles resolver instead") {
+		t.Errorf("expected error to point to the replacement, received %q", err.Error())
+	}
+
+	if err := rr.Validate(apis.WithinUpdate(ctx, rr)); err != nil {
+		t.Errorf("expected update of existing request to be valid, received %v", err)
+	}
+}
+
+func TestWarnedDeprecatedType(t *testing.T) {
+	deprecations := testDeprecations(t)
+	ctx := config.ToContext(context.Background(), deprecations)
+	rr := helpers.NewResolutionRequest("legacy", "rr", "foo", nil)
+
+	if err := rr.Validate(apis.WithinCreate(ctx)); err != nil {
+		t.Fatalf("expected new request of warned deprecated type to be valid, received %v", err)
+	}
+
+	raw, err := json.Marshal(rr)
+	if err != nil {
+		t.Fatalf("unexpected error marshalling request: %v", err)
+	}
+	warner := &deprecationWarner{
+		admissionReconciler: &allowingAdmitter{},
+		withContext: func(ctx context.Context) context.Context {
+			return config.ToContext(ctx, deprecations)
+		},
+	}
+
+	response := warner.Admit(context.Background(), &admissionv1.AdmissionRequest{
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	})
+	if !response.Allowed {
+		t.Fatalf("expected request to be allowed")
+	}
+	if len(response.Warnings) != 1 || !strings.Contains(response.Warnings[0], "use the git resolver instead") {
+		t.Errorf("expected a single warning pointing to the replacement, received %v", response.Warnings)
+	}
+
+	response = warner.Admit(context.Background(), &admissionv1.AdmissionRequest{
+		Operation: admissionv1.Update,
+		Object:    runtime.RawExtension{Raw: raw},
+	})
+	if len(response.Warnings) != 0 {
+		t.Errorf("expected no warnings on update, received %v", response.Warnings)
+	}
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"testing"
+
+	"github.com/tektoncd/resolution/pkg/apis/resolution/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
+)
+
+// AssertCondition fails the test unless the condition of the given type
+// on rr has the given status and reason. An empty reason matches any
+// reason.
+func AssertCondition(t testing.TB, rr *v1alpha1.ResolutionRequest, conditionType apis.ConditionType, status corev1.ConditionStatus, reason string) {
+	t.Helper()
+	condition := rr.Status.GetCondition(conditionType)
+	if condition == nil {
+		t.Errorf("expected %s condition on resolutionrequest %s/%s, found none", conditionType, rr.Namespace, rr.Name)
+		return
+	}
+	if condition.Status != status {
+		t.Errorf("expected %s condition status %s, received %s: %s", conditionType, status, condition.Status, condition.Message)
+	}
+	if reason != "" && condition.Reason != reason {
+		t.Errorf("expected %s condition reason %q, received %q: %s", conditionType, reason, condition.Reason, condition.Message)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The Tekton Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/tektoncd/resolution/pkg/apis/resolution/v1alpha1"
+	resolutioncommon "github.com/tektoncd/resolution/pkg/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// NewResolutionRequest returns a ResolutionRequest for the given
+// resolver type with the given params. Its UID is derived from its
+// namespace and name so that tests creating several requests get
+// stable, distinct UIDs.
+func NewResolutionRequest(resolverType, name, namespace string, params map[string]string) *v1alpha1.ResolutionRequest {
+	return &v1alpha1.ResolutionRequest{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       "ResolutionRequest",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       DeterministicUID(namespace, name),
+			Labels: map[string]string{
+				resolutioncommon.LabelKeyResolverType: resolverType,
+			},
+		},
+		Spec: v1alpha1.ResolutionRequestSpec{
+			Parameters: params,
+		},
+	}
+}
+
+// DeterministicUID returns a UID in the usual format that's always the
+// same for a given namespace and name.
+func DeterministicUID(namespace, name string) types.UID {
+	sum := sha256.Sum256([]byte(namespace + "/" + name))
+	return types.UID(fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16]))
+}